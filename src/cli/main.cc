@@ -33,6 +33,7 @@
 #include "config.h"
 #include "daemon_util.h"
 #include "io_util.h"
+#include "log_util.h"
 #include "pid_util.h"
 #include "scope_exit.h"
 #include "server/server.h"
@@ -96,11 +97,24 @@ static CLIOptions ParseCommandLineOptions(int argc, char **argv) {
   return opts;
 }
 
-static void InitGoogleLog(const Config *config) {
+static Status InitGoogleLog(const Config *config, std::unique_ptr<util::JsonLogSink> *json_log_sink) {
   FLAGS_minloglevel = config->log_level;
   FLAGS_max_log_size = 100;
   FLAGS_logbufsecs = 0;
 
+  if (config->log_format == kLogFormatJson) {
+    // the JSON log file is rotated and cleaned like the text log files
+    *json_log_sink = GET_OR_RET(util::JsonLogSink::Open(config->log_dir, FLAGS_max_log_size * MiB));
+    util::JsonLogSink::SetRetentionDays(config->log_retention_days);
+    // the logs are only written by the JSON sink, so the text destinations of glog are disabled
+    for (int level = google::INFO; level <= google::FATAL; level++) {
+      google::SetLogDestination(level, "");
+    }
+    FLAGS_stderrthreshold = google::NUM_SEVERITIES;
+    google::AddLogSink(json_log_sink->get());
+    return Status::OK();
+  }
+
   if (util::EqualICase(config->log_dir, "stdout")) {
     for (int level = google::INFO; level <= google::FATAL; level++) {
       google::SetLogDestination(level, "");
@@ -113,6 +127,7 @@ static void InitGoogleLog(const Config *config) {
       google::EnableLogCleaner(config->log_retention_days);
     }
   }
+  return Status::OK();
 }
 
 int main(int argc, char *argv[]) {
@@ -137,7 +152,15 @@ int main(int argc, char *argv[]) {
   }
 
   crc64_init();
-  InitGoogleLog(&config);
+  std::unique_ptr<util::JsonLogSink> json_log_sink;
+  s = InitGoogleLog(&config, &json_log_sink);
+  if (!s.IsOK()) {
+    std::cout << "Failed to init the log. Error: " << s.Msg() << std::endl;
+    return 1;
+  }
+  auto log_sink_exit = MakeScopeExit([&json_log_sink] {
+    if (json_log_sink) google::RemoveLogSink(json_log_sink.get());
+  });
   LOG(INFO) << "kvrocks " << PrintVersion;
   // Tricky: We don't expect that different instances running on the same port,
   // but the server use REUSE_PORT to support the multi listeners. So we connect
@@ -36,12 +36,14 @@
 #include "event_util.h"
 #include "fmt/format.h"
 #include "io_util.h"
+#include "parse_util.h"
 #include "rocksdb_crc32c.h"
 #include "scope_exit.h"
 #include "server/redis_reply.h"
 #include "server/server.h"
 #include "status.h"
 #include "storage/batch_debugger.h"
+#include "string_util.h"
 #include "thread_util.h"
 #include "time_util.h"
 #include "unique_fd.h"
@@ -99,6 +101,36 @@ void FeedSlaveThread::checkLivenessIfNeed() {
   }
 }
 
+void FeedSlaveThread::readAcksIfNeed() {
+  // replicas which support the ack capability would report their latest sequence
+  // via REPLCONF ACK after applying the batches, poll the socket without blocking
+  // the feeding loop since the acks are only used by WAIT.
+  int fd = conn_->GetFD();
+  if (!util::SockReadable(fd)) return;
+
+  if (!ack_req_) ack_req_ = std::make_unique<redis::Request>(srv_);
+  if (auto s = util::EvbufferRead(conn_->Input(), fd, -1, conn_->GetBufferEvent()); !s) {
+    LOG(ERROR) << "Read ack from slave[" << conn_->GetAddr() << "] err: " << s.Msg() << ", would stop the thread";
+    Stop();
+    return;
+  }
+
+  if (auto s = ack_req_->Tokenize(conn_->Input()); !s.IsOK()) {
+    LOG(ERROR) << "Invalid ack from slave[" << conn_->GetAddr() << "]: " << s.Msg() << ", would stop the thread";
+    Stop();
+    return;
+  }
+
+  auto cmds = ack_req_->GetCommands();
+  for (const auto &tokens : *cmds) {
+    if (tokens.size() != 3 || util::ToLower(tokens[0]) != "replconf" || util::ToLower(tokens[1]) != "ack") continue;
+    if (auto seq = ParseInt<uint64_t>(tokens[2], 10); seq && *seq > ack_repl_seq_.load()) {
+      ack_repl_seq_.store(*seq);
+    }
+  }
+  cmds->clear();
+}
+
 void FeedSlaveThread::loop() {
   // is_first_repl_batch was used to fix that replication may be stuck in a dead loop
   // when some seqs might be lost in the middle of the WAL log, so forced to replicate
@@ -116,6 +148,7 @@ void FeedSlaveThread::loop() {
         iter_ = nullptr;
         usleep(yield_microseconds);
         checkLivenessIfNeed();
+        readAcksIfNeed();
         continue;
       }
     }
@@ -140,11 +173,17 @@ void FeedSlaveThread::loop() {
     //    kMaxDelayUpdates than latest sequence.
     if (is_first_repl_batch || batches_bulk.size() >= kMaxDelayBytes || updates_in_batches >= kMaxDelayUpdates ||
         srv_->storage->LatestSeqNumber() - batch.sequence <= kMaxDelayUpdates) {
+      tracing::Span span(srv_->GetTracer(), "kvrocks.replication.propagate", tracing::SpanKind::kProducer);
+      span.SetAttribute("kvrocks.replica.addr", conn_->GetAddr());
+      span.SetAttribute("kvrocks.replication.seq", static_cast<int64_t>(batch.sequence));
+      span.SetAttribute("kvrocks.replication.updates", static_cast<int64_t>(updates_in_batches));
+      span.SetAttribute("kvrocks.replication.bytes", static_cast<int64_t>(batches_bulk.size()));
       // Send entire bulk which contain multiple batches
       auto s = util::SockSend(conn_->GetFD(), batches_bulk, conn_->GetBufferEvent());
       if (!s.IsOK()) {
         LOG(ERROR) << "Write error while sending batch to slave: " << s.Msg() << ". batches: 0x"
                    << util::StringToHex(batches_bulk);
+        span.SetError(s.Msg());
         Stop();
         return;
       }
@@ -155,9 +194,11 @@ void FeedSlaveThread::loop() {
     }
     curr_seq = batch.sequence + batch.writeBatchPtr->Count();
     next_repl_seq_.store(curr_seq);
+    readAcksIfNeed();
     while (!IsStopped() && !srv_->storage->WALHasNewData(curr_seq)) {
       usleep(yield_microseconds);
       checkLivenessIfNeed();
+      readAcksIfNeed();
     }
     iter_->Next();
   }
@@ -199,7 +240,7 @@ void ReplicationThread::CallbacksStateMachine::SetWriteCB(bufferevent *bev, buff
 void ReplicationThread::CallbacksStateMachine::ReadWriteCB(bufferevent *bev) {
 LOOP_LABEL:
   assert(handler_idx_ <= handlers_.size());
-  DLOG(INFO) << "[replication] Execute handler[" << getHandlerName(handler_idx_) << "]";
+  VLOG(1) << "[replication] Execute handler[" << getHandlerName(handler_idx_) << "]";
   auto st = getHandlerFunc(handler_idx_)(repl_, bev);
   repl_->last_io_time_.store(util::GetTimeStamp(), std::memory_order_relaxed);
   switch (st) {
@@ -456,6 +497,10 @@ ReplicationThread::CBState ReplicationThread::replConfWriteCB(bufferevent *bev)
     data_to_send.emplace_back("ip-address");
     data_to_send.emplace_back(config->replica_announce_ip);
   }
+  if (!next_try_without_ack_capa_) {
+    data_to_send.emplace_back("capa");
+    data_to_send.emplace_back("ack");
+  }
   SendString(bev, redis::MultiBulkString(data_to_send));
   repl_state_.store(kReplReplConf, std::memory_order_relaxed);
   LOG(INFO) << "[replication] replconf request was sent, waiting for response";
@@ -467,7 +512,15 @@ ReplicationThread::CBState ReplicationThread::replConfReadCB(bufferevent *bev) {
   UniqueEvbufReadln line(input, EVBUFFER_EOL_CRLF_STRICT);
   if (!line) return CBState::AGAIN;
 
-  // on unknown option: first try without announce ip, if it fails again - do nothing (to prevent infinite loop)
+  // on unknown option: first try without the ack capability, then without announce ip,
+  // if it fails again - do nothing (to prevent infinite loop)
+  if (isUnknownOption(line.get()) && !next_try_without_ack_capa_) {
+    next_try_without_ack_capa_ = true;
+    LOG(WARNING) << "The old version master, can't handle capa, "
+                 << "try without it again";
+    // Retry previous state, i.e. send replconf again
+    return CBState::PREV;
+  }
   if (isUnknownOption(line.get()) && !next_try_without_announce_ip_address_) {
     next_try_without_announce_ip_address_ = true;
     LOG(WARNING) << "The old version master, can't handle ip-address, "
@@ -482,8 +535,11 @@ ReplicationThread::CBState ReplicationThread::replConfReadCB(bufferevent *bev) {
   if (!ResponseLineIsOK(line.get())) {
     LOG(WARNING) << "[replication] Failed to replconf: " << line.get() + 1;
     //  backward compatible with old version that doesn't support replconf cmd
+    master_ack_capa_ = false;
     return CBState::NEXT;
   } else {
+    // only send acks to the master which accepted the capability, or they would never be read
+    master_ack_capa_ = !next_try_without_ack_capa_;
     LOG(INFO) << "[replication] replconf is ok, start psync";
     return CBState::NEXT;
   }
@@ -556,8 +612,14 @@ ReplicationThread::CBState ReplicationThread::tryPSyncReadCB(bufferevent *bev) {
   }
 }
 
+void ReplicationThread::sendReplConfAck(bufferevent *bev) {
+  if (!master_ack_capa_) return;
+  SendString(bev, redis::MultiBulkString({"replconf", "ack", std::to_string(storage_->LatestSeqNumber())}));
+}
+
 ReplicationThread::CBState ReplicationThread::incrementBatchLoopCB(bufferevent *bev) {
   char *bulk_data = nullptr;
+  bool bulk_received = false;
   repl_state_.store(kReplConnected, std::memory_order_relaxed);
   auto input = bufferevent_get_input(bev);
   while (true) {
@@ -565,7 +627,11 @@ ReplicationThread::CBState ReplicationThread::incrementBatchLoopCB(bufferevent *
       case Incr_batch_size: {
         // Read bulk length
         UniqueEvbufReadln line(input, EVBUFFER_EOL_CRLF_STRICT);
-        if (!line) return CBState::AGAIN;
+        if (!line) {
+          // acknowledge once all received batches were applied, it's also the reply of the ping
+          if (bulk_received) sendReplConfAck(bev);
+          return CBState::AGAIN;
+        }
         incr_bulk_len_ = line.length > 0 ? std::strtoull(line.get() + 1, nullptr, 10) : 0;
         if (incr_bulk_len_ == 0) {
           LOG(ERROR) << "[replication] Invalid increment data size";
@@ -598,7 +664,9 @@ ReplicationThread::CBState ReplicationThread::incrementBatchLoopCB(bufferevent *
           }
           evbuffer_drain(input, incr_bulk_len_ + 2);
           incr_state_ = Incr_batch_size;
+          bulk_received = true;
         } else {
+          if (bulk_received) sendReplConfAck(bev);
           return CBState::AGAIN;
         }
         break;
@@ -927,14 +995,14 @@ Status ReplicationThread::fetchFiles(int sock_fd, const std::string &dir, const
 
   UniqueEvbuf evbuf;
   for (unsigned i = 0; i < files.size(); i++) {
-    DLOG(INFO) << "[fetch] Start to fetch file " << files[i];
+    VLOG(1) << "[fetch] Start to fetch file " << files[i];
     s = fetchFile(sock_fd, evbuf.get(), dir, files[i], crcs[i], fn, ssl);
     if (!s.IsOK()) {
       s = Status(Status::NotOK, "fetch file err: " + s.Msg());
       LOG(WARNING) << "[fetch] Fail to fetch file " << files[i] << ", err: " << s.Msg();
       break;
     }
-    DLOG(INFO) << "[fetch] Succeed fetching file " << files[i];
+    VLOG(1) << "[fetch] Succeed fetching file " << files[i];
 
     // Just for tests
     if (srv_->GetConfig()->fullsync_recv_file_delay) {
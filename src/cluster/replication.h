@@ -63,7 +63,10 @@ using FetchFileCallback = std::function<void(const std::string &, uint32_t)>;
 class FeedSlaveThread {
  public:
   explicit FeedSlaveThread(Server *srv, redis::Connection *conn, rocksdb::SequenceNumber next_repl_seq)
-      : srv_(srv), conn_(conn), next_repl_seq_(next_repl_seq) {}
+      : srv_(srv),
+        conn_(conn),
+        next_repl_seq_(next_repl_seq),
+        ack_repl_seq_(next_repl_seq == 0 ? 0 : next_repl_seq - 1) {}
   ~FeedSlaveThread() = default;
 
   Status Start();
@@ -75,6 +78,9 @@ class FeedSlaveThread {
     auto seq = next_repl_seq_.load();
     return seq == 0 ? 0 : seq - 1;
   }
+  // the latest sequence the replica has acknowledged, it stays at the psync sequence
+  // for replicas which don't send REPLCONF ACK
+  rocksdb::SequenceNumber GetAckReplSeq() { return ack_repl_seq_.load(); }
 
  private:
   uint64_t interval_ = 0;
@@ -82,6 +88,8 @@ class FeedSlaveThread {
   Server *srv_ = nullptr;
   std::unique_ptr<redis::Connection> conn_ = nullptr;
   std::atomic<rocksdb::SequenceNumber> next_repl_seq_ = 0;
+  std::atomic<rocksdb::SequenceNumber> ack_repl_seq_ = 0;
+  std::unique_ptr<redis::Request> ack_req_ = nullptr;
   std::thread t_;
   std::unique_ptr<rocksdb::TransactionLogIterator> iter_ = nullptr;
 
@@ -90,6 +98,7 @@ class FeedSlaveThread {
 
   void loop();
   void checkLivenessIfNeed();
+  void readAcksIfNeed();
 };
 
 class ReplicationThread : private EventCallbackBase<ReplicationThread> {
@@ -158,6 +167,8 @@ class ReplicationThread : private EventCallbackBase<ReplicationThread> {
   std::atomic<time_t> last_io_time_ = 0;
   bool next_try_old_psync_ = false;
   bool next_try_without_announce_ip_address_ = false;
+  bool next_try_without_ack_capa_ = false;
+  bool master_ack_capa_ = false;
 
   std::function<void()> pre_fullsync_cb_;
   std::function<void()> post_fullsync_cb_;
@@ -194,6 +205,7 @@ class ReplicationThread : private EventCallbackBase<ReplicationThread> {
   CBState tryPSyncWriteCB(bufferevent *bev);
   CBState tryPSyncReadCB(bufferevent *bev);
   CBState incrementBatchLoopCB(bufferevent *bev);
+  void sendReplConfAck(bufferevent *bev);
   CBState fullSyncWriteCB(bufferevent *bev);
   CBState fullSyncReadCB(bufferevent *bev);
 
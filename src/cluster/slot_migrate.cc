@@ -339,13 +339,13 @@ Status SlotMigrator::sendSnapshot() {
     }
 
     if (*result == KeyMigrationResult::kMigrated) {
-      LOG(INFO) << "[migrate] The key " << user_key << " successfully migrated";
+      VLOG(1) << "[migrate] The key " << user_key << " successfully migrated";
       migrated_key_cnt++;
     } else if (*result == KeyMigrationResult::kExpired) {
-      LOG(INFO) << "[migrate] The key " << user_key << " is expired";
+      VLOG(1) << "[migrate] The key " << user_key << " is expired";
       expired_key_cnt++;
     } else if (*result == KeyMigrationResult::kUnderlyingStructEmpty) {
-      LOG(INFO) << "[migrate] The key " << user_key << " has no elements";
+      VLOG(1) << "[migrate] The key " << user_key << " has no elements";
       empty_key_cnt++;
     } else {
       LOG(ERROR) << "[migrate] Migrated a key " << user_key << " with unexpected result: " << static_cast<int>(*result);
@@ -528,7 +528,7 @@ Status SlotMigrator::checkMultipleResponses(int sock_fd, int total) {
         case ParserState::ArrayLen: {
           UniqueEvbufReadln line(evbuf.get(), EVBUFFER_EOL_CRLF_STRICT);
           if (!line) {
-            LOG(INFO) << "[migrate] Event buffer is empty, read socket again";
+            VLOG(1) << "[migrate] Event buffer is empty, read socket again";
             run = false;
             break;
           }
@@ -560,7 +560,7 @@ Status SlotMigrator::checkMultipleResponses(int sock_fd, int total) {
         // Handle bulk string response
         case ParserState::BulkData: {
           if (evbuffer_get_length(evbuf.get()) < bulk_or_array_len + 2) {
-            LOG(INFO) << "[migrate] Bulk data in event buffer is not complete, read socket again";
+            VLOG(1) << "[migrate] Bulk data in event buffer is not complete, read socket again";
             run = false;
             break;
           }
@@ -574,7 +574,7 @@ Status SlotMigrator::checkMultipleResponses(int sock_fd, int total) {
           while (run && bulk_or_array_len > 0) {
             evbuffer_ptr ptr = evbuffer_search_eol(evbuf.get(), nullptr, nullptr, EVBUFFER_EOL_CRLF_STRICT);
             if (ptr.pos < 0) {
-              LOG(INFO) << "[migrate] Array data in event buffer is not complete, read socket again";
+              VLOG(1) << "[migrate] Array data in event buffer is not complete, read socket again";
               run = false;
               break;
             }
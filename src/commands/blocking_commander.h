@@ -23,6 +23,7 @@
 #include "commander.h"
 #include "event_util.h"
 #include "server/redis_connection.h"
+#include "server/server.h"
 
 namespace redis {
 
@@ -53,6 +54,8 @@ class BlockingCommander : public Commander,
     }
 
     BlockKeys();
+    // A client blocking on many keys is still counted once
+    conn_->GetServer()->IncrBlockedClientNum();
     SetCB(conn_->GetBufferEvent());
 
     if (timeout) {
@@ -79,7 +82,7 @@ class BlockingCommander : public Commander,
       timer_.reset();
     }
 
-    UnblockKeys();
+    unblockAll();
     conn_->SetCB(bev);
     bufferevent_enable(bev, EV_READ);
     // We need to manually trigger the read event since we will stop processing commands
@@ -93,7 +96,7 @@ class BlockingCommander : public Commander,
       if (timer_ != nullptr) {
         timer_.reset();
       }
-      UnblockKeys();
+      unblockAll();
     }
     conn_->OnEvent(bev, events);
   }
@@ -113,7 +116,7 @@ class BlockingCommander : public Commander,
   void TimerCB(int, int16_t) {
     conn_->Reply(NoopReply());
     timer_.reset();
-    UnblockKeys();
+    unblockAll();
     auto bev = conn_->GetBufferEvent();
     conn_->SetCB(bev);
     bufferevent_enable(bev, EV_READ);
@@ -122,6 +125,12 @@ class BlockingCommander : public Commander,
  protected:
   Connection *conn_ = nullptr;
   UniqueEvent timer_;
+
+ private:
+  void unblockAll() {
+    UnblockKeys();
+    conn_->GetServer()->DecrBlockedClientNum();
+  }
 };
 
 }  // namespace redis
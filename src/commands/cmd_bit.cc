@@ -275,7 +275,7 @@ class CommandBitfield : public Commander {
       cmd.encoding = encoding.GetValue();
 
       // parse offset
-      if (!GetBitOffsetFromArgument(group[2], &cmd.offset).IsOK()) {
+      if (!parseBitfieldOffset(group[2], cmd.encoding, &cmd.offset).IsOK()) {
         return {Status::RedisParseErr, "bit offset is not an integer or out of range"};
       }
 
@@ -312,6 +312,10 @@ class CommandBitfield : public Commander {
     } else {
       s = bitmap_db.Bitfield(args_[1], cmds_, &rets);
     }
+    if (!s.ok() && !s.IsNotFound()) {
+      return {Status::RedisExecErr, s.ToString()};
+    }
+
     std::vector<std::string> str_rets(rets.size());
     for (size_t i = 0; i != rets.size(); ++i) {
       if (rets[i].has_value()) {
@@ -343,6 +347,20 @@ class CommandBitfield : public Commander {
     return Status::OK();
   }
 
+  // the offset is either a bit offset or `#<index>`, which is multiplied by the width of the encoding
+  static Status parseBitfieldOffset(const std::string &token, const BitfieldEncoding &encoding, uint32_t *offset) {
+    if (token.empty() || token[0] != '#') {
+      return GetBitOffsetFromArgument(token, offset);
+    }
+
+    auto index = GET_OR_RET(ParseInt<uint64_t>(token.substr(1), 10));
+    if (index > std::numeric_limits<uint32_t>::max() / encoding.Bits()) {
+      return {Status::NotOK, "bit offset is out of range"};
+    }
+    *offset = static_cast<uint32_t>(index * encoding.Bits());
+    return Status::OK();
+  }
+
   static StatusOr<BitfieldEncoding> parseBitfieldEncoding(const std::string &token) {
     if (token.empty()) {
       return {Status::RedisParseErr, errUnknownSubcommandOrWrongArguments};
@@ -372,14 +390,28 @@ class CommandBitfield : public Commander {
   }
 
   std::vector<BitfieldOperation> cmds_;
+
+ protected:
   bool read_only_;
 };
 
+class CommandBitfieldRO : public CommandBitfield {
+ public:
+  Status Parse(const std::vector<std::string> &args) override {
+    GET_OR_RET(CommandBitfield::Parse(args));
+    if (!read_only_) {
+      return {Status::RedisParseErr, "BITFIELD_RO only supports the GET subcommand"};
+    }
+    return Status::OK();
+  }
+};
+
 REDIS_REGISTER_COMMANDS(MakeCmdAttr<CommandGetBit>("getbit", 3, "read-only", 1, 1, 1),
                         MakeCmdAttr<CommandSetBit>("setbit", 4, "write", 1, 1, 1),
                         MakeCmdAttr<CommandBitCount>("bitcount", -2, "read-only", 1, 1, 1),
                         MakeCmdAttr<CommandBitPos>("bitpos", -3, "read-only", 1, 1, 1),
                         MakeCmdAttr<CommandBitOp>("bitop", -4, "write", 2, -1, 1),
-                        MakeCmdAttr<CommandBitfield>("bitfield", -2, "write", 1, 1, 1), )
+                        MakeCmdAttr<CommandBitfield>("bitfield", -2, "write", 1, 1, 1),
+                        MakeCmdAttr<CommandBitfieldRO>("bitfield_ro", -2, "read-only", 1, 1, 1), )
 
 }  // namespace redis
@@ -21,6 +21,7 @@
 #include "commander.h"
 #include "commands/command_parser.h"
 #include "error_constants.h"
+#include "random_util.h"
 #include "scan_base.h"
 #include "server/server.h"
 #include "types/redis_hash.h"
@@ -382,7 +383,7 @@ class CommandHRandField : public Commander {
   Status Parse(const std::vector<std::string> &args) override {
     if (args.size() >= 3) {
       no_parameters_ = false;
-      auto parse_result = ParseInt<int64_t>(args[2], 10);
+      auto parse_result = ParseInt<int64_t>(args[2], {util::kMinRandomCount, INT64_MAX}, 10);
       if (!parse_result) {
         return {Status::RedisParseErr, errValueNotInteger};
       }
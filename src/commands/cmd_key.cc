@@ -20,12 +20,14 @@
 
 #include <cstdint>
 
+#include "command_parser.h"
 #include "commander.h"
 #include "commands/ttl_util.h"
 #include "error_constants.h"
 #include "server/redis_reply.h"
 #include "server/server.h"
 #include "storage/redis_db.h"
+#include "types/redis_list.h"
 #include "time_util.h"
 
 namespace redis {
@@ -79,11 +81,97 @@ class CommandObject : public Commander {
       for (const auto &info : infos) {
         output->append(redis::BulkString(info));
       }
+    } else if (util::ToLower(args_[1]) == "details") {
+      redis::Database redis(srv->storage, conn->GetNamespace());
+      std::vector<std::string> infos;
+      auto s = redis.Details(args_[2], &infos);
+      if (!s.ok()) {
+        return {Status::RedisExecErr, s.ToString()};
+      }
+
+      output->append(redis::MultiLen(infos.size()));
+      for (const auto &info : infos) {
+        output->append(redis::BulkString(info));
+      }
+    } else if (util::ToLower(args_[1]) == "encoding") {
+      // the encoding is decided by the metadata, and the value of a string is stored right after it
+      redis::Database redis(srv->storage, conn->GetNamespace());
+      std::string raw_metadata;
+      auto s = redis.GetRawMetadataByUserKey(args_[2], &raw_metadata);
+      if (!s.ok() && !s.IsNotFound()) {
+        return {Status::RedisExecErr, s.ToString()};
+      }
+
+      Metadata metadata(kRedisNone, false);
+      Slice value(raw_metadata);
+      if (s.ok()) {
+        s = metadata.Decode(&value);
+        if (!s.ok()) return {Status::RedisExecErr, s.ToString()};
+      }
+      if (s.IsNotFound() || metadata.Expired() || (!metadata.IsEmptyableType() && metadata.size == 0)) {
+        *output = redis::NilString();
+        return Status::OK();
+      }
+      *output = redis::BulkString(objectEncoding(metadata.Type(), value));
+    } else if (util::ToLower(args_[1]) == "freq" || util::ToLower(args_[1]) == "idletime") {
+      if (!srv->GetConfig()->key_access_tracking) {
+        return {Status::RedisExecErr,
+                "key access tracking is disabled, enable it by setting key-access-tracking to yes"};
+      }
+
+      redis::Database redis(srv->storage, conn->GetNamespace());
+      RedisType type = kRedisNone;
+      auto s = redis.Type(args_[2], &type);
+      if (!s.ok()) {
+        return {Status::RedisExecErr, s.ToString()};
+      }
+      if (type == kRedisNone) {
+        *output = redis::NilString();
+        return Status::OK();
+      }
+
+      uint64_t now_ms = util::GetTimeStampMS();
+      if (util::ToLower(args_[1]) == "freq") {
+        *output = redis::Integer(srv->key_access.Frequency(conn->GetNamespace(), args_[2], now_ms));
+      } else {
+        *output = redis::Integer(srv->key_access.IdleTime(conn->GetNamespace(), args_[2], now_ms) / 1000);
+      }
     } else {
-      return {Status::RedisExecErr, "object subcommand must be dump"};
+      return {Status::RedisExecErr, "object subcommand must be dump, details, encoding, freq or idletime"};
     }
     return Status::OK();
   }
+
+ private:
+  // objectEncoding returns the encoding in the same names as Redis, so that the clients probing
+  // the encoding work as expected, though all types except strings are stored in subkeys on disk
+  static std::string objectEncoding(RedisType type, Slice value) {
+    switch (type) {
+      case kRedisString:
+        // only the short values are parsed, since an integer has at most 20 characters
+        if (value.size() <= 20 && ParseInt<int64_t>(value.ToString(), 10)) return "int";
+        return value.size() <= 44 ? "embstr" : "raw";
+      case kRedisHash:
+      case kRedisSet:
+        return "hashtable";
+      case kRedisList:
+        return "quicklist";
+      case kRedisZSet:
+        return "skiplist";
+      case kRedisBitmap:
+        return "raw";
+      case kRedisSortedint:
+        return "intset";
+      case kRedisStream:
+        return "stream";
+      case kRedisBloomFilter:
+        return "bloomfilter";
+      case kRedisJson:
+        return "json";
+      default:
+        return "unknown";
+    }
+  }
 };
 
 class CommandTTL : public Commander {
@@ -114,6 +202,32 @@ class CommandPTTL : public Commander {
   }
 };
 
+class CommandExpireTime : public Commander {
+ public:
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    redis::Database redis(srv->storage, conn->GetNamespace());
+    int64_t timestamp = 0;
+    auto s = redis.GetExpireTime(args_[1], &timestamp);
+    if (!s.ok()) return {Status::RedisExecErr, s.ToString()};
+
+    *output = redis::Integer(timestamp > 0 ? timestamp / 1000 : timestamp);
+    return Status::OK();
+  }
+};
+
+class CommandPExpireTime : public Commander {
+ public:
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    redis::Database redis(srv->storage, conn->GetNamespace());
+    int64_t timestamp = 0;
+    auto s = redis.GetExpireTime(args_[1], &timestamp);
+    if (!s.ok()) return {Status::RedisExecErr, s.ToString()};
+
+    *output = redis::Integer(timestamp);
+    return Status::OK();
+  }
+};
+
 class CommandExists : public Commander {
  public:
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
@@ -133,16 +247,44 @@ class CommandExists : public Commander {
   }
 };
 
+// parse the [NX | XX | GT | LT] options following the key and the time of EXPIRE-like commands
+static StatusOr<uint8_t> ParseExpireFlags(const std::vector<std::string> &args) {
+  uint8_t flags = 0;
+  CommandParser parser(args, 3);
+  while (parser.Good()) {
+    if (parser.EatEqICase("nx")) {
+      flags |= kExpireNX;
+    } else if (parser.EatEqICase("xx")) {
+      flags |= kExpireXX;
+    } else if (parser.EatEqICase("gt")) {
+      flags |= kExpireGT;
+    } else if (parser.EatEqICase("lt")) {
+      flags |= kExpireLT;
+    } else {
+      return parser.InvalidSyntax();
+    }
+  }
+
+  if ((flags & kExpireNX) && (flags & ~kExpireNX)) {
+    return {Status::RedisParseErr, "NX and XX, GT or LT options at the same time are not compatible"};
+  }
+  if ((flags & kExpireGT) && (flags & kExpireLT)) {
+    return {Status::RedisParseErr, "GT and LT options at the same time are not compatible"};
+  }
+  return flags;
+}
+
 class CommandExpire : public Commander {
  public:
   Status Parse(const std::vector<std::string> &args) override {
     ttl_ = GET_OR_RET(ParseInt<int64_t>(args[2], 10));
+    flags_ = GET_OR_RET(ParseExpireFlags(args));
     return Status::OK();
   }
 
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
     redis::Database redis(srv->storage, conn->GetNamespace());
-    auto s = redis.Expire(args_[1], ttl_ * 1000 + util::GetTimeStampMS());
+    auto s = redis.Expire(args_[1], ttl_ * 1000 + util::GetExpireTimeStampMS(), flags_);
     if (s.ok()) {
       *output = redis::Integer(1);
     } else {
@@ -153,18 +295,20 @@ class CommandExpire : public Commander {
 
  private:
   uint64_t ttl_ = 0;
+  uint8_t flags_ = 0;
 };
 
 class CommandPExpire : public Commander {
  public:
   Status Parse(const std::vector<std::string> &args) override {
-    seconds_ = GET_OR_RET(ParseInt<int64_t>(args[2], 10)) + util::GetTimeStampMS();
+    seconds_ = GET_OR_RET(ParseInt<int64_t>(args[2], 10)) + util::GetExpireTimeStampMS();
+    flags_ = GET_OR_RET(ParseExpireFlags(args));
     return Status::OK();
   }
 
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
     redis::Database redis(srv->storage, conn->GetNamespace());
-    auto s = redis.Expire(args_[1], seconds_);
+    auto s = redis.Expire(args_[1], seconds_, flags_);
     if (s.ok()) {
       *output = redis::Integer(1);
     } else {
@@ -175,6 +319,7 @@ class CommandPExpire : public Commander {
 
  private:
   uint64_t seconds_ = 0;
+  uint8_t flags_ = 0;
 };
 
 class CommandExpireAt : public Commander {
@@ -186,13 +331,14 @@ class CommandExpireAt : public Commander {
     }
 
     timestamp_ = *parse_result;
+    flags_ = GET_OR_RET(ParseExpireFlags(args));
 
     return Commander::Parse(args);
   }
 
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
     redis::Database redis(srv->storage, conn->GetNamespace());
-    auto s = redis.Expire(args_[1], timestamp_ * 1000);
+    auto s = redis.Expire(args_[1], timestamp_ * 1000, flags_);
     if (s.ok()) {
       *output = redis::Integer(1);
     } else {
@@ -203,6 +349,7 @@ class CommandExpireAt : public Commander {
 
  private:
   uint64_t timestamp_ = 0;
+  uint8_t flags_ = 0;
 };
 
 class CommandPExpireAt : public Commander {
@@ -214,13 +361,14 @@ class CommandPExpireAt : public Commander {
     }
 
     timestamp_ = *parse_result;
+    flags_ = GET_OR_RET(ParseExpireFlags(args));
 
     return Commander::Parse(args);
   }
 
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
     redis::Database redis(srv->storage, conn->GetNamespace());
-    auto s = redis.Expire(args_[1], timestamp_);
+    auto s = redis.Expire(args_[1], timestamp_, flags_);
     if (s.ok()) {
       *output = redis::Integer(1);
     } else {
@@ -231,6 +379,7 @@ class CommandPExpireAt : public Commander {
 
  private:
   uint64_t timestamp_ = 0;
+  uint8_t flags_ = 0;
 };
 
 class CommandPersist : public Commander {
@@ -254,6 +403,167 @@ class CommandPersist : public Commander {
   }
 };
 
+class CommandCopy : public Commander {
+ public:
+  // format: COPY source destination [DB destination-db] [REPLACE]
+  Status Parse(const std::vector<std::string> &args) override {
+    CommandParser parser(args, 3);
+    while (parser.Good()) {
+      if (parser.EatEqICase("replace")) {
+        replace_ = true;
+      } else if (parser.EatEqICase("db")) {
+        // kvrocks has only one database, so the destination must be it
+        auto db = GET_OR_RET(parser.TakeInt<int64_t>());
+        if (db != 0) {
+          return {Status::RedisParseErr, "DB index is out of range"};
+        }
+      } else {
+        return parser.InvalidSyntax();
+      }
+    }
+    return Commander::Parse(args);
+  }
+
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    if (args_[1] == args_[2]) {
+      return {Status::RedisExecErr, "source and destination objects are the same"};
+    }
+
+    redis::Database redis(srv->storage, conn->GetNamespace());
+    Database::CopyResult res = Database::CopyResult::DONE;
+    auto s = redis.Copy(args_[1], args_[2], !replace_, &res);
+    if (!s.ok()) return {Status::RedisExecErr, s.ToString()};
+
+    if (res == Database::CopyResult::DONE) {
+      // the clients blocked on the destination, e.g. by BLPOP or BZPOPMIN, can pop the copied elements
+      std::string raw_metadata;
+      Metadata metadata(kRedisNone, false);
+      if (redis.GetRawMetadataByUserKey(args_[2], &raw_metadata).ok() && metadata.Decode(raw_metadata).ok() &&
+          (metadata.Type() == kRedisList || metadata.Type() == kRedisZSet)) {
+        srv->WakeupBlockingConns(args_[2], metadata.size);
+      }
+    }
+
+    *output = redis::Integer(res == Database::CopyResult::DONE ? 1 : 0);
+    return Status::OK();
+  }
+
+ private:
+  bool replace_ = false;
+};
+
+template <bool read_only = false>
+class CommandSort : public Commander {
+ public:
+  // format: SORT key [BY pattern] [LIMIT offset count] [GET pattern [GET pattern ...]] [ASC|DESC] [ALPHA]
+  //              [STORE destination]
+  Status Parse(const std::vector<std::string> &args) override {
+    CommandParser parser(args, 2);
+    while (parser.Good()) {
+      if (parser.EatEqICase("BY")) {
+        sort_argument_.sortby = GET_OR_RET(parser.TakeStr());
+        // A pattern without '*' maps every element to the same key, so there's nothing to sort by
+        if (sort_argument_.sortby.find('*') == std::string::npos) {
+          sort_argument_.dontsort = true;
+        }
+      } else if (parser.EatEqICase("LIMIT")) {
+        sort_argument_.offset = GET_OR_RET(parser.TakeInt<int>());
+        sort_argument_.count = GET_OR_RET(parser.TakeInt<int>());
+      } else if (parser.EatEqICase("GET")) {
+        sort_argument_.getpatterns.emplace_back(GET_OR_RET(parser.TakeStr()));
+      } else if (parser.EatEqICase("ASC")) {
+        sort_argument_.desc = false;
+      } else if (parser.EatEqICase("DESC")) {
+        sort_argument_.desc = true;
+      } else if (parser.EatEqICase("ALPHA")) {
+        sort_argument_.alpha = true;
+      } else if (!read_only && parser.EatEqICase("STORE")) {
+        store_key_ = GET_OR_RET(parser.TakeStr());
+      } else {
+        return parser.InvalidSyntax();
+      }
+    }
+    return Status::OK();
+  }
+
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    // Patterns may refer to keys in other slots, which isn't allowed in cluster mode
+    if (srv->GetConfig()->cluster_enabled) {
+      if (!sort_argument_.sortby.empty() && !sort_argument_.dontsort) {
+        return {Status::RedisExecErr, "BY option of SORT denied in Cluster mode."};
+      }
+      for (const auto &pattern : sort_argument_.getpatterns) {
+        if (pattern != "#") {
+          return {Status::RedisExecErr, "GET option of SORT denied in Cluster mode."};
+        }
+      }
+    }
+
+    redis::Database redis(srv->storage, conn->GetNamespace());
+    std::vector<std::optional<std::string>> elems;
+    Database::SortResult res = Database::SortResult::DONE;
+    auto s = redis.Sort(args_[1], sort_argument_, &elems, &res);
+    if (!s.ok()) return {Status::RedisExecErr, s.ToString()};
+
+    switch (res) {
+      case Database::SortResult::UNKNOWN_TYPE:
+        return {Status::RedisExecErr, kErrMsgWrongType};
+      case Database::SortResult::DOUBLE_CONVERT_ERROR:
+        return {Status::RedisExecErr, "One or more scores can't be converted into double"};
+      case Database::SortResult::DONE:
+        break;
+    }
+
+    if (!store_key_.empty()) {
+      std::vector<std::string> values;
+      values.reserve(elems.size());
+      for (auto &elem : elems) {
+        values.emplace_back(elem.value_or(""));
+      }
+      redis::List list_db(srv->storage, conn->GetNamespace());
+      s = list_db.Overwrite(store_key_, values);
+      if (!s.ok()) return {Status::RedisExecErr, s.ToString()};
+
+      if (!values.empty()) {
+        srv->WakeupBlockingConns(store_key_, values.size());
+      }
+      *output = redis::Integer(values.size());
+      return Status::OK();
+    }
+
+    *output = redis::MultiLen(elems.size());
+    for (const auto &elem : elems) {
+      *output += elem ? redis::BulkString(*elem) : redis::NilString();
+    }
+    return Status::OK();
+  }
+
+  // the STORE destination is also a key of the command, like sortGetKeys in Redis
+  static const inline CommandKeyRangeVecGen keyRangeGen = [](const std::vector<std::string> &args) {
+    std::vector<CommandKeyRange> key_ranges{{1, 1, 1}};
+    int store_index = 0;
+    for (size_t i = 2; i < args.size(); i++) {
+      if (util::EqualICase(args[i], "limit")) {
+        i += 2;
+      } else if (util::EqualICase(args[i], "by") || util::EqualICase(args[i], "get")) {
+        i += 1;
+      } else if (util::EqualICase(args[i], "store") && i + 1 < args.size()) {
+        // only the last STORE option takes effect
+        store_index = static_cast<int>(i + 1);
+        i += 1;
+      }
+    }
+    if (store_index > 0) {
+      key_ranges.push_back({store_index, store_index, 1});
+    }
+    return key_ranges;
+  };
+
+ private:
+  SortArgument sort_argument_;
+  std::string store_key_;
+};
+
 class CommandDel : public Commander {
  public:
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
@@ -273,18 +583,23 @@ class CommandDel : public Commander {
   }
 };
 
-REDIS_REGISTER_COMMANDS(MakeCmdAttr<CommandTTL>("ttl", 2, "read-only", 1, 1, 1),
-                        MakeCmdAttr<CommandPTTL>("pttl", 2, "read-only", 1, 1, 1),
-                        MakeCmdAttr<CommandType>("type", 2, "read-only", 1, 1, 1),
+REDIS_REGISTER_COMMANDS(MakeCmdAttr<CommandTTL>("ttl", 2, "read-only no-touch", 1, 1, 1),
+                        MakeCmdAttr<CommandPTTL>("pttl", 2, "read-only no-touch", 1, 1, 1),
+                        MakeCmdAttr<CommandType>("type", 2, "read-only no-touch", 1, 1, 1),
                         MakeCmdAttr<CommandMove>("move", 3, "write", 1, 1, 1),
-                        MakeCmdAttr<CommandObject>("object", 3, "read-only", 2, 2, 1),
-                        MakeCmdAttr<CommandExists>("exists", -2, "read-only", 1, -1, 1),
+                        MakeCmdAttr<CommandObject>("object", 3, "read-only no-touch", 2, 2, 1),
+                        MakeCmdAttr<CommandExists>("exists", -2, "read-only no-touch", 1, -1, 1),
                         MakeCmdAttr<CommandPersist>("persist", 2, "write", 1, 1, 1),
-                        MakeCmdAttr<CommandExpire>("expire", 3, "write", 1, 1, 1),
-                        MakeCmdAttr<CommandPExpire>("pexpire", 3, "write", 1, 1, 1),
-                        MakeCmdAttr<CommandExpireAt>("expireat", 3, "write", 1, 1, 1),
-                        MakeCmdAttr<CommandPExpireAt>("pexpireat", 3, "write", 1, 1, 1),
+                        MakeCmdAttr<CommandExpire>("expire", -3, "write", 1, 1, 1),
+                        MakeCmdAttr<CommandPExpire>("pexpire", -3, "write", 1, 1, 1),
+                        MakeCmdAttr<CommandExpireAt>("expireat", -3, "write", 1, 1, 1),
+                        MakeCmdAttr<CommandPExpireAt>("pexpireat", -3, "write", 1, 1, 1),
+                        MakeCmdAttr<CommandExpireTime>("expiretime", 2, "read-only no-touch", 1, 1, 1),
+                        MakeCmdAttr<CommandPExpireTime>("pexpiretime", 2, "read-only no-touch", 1, 1, 1),
+                        MakeCmdAttr<CommandCopy>("copy", -3, "write", 1, 2, 1),
                         MakeCmdAttr<CommandDel>("del", -2, "write", 1, -1, 1),
-                        MakeCmdAttr<CommandDel>("unlink", -2, "write", 1, -1, 1), )
+                        MakeCmdAttr<CommandDel>("unlink", -2, "write", 1, -1, 1),
+                        MakeCmdAttr<CommandSort<>>("sort", -2, "write", CommandSort<>::keyRangeGen),
+                        MakeCmdAttr<CommandSort<true>>("sort_ro", -2, "read-only", 1, 1, 1), )
 
 }  // namespace redis
@@ -163,6 +163,9 @@ class CommandLMPop : public Commander {
     CommandParser parser(args, 1);
 
     auto num_keys = GET_OR_RET(parser.TakeInt<uint32_t>());
+    if (num_keys == 0) {
+      return {Status::RedisParseErr, "numkeys should be greater than 0"};
+    }
     keys_.clear();
     keys_.reserve(num_keys);
     for (uint32_t i = 0; i < num_keys; ++i) {
@@ -181,6 +184,9 @@ class CommandLMPop : public Commander {
     while (parser.Good()) {
       if (parser.EatEqICase("count") && count_ == static_cast<uint32_t>(-1)) {
         count_ = GET_OR_RET(parser.TakeInt<uint32_t>());
+        if (count_ == 0 || count_ == static_cast<uint32_t>(-1)) {
+          return {Status::RedisParseErr, "count should be greater than 0"};
+        }
       } else {
         return parser.InvalidSyntax();
       }
@@ -347,9 +353,15 @@ class CommandBLMPop : public BlockingCommander {
     CommandParser parser(args, 1);
 
     auto timeout = GET_OR_RET(parser.TakeFloat());
+    if (timeout < 0) {
+      return {Status::RedisParseErr, "timeout should not be negative"};
+    }
     timeout_ = static_cast<int64_t>(timeout * 1000 * 1000);
 
     auto num_keys = GET_OR_RET(parser.TakeInt<uint32_t>());
+    if (num_keys == 0) {
+      return {Status::RedisParseErr, "numkeys should be greater than 0"};
+    }
     keys_.clear();
     keys_.reserve(num_keys);
     for (uint32_t i = 0; i < num_keys; ++i) {
@@ -368,6 +380,9 @@ class CommandBLMPop : public BlockingCommander {
     while (parser.Good()) {
       if (parser.EatEqICase("count") && count_ == static_cast<uint32_t>(-1)) {
         count_ = GET_OR_RET(parser.TakeInt<uint32_t>());
+        if (count_ == 0 || count_ == static_cast<uint32_t>(-1)) {
+          return {Status::RedisParseErr, "count should be greater than 0"};
+        }
       } else {
         return parser.InvalidSyntax();
       }
@@ -781,10 +796,6 @@ class CommandBLMove : public BlockingCommander {
 class CommandLPos : public Commander {
  public:
   Status Parse(const std::vector<std::string> &args) override {
-    if (args.size() > 9) {
-      return {Status::RedisParseErr, errInvalidSyntax};
-    }
-
     CommandParser parser(args, 3);
     while (parser.Good()) {
       if (parser.EatEqICase("rank")) {
@@ -832,14 +843,12 @@ class CommandLPos : public Commander {
         *output = redis::Integer(indexes[0]);
       }
     }
-    // Otherwise we return an array.
+    // Otherwise we return an array of integers.
     else {
-      std::vector<std::string> values;
-      values.reserve(indexes.size());
+      *output = redis::MultiLen(indexes.size());
       for (const auto &index : indexes) {
-        values.emplace_back(std::to_string(index));
+        output->append(redis::Integer(index));
       }
-      *output = redis::MultiBulkString(values, false);
     }
     return Status::OK();
   }
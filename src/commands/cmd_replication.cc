@@ -20,6 +20,7 @@
 
 #include "commander.h"
 #include "error_constants.h"
+#include "event_util.h"
 #include "io_util.h"
 #include "scope_exit.h"
 #include "server/server.h"
@@ -178,6 +179,9 @@ class CommandReplConf : public Commander {
         return {Status::RedisParseErr, "ip-address should not be empty"};
       }
       ip_address_ = value;
+    } else if (option == "capa") {
+      // the acks are read by the feeding thread without checking the capability,
+      // and unknown capabilities are ignored for the forward compatibility
     } else {
       return {Status::RedisParseErr, errUnknownOption};
     }
@@ -337,12 +341,107 @@ class CommandDBName : public Commander {
   }
 };
 
+class CommandWait : public Commander,
+                    private EvbufCallbackBase<CommandWait, false, false>,
+                    private EventCallbackBase<CommandWait> {
+ public:
+  Status Parse(const std::vector<std::string> &args) override {
+    auto num_replicas = ParseInt<int64_t>(args[1], 10);
+    if (!num_replicas) {
+      return {Status::RedisParseErr, errValueNotInteger};
+    }
+    num_replicas_ = *num_replicas;
+
+    auto timeout = ParseInt<int64_t>(args[2], 10);
+    if (!timeout) {
+      return {Status::RedisParseErr, errValueNotInteger};
+    }
+    if (*timeout < 0) {
+      return {Status::RedisParseErr, errTimeoutIsNegative};
+    }
+    timeout_ms_ = *timeout;
+
+    return Commander::Parse(args);
+  }
+
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    if (srv->IsSlave()) {
+      return {Status::RedisExecErr, "WAIT cannot be used with replica instances"};
+    }
+
+    srv_ = srv;
+    conn_ = conn;
+    // replicas which acknowledged the sequence of the last write in this connection
+    // have received all writes sent by this connection
+    target_seq_ = conn->GetLastWriteSeq();
+    auto acked = srv->GetAckedSlavesNum(target_seq_);
+    if (conn->IsInExec() || static_cast<int64_t>(acked) >= num_replicas_) {
+      *output = redis::Integer(acked);
+      return Status::OK();  // no blocking in multi-exec
+    }
+
+    if (timeout_ms_ > 0) {
+      deadline_ms_ = util::GetTimeStampMS() + timeout_ms_;
+    }
+
+    srv->IncrBlockedClientNum();
+    auto bev = conn->GetBufferEvent();
+    SetCB(bev);
+
+    // the acks are received by the feeding threads, so check them periodically
+    timer_.reset(NewEvent(bufferevent_get_base(bev), -1, EV_PERSIST));
+    timeval tm = {0, kCheckIntervalMs * 1000};
+    evtimer_add(timer_.get(), &tm);
+
+    return {Status::BlockingCmd};
+  }
+
+  void OnEvent(bufferevent *bev, int16_t events) {
+    if (events & (BEV_EVENT_EOF | BEV_EVENT_ERROR)) {
+      if (timer_ != nullptr) {
+        timer_.reset();
+      }
+      srv_->DecrBlockedClientNum();
+    }
+    conn_->OnEvent(bev, events);
+  }
+
+  void TimerCB(int, int16_t) {
+    auto acked = srv_->GetAckedSlavesNum(target_seq_);
+    if (static_cast<int64_t>(acked) < num_replicas_ && (deadline_ms_ == 0 || util::GetTimeStampMS() < deadline_ms_)) {
+      return;
+    }
+
+    conn_->Reply(redis::Integer(acked));
+    timer_.reset();
+    srv_->DecrBlockedClientNum();
+
+    auto bev = conn_->GetBufferEvent();
+    conn_->SetCB(bev);
+    bufferevent_enable(bev, EV_READ);
+    // process the commands which were sent while waiting
+    bufferevent_trigger(bev, EV_READ, BEV_TRIG_IGNORE_WATERMARKS);
+  }
+
+ private:
+  static const int kCheckIntervalMs = 10;
+
+  int64_t num_replicas_ = 0;
+  int64_t timeout_ms_ = 0;
+  uint64_t deadline_ms_ = 0;
+  uint64_t target_seq_ = 0;
+  Server *srv_ = nullptr;
+  Connection *conn_ = nullptr;
+  UniqueEvent timer_;
+};
+
 REDIS_REGISTER_COMMANDS(MakeCmdAttr<CommandReplConf>("replconf", -3, "read-only replication no-script", 0, 0, 0),
                         MakeCmdAttr<CommandPSync>("psync", -2, "read-only replication no-multi no-script", 0, 0, 0),
                         MakeCmdAttr<CommandFetchMeta>("_fetch_meta", 1, "read-only replication no-multi no-script", 0,
                                                       0, 0),
                         MakeCmdAttr<CommandFetchFile>("_fetch_file", 2, "read-only replication no-multi no-script", 0,
                                                       0, 0),
-                        MakeCmdAttr<CommandDBName>("_db_name", 1, "read-only replication no-multi", 0, 0, 0), )
+                        MakeCmdAttr<CommandDBName>("_db_name", 1, "read-only replication no-multi", 0, 0, 0),
+                        MakeCmdAttr<CommandWait>("wait", 3, "read-only no-script", 0, 0, 0), )
 
 }  // namespace redis
@@ -18,6 +18,9 @@
  *
  */
 
+#include <algorithm>
+#include <climits>
+
 #include "command_parser.h"
 #include "commander.h"
 #include "commands/scan_base.h"
@@ -31,6 +34,7 @@
 #include "storage/rdb.h"
 #include "string_util.h"
 #include "time_util.h"
+#include "unique_fd.h"
 
 namespace redis {
 
@@ -89,7 +93,7 @@ class CommandNamespace : public Commander {
 
     Config *config = srv->GetConfig();
     std::string sub_command = util::ToLower(args_[1]);
-    if (config->repl_namespace_enabled && config->IsSlave() && sub_command != "get") {
+    if (config->repl_namespace_enabled && config->IsSlave() && sub_command != "get" && sub_command != "stats") {
       return {Status::RedisExecErr, "namespace is read-only for slave"};
     }
     if (args_.size() == 3 && sub_command == "get") {
@@ -125,11 +129,48 @@ class CommandNamespace : public Commander {
       Status s = srv->GetNamespace()->Del(args_[2]);
       *output = s.IsOK() ? redis::SimpleString("OK") : redis::Error("ERR " + s.Msg());
       LOG(WARNING) << "Deleted namespace: " << args_[2] << ", addr: " << conn->GetAddr() << ", result: " << s.Msg();
+    } else if (args_.size() == 3 && sub_command == "stats") {
+      if (args_[2] == "*") {
+        std::vector<std::string> stats;
+        for (const auto &token : srv->GetNamespace()->List()) {
+          stats.emplace_back(redis::BulkString(token.second));
+          stats.emplace_back(namespaceStats(srv, token.second));
+        }
+        stats.emplace_back(redis::BulkString(kDefaultNamespace));
+        stats.emplace_back(namespaceStats(srv, kDefaultNamespace));
+        *output = redis::Array(stats);
+      } else if (args_[2] != kDefaultNamespace && srv->GetNamespace()->Get(args_[2]).Is<Status::NotFound>()) {
+        *output = redis::NilString();
+      } else {
+        *output = namespaceStats(srv, args_[2]);
+      }
     } else {
-      return {Status::RedisExecErr, "NAMESPACE subcommand must be one of GET, SET, DEL, ADD"};
+      return {Status::RedisExecErr, "NAMESPACE subcommand must be one of GET, SET, DEL, ADD, STATS"};
     }
     return Status::OK();
   }
+
+ private:
+  // The key numbers are as of the latest DBSIZE SCAN in the namespace, and the size
+  // of the default namespace covers all namespaces.
+  static std::string namespaceStats(Server *srv, const std::string &ns) {
+    KeyNumStats stats;
+    srv->GetLatestKeyNumStats(ns, &stats);
+    return redis::Array({
+        redis::BulkString("keys"),
+        redis::Integer(stats.n_key),
+        redis::BulkString("expires"),
+        redis::Integer(stats.n_expires),
+        redis::BulkString("avg_ttl"),
+        redis::Integer(stats.avg_ttl),
+        redis::BulkString("expired"),
+        redis::Integer(stats.n_expired),
+        redis::BulkString("used_db_size"),
+        redis::Integer(srv->storage->GetTotalSize(ns)),
+        redis::BulkString("last_scan_time"),
+        redis::Integer(srv->GetLastScanTime(ns)),
+    });
+  }
 };
 
 class CommandKeys : public Commander {
@@ -236,7 +277,7 @@ class CommandConfig : public Commander {
     Config *config = srv->GetConfig();
     std::string sub_command = util::ToLower(args_[1]);
     if ((sub_command == "rewrite" && args_.size() != 2) || (sub_command == "get" && args_.size() != 3) ||
-        (sub_command == "set" && args_.size() != 4)) {
+        (sub_command == "set" && args_.size() != 4) || (sub_command == "resetstat" && args_.size() != 2)) {
       return {Status::RedisExecErr, errWrongNumOfArguments};
     }
 
@@ -257,8 +298,11 @@ class CommandConfig : public Commander {
       } else {
         *output = redis::SimpleString("OK");
       }
+    } else if (args_.size() == 2 && sub_command == "resetstat") {
+      srv->stats.Reset();
+      *output = redis::SimpleString("OK");
     } else {
-      return {Status::RedisExecErr, "CONFIG subcommand must be one of GET, SET, REWRITE"};
+      return {Status::RedisExecErr, "CONFIG subcommand must be one of GET, SET, REWRITE, RESETSTAT"};
     }
     return Status::OK();
   }
@@ -310,7 +354,64 @@ class CommandDisk : public Commander {
   }
 };
 
-class CommandMemory : public CommandDisk {};
+class CommandMemory : public Commander {
+ public:
+  Status Parse(const std::vector<std::string> &args) override {
+    CommandParser parser(args, 1);
+    if (parser.EatEqICase("stats") || parser.EatEqICase("doctor")) {
+      if (args.size() != 2) return {Status::RedisParseErr, errWrongNumOfArguments};
+      return Commander::Parse(args);
+    }
+    if (!parser.EatEqICase("usage")) return {Status::RedisInvalidCmd, "Unknown operation"};
+    if (args.size() < 3) return {Status::RedisParseErr, errWrongNumOfArguments};
+    // skip the key
+    parser.Skip(1);
+    while (parser.Good()) {
+      if (parser.EatEqICase("samples")) {
+        samples_ = GET_OR_RET(parser.TakeInt<uint64_t>());
+      } else {
+        return parser.InvalidSyntax();
+      }
+    }
+    return Commander::Parse(args);
+  }
+
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    std::string subcommand = util::ToLower(args_[1]);
+    if (subcommand == "stats") {
+      auto stats = srv->GetMemoryStats();
+      output->append(redis::MultiLen(stats.size() * 2));
+      for (const auto &[name, value] : stats) {
+        output->append(redis::BulkString(name));
+        output->append(redis::Integer(value));
+      }
+      return Status::OK();
+    }
+    if (subcommand == "doctor") {
+      *output = redis::BulkString(srv->GetMemoryDoctorReport());
+      return Status::OK();
+    }
+
+    redis::Disk disk_db(srv->storage, conn->GetNamespace());
+    uint64_t usage = 0;
+    auto s = disk_db.EstimateKeyUsage(args_[2], samples_, &usage);
+    if (!s.ok()) {
+      // Redis returns the Nil string when the key does not exist
+      if (s.IsNotFound()) {
+        *output = redis::NilString();
+        return Status::OK();
+      }
+      return {Status::RedisExecErr, s.ToString()};
+    }
+
+    *output = redis::Integer(usage);
+    return Status::OK();
+  }
+
+ private:
+  // the same default as Redis, 0 means all elements are read
+  uint64_t samples_ = 5;
+};
 
 class CommandRole : public Commander {
  public:
@@ -342,6 +443,93 @@ class CommandDBSize : public Commander {
   }
 };
 
+class CommandBigKeys : public Commander {
+ public:
+  Status Parse(const std::vector<std::string> &args) override {
+    CommandParser parser(args, 1);
+    if (!parser.Good()) return Status::OK();
+    if (!parser.EatEqICase("scan")) return {Status::RedisParseErr, "BIGKEYS subcommand only supports scan"};
+
+    is_scan_ = true;
+    while (parser.Good()) {
+      if (parser.EatEqICase("count")) {
+        count_ = GET_OR_RET(parser.TakeInt<uint64_t>(NumericRange<uint64_t>{1, kMaxCount}));
+      } else if (parser.EatEqICase("rate")) {
+        keys_per_sec_ = GET_OR_RET(parser.TakeInt<uint64_t>(NumericRange<uint64_t>{0, kMaxKeysPerSec}));
+      } else {
+        return parser.InvalidSyntax();
+      }
+    }
+    return Status::OK();
+  }
+
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    std::string ns = conn->GetNamespace();
+    if (is_scan_) {
+      Status s = srv->AsyncScanBigKeys(ns, count_, keys_per_sec_);
+      if (!s.IsOK()) return {Status::RedisExecErr, s.Msg()};
+      *output = redis::SimpleString("OK");
+      return Status::OK();
+    }
+
+    // The big keys are as of the latest BIGKEYS SCAN in the namespace
+    auto info = srv->GetBigKeyScanInfo(ns);
+    std::vector<std::string> big_keys;
+    for (const auto &big_key : info.big_key_stats.big_keys) {
+      big_keys.emplace_back(redis::Array({redis::BulkString(RedisTypeNames[big_key.type]),
+                                          redis::BulkString(big_key.key), redis::Integer(big_key.size)}));
+    }
+    *output = redis::Array({
+        redis::BulkString("last_scan_time"),
+        redis::Integer(info.last_scan_time),
+        redis::BulkString("scanning"),
+        redis::Integer(info.is_scanning ? 1 : 0),
+        redis::BulkString("scanned_keys"),
+        redis::Integer(info.big_key_stats.n_scanned),
+        redis::BulkString("keys"),
+        redis::Array(big_keys),
+    });
+    return Status::OK();
+  }
+
+ private:
+  static constexpr uint64_t kMaxCount = 1000;
+  static constexpr uint64_t kMaxKeysPerSec = 100 * 1000 * 1000;
+
+  bool is_scan_ = false;
+  uint64_t count_ = 10;
+  uint64_t keys_per_sec_ = 10000;
+};
+
+class CommandHotKeys : public Commander {
+ public:
+  Status Parse(const std::vector<std::string> &args) override {
+    if (args.size() > 2) return {Status::RedisParseErr, errWrongNumOfArguments};
+    if (args.size() == 2) {
+      count_ = GET_OR_RET(ParseInt<uint64_t>(args[1], NumericRange<uint64_t>{1, kHotKeysMaxCount}, 10));
+    }
+    return Status::OK();
+  }
+
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    if (!srv->GetConfig()->hot_keys_tracking) {
+      return {Status::RedisExecErr, "hot keys tracking is disabled, enable it by setting hot-keys-tracking to yes"};
+    }
+
+    auto hot_keys = srv->hot_keys.GetTop(conn->GetNamespace(), count_, util::GetTimeStampMS());
+    output->append(redis::MultiLen(hot_keys.size()));
+    for (const auto &hot_key : hot_keys) {
+      output->append(redis::MultiLen(2));
+      output->append(redis::BulkString(hot_key.key));
+      output->append(redis::Integer(hot_key.qps));
+    }
+    return Status::OK();
+  }
+
+ private:
+  uint64_t count_ = 10;
+};
+
 class CommandPerfLog : public Commander {
  public:
   Status Parse(const std::vector<std::string> &args) override {
@@ -387,28 +575,56 @@ class CommandSlowlog : public Commander {
       return {Status::NotOK, "SLOWLOG subcommand must be one of RESET, LEN, GET"};
     }
 
-    if (subcommand_ == "get" && args.size() >= 3) {
+    CommandParser parser(args, 2);
+    if (subcommand_ == "get" && parser.Good() && !util::EqualICase(args[2], "namespace") &&
+        !util::EqualICase(args[2], "withnamespace")) {
       if (args[2] == "*") {
         cnt_ = 0;
       } else {
         cnt_ = GET_OR_RET(ParseInt<int64_t>(args[2], 10));
       }
+      parser.Skip(1);
+    }
+
+    while (parser.Good()) {
+      if (parser.EatEqICase("namespace")) {
+        namespace_ = GET_OR_RET(parser.TakeStr());
+      } else if (subcommand_ == "get" && parser.EatEqICase("withnamespace")) {
+        with_namespace_ = true;
+      } else {
+        return parser.InvalidSyntax();
+      }
     }
 
     return Status::OK();
   }
 
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    // clients of namespaces can only see and reset the entries of their own namespace
+    std::string ns = namespace_;
+    if (!conn->IsAdmin()) {
+      if (!ns.empty() && ns != conn->GetNamespace()) return {Status::RedisExecErr, errAdminPermissionRequired};
+      ns = conn->GetNamespace();
+    }
+    LogCollector<SlowEntry>::Filter filter;
+    if (!ns.empty()) {
+      filter = [ns](const SlowEntry &entry) { return entry.ns == ns; };
+    }
+
     auto slowlog = srv->GetSlowLog();
     if (subcommand_ == "reset") {
-      slowlog->Reset();
+      slowlog->Reset(filter);
       *output = redis::SimpleString("OK");
       return Status::OK();
     } else if (subcommand_ == "len") {
-      *output = redis::Integer(static_cast<int64_t>(slowlog->Size()));
+      *output = redis::Integer(static_cast<int64_t>(slowlog->Size(filter)));
       return Status::OK();
     } else if (subcommand_ == "get") {
-      *output = slowlog->GetLatestEntries(cnt_);
+      auto entries = slowlog->GetLatest(cnt_, filter);
+      *output = redis::MultiLen(entries.size());
+      for (const auto &entry : entries) {
+        output->append(entry.ToRedisString(with_namespace_));
+      }
       return Status::OK();
     }
     return {Status::NotOK, "SLOWLOG subcommand must be one of RESET, LEN, GET"};
@@ -417,18 +633,117 @@ class CommandSlowlog : public Commander {
  private:
   std::string subcommand_;
   int64_t cnt_ = 10;
+  std::string namespace_;
+  bool with_namespace_ = false;
+};
+
+class CommandLatency : public Commander {
+ public:
+  Status Parse(const std::vector<std::string> &args) override {
+    subcommand_ = util::ToLower(args[1]);
+    if ((subcommand_ == "latest" && args.size() == 2) || (subcommand_ == "history" && args.size() == 3) ||
+        subcommand_ == "reset" || subcommand_ == "histogram") {
+      return Status::OK();
+    }
+    return {Status::RedisParseErr,
+            "LATENCY subcommand must be one of LATEST, HISTORY <event>, RESET [<event> ...], HISTOGRAM [<cmd> ...]"};
+  }
+
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    if (subcommand_ == "histogram") {
+      histogram(srv, output);
+      return Status::OK();
+    }
+
+    auto monitor = srv->storage->GetLatencyMonitor();
+    if (subcommand_ == "latest") {
+      auto latest = monitor->GetLatest();
+      output->append(redis::MultiLen(latest.size()));
+      for (const auto &stats : latest) {
+        output->append(redis::MultiLen(4));
+        output->append(redis::BulkString(stats.event));
+        output->append(redis::Integer(stats.latest.time));
+        output->append(redis::Integer(stats.latest.latency_ms));
+        output->append(redis::Integer(stats.max_latency_ms));
+      }
+    } else if (subcommand_ == "history") {
+      auto history = monitor->GetHistory(args_[2]);
+      output->append(redis::MultiLen(history.size()));
+      for (const auto &sample : history) {
+        output->append(redis::MultiLen(2));
+        output->append(redis::Integer(sample.time));
+        output->append(redis::Integer(sample.latency_ms));
+      }
+    } else {
+      // the latency history is server-wide, so only the admin can wipe it
+      if (!conn->IsAdmin()) {
+        return {Status::RedisExecErr, errAdminPermissionRequired};
+      }
+      std::vector<std::string> events(args_.begin() + 2, args_.end());
+      *output = redis::Integer(monitor->Reset(events));
+    }
+    return Status::OK();
+  }
+
+ private:
+  std::string subcommand_;
+
+  // histogram replies in the same layout as the Redis 7 LATENCY HISTOGRAM, commands without
+  // any calls or unknown commands are skipped and all called commands are reported if none is given.
+  void histogram(Server *srv, std::string *output) {
+    std::vector<std::string> names;
+    if (args_.size() == 2) {
+      for (const auto &iter : srv->stats.commands_stats) names.emplace_back(iter.first);
+    } else {
+      for (size_t i = 2; i < args_.size(); i++) names.emplace_back(util::ToLower(args_[i]));
+    }
+
+    std::string entries;
+    size_t count = 0;
+    for (const auto &name : names) {
+      auto iter = srv->stats.commands_stats.find(name);
+      if (iter == srv->stats.commands_stats.end()) continue;
+      auto calls = iter->second.latency_histogram.Count();
+      if (calls == 0) continue;
+
+      auto buckets = iter->second.latency_histogram.CumulativeBuckets();
+      entries.append(redis::BulkString(name));
+      entries.append(redis::MultiLen(4));
+      entries.append(redis::BulkString("calls"));
+      entries.append(redis::Integer(calls));
+      entries.append(redis::BulkString("histogram_usec"));
+      entries.append(redis::MultiLen(buckets.size() * 2));
+      for (const auto &[bound, cumulative] : buckets) {
+        entries.append(redis::Integer(bound));
+        entries.append(redis::Integer(cumulative));
+      }
+      count++;
+    }
+    *output = redis::MultiLen(count * 2) + entries;
+  }
 };
 
 class CommandClient : public Commander {
  public:
   Status Parse(const std::vector<std::string> &args) override {
     subcommand_ = util::ToLower(args[1]);
-    // subcommand: getname id kill list info setname
+    // subcommand: getname id kill list info setname setinfo no-touch no-evict
     if ((subcommand_ == "id" || subcommand_ == "getname" || subcommand_ == "list" || subcommand_ == "info") &&
         args.size() == 2) {
       return Status::OK();
     }
 
+    if ((subcommand_ == "no-touch" || subcommand_ == "no-evict") && args.size() == 3) {
+      if (!strcasecmp(args[2].c_str(), "on")) {
+        flag_on_ = true;
+      } else if (!strcasecmp(args[2].c_str(), "off")) {
+        flag_on_ = false;
+      } else {
+        return {Status::RedisParseErr, errInvalidSyntax};
+      }
+      return Status::OK();
+    }
+
     if ((subcommand_ == "setname") && args.size() == 3) {
       // Check if the charset is ok. We need to do this otherwise
       // CLIENT LIST or CLIENT INFO format will break. You should always be able to
@@ -443,59 +758,89 @@ class CommandClient : public Commander {
       return Status::OK();
     }
 
+    if ((subcommand_ == "setinfo") && args.size() == 4) {
+      info_attr_ = util::ToLower(args[2]);
+      if (info_attr_ != "lib-name" && info_attr_ != "lib-ver") {
+        return {Status::RedisParseErr, "Unrecognized option '" + args[2] + "'"};
+      }
+      // the same as the client name, the lib info shouldn't break the format of CLIENT LIST
+      for (auto ch : args[3]) {
+        if (ch < '!' || ch > '~') {
+          return {Status::RedisInvalidCmd, info_attr_ + " cannot contain spaces, newlines or special characters"};
+        }
+      }
+
+      info_value_ = args[3];
+      return Status::OK();
+    }
+
     if ((subcommand_ == "kill")) {
       if (args.size() == 2) {
         return {Status::RedisParseErr, errInvalidSyntax};
       }
 
       if (args.size() == 3) {
-        addr_ = args[2];
+        kill_filter_.addr = args[2];
         new_format_ = false;
         return Status::OK();
       }
 
-      size_t i = 2;
+      // the new format must be in pairs of <filter> <value>
+      if (args.size() % 2 != 0) {
+        return {Status::RedisParseErr, errInvalidSyntax};
+      }
+
       new_format_ = true;
+      for (size_t i = 2; i < args.size(); i += 2) {
+        const auto &value = args[i + 1];
+        if (!strcasecmp(args[i].c_str(), "addr")) {
+          kill_filter_.addr = value;
+        } else if (!strcasecmp(args[i].c_str(), "laddr")) {
+          kill_filter_.laddr = value;
+        } else if (!strcasecmp(args[i].c_str(), "id")) {
+          auto parse_result = ParseInt<uint64_t>(value, 10);
+          if (!parse_result) {
+            return {Status::RedisParseErr, errValueNotInteger};
+          }
 
-      while (i < args.size()) {
-        bool more_args = i < args.size();
-        if (!strcasecmp(args[i].c_str(), "addr") && more_args) {
-          addr_ = args[i + 1];
-        } else if (!strcasecmp(args[i].c_str(), "id") && more_args) {
-          auto parse_result = ParseInt<uint64_t>(args[i + 1], 10);
+          kill_filter_.id = *parse_result;
+        } else if (!strcasecmp(args[i].c_str(), "user") || !strcasecmp(args[i].c_str(), "namespace")) {
+          // kvrocks has no ACL users, clients are authenticated into namespaces instead
+          kill_filter_.ns = value;
+        } else if (!strcasecmp(args[i].c_str(), "maxage")) {
+          auto parse_result = ParseInt<uint64_t>(value, 10);
           if (!parse_result) {
             return {Status::RedisParseErr, errValueNotInteger};
           }
 
-          id_ = *parse_result;
-        } else if (!strcasecmp(args[i].c_str(), "skipme") && more_args) {
-          if (!strcasecmp(args[i + 1].c_str(), "yes")) {
-            skipme_ = true;
-          } else if (!strcasecmp(args[i + 1].c_str(), "no")) {
-            skipme_ = false;
+          kill_filter_.max_age = *parse_result;
+        } else if (!strcasecmp(args[i].c_str(), "skipme")) {
+          if (!strcasecmp(value.c_str(), "yes")) {
+            kill_filter_.skipme = true;
+          } else if (!strcasecmp(value.c_str(), "no")) {
+            kill_filter_.skipme = false;
           } else {
             return {Status::RedisParseErr, errInvalidSyntax};
           }
-        } else if (!strcasecmp(args[i].c_str(), "type") && more_args) {
-          if (!strcasecmp(args[i + 1].c_str(), "normal")) {
-            kill_type_ |= kTypeNormal;
-          } else if (!strcasecmp(args[i + 1].c_str(), "pubsub")) {
-            kill_type_ |= kTypePubsub;
-          } else if (!strcasecmp(args[i + 1].c_str(), "master")) {
-            kill_type_ |= kTypeMaster;
-          } else if (!strcasecmp(args[i + 1].c_str(), "replica") || !strcasecmp(args[i + 1].c_str(), "slave")) {
-            kill_type_ |= kTypeSlave;
+        } else if (!strcasecmp(args[i].c_str(), "type")) {
+          if (!strcasecmp(value.c_str(), "normal")) {
+            kill_filter_.type |= kTypeNormal;
+          } else if (!strcasecmp(value.c_str(), "pubsub")) {
+            kill_filter_.type |= kTypePubsub;
+          } else if (!strcasecmp(value.c_str(), "master")) {
+            kill_filter_.type |= kTypeMaster;
+          } else if (!strcasecmp(value.c_str(), "replica") || !strcasecmp(value.c_str(), "slave")) {
+            kill_filter_.type |= kTypeSlave;
           } else {
             return {Status::RedisParseErr, errInvalidSyntax};
           }
         } else {
           return {Status::RedisParseErr, errInvalidSyntax};
         }
-        i += 2;
       }
       return Status::OK();
     }
-    return {Status::RedisInvalidCmd, "Syntax error, try CLIENT LIST|INFO|KILL ip:port|GETNAME|SETNAME"};
+    return {Status::RedisInvalidCmd, "Syntax error, try CLIENT LIST|INFO|KILL ip:port|GETNAME|SETNAME|SETINFO"};
   }
 
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
@@ -509,6 +854,24 @@ class CommandClient : public Commander {
       conn->SetName(conn_name_);
       *output = redis::SimpleString("OK");
       return Status::OK();
+    } else if (subcommand_ == "setinfo") {
+      if (info_attr_ == "lib-name") {
+        conn->SetLibName(info_value_);
+      } else {
+        conn->SetLibVer(info_value_);
+      }
+      *output = redis::SimpleString("OK");
+      return Status::OK();
+    } else if (subcommand_ == "no-touch" || subcommand_ == "no-evict") {
+      // kvrocks never evicts clients, so NO-EVICT only records the flag for the diagnostic tools
+      auto flag = subcommand_ == "no-touch" ? Connection::kNoTouch : Connection::kNoEvict;
+      if (flag_on_) {
+        conn->EnableFlag(flag);
+      } else {
+        conn->DisableFlag(flag);
+      }
+      *output = redis::SimpleString("OK");
+      return Status::OK();
     } else if (subcommand_ == "getname") {
       std::string name = conn->GetName();
       *output = name == "" ? redis::NilString() : redis::BulkString(name);
@@ -518,7 +881,7 @@ class CommandClient : public Commander {
       return Status::OK();
     } else if (subcommand_ == "kill") {
       int64_t killed = 0;
-      srv->KillClient(&killed, addr_, id_, kill_type_, skipme_, conn);
+      srv->KillClient(&killed, kill_filter_, conn);
       if (new_format_) {
         *output = redis::Integer(killed);
       } else {
@@ -530,17 +893,17 @@ class CommandClient : public Commander {
       return Status::OK();
     }
 
-    return {Status::RedisInvalidCmd, "Syntax error, try CLIENT LIST|INFO|KILL ip:port|GETNAME|SETNAME"};
+    return {Status::RedisInvalidCmd, "Syntax error, try CLIENT LIST|INFO|KILL ip:port|GETNAME|SETNAME|SETINFO"};
   }
 
  private:
-  std::string addr_;
   std::string conn_name_;
+  std::string info_attr_;
+  std::string info_value_;
   std::string subcommand_;
-  bool skipme_ = false;
-  int64_t kill_type_ = 0;
-  uint64_t id_ = 0;
+  ClientKillFilter kill_filter_;
   bool new_format_ = true;
+  bool flag_on_ = false;
 };
 
 class CommandMonitor : public Commander {
@@ -576,6 +939,15 @@ class CommandQuit : public Commander {
   }
 };
 
+class CommandReset : public Commander {
+ public:
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    conn->Reset();
+    *output = redis::SimpleString("RESET");
+    return Status::OK();
+  }
+};
+
 class CommandDebug : public Commander {
  public:
   Status Parse(const std::vector<std::string> &args) override {
@@ -589,20 +961,41 @@ class CommandDebug : public Commander {
       microsecond_ = static_cast<uint64_t>(*second * 1000 * 1000);
       return Status::OK();
     }
-    return {Status::RedisInvalidCmd, "Syntax error, DEBUG SLEEP <seconds>"};
+    if ((subcommand_ == "set-clock-offset") && args.size() == 3) {
+      auto offset = ParseInt<int64_t>(args[2], {-kMaxClockOffsetMS, kMaxClockOffsetMS}, 10);
+      if (!offset) {
+        return {Status::RedisParseErr, "invalid debug clock offset"};
+      }
+
+      clock_offset_ms_ = *offset;
+      return Status::OK();
+    }
+    return {Status::RedisInvalidCmd, "Syntax error, DEBUG SLEEP <seconds>|SET-CLOCK-OFFSET <milliseconds>"};
   }
 
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
     if (subcommand_ == "sleep") {
       usleep(microsecond_);
+    } else if (subcommand_ == "set-clock-offset") {
+      if (!conn->IsAdmin()) {
+        return {Status::RedisExecErr, errAdminPermissionRequired};
+      }
+      if (!srv->GetConfig()->debug_clock_offset_enabled) {
+        return {Status::RedisExecErr, "DEBUG SET-CLOCK-OFFSET is disabled, enable it by debug-clock-offset-enabled"};
+      }
+      util::expire_clock_offset_ms.store(clock_offset_ms_, std::memory_order_relaxed);
     }
     *output = redis::SimpleString("OK");
     return Status::OK();
   }
 
  private:
+  // the clock can be moved by 10 years at most
+  static constexpr int64_t kMaxClockOffsetMS = 10LL * 365 * 24 * 3600 * 1000;
+
   std::string subcommand_;
   uint64_t microsecond_ = 0;
+  int64_t clock_offset_ms_ = 0;
 };
 
 class CommandCommand : public Commander {
@@ -758,15 +1151,15 @@ class CommandScan : public CommandScanBase {
     }
 
     ParseCursor(args[1]);
-    if (args.size() >= 4) {
-      Status s = ParseMatchAndCountParam(util::ToLower(args[2]), args_[3]);
-      if (!s.IsOK()) {
-        return s;
+    for (size_t i = 2; i < args.size(); i += 2) {
+      auto option = util::ToLower(args[i]);
+      if (option == "type") {
+        auto s = parseType(args[i + 1]);
+        if (!s.IsOK()) return s;
+        continue;
       }
-    }
 
-    if (args.size() >= 6) {
-      Status s = ParseMatchAndCountParam(util::ToLower(args[4]), args_[5]);
+      Status s = ParseMatchAndCountParam(option, args_[i + 1]);
       if (!s.IsOK()) {
         return s;
       }
@@ -794,27 +1187,39 @@ class CommandScan : public CommandScanBase {
 
     std::vector<std::string> keys;
     std::string end_key;
-    auto s = redis_db.Scan(key_name, limit_, prefix_, &keys, &end_key);
+    auto s = redis_db.Scan(key_name, limit_, prefix_, &keys, &end_key, type_);
     if (!s.ok()) {
       return {Status::RedisExecErr, s.ToString()};
     }
     *output = GenerateOutput(srv, keys, end_key);
     return Status::OK();
   }
+
+ private:
+  Status parseType(const std::string &name) {
+    // skip "none", which isn't the type of any existing key
+    for (size_t i = kRedisString; i < RedisTypeNames.size(); i++) {
+      if (util::EqualICase(name, RedisTypeNames[i])) {
+        type_ = static_cast<RedisType>(i);
+        return Status::OK();
+      }
+    }
+    return {Status::RedisParseErr, "unknown type name"};
+  }
+
+  RedisType type_ = kRedisNone;
 };
 
 class CommandRandomKey : public Commander {
  public:
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
     std::string key;
-    auto cursor = srv->GetLastRandomKeyCursor();
     redis::Database redis(srv->storage, conn->GetNamespace());
-    auto s = redis.RandomKey(cursor, &key);
-    if (!s.ok()) {
+    auto s = redis.RandomKey(&key);
+    if (!s.ok() && !s.IsNotFound()) {
       return {Status::RedisExecErr, s.ToString()};
     }
-    srv->SetLastRandomKeyCursor(key);
-    *output = redis::BulkString(key);
+    *output = s.IsNotFound() ? redis::NilString() : redis::BulkString(key);
     return Status::OK();
   }
 };
@@ -973,6 +1378,81 @@ class CommandSlaveOf : public Commander {
   uint32_t port_ = 0;
 };
 
+class CommandFailover : public Commander {
+ public:
+  Status Parse(const std::vector<std::string> &args) override {
+    CommandParser parser(args, 1);
+    while (parser.Good()) {
+      if (parser.EatEqICase("to")) {
+        host_ = GET_OR_RET(parser.TakeStr());
+        port_ = GET_OR_RET(parser.TakeInt<uint32_t>(NumericRange<uint32_t>{1, PORT_LIMIT - 1}));
+      } else if (parser.EatEqICase("abort")) {
+        abort_ = true;
+      } else if (parser.EatEqICase("timeout")) {
+        auto timeout = GET_OR_RET(parser.TakeInt<int64_t>());
+        if (timeout <= 0) {
+          return {Status::RedisParseErr, "FAILOVER timeout must be greater than 0"};
+        }
+        timeout_ms_ = static_cast<uint64_t>(timeout);
+      } else {
+        return parser.InvalidSyntax();
+      }
+    }
+
+    if (abort_ && (!host_.empty() || timeout_ms_ > 0)) {
+      return {Status::RedisParseErr, "FAILOVER abort cannot be used with other arguments"};
+    }
+    return Status::OK();
+  }
+
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    if (srv->GetConfig()->cluster_enabled) {
+      return {Status::RedisExecErr, "FAILOVER not allowed in cluster mode"};
+    }
+
+    if (!conn->IsAdmin()) {
+      return {Status::RedisExecErr, errAdminPermissionRequired};
+    }
+
+    if (abort_) {
+      auto s = srv->AbortFailover();
+      if (!s.IsOK()) {
+        return {Status::RedisExecErr, s.Msg()};
+      }
+      *output = redis::SimpleString("OK");
+      return Status::OK();
+    }
+
+    if (srv->IsSlave()) {
+      return {Status::RedisExecErr, "FAILOVER is not valid when server is a replica."};
+    }
+
+    auto replicas = srv->GetSlaveHostAndPort();
+    if (replicas.empty()) {
+      return {Status::RedisExecErr, "FAILOVER requires connected replicas."};
+    }
+    if (!host_.empty() && std::find(replicas.begin(), replicas.end(), std::make_pair(host_, port_)) == replicas.end()) {
+      return {Status::RedisExecErr, "FAILOVER target HOST and PORT is not a replica."};
+    }
+
+    auto s = srv->StartFailover(host_, port_, timeout_ms_);
+    if (!s.IsOK()) {
+      return {Status::RedisExecErr, s.Msg()};
+    }
+
+    LOG(WARNING) << "FAILOVER " << (host_.empty() ? "to any replica" : "to " + host_ + ":" + std::to_string(port_))
+                 << " started (user request from '" << conn->GetAddr() << "')";
+    *output = redis::SimpleString("OK");
+    return Status::OK();
+  }
+
+ private:
+  std::string host_;
+  uint32_t port_ = 0;
+  uint64_t timeout_ms_ = 0;
+  bool abort_ = false;
+};
+
 class CommandStats : public Commander {
  public:
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
@@ -1003,6 +1483,29 @@ class CommandLastSave : public Commander {
   }
 };
 
+class CommandDump : public Commander {
+ public:
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    redis::Database redis(srv->storage, conn->GetNamespace());
+    RedisType type = kRedisNone;
+    auto db_status = redis.Type(args_[1], &type);
+    if (!db_status.ok()) return {Status::RedisExecErr, db_status.ToString()};
+    if (type == kRedisNone) {
+      *output = redis::NilString();
+      return Status::OK();
+    }
+
+    auto stream_ptr = std::make_unique<RdbStringStream>();
+    auto stream = stream_ptr.get();
+    RDB rdb(srv->storage, conn->GetNamespace(), std::move(stream_ptr));
+    auto s = rdb.Dump(args_[1], type);
+    if (!s.IsOK()) return {Status::RedisExecErr, s.Msg()};
+
+    *output = redis::BulkString(stream->GetInput());
+    return Status::OK();
+  }
+};
+
 class CommandRestore : public Commander {
  public:
   Status Parse(const std::vector<std::string> &args) override {
@@ -1051,7 +1554,7 @@ class CommandRestore : public Commander {
       }
     }
     if (ttl_ms_ && absttl_) {
-      auto now = util::GetTimeStampMS();
+      auto now = util::GetExpireTimeStampMS();
       if (ttl_ms_ <= now) {
         // return ok if the ttl is already expired
         *output = redis::SimpleString("OK");
@@ -1074,6 +1577,164 @@ class CommandRestore : public Commander {
   uint64_t ttl_ms_ = 0;
 };
 
+// command format: MIGRATE host port key|"" destination-db timeout [COPY] [REPLACE]
+//                 [AUTH password | AUTH2 username password] [KEYS key [key ...]]
+class CommandMigrate : public Commander {
+ public:
+  Status Parse(const std::vector<std::string> &args) override {
+    CommandParser parser(args, 1);
+    host_ = GET_OR_RET(parser.TakeStr());
+    port_ = GET_OR_RET(parser.TakeInt<uint32_t>(NumericRange<uint32_t>{1, PORT_LIMIT}));
+    auto key = GET_OR_RET(parser.TakeStr());
+    db_ = GET_OR_RET(parser.TakeInt<int64_t>(NumericRange<int64_t>{0, INT64_MAX}));
+    timeout_ms_ = GET_OR_RET(parser.TakeInt<int>(NumericRange<int>{0, INT_MAX}));
+    // Wait for 1 second at most by default, the same as Redis
+    if (timeout_ms_ == 0) timeout_ms_ = 1000;
+
+    while (parser.Good()) {
+      if (parser.EatEqICase("copy")) {
+        copy_ = true;
+      } else if (parser.EatEqICase("replace")) {
+        replace_ = true;
+      } else if (parser.EatEqICase("auth")) {
+        auth_ = {GET_OR_RET(parser.TakeStr())};
+      } else if (parser.EatEqICase("auth2")) {
+        auto username = GET_OR_RET(parser.TakeStr());
+        auth_ = {username, GET_OR_RET(parser.TakeStr())};
+      } else if (parser.EatEqICase("keys")) {
+        if (!key.empty()) {
+          return {Status::RedisParseErr,
+                  "When using MIGRATE KEYS option, the key argument must be set to the empty string"};
+        }
+        while (parser.Good()) {
+          keys_.emplace_back(GET_OR_RET(parser.TakeStr()));
+        }
+      } else {
+        return parser.InvalidSyntax();
+      }
+    }
+
+    if (keys_.empty()) {
+      keys_.emplace_back(key);
+    }
+    return Status::OK();
+  }
+
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    redis::Database redis(srv->storage, conn->GetNamespace());
+
+    // Lock the keys until they're deleted after being restored, otherwise the writes
+    // to them after the dump would be lost
+    std::vector<std::string> lock_keys;
+    lock_keys.reserve(keys_.size());
+    for (const auto &key : keys_) {
+      lock_keys.emplace_back(redis.AppendNamespacePrefix(key));
+    }
+    MultiLockGuard guard(srv->storage->GetLockManager(), lock_keys);
+
+    // Serialize the keys first, and missing keys are skipped
+    std::vector<std::string> keys;
+    std::vector<std::string> restore_commands;
+    for (const auto &key : keys_) {
+      RedisType type = kRedisNone;
+      auto db_status = redis.Type(key, &type);
+      if (!db_status.ok()) return {Status::RedisExecErr, db_status.ToString()};
+      if (type == kRedisNone) continue;
+
+      int64_t ttl = 0;
+      db_status = redis.TTL(key, &ttl);
+      if (!db_status.ok()) return {Status::RedisExecErr, db_status.ToString()};
+      // the key was expired after getting its type
+      if (ttl == -2) continue;
+
+      auto stream_ptr = std::make_unique<RdbStringStream>();
+      auto stream = stream_ptr.get();
+      RDB rdb(srv->storage, conn->GetNamespace(), std::move(stream_ptr));
+      auto s = rdb.Dump(key, type);
+      if (!s.IsOK()) return {Status::RedisExecErr, s.Msg()};
+
+      std::vector<std::string> restore_args = {"RESTORE", key, std::to_string(std::max<int64_t>(ttl, 0)),
+                                               stream->GetInput()};
+      if (replace_) restore_args.emplace_back("REPLACE");
+      restore_commands.emplace_back(redis::MultiBulkString(restore_args, false));
+      keys.emplace_back(key);
+    }
+    if (keys.empty()) {
+      *output = redis::SimpleString("NOKEY");
+      return Status::OK();
+    }
+
+    auto fd = util::SockConnect(host_, port_, timeout_ms_, timeout_ms_);
+    if (!fd) {
+      return {Status::RedisExecErr, fmt::format("IOERR error or timeout connecting to the client: {}", fd.Msg())};
+    }
+    UniqueFD sock_fd(*fd);
+
+    if (!auth_.empty()) {
+      std::vector<std::string> auth_args = {"AUTH"};
+      auth_args.insert(auth_args.end(), auth_.begin(), auth_.end());
+      GET_OR_RET(sendAndCheckResponse(*sock_fd, redis::MultiBulkString(auth_args, false)));
+    }
+    GET_OR_RET(sendAndCheckResponse(*sock_fd, redis::MultiBulkString({"SELECT", std::to_string(db_)}, false)));
+
+    // Keys are restored one by one, so the ones restored before an error are still removed from here
+    size_t migrated = 0;
+    Status s;
+    for (; migrated < restore_commands.size(); migrated++) {
+      s = sendAndCheckResponse(*sock_fd, restore_commands[migrated]);
+      if (!s.IsOK()) break;
+    }
+
+    if (!copy_ && migrated > 0) {
+      std::vector<Slice> migrated_keys(keys.begin(), keys.begin() + static_cast<std::ptrdiff_t>(migrated));
+      uint64_t deleted = 0;
+      auto db_status = redis.MDelWithoutLock(migrated_keys, &deleted);
+      if (!db_status.ok()) return {Status::RedisExecErr, db_status.ToString()};
+    }
+    if (!s.IsOK()) return s;
+
+    *output = redis::SimpleString("OK");
+    return Status::OK();
+  }
+
+ private:
+  static Status sendAndCheckResponse(int fd, const std::string &command) {
+    auto s = util::SockSend(fd, command);
+    if (!s.IsOK()) {
+      return {Status::RedisExecErr, fmt::format("IOERR error or timeout writing to target instance: {}", s.Msg())};
+    }
+
+    auto line = util::SockReadLine(fd);
+    if (!line) {
+      return {Status::RedisExecErr, fmt::format("IOERR error or timeout reading to target instance: {}", line.Msg())};
+    }
+    if (!line->empty() && (*line)[0] == '-') {
+      return {Status::RedisExecErr, fmt::format("Target instance replied with error: {}", line->substr(1))};
+    }
+    return Status::OK();
+  }
+
+  std::string host_;
+  uint32_t port_ = 0;
+  int64_t db_ = 0;
+  int timeout_ms_ = 0;
+  bool copy_ = false;
+  bool replace_ = false;
+  std::vector<std::string> auth_;
+  std::vector<std::string> keys_;
+};
+
+CommandKeyRange GetMigrateKeyRange(const std::vector<std::string> &args) {
+  if (!args[3].empty()) return {3, 3, 1};
+
+  for (size_t i = 6; i < args.size(); i++) {
+    if (util::EqualICase(args[i], "keys")) {
+      return {static_cast<int>(i) + 1, static_cast<int>(args.size()) - 1, 1};
+    }
+  }
+  return {0, 0, 0};
+}
+
 // command format: rdb load <path> [NX]  [DB index]
 class CommandRdb : public Commander {
  public:
@@ -1134,12 +1795,16 @@ REDIS_REGISTER_COMMANDS(MakeCmdAttr<CommandAuth>("auth", 2, "read-only ok-loadin
                         MakeCmdAttr<CommandFlushDB>("flushdb", 1, "write", 0, 0, 0),
                         MakeCmdAttr<CommandFlushAll>("flushall", 1, "write", 0, 0, 0),
                         MakeCmdAttr<CommandDBSize>("dbsize", -1, "read-only", 0, 0, 0),
+                        MakeCmdAttr<CommandBigKeys>("bigkeys", -1, "read-only", 0, 0, 0),
+                        MakeCmdAttr<CommandHotKeys>("hotkeys", -1, "read-only", 0, 0, 0),
                         MakeCmdAttr<CommandSlowlog>("slowlog", -2, "read-only", 0, 0, 0),
+                        MakeCmdAttr<CommandLatency>("latency", -2, "read-only", 0, 0, 0),
                         MakeCmdAttr<CommandPerfLog>("perflog", -2, "read-only", 0, 0, 0),
                         MakeCmdAttr<CommandClient>("client", -2, "read-only", 0, 0, 0),
                         MakeCmdAttr<CommandMonitor>("monitor", 1, "read-only no-multi", 0, 0, 0),
                         MakeCmdAttr<CommandShutdown>("shutdown", 1, "read-only", 0, 0, 0),
                         MakeCmdAttr<CommandQuit>("quit", 1, "read-only", 0, 0, 0),
+                        MakeCmdAttr<CommandReset>("reset", 1, "read-only multi ok-loading", 0, 0, 0),
                         MakeCmdAttr<CommandScan>("scan", -2, "read-only", 0, 0, 0),
                         MakeCmdAttr<CommandRandomKey>("randomkey", 1, "read-only", 0, 0, 0),
                         MakeCmdAttr<CommandDebug>("debug", -2, "read-only exclusive", 0, 0, 0),
@@ -1147,15 +1812,18 @@ REDIS_REGISTER_COMMANDS(MakeCmdAttr<CommandAuth>("auth", 2, "read-only ok-loadin
                         MakeCmdAttr<CommandEcho>("echo", 2, "read-only", 0, 0, 0),
                         MakeCmdAttr<CommandTime>("time", 1, "read-only ok-loading", 0, 0, 0),
                         MakeCmdAttr<CommandDisk>("disk", 3, "read-only", 0, 0, 0),
-                        MakeCmdAttr<CommandMemory>("memory", 3, "read-only", 0, 0, 0),
+                        MakeCmdAttr<CommandMemory>("memory", -2, "read-only", 0, 0, 0),
                         MakeCmdAttr<CommandHello>("hello", -1, "read-only ok-loading", 0, 0, 0),
+                        MakeCmdAttr<CommandDump>("dump", 2, "read-only", 1, 1, 1),
                         MakeCmdAttr<CommandRestore>("restore", -4, "write", 1, 1, 1),
+                        MakeCmdAttr<CommandMigrate>("migrate", -6, "write", GetMigrateKeyRange),
 
                         MakeCmdAttr<CommandCompact>("compact", 1, "read-only no-script", 0, 0, 0),
                         MakeCmdAttr<CommandBGSave>("bgsave", 1, "read-only no-script", 0, 0, 0),
                         MakeCmdAttr<CommandLastSave>("lastsave", 1, "read-only", 0, 0, 0),
                         MakeCmdAttr<CommandFlushBackup>("flushbackup", 1, "read-only no-script", 0, 0, 0),
                         MakeCmdAttr<CommandSlaveOf>("slaveof", 3, "read-only exclusive no-script", 0, 0, 0),
+                        MakeCmdAttr<CommandFailover>("failover", -1, "read-only no-multi no-script", 0, 0, 0),
                         MakeCmdAttr<CommandStats>("stats", 1, "read-only", 0, 0, 0),
                         MakeCmdAttr<CommandRdb>("rdb", -3, "write exclusive", 0, 0, 0), )
 
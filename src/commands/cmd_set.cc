@@ -23,6 +23,7 @@
 #include "commander.h"
 #include "commands/scan_base.h"
 #include "error_constants.h"
+#include "random_util.h"
 #include "server/server.h"
 #include "types/redis_set.h"
 
@@ -194,12 +195,13 @@ class CommandSRandMember : public Commander {
       return {Status::RedisParseErr, errWrongNumOfArguments};
     }
     if (args.size() == 3) {
-      auto parse_result = ParseInt<int>(args[2], 10);
+      auto parse_result = ParseInt<int64_t>(args[2], {util::kMinRandomCount, INT64_MAX}, 10);
       if (!parse_result) {
         return {Status::RedisParseErr, errValueNotInteger};
       }
 
       count_ = *parse_result;
+      with_count_ = true;
     }
     return Commander::Parse(args);
   }
@@ -207,16 +209,26 @@ class CommandSRandMember : public Commander {
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
     redis::Set set_db(srv->storage, conn->GetNamespace());
     std::vector<std::string> members;
-    auto s = set_db.Take(args_[1], &members, count_, false);
+    auto s = set_db.RandMember(args_[1], count_, &members);
     if (!s.ok()) {
       return {Status::RedisExecErr, s.ToString()};
     }
-    *output = redis::MultiBulkString(members, false);
+
+    if (with_count_) {
+      *output = redis::MultiBulkString(members, false);
+    } else {
+      if (members.size() > 0) {
+        *output = redis::BulkString(members.front());
+      } else {
+        *output = redis::NilString();
+      }
+    }
     return Status::OK();
   }
 
  private:
-  int count_ = 1;
+  int64_t count_ = 1;
+  bool with_count_ = false;
 };
 
 class CommandSMove : public Commander {
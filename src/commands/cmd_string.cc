@@ -18,6 +18,7 @@
  *
  */
 
+#include <algorithm>
 #include <cstdint>
 #include <optional>
 
@@ -64,7 +65,9 @@ class CommandGetEx : public Commander {
     std::string_view ttl_flag;
     while (parser.Good()) {
       if (auto v = GET_OR_RET(ParseTTL(parser, ttl_flag))) {
-        ttl_ = *v;
+        // EXAT/PXAT with a timestamp in the past expire the key immediately
+        expired_ = *v <= 0;
+        ttl_ = expired_ ? 0 : *v;
       } else if (parser.EatEqICaseFlag("PERSIST", ttl_flag)) {
         persist_ = true;
       } else {
@@ -77,7 +80,7 @@ class CommandGetEx : public Commander {
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
     std::string value;
     redis::String string_db(srv->storage, conn->GetNamespace());
-    auto s = string_db.GetEx(args_[1], &value, ttl_, persist_);
+    auto s = expired_ ? string_db.GetDel(args_[1], &value) : string_db.GetEx(args_[1], &value, ttl_, persist_);
 
     // The IsInvalidArgument error means the key type maybe a bitmap
     // which we need to fall back to the bitmap's GetString according
@@ -88,8 +91,10 @@ class CommandGetEx : public Commander {
       redis::Bitmap bitmap_db(srv->storage, conn->GetNamespace());
       s = bitmap_db.GetString(args_[1], max_btos_size, &value);
       if (s.ok()) {
-        if (ttl_ > 0) {
-          s = bitmap_db.Expire(args_[1], ttl_ + util::GetTimeStampMS());
+        if (expired_) {
+          s = bitmap_db.Del(args_[1]);
+        } else if (ttl_ > 0) {
+          s = bitmap_db.Expire(args_[1], ttl_ + util::GetExpireTimeStampMS());
         } else if (persist_) {
           s = bitmap_db.Expire(args_[1], 0);
         }
@@ -106,6 +111,7 @@ class CommandGetEx : public Commander {
  private:
   uint64_t ttl_ = 0;
   bool persist_ = false;
+  bool expired_ = false;
 };
 
 class CommandStrlen : public Commander {
@@ -152,6 +158,17 @@ class CommandGetDel : public Commander {
     redis::String string_db(srv->storage, conn->GetNamespace());
     std::string value;
     auto s = string_db.GetDel(args_[1], &value);
+    // The same as GET, a bitmap is deleted as a string according to
+    // the `max-bitmap-to-string-mb` configuration.
+    if (s.IsInvalidArgument()) {
+      Config *config = srv->GetConfig();
+      uint32_t max_btos_size = static_cast<uint32_t>(config->max_bitmap_to_string_mb) * MiB;
+      redis::Bitmap bitmap_db(srv->storage, conn->GetNamespace());
+      s = bitmap_db.GetString(args_[1], max_btos_size, &value);
+      if (s.ok()) {
+        s = bitmap_db.Del(args_[1]);
+      }
+    }
     if (!s.ok() && !s.IsNotFound()) {
       return {Status::RedisExecErr, s.ToString()};
     }
@@ -610,6 +627,79 @@ class CommandCAD : public Commander {
   }
 };
 
+class CommandLCS : public Commander {
+ public:
+  Status Parse(const std::vector<std::string> &args) override {
+    CommandParser parser(args, 3);
+    bool get_len = false;
+    bool get_idx = false;
+    while (parser.Good()) {
+      if (parser.EatEqICase("LEN")) {
+        get_len = true;
+      } else if (parser.EatEqICase("IDX")) {
+        get_idx = true;
+      } else if (parser.EatEqICase("MINMATCHLEN")) {
+        // A negative min match length is treated as zero
+        min_match_len_ = std::max(GET_OR_RET(parser.TakeInt<int64_t>()), int64_t(0));
+      } else if (parser.EatEqICase("WITHMATCHLEN")) {
+        with_match_len_ = true;
+      } else {
+        return parser.InvalidSyntax();
+      }
+    }
+
+    if (get_len && get_idx) {
+      return {Status::RedisParseErr, "If you want both the length and indexes, please just use IDX."};
+    }
+    if (get_len) {
+      type_ = StringLCSType::LEN;
+    } else if (get_idx) {
+      type_ = StringLCSType::IDX;
+    }
+    return Status::OK();
+  }
+
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    redis::String string_db(srv->storage, conn->GetNamespace());
+    StringLCSResult rst;
+    auto s = string_db.LCS(args_[1], args_[2], {type_, min_match_len_}, &rst);
+    if (!s.ok()) {
+      return {Status::RedisExecErr, s.ToString()};
+    }
+
+    if (type_ == StringLCSType::NONE) {
+      *output = redis::BulkString(std::get<std::string>(rst));
+    } else if (type_ == StringLCSType::LEN) {
+      *output = redis::Integer(std::get<uint32_t>(rst));
+    } else {
+      const auto &result = std::get<StringLCSIdxResult>(rst);
+      *output = redis::MultiLen(4);
+      *output += redis::BulkString("matches");
+      *output += redis::MultiLen(result.matches.size());
+      for (const auto &match : result.matches) {
+        *output += redis::MultiLen(with_match_len_ ? 3 : 2);
+        *output += redis::MultiLen(2);
+        *output += redis::Integer(match.a.start);
+        *output += redis::Integer(match.a.end);
+        *output += redis::MultiLen(2);
+        *output += redis::Integer(match.b.start);
+        *output += redis::Integer(match.b.end);
+        if (with_match_len_) {
+          *output += redis::Integer(match.match_len);
+        }
+      }
+      *output += redis::BulkString("len");
+      *output += redis::Integer(result.len);
+    }
+    return Status::OK();
+  }
+
+ private:
+  StringLCSType type_ = StringLCSType::NONE;
+  bool with_match_len_ = false;
+  int64_t min_match_len_ = 0;
+};
+
 REDIS_REGISTER_COMMANDS(
     MakeCmdAttr<CommandGet>("get", 2, "read-only", 1, 1, 1), MakeCmdAttr<CommandGetEx>("getex", -2, "write", 1, 1, 1),
     MakeCmdAttr<CommandStrlen>("strlen", 2, "read-only", 1, 1, 1),
@@ -627,6 +717,6 @@ REDIS_REGISTER_COMMANDS(
     MakeCmdAttr<CommandIncrByFloat>("incrbyfloat", 3, "write", 1, 1, 1),
     MakeCmdAttr<CommandIncr>("incr", 2, "write", 1, 1, 1), MakeCmdAttr<CommandDecrBy>("decrby", 3, "write", 1, 1, 1),
     MakeCmdAttr<CommandDecr>("decr", 2, "write", 1, 1, 1), MakeCmdAttr<CommandCAS>("cas", -4, "write", 1, 1, 1),
-    MakeCmdAttr<CommandCAD>("cad", 3, "write", 1, 1, 1), )
+    MakeCmdAttr<CommandCAD>("cad", 3, "write", 1, 1, 1), MakeCmdAttr<CommandLCS>("lcs", -3, "read-only", 1, 2, 1), )
 
 }  // namespace redis
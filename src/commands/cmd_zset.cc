@@ -25,6 +25,7 @@
 #include "commands/blocking_commander.h"
 #include "commands/scan_base.h"
 #include "error_constants.h"
+#include "random_util.h"
 #include "server/redis_reply.h"
 #include "server/server.h"
 #include "types/redis_zset.h"
@@ -67,9 +68,14 @@ class CommandZAdd : public Commander {
 
   Status Execute(Server *srv, Connection *conn, std::string *output) override {
     uint64_t ret = 0;
-    double old_score = member_scores_[0].score;
+    // with INCR, the changed elements are also counted to know whether
+    // the element was skipped due to the NX/XX/LT/GT conditions
+    ZAddFlags flags = flags_;
+    if (flags_.HasIncr()) {
+      flags.SetFlag(kZSetCH);
+    }
     redis::ZSet zset_db(srv->storage, conn->GetNamespace());
-    auto s = zset_db.Add(args_[1], flags_, &member_scores_, &ret);
+    auto s = zset_db.Add(args_[1], flags, &member_scores_, &ret);
     if (!s.ok()) {
       return {Status::RedisExecErr, s.ToString()};
     }
@@ -77,14 +83,12 @@ class CommandZAdd : public Commander {
     srv->WakeupBlockingConns(args_[1], member_scores_.size());
 
     if (flags_.HasIncr()) {
-      auto new_score = member_scores_[0].score;
-      if ((flags_.HasNX() || flags_.HasXX() || flags_.HasLT() || flags_.HasGT()) && old_score == new_score &&
-          ret == 0) {  // not the first time using incr && score not changed
+      if (ret == 0) {
         *output = redis::NilString();
         return Status::OK();
       }
 
-      *output = redis::BulkString(util::Float2String(new_score));
+      *output = redis::BulkString(util::Float2String(member_scores_[0].score));
     } else {
       *output = redis::Integer(ret);
     }
@@ -189,6 +193,8 @@ class CommandZIncrBy : public Commander {
       return {Status::RedisExecErr, s.ToString()};
     }
 
+    srv->WakeupBlockingConns(args_[1], 1);
+
     *output = redis::BulkString(util::Float2String(score));
     return Status::OK();
   }
@@ -371,6 +377,7 @@ class CommandBZPop : public BlockingCommander {
 
     bool empty = member_scores.empty();
     if (!empty) {
+      conn_->GetServer()->UpdateWatchedKeysManually({user_key});
       SendMembersWithScores(member_scores, user_key);
     }
 
@@ -550,6 +557,7 @@ class CommandBZMPop : public BlockingCommander {
 
     bool empty = member_scores.empty();
     if (!empty) {
+      conn_->GetServer()->UpdateWatchedKeysManually({user_key});
       SendMembersWithScoresForZMpop(conn_, user_key, member_scores);
     }
 
@@ -672,6 +680,10 @@ class CommandZRangeStore : public Commander {
     if (!s.ok()) {
       return {Status::RedisExecErr, s.ToString()};
     }
+
+    if (ret > 0) {
+      srv->WakeupBlockingConns(dst_, ret);
+    }
     *output = redis::Integer(ret);
     return Status::OK();
   }
@@ -1082,6 +1094,53 @@ class CommandZMScore : public Commander {
   }
 };
 
+class CommandZRandMember : public Commander {
+ public:
+  Status Parse(const std::vector<std::string> &args) override {
+    if (args.size() >= 3) {
+      no_parameters_ = false;
+      auto parse_result = ParseInt<int64_t>(args[2], {util::kMinRandomCount, INT64_MAX}, 10);
+      if (!parse_result) {
+        return {Status::RedisParseErr, errValueNotInteger};
+      }
+      command_count_ = *parse_result;
+
+      if (args.size() > 4 || (args.size() == 4 && !util::EqualICase(args[3], "withscores"))) {
+        return {Status::RedisParseErr, errInvalidSyntax};
+      } else if (args.size() == 4) {
+        with_scores_ = true;
+      }
+    }
+    return Commander::Parse(args);
+  }
+
+  Status Execute(Server *srv, Connection *conn, std::string *output) override {
+    redis::ZSet zset_db(srv->storage, conn->GetNamespace());
+    std::vector<MemberScore> member_scores;
+    auto s = zset_db.RandMember(args_[1], command_count_, &member_scores);
+    if (!s.ok() && !s.IsNotFound()) {
+      return {Status::RedisExecErr, s.ToString()};
+    }
+
+    if (no_parameters_) {
+      *output = member_scores.empty() ? redis::NilString() : redis::BulkString(member_scores[0].member);
+      return Status::OK();
+    }
+
+    output->append(redis::MultiLen(member_scores.size() * (with_scores_ ? 2 : 1)));
+    for (const auto &ms : member_scores) {
+      output->append(redis::BulkString(ms.member));
+      if (with_scores_) output->append(redis::BulkString(util::Float2String(ms.score)));
+    }
+    return Status::OK();
+  }
+
+ private:
+  int64_t command_count_ = 1;
+  bool no_parameters_ = true;
+  bool with_scores_ = false;
+};
+
 class CommandZUnion : public Commander {
  public:
   Status Parse(const std::vector<std::string> &args) override {
@@ -1215,6 +1274,10 @@ class CommandZUnionStore : public Commander {
       return {Status::RedisExecErr, s.ToString()};
     }
 
+    if (size > 0) {
+      srv->WakeupBlockingConns(args_[1], size);
+    }
+
     *output = redis::Integer(size);
     return Status::OK();
   }
@@ -1242,6 +1305,10 @@ class CommandZInterStore : public CommandZUnionStore {
       return {Status::RedisExecErr, s.ToString()};
     }
 
+    if (size > 0) {
+      srv->WakeupBlockingConns(args_[1], size);
+    }
+
     *output = redis::Integer(size);
     return Status::OK();
   }
@@ -1288,7 +1355,7 @@ REDIS_REGISTER_COMMANDS(MakeCmdAttr<CommandZAdd>("zadd", -4, "write", 1, 1, 1),
                         MakeCmdAttr<CommandBZPopMin>("bzpopmin", -3, "write", 1, -2, 1),
                         MakeCmdAttr<CommandZMPop>("zmpop", -4, "write", CommandZMPop::Range),
                         MakeCmdAttr<CommandBZMPop>("bzmpop", -5, "write", CommandBZMPop::Range),
-                        MakeCmdAttr<CommandZRangeStore>("zrangestore", -5, "write", 1, 1, 1),
+                        MakeCmdAttr<CommandZRangeStore>("zrangestore", -5, "write", 1, 2, 1),
                         MakeCmdAttr<CommandZRange>("zrange", -4, "read-only", 1, 1, 1),
                         MakeCmdAttr<CommandZRevRange>("zrevrange", -4, "read-only", 1, 1, 1),
                         MakeCmdAttr<CommandZRangeByLex>("zrangebylex", -4, "read-only", 1, 1, 1),
@@ -1303,6 +1370,7 @@ REDIS_REGISTER_COMMANDS(MakeCmdAttr<CommandZAdd>("zadd", -4, "write", 1, 1, 1),
                         MakeCmdAttr<CommandZRevRank>("zrevrank", -3, "read-only", 1, 1, 1),
                         MakeCmdAttr<CommandZScore>("zscore", 3, "read-only", 1, 1, 1),
                         MakeCmdAttr<CommandZMScore>("zmscore", -3, "read-only", 1, 1, 1),
+                        MakeCmdAttr<CommandZRandMember>("zrandmember", -2, "read-only", 1, 1, 1),
                         MakeCmdAttr<CommandZScan>("zscan", -3, "read-only", 1, 1, 1),
                         MakeCmdAttr<CommandZUnionStore>("zunionstore", -4, "write", CommandZUnionStore::Range),
                         MakeCmdAttr<CommandZUnion>("zunion", -3, "read-only", CommandZUnion::Range), )
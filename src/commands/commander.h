@@ -63,6 +63,7 @@ enum CommandFlags : uint64_t {
   kCmdNoScript = 1ULL << 9,     // "no-script" flag
   kCmdROScript = 1ULL << 10,    // "ro-script" flag for read-only script commands
   kCmdCluster = 1ULL << 11,     // "cluster" flag
+  kCmdNoTouch = 1ULL << 12,     // "no-touch" flag for commands which don't count as accesses of keys
 };
 
 class Commander {
@@ -177,6 +178,8 @@ inline uint64_t ParseCommandFlags(const std::string &description, const std::str
       flags |= kCmdROScript;
     else if (flag == "cluster")
       flags |= kCmdCluster;
+    else if (flag == "no-touch")
+      flags |= kCmdNoTouch;
     else {
       std::cout << fmt::format("Encountered non-existent flag '{}' in command {} in command attribute parsing", flag,
                                cmd_name)
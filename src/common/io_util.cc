@@ -325,6 +325,22 @@ StatusOr<std::string> SockReadLine(int fd) {
   return std::string(line.get(), line.length);
 }
 
+static StatusOr<std::tuple<std::string, uint32_t>> SockAddrToIPPort(const sockaddr_storage &sa) {
+  if (sa.ss_family == AF_INET6) {
+    char buf[INET6_ADDRSTRLEN];
+    auto sa6 = reinterpret_cast<const sockaddr_in6 *>(&sa);
+    inet_ntop(AF_INET6, reinterpret_cast<const void *>(&sa6->sin6_addr), buf, INET6_ADDRSTRLEN);
+    return {buf, ntohs(sa6->sin6_port)};
+  } else if (sa.ss_family == AF_INET) {
+    auto sa4 = reinterpret_cast<const sockaddr_in *>(&sa);
+    char buf[INET_ADDRSTRLEN];
+    inet_ntop(AF_INET, reinterpret_cast<const void *>(&sa4->sin_addr), buf, INET_ADDRSTRLEN);
+    return {buf, ntohs(sa4->sin_port)};
+  }
+
+  return {Status::NotOK, "invalid family type"};
+}
+
 StatusOr<std::tuple<std::string, uint32_t>> GetPeerAddr(int fd) {
   sockaddr_storage sa{};
   socklen_t sa_len = sizeof(sa);
@@ -332,19 +348,17 @@ StatusOr<std::tuple<std::string, uint32_t>> GetPeerAddr(int fd) {
     return Status::FromErrno("Failed to get peer name");
   }
 
-  if (sa.ss_family == AF_INET6) {
-    char buf[INET6_ADDRSTRLEN];
-    auto sa6 = reinterpret_cast<sockaddr_in6 *>(&sa);
-    inet_ntop(AF_INET6, reinterpret_cast<void *>(&sa6->sin6_addr), buf, INET_ADDRSTRLEN);
-    return {buf, ntohs(sa6->sin6_port)};
-  } else if (sa.ss_family == AF_INET) {
-    auto sa4 = reinterpret_cast<sockaddr_in *>(&sa);
-    char buf[INET_ADDRSTRLEN];
-    inet_ntop(AF_INET, reinterpret_cast<void *>(&sa4->sin_addr), buf, INET_ADDRSTRLEN);
-    return {buf, ntohs(sa4->sin_port)};
+  return SockAddrToIPPort(sa).Prefixed("Failed to get peer name");
+}
+
+StatusOr<std::tuple<std::string, uint32_t>> GetLocalAddr(int fd) {
+  sockaddr_storage sa{};
+  socklen_t sa_len = sizeof(sa);
+  if (getsockname(fd, reinterpret_cast<sockaddr *>(&sa), &sa_len) < 0) {
+    return Status::FromErrno("Failed to get sock name");
   }
 
-  return {Status::NotOK, "Failed to get peer name due to invalid family type"};
+  return SockAddrToIPPort(sa).Prefixed("Failed to get sock name");
 }
 
 int GetLocalPort(int fd) {
@@ -391,6 +405,11 @@ int AeWait(int fd, int mask, int timeout) {
   }
 }
 
+bool SockReadable(int fd) {
+  int retmask = AeWait(fd, AE_READABLE, 0);
+  return retmask > 0 && (retmask & AE_READABLE);
+}
+
 bool MatchListeningIP(std::vector<std::string> &binds, const std::string &ip) {
   if (std::find(binds.begin(), binds.end(), ip) != binds.end()) {
     return true;
@@ -518,4 +537,12 @@ StatusOr<int> EvbufferRead(evbuffer *buf, evutil_socket_t fd, int howmuch, ssl_s
   }
 }
 
+StatusOr<int> EvbufferRead(evbuffer *buf, evutil_socket_t fd, int howmuch, bufferevent *bev) {
+#ifdef ENABLE_OPENSSL
+  return EvbufferRead(buf, fd, howmuch, bufferevent_openssl_get_ssl(bev));
+#else
+  return EvbufferRead(buf, fd, howmuch, nullptr);
+#endif
+}
+
 }  // namespace util
@@ -41,6 +41,7 @@ StatusOr<std::string> SockReadLine(int fd);
 Status SockSendFile(int out_fd, int in_fd, size_t size);
 Status SockSetBlocking(int fd, int blocking);
 StatusOr<std::tuple<std::string, uint32_t>> GetPeerAddr(int fd);
+StatusOr<std::tuple<std::string, uint32_t>> GetLocalAddr(int fd);
 int GetLocalPort(int fd);
 bool IsPortInUse(uint32_t port);
 
@@ -48,6 +49,7 @@ bool MatchListeningIP(std::vector<std::string> &binds, const std::string &ip);
 std::vector<std::string> GetLocalIPAddresses();
 
 int AeWait(int fd, int mask, int milliseconds);
+bool SockReadable(int fd);
 Status Write(int fd, const std::string &data);
 Status Pwrite(int fd, const std::string &data, off_t offset);
 
@@ -59,5 +61,6 @@ Status SockSendFile(int out_fd, int in_fd, size_t size, bufferevent *bev);
 
 StatusOr<int> SockConnect(const std::string &host, uint32_t port, ssl_st *ssl, int conn_timeout = 0, int timeout = 0);
 StatusOr<int> EvbufferRead(evbuffer *buf, int fd, int howmuch, ssl_st *ssl);
+StatusOr<int> EvbufferRead(evbuffer *buf, int fd, int howmuch, bufferevent *bev);
 
 }  // namespace util
@@ -0,0 +1,235 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "log_util.h"
+
+#include <dirent.h>
+#include <fmt/format.h>
+#include <pthread.h>
+#include <sys/stat.h>
+#include <unistd.h>
+
+#include <cerrno>
+#include <cstring>
+#include <ctime>
+#include <utility>
+#include <vector>
+
+#include "parse_util.h"
+#include "string_util.h"
+#include "time_util.h"
+
+namespace util {
+
+// the source files of each component, which are the modules of VLOG
+static const std::map<std::string, std::vector<std::string>> kLogComponentModules = {
+    {"replication", {"replication"}},
+    {"migration", {"slot_migrate", "slot_import"}},
+    {"rocksdb", {"event_listener"}},
+};
+
+static void appendJsonString(std::string *output, std::string_view s) {
+  output->push_back('"');
+  for (unsigned char c : s) {
+    switch (c) {
+      case '"':
+        output->append("\\\"");
+        break;
+      case '\\':
+        output->append("\\\\");
+        break;
+      case '\n':
+        output->append("\\n");
+        break;
+      case '\r':
+        output->append("\\r");
+        break;
+      case '\t':
+        output->append("\\t");
+        break;
+      default:
+        if (c < 0x20) {
+          output->append(fmt::format("\\u{:04x}", c));
+        } else {
+          output->push_back(static_cast<char>(c));
+        }
+    }
+  }
+  output->push_back('"');
+}
+
+std::string FormatJsonLogLine(std::string_view level, int64_t timestamp_us, std::string_view thread,
+                              std::string_view base_filename, int line, std::string_view message) {
+  std::string_view component = base_filename.substr(0, base_filename.rfind('.'));
+  if (message.size() > 2 && message[0] == '[') {
+    auto end = message.find(']');
+    if (end != std::string_view::npos && end > 1) {
+      component = message.substr(1, end - 1);
+      message = message.substr(end + 1);
+      while (!message.empty() && message[0] == ' ') message.remove_prefix(1);
+    }
+  }
+
+  time_t seconds = timestamp_us / 1000000;
+  struct tm tm {};
+  gmtime_r(&seconds, &tm);
+  char ts[32];
+  strftime(ts, sizeof(ts), "%Y-%m-%dT%H:%M:%S", &tm);
+
+  std::string output;
+  output.append(R"({"ts":)");
+  appendJsonString(&output, fmt::format("{}.{:06d}Z", ts, timestamp_us % 1000000));
+  output.append(R"(,"level":)");
+  appendJsonString(&output, level);
+  output.append(R"(,"thread":)");
+  appendJsonString(&output, thread);
+  output.append(R"(,"component":)");
+  appendJsonString(&output, component);
+  output.append(R"(,"msg":)");
+  appendJsonString(&output, message);
+  output.append(R"(,"fields":{"file":)");
+  appendJsonString(&output, base_filename);
+  output.append(fmt::format(R"(,"line":{}}}}})", line));
+  return output;
+}
+
+StatusOr<std::map<std::string, int>> ParseLogComponentVerbosity(const std::string &spec) {
+  std::map<std::string, int> verbosity;
+  for (const auto &item : Split(spec, ", ")) {
+    auto pos = item.find(':');
+    auto component = ToLower(item.substr(0, pos));
+    if (kLogComponentModules.find(component) == kLogComponentModules.end()) {
+      return {Status::NotOK,
+              fmt::format("unknown log component '{}', it must be one of replication, migration, rocksdb", component)};
+    }
+
+    int level = 1;
+    if (pos != std::string::npos) {
+      auto parse_result = ParseInt<int>(item.substr(pos + 1), {0, 9}, 10);
+      if (!parse_result) {
+        return {Status::NotOK, fmt::format("invalid verbosity level of the log component '{}'", component)};
+      }
+      level = *parse_result;
+    }
+    verbosity[component] = level;
+  }
+  return verbosity;
+}
+
+Status SetLogComponentVerbosity(const std::string &spec) {
+  auto verbosity = GET_OR_RET(ParseLogComponentVerbosity(spec));
+  for (const auto &[component, modules] : kLogComponentModules) {
+    auto iter = verbosity.find(component);
+    int level = iter != verbosity.end() ? iter->second : 0;
+    for (const auto &module : modules) {
+      google::SetVLOGLevel(module.c_str(), level);
+    }
+  }
+  return Status::OK();
+}
+
+StatusOr<std::unique_ptr<JsonLogSink>> JsonLogSink::Open(const std::string &log_dir, uint64_t max_size) {
+  if (EqualICase(log_dir, "stdout")) {
+    return std::make_unique<JsonLogSink>(stdout, "", 0);
+  }
+
+  std::string path = log_dir + "/" + kLogFileName;
+  FILE *file = fopen(path.c_str(), "a");
+  if (!file) {
+    return {Status::NotOK, fmt::format("failed to open the log file {}: {}", path, strerror(errno))};
+  }
+  return std::make_unique<JsonLogSink>(file, log_dir, max_size);
+}
+
+JsonLogSink::JsonLogSink(FILE *file, std::string dir, uint64_t max_size)
+    : file_(file), dir_(std::move(dir)), max_size_(max_size) {
+  // the logs are appended to the existing file, which counts towards the max size
+  if (!dir_.empty() && fseek(file_, 0, SEEK_END) == 0) {
+    auto pos = ftell(file_);
+    if (pos > 0) size_ = pos;
+  }
+}
+
+JsonLogSink::~JsonLogSink() {
+  if (!dir_.empty() && file_) fclose(file_);
+}
+
+void JsonLogSink::send(google::LogSeverity severity, const char *full_filename, const char *base_filename, int line,
+                       const google::LogMessageTime &time, const char *message, size_t message_len) {
+  char thread[16] = {0};
+  pthread_getname_np(pthread_self(), thread, sizeof(thread));
+  int64_t timestamp_us = static_cast<int64_t>(time.timestamp()) * 1000000 + time.usec();
+  auto output = FormatJsonLogLine(google::GetLogSeverityName(severity), timestamp_us, thread, base_filename, line,
+                                  std::string_view(message, message_len));
+  output.push_back('\n');
+  WriteLine(output);
+}
+
+void JsonLogSink::WriteLine(const std::string &line) {
+  std::lock_guard<std::mutex> guard(mu_);
+  if (!file_) return;
+  fwrite(line.data(), 1, line.size(), file_);
+  fflush(file_);
+
+  size_ += line.size();
+  if (!dir_.empty() && max_size_ > 0 && size_ >= max_size_) rotate();
+}
+
+void JsonLogSink::rotate() {
+  fclose(file_);
+  size_ = 0;
+
+  // the suffix is the time in microseconds, so that the renamed files are ordered by the time
+  std::string path = dir_ + "/" + kLogFileName;
+  std::string rotated_path = fmt::format("{}.{}", path, GetTimeStampUS());
+  if (rename(path.c_str(), rotated_path.c_str()) != 0) {
+    fmt::print(stderr, "failed to rename the log file {}: {}\n", path, strerror(errno));
+  }
+  file_ = fopen(path.c_str(), "a");
+  if (!file_) {
+    // there's no way to log the failure, and the logs are dropped until the restart
+    fmt::print(stderr, "failed to open the log file {}: {}\n", path, strerror(errno));
+  }
+
+  removeExpiredFiles();
+}
+
+void JsonLogSink::removeExpiredFiles() {
+  int days = retention_days_;
+  if (days < 0) return;
+
+  DIR *dir = opendir(dir_.c_str());
+  if (!dir) return;
+  auto now = static_cast<int64_t>(GetTimeStamp());
+  std::string prefix = std::string(kLogFileName) + ".";
+  while (auto entry = readdir(dir)) {
+    std::string name = entry->d_name;
+    if (name.compare(0, prefix.size(), prefix) != 0) continue;
+
+    std::string path = dir_ + "/" + name;
+    struct stat st {};
+    if (stat(path.c_str(), &st) == 0 && now - st.st_mtime >= static_cast<int64_t>(days) * 24 * 3600) {
+      unlink(path.c_str());
+    }
+  }
+  closedir(dir);
+}
+
+}  // namespace util
@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#pragma once
+
+#include <glog/logging.h>
+
+#include <atomic>
+#include <cstdint>
+#include <cstdio>
+#include <map>
+#include <memory>
+#include <mutex>
+#include <string>
+#include <string_view>
+
+#include "status.h"
+
+namespace util {
+
+// FormatJsonLogLine formats a log record as a JSON object in one line. The component is taken from
+// the leading "[component]" tag of the message if any, e.g. "[replication]", or the source file name otherwise.
+std::string FormatJsonLogLine(std::string_view level, int64_t timestamp_us, std::string_view thread,
+                              std::string_view base_filename, int line, std::string_view message);
+
+// ParseLogComponentVerbosity parses the verbosity of components in the form of "component[:level] ...",
+// the level is 1 if it's omitted, and the component must be one of replication, migration and rocksdb.
+StatusOr<std::map<std::string, int>> ParseLogComponentVerbosity(const std::string &spec);
+
+// SetLogComponentVerbosity enables the verbose logs (VLOG) of the components in the spec,
+// and disables them for the components which are not in the spec.
+Status SetLogComponentVerbosity(const std::string &spec);
+
+// JsonLogSink writes the log records as JSON lines, which is used instead of
+// the text log files of glog when the log format is json.
+class JsonLogSink : public google::LogSink {
+ public:
+  static constexpr const char *kLogFileName = "kvrocks.json.log";
+
+  // Open creates the sink writing to stdout if the log dir is "stdout", or kvrocks.json.log in the log dir otherwise.
+  // Like the text logs of glog, the log file is renamed with the time as the suffix once it reaches max_size bytes,
+  // and the renamed files are removed after the retention days, see SetRetentionDays.
+  static StatusOr<std::unique_ptr<JsonLogSink>> Open(const std::string &log_dir, uint64_t max_size);
+  // SetRetentionDays sets the days to keep the renamed log files like google::EnableLogCleaner,
+  // which are kept forever if it's -1
+  static void SetRetentionDays(int days) { retention_days_ = days; }
+
+  JsonLogSink(FILE *file, std::string dir, uint64_t max_size);
+  ~JsonLogSink() override;
+  JsonLogSink(const JsonLogSink &) = delete;
+  JsonLogSink &operator=(const JsonLogSink &) = delete;
+
+  void send(google::LogSeverity severity, const char *full_filename, const char *base_filename, int line,
+            const google::LogMessageTime &time, const char *message, size_t message_len) override;
+  // WriteLine writes a formatted line with the trailing newline, and rotates the log file if needed
+  void WriteLine(const std::string &line);
+
+ private:
+  void rotate();
+  void removeExpiredFiles();
+
+  static inline std::atomic<int> retention_days_ = -1;
+
+  std::mutex mu_;
+  FILE *file_;
+  // the log directory, which is empty if the logs are written to stdout
+  std::string dir_;
+  uint64_t max_size_;
+  uint64_t size_ = 0;
+};
+
+}  // namespace util
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "random_util.h"
+
+#include <algorithm>
+#include <numeric>
+#include <random>
+#include <unordered_set>
+
+namespace util {
+
+static std::mt19937_64 &rng() {
+  thread_local std::mt19937_64 gen(std::random_device{}());
+  return gen;
+}
+
+std::vector<uint64_t> RandomIndices(uint64_t size, int64_t count) {
+  std::vector<uint64_t> indices;
+  if (size == 0 || count == 0) return indices;
+
+  if (count < 0) {
+    uint64_t n = std::min(-static_cast<uint64_t>(count), kMaxRandomRepeatedCount);
+    indices.reserve(n);
+    std::uniform_int_distribution<uint64_t> dis(0, size - 1);
+    for (uint64_t i = 0; i < n; i++) {
+      indices.emplace_back(dis(rng()));
+    }
+    return indices;
+  }
+
+  if (static_cast<uint64_t>(count) >= size) {
+    indices.resize(size);
+    std::iota(indices.begin(), indices.end(), 0);
+    return indices;
+  }
+
+  // Floyd's algorithm picks distinct indices without materializing all of them
+  std::unordered_set<uint64_t> picked;
+  picked.reserve(count);
+  for (uint64_t j = size - count; j < size; j++) {
+    uint64_t t = std::uniform_int_distribution<uint64_t>(0, j)(rng());
+    if (!picked.insert(t).second) picked.insert(j);
+  }
+  indices.assign(picked.begin(), picked.end());
+  std::shuffle(indices.begin(), indices.end(), rng());
+  return indices;
+}
+
+uint64_t RandomInt(uint64_t min, uint64_t max) { return std::uniform_int_distribution<uint64_t>(min, max)(rng()); }
+
+}  // namespace util
@@ -0,0 +1,46 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#pragma once
+
+#include <cstdint>
+#include <vector>
+
+namespace util {
+
+// The count of SRANDMEMBER like commands must not be less than it, which is the same as Redis
+constexpr const int64_t kMinRandomCount = -(INT64_MAX / 2);
+
+// The max number of the indices picked by a negative count, since they may repeat
+// and the reply is not bounded by the size of the collection
+constexpr const uint64_t kMaxRandomRepeatedCount = 1024 * 1024;
+
+// Pick `count` random indices of a collection with `size` elements, following
+// the semantics of the count argument of SRANDMEMBER: a non-negative count picks
+// at most `size` distinct indices, while a negative count picks -count indices
+// (at most kMaxRandomRepeatedCount) which may repeat. The indices are returned
+// in a random order, except that all indices are returned in order when count
+// is not less than `size`.
+std::vector<uint64_t> RandomIndices(uint64_t size, int64_t count);
+
+// Pick a random integer which is uniformly distributed in [min, max].
+uint64_t RandomInt(uint64_t min, uint64_t max);
+
+}  // namespace util
@@ -33,6 +33,7 @@ class RdbStream {
   virtual ~RdbStream() = default;
 
   virtual Status Read(char *buf, size_t len) = 0;
+  virtual Status Write(const char *buf, size_t len) = 0;
   virtual StatusOr<uint64_t> GetCheckSum() const = 0;
   StatusOr<uint8_t> ReadByte() {
     uint8_t value = 0;
@@ -46,13 +47,16 @@ class RdbStream {
 
 class RdbStringStream : public RdbStream {
  public:
+  RdbStringStream() = default;
   explicit RdbStringStream(std::string_view input) : input_(input){};
   RdbStringStream(const RdbStringStream &) = delete;
   RdbStringStream &operator=(const RdbStringStream &) = delete;
   ~RdbStringStream() override = default;
 
   Status Read(char *buf, size_t len) override;
+  Status Write(const char *buf, size_t len) override;
   StatusOr<uint64_t> GetCheckSum() const override;
+  const std::string &GetInput() const { return input_; }
 
  private:
   std::string input_;
@@ -69,6 +73,9 @@ class RdbFileStream : public RdbStream {
 
   Status Open();
   Status Read(char *buf, size_t len) override;
+  Status Write(const char *buf, size_t len) override {
+    return {Status::NotOK, "writing to the rdb file stream is not supported"};
+  }
   StatusOr<uint64_t> GetCheckSum() const override {
     uint64_t crc = check_sum_;
     memrev64ifbe(&crc);
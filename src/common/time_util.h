@@ -20,7 +20,9 @@
 
 #pragma once
 
+#include <atomic>
 #include <chrono>
+#include <cstdint>
 
 namespace util {
 
@@ -31,4 +33,12 @@ auto GetTimeStamp() {
 inline uint64_t GetTimeStampMS() { return GetTimeStamp<std::chrono::milliseconds>(); }
 inline uint64_t GetTimeStampUS() { return GetTimeStamp<std::chrono::microseconds>(); }
 
+// The offset in milliseconds of the clock which decides the expiration of keys,
+// it's only changed by DEBUG SET-CLOCK-OFFSET to test expiration without waiting.
+inline std::atomic<int64_t> expire_clock_offset_ms{0};
+
+inline uint64_t GetExpireTimeStampMS() {
+  return GetTimeStampMS() + expire_clock_offset_ms.load(std::memory_order_relaxed);
+}
+
 }  // namespace util
@@ -36,11 +36,13 @@
 
 #include "config_type.h"
 #include "config_util.h"
+#include "log_util.h"
 #include "parse_util.h"
 #include "rocksdb/compression_type.h"
 #include "server/server.h"
 #include "status.h"
 #include "storage/redis_metadata.h"
+#include "time_util.h"
 
 constexpr const char *kDefaultBindAddress = "127.0.0.1";
 
@@ -62,6 +64,11 @@ const std::vector<ConfigEnum<int>> log_levels{
     {"fatal", google::FATAL},
 };
 
+const std::vector<ConfigEnum<LogFormat>> log_formats{
+    {"text", kLogFormatText},
+    {"json", kLogFormatJson},
+};
+
 const std::vector<ConfigEnum<JsonStorageFormat>> json_storage_formats{{"json", JsonStorageFormat::JSON},
                                                                       {"cbor", JsonStorageFormat::CBOR}};
 
@@ -93,6 +100,10 @@ Config::Config() {
       {"daemonize", true, new YesNoField(&daemonize, false)},
       {"bind", true, new StringField(&binds_str_, "")},
       {"port", true, new UInt32Field(&port, kDefaultPort, 1, PORT_LIMIT)},
+      {"metrics-port", true, new UInt32Field(&metrics_port, 0, 0, PORT_LIMIT)},
+      {"metrics-bind", true, new StringField(&metrics_binds_str_, kDefaultBindAddress)},
+      {"tracing-otlp-endpoint", true, new StringField(&tracing_otlp_endpoint, "")},
+      {"tracing-sample-percent", true, new IntField(&tracing_sample_percent, 100, 0, 100)},
 #ifdef ENABLE_OPENSSL
       {"tls-port", true, new UInt32Field(&tls_port, 0, 0, PORT_LIMIT)},
       {"tls-cert-file", false, new StringField(&tls_cert_file, "")},
@@ -133,6 +144,8 @@ Config::Config() {
       {"backup-dir", false, new StringField(&backup_dir_, "")},
       {"log-dir", true, new StringField(&log_dir, "")},
       {"log-level", false, new EnumField<int>(&log_level, log_levels, google::INFO)},
+      {"log-format", true, new EnumField<LogFormat>(&log_format, log_formats, kLogFormatText)},
+      {"log-component-verbosity", false, new StringField(&log_component_verbosity, "")},
       {"pidfile", true, new StringField(&pidfile_, "")},
       {"max-io-mb", false, new IntField(&max_io_mb, 0, 0, INT_MAX)},
       {"max-bitmap-to-string-mb", false, new IntField(&max_bitmap_to_string_mb, 16, 0, INT_MAX)},
@@ -151,6 +164,11 @@ Config::Config() {
       {"slowlog-log-slower-than", false, new IntField(&slowlog_log_slower_than, 200000, -1, INT_MAX)},
       {"profiling-sample-commands", false, new StringField(&profiling_sample_commands_str_, "")},
       {"slowlog-max-len", false, new IntField(&slowlog_max_len, 128, 0, INT_MAX)},
+      {"latency-monitor-threshold", false, new IntField(&latency_monitor_threshold, 0, 0, INT_MAX)},
+      {"hot-keys-tracking", false, new YesNoField(&hot_keys_tracking, false)},
+      {"key-access-tracking", false, new YesNoField(&key_access_tracking, false)},
+      {"debug-clock-offset-enabled", true, new YesNoField(&debug_clock_offset_enabled, false)},
+      {"audit-log", true, new StringField(&audit_log, "")},
       {"purge-backup-on-fullsync", false, new YesNoField(&purge_backup_on_fullsync, false)},
       {"rename-command", true, new MultiStringField(&rename_command_, std::vector<std::string>{})},
       {"auto-resize-block-and-sst", false, new YesNoField(&auto_resize_block_and_sst, true)},
@@ -412,6 +430,11 @@ void Config::initFieldCallback() {
              binds = std::move(args);
              return Status::OK();
            }},
+          {"metrics-bind",
+           [this](Server *srv, const std::string &k, const std::string &v) -> Status {
+             metrics_binds = util::Split(v, " \t");
+             return Status::OK();
+           }},
           {"maxclients",
            [](Server *srv, const std::string &k, const std::string &v) -> Status {
              if (!srv) return Status::OK();
@@ -454,6 +477,12 @@ void Config::initFieldCallback() {
              }
              return Status::OK();
            }},
+          {"key-access-tracking",
+           [](Server *srv, const std::string &k, const std::string &v) -> Status {
+             if (!srv) return Status::OK();
+             srv->key_access.Reset(util::GetTimeStampMS());
+             return Status::OK();
+           }},
           {"slowlog-max-len",
            [this](Server *srv, const std::string &k, const std::string &v) -> Status {
              if (!srv) return Status::OK();
@@ -502,6 +531,10 @@ void Config::initFieldCallback() {
              FLAGS_minloglevel = log_level;
              return Status::OK();
            }},
+          {"log-component-verbosity",
+           [this](Server *srv, const std::string &k, const std::string &v) -> Status {
+             return util::SetLogComponentVerbosity(log_component_verbosity);
+           }},
           {"log-retention-days",
            [this](Server *srv, const std::string &k, const std::string &v) -> Status {
              if (!srv) return Status::OK();
@@ -509,6 +542,7 @@ void Config::initFieldCallback() {
                return {Status::NotOK, "can't set the 'log-retention-days' when the log dir is stdout"};
              }
 
+             util::JsonLogSink::SetRetentionDays(log_retention_days);
              if (log_retention_days != -1) {
                google::EnableLogCleaner(log_retention_days);
              } else {
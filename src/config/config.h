@@ -44,6 +44,8 @@ constexpr const uint32_t PORT_LIMIT = 65535;
 
 enum SupervisedMode { kSupervisedNone = 0, kSupervisedAutoDetect, kSupervisedSystemd, kSupervisedUpStart };
 
+enum LogFormat { kLogFormatText = 0, kLogFormatJson };
+
 constexpr const char *TLS_AUTH_CLIENTS_NO = "no";
 constexpr const char *TLS_AUTH_CLIENTS_OPTIONAL = "optional";
 
@@ -75,6 +77,10 @@ struct Config {
   Config();
   ~Config() = default;
   uint32_t port = 0;
+  uint32_t metrics_port = 0;
+  std::vector<std::string> metrics_binds;
+  std::string tracing_otlp_endpoint;
+  int tracing_sample_percent = 100;
 
   uint32_t tls_port = 0;
   std::string tls_cert_file;
@@ -95,11 +101,18 @@ struct Config {
   int workers = 0;
   int timeout = 0;
   int log_level = 0;
+  LogFormat log_format = kLogFormatText;
+  std::string log_component_verbosity;
   int backlog = 511;
   int maxclients = 10000;
   int max_backup_to_keep = 1;
   int max_backup_keep_hours = 24;
   int slowlog_log_slower_than = 100000;
+  int latency_monitor_threshold = 0;
+  bool hot_keys_tracking = false;
+  bool key_access_tracking = false;
+  bool debug_clock_offset_enabled = false;
+  std::string audit_log;
   int slowlog_max_len = 128;
   bool daemonize = false;
   SupervisedMode supervised_mode = kSupervisedNone;
@@ -235,6 +248,7 @@ struct Config {
   std::string backup_dir_;  // GUARD_BY(backup_mu_)
   std::string pidfile_;
   std::string binds_str_;
+  std::string metrics_binds_str_;
   std::string slaveof_;
   std::string compact_cron_str_;
   std::string bgsave_cron_str_;
@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "audit_log.h"
+
+#include <fmt/format.h>
+#include <syslog.h>
+
+#include <cerrno>
+#include <cstring>
+
+#include "string_util.h"
+
+static constexpr const char *kAuditLogSyslog = "syslog";
+static constexpr const char *kRedacted = "(redacted)";
+
+Status AuditLog::Open(const std::string &target) {
+  std::lock_guard<std::mutex> guard(mu_);
+  FILE *file = nullptr;
+  if (!target.empty() && target != kAuditLogSyslog) {
+    file = fopen(target.c_str(), "a");
+    if (!file) return {Status::NotOK, fmt::format("failed to open the audit log {}: {}", target, strerror(errno))};
+  }
+
+  closeTarget();
+  enabled_ = !target.empty();
+  use_syslog_ = target == kAuditLogSyslog;
+  file_ = file;
+  if (use_syslog_) openlog("kvrocks", LOG_PID, LOG_USER);
+  return Status::OK();
+}
+
+void AuditLog::Write(const std::string &entry) {
+  std::lock_guard<std::mutex> guard(mu_);
+  if (use_syslog_) {
+    syslog(LOG_NOTICE, "%s", entry.c_str());
+  } else if (file_) {
+    fprintf(file_, "%s\n", entry.c_str());
+    fflush(file_);
+  }
+}
+
+void AuditLog::closeTarget() {
+  if (file_) fclose(file_);
+  if (use_syslog_) closelog();
+  file_ = nullptr;
+  use_syslog_ = false;
+}
+
+bool AuditLog::IsAuditedCommand(const std::string &cmd_name, const std::vector<std::string> &args) {
+  if (cmd_name == "config" || cmd_name == "namespace") {
+    return args.size() < 2 || !util::EqualICase(args[1], "get");
+  }
+  return cmd_name == "flushall" || cmd_name == "flushdb" || cmd_name == "clusterx" || cmd_name == "slaveof" ||
+         cmd_name == "failover" || cmd_name == "shutdown";
+}
+
+std::string AuditLog::FormatEntry(uint64_t timestamp_us, const std::string &ns, const std::string &addr, uint64_t id,
+                                  const std::string &name, bool ok, const std::vector<std::string> &args) {
+  std::string entry = fmt::format("{}.{:06} [{} {}] id={} name={} result={}", timestamp_us / 1000000,
+                                  timestamp_us % 1000000, ns, addr, id, name, ok ? "ok" : "err");
+
+  std::string cmd_name = args.empty() ? "" : util::ToLower(args[0]);
+  std::string subcommand = args.size() < 2 ? "" : util::ToLower(args[1]);
+  for (size_t i = 0; i < args.size(); i++) {
+    bool redacted = false;
+    if (cmd_name == "config" && subcommand == "set" && i >= 3 && i % 2 == 1) {
+      // the values of the password fields, e.g. requirepass and masterauth
+      std::string field = util::ToLower(args[i - 1]);
+      redacted = field.find("pass") != std::string::npos || field.find("auth") != std::string::npos;
+    } else if (cmd_name == "namespace" && (subcommand == "add" || subcommand == "set") && i == 3) {
+      redacted = true;
+    }
+
+    entry += " \"";
+    entry += redacted ? kRedacted : util::EscapeString(args[i]);
+    entry += "\"";
+  }
+  return entry;
+}
@@ -0,0 +1,63 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#pragma once
+
+#include <atomic>
+#include <cstdint>
+#include <cstdio>
+#include <mutex>
+#include <string>
+#include <vector>
+
+#include "status.h"
+
+// AuditLog records who executed the administrative commands and when, the entries are appended
+// to a separate file or sent to syslog, so they are kept apart from the server log.
+class AuditLog {
+ public:
+  AuditLog() = default;
+  ~AuditLog() { closeTarget(); }
+
+  AuditLog(const AuditLog &) = delete;
+  AuditLog &operator=(const AuditLog &) = delete;
+
+  // Open switches the target of the audit log, which is a file path, "syslog",
+  // or empty to disable the audit log
+  Status Open(const std::string &target);
+  bool IsEnabled() const { return enabled_; }
+  void Write(const std::string &entry);
+
+  // IsAuditedCommand returns whether the command is administrative, the read-only subcommands
+  // like CONFIG GET are not audited
+  static bool IsAuditedCommand(const std::string &cmd_name, const std::vector<std::string> &args);
+  // FormatEntry formats the entry in the same way as MONITOR, the secrets like passwords
+  // and namespace tokens in the arguments are redacted
+  static std::string FormatEntry(uint64_t timestamp_us, const std::string &ns, const std::string &addr, uint64_t id,
+                                 const std::string &name, bool ok, const std::vector<std::string> &args);
+
+ private:
+  void closeTarget();
+
+  std::mutex mu_;
+  std::atomic<bool> enabled_ = false;
+  bool use_syslog_ = false;
+  FILE *file_ = nullptr;
+};
@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "metrics_server.h"
+
+#include <event2/buffer.h>
+#include <glog/logging.h>
+
+#include <cstring>
+#include <utility>
+
+#include "fmt/format.h"
+#include "server.h"
+#include "stats/prometheus.h"
+#include "thread_util.h"
+
+MetricsServer::MetricsServer(Server *srv, std::vector<std::string> binds, uint32_t port)
+    : srv_(srv), binds_(std::move(binds)), port_(port) {
+  // the metrics aren't protected by the password, so they are only served locally by default
+  if (binds_.empty()) binds_.emplace_back("127.0.0.1");
+}
+
+MetricsServer::~MetricsServer() {
+  if (http_) evhttp_free(http_);
+  if (base_) event_base_free(base_);
+}
+
+Status MetricsServer::Start() {
+  base_ = event_base_new();
+  if (!base_) return {Status::NotOK, "failed to create the event base of the metrics server"};
+  http_ = evhttp_new(base_);
+  if (!http_) return {Status::NotOK, "failed to create the metrics server"};
+
+  evhttp_set_allowed_methods(http_, EVHTTP_REQ_GET);
+  evhttp_set_cb(http_, "/metrics", &MetricsServer::handleRequest, this);
+  for (const auto &bind : binds_) {
+    if (evhttp_bind_socket(http_, bind.c_str(), port_) != 0) {
+      return {Status::NotOK, fmt::format("failed to listen on {}:{} for metrics: {}", bind, port_, strerror(errno))};
+    }
+  }
+
+  thread_ = GET_OR_RET(util::CreateThread("metrics", [this] {
+    if (event_base_dispatch(base_) != 0) {
+      LOG(ERROR) << "[metrics] Failed to run the metrics server, err: " << strerror(errno);
+    }
+  }));
+  LOG(INFO) << "[metrics] Serving metrics on port " << port_;
+  return Status::OK();
+}
+
+void MetricsServer::Stop() {
+  if (base_) event_base_loopbreak(base_);
+}
+
+void MetricsServer::Join() {
+  if (!thread_.joinable()) return;
+  if (auto s = util::ThreadJoin(thread_); !s) {
+    LOG(WARNING) << "Metrics thread operation failed: " << s.Msg();
+  }
+}
+
+void MetricsServer::handleRequest(evhttp_request *req, void *arg) {
+  auto self = static_cast<MetricsServer *>(arg);
+
+  std::string info;
+  {
+    // the same guard as the INFO command, which prevents reading while exclusive commands are running
+    auto guard = self->srv_->WorkConcurrencyGuard();
+    self->srv_->GetInfo(kDefaultNamespace, "all", &info);
+  }
+  std::string metrics = PrometheusMetricsFromInfo(info);
+
+  evhttp_add_header(evhttp_request_get_output_headers(req), "Content-Type", "text/plain; version=0.0.4");
+  evbuffer *body = evbuffer_new();
+  evbuffer_add(body, metrics.data(), metrics.size());
+  evhttp_send_reply(req, HTTP_OK, "OK", body);
+  evbuffer_free(body);
+}
@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#pragma once
+
+#include <event2/event.h>
+#include <event2/http.h>
+
+#include <string>
+#include <thread>
+#include <vector>
+
+#include "status.h"
+
+class Server;
+
+// MetricsServer serves the metrics of the server in the Prometheus text format on
+// `http://<bind>:<metrics-port>/metrics` in its own thread.
+class MetricsServer {
+ public:
+  MetricsServer(Server *srv, std::vector<std::string> binds, uint32_t port);
+  ~MetricsServer();
+  MetricsServer(const MetricsServer &) = delete;
+  MetricsServer &operator=(const MetricsServer &) = delete;
+
+  Status Start();
+  void Stop();
+  void Join();
+
+ private:
+  static void handleRequest(evhttp_request *req, void *arg);
+
+  Server *srv_;
+  std::vector<std::string> binds_;
+  uint32_t port_;
+  event_base *base_ = nullptr;
+  evhttp *http_ = nullptr;
+  std::thread thread_;
+};
@@ -36,6 +36,7 @@
 #include "redis_connection.h"
 #include "scope_exit.h"
 #include "server.h"
+#include "storage/redis_db.h"
 #include "time_util.h"
 #include "tls_util.h"
 #include "worker.h"
@@ -64,9 +65,13 @@ Connection::~Connection() {
 }
 
 std::string Connection::ToString() {
-  return fmt::format("id={} addr={} fd={} name={} age={} idle={} flags={} namespace={} qbuf={} obuf={} cmd={}\n", id_,
-                     addr_, bufferevent_getfd(bev_), name_, GetAge(), GetIdleTime(), GetFlags(), ns_,
-                     evbuffer_get_length(Input()), evbuffer_get_length(Output()), last_cmd_);
+  // kvrocks only speaks RESP2, so the resp field is always 2 even if the client sent HELLO 3
+  return fmt::format(
+      "id={} addr={} laddr={} fd={} name={} age={} idle={} flags={} namespace={} multi={} watch={} qbuf={} obuf={} "
+      "cmd={} resp=2 lib-name={} lib-ver={} tot-net-in={} tot-net-out={}\n",
+      id_, addr_, laddr_, bufferevent_getfd(bev_), name_, GetAge(), GetIdleTime(), GetFlags(), ns_, GetMultiDepth(),
+      watched_keys_cnt.load(), evbuffer_get_length(Input()), evbuffer_get_length(Output()), last_cmd_, lib_name_,
+      lib_ver_, net_input_bytes_.load(), net_output_bytes_.load());
 }
 
 void Connection::Close() {
@@ -81,7 +86,10 @@ void Connection::OnRead(struct bufferevent *bev) {
   MakeScopeExit([this] { is_running_ = false; });
 
   SetLastInteraction();
+  size_t input_len = evbuffer_get_length(Input());
   auto s = req_.Tokenize(Input());
+  // count the consumed bytes only, the incomplete request is counted once it's parsed by the next read
+  net_input_bytes_.fetch_add(input_len - evbuffer_get_length(Input()), std::memory_order_relaxed);
   if (!s.IsOK()) {
     EnableFlag(redis::Connection::kCloseAfterReply);
     Reply(redis::Error("ERR " + s.Msg()));
@@ -98,6 +106,15 @@ void Connection::OnRead(struct bufferevent *bev) {
 void Connection::OnWrite(bufferevent *bev) {
   if (IsFlagEnabled(kCloseAfterReply) || IsFlagEnabled(kCloseAsync)) {
     Close();
+    return;
+  }
+
+  // The server enables the write event to resume the connection parked by the write pause,
+  // and the parked command is still at the front of the pending commands.
+  if (IsFlagEnabled(kWritePaused) && !srv_->IsWritePaused()) {
+    DisableFlag(kWritePaused);
+    bufferevent_enable(bev, EV_READ);
+    bufferevent_trigger(bev, EV_READ, BEV_TRIG_IGNORE_WATERMARKS);
   }
 }
 
@@ -127,6 +144,8 @@ void Connection::OnEvent(bufferevent *bev, int16_t events) {
 
 void Connection::Reply(const std::string &msg) {
   owner_->srv->stats.IncrOutbondBytes(msg.size());
+  IncrNetOutputBytes(msg.size());
+  if (!msg.empty() && msg[0] == '-') owner_->srv->stats.IncrErrorReplies(msg);
   redis::Reply(bufferevent_get_output(bev_), msg);
 }
 
@@ -168,6 +187,8 @@ std::string Connection::GetFlags() const {
   if (IsFlagEnabled(kSlave)) flags.append("S");
   if (IsFlagEnabled(kCloseAfterReply)) flags.append("c");
   if (IsFlagEnabled(kMonitor)) flags.append("M");
+  if (IsFlagEnabled(kNoTouch)) flags.append("T");
+  if (IsFlagEnabled(kNoEvict)) flags.append("e");
   if (!subscribe_channels_.empty() || !subscribe_patterns_.empty()) flags.append("P");
   if (flags.empty()) flags = "N";
   return flags;
@@ -183,6 +204,7 @@ bool Connection::CanMigrate() const {
   return !is_running_                                                    // reading or writing
          && !IsFlagEnabled(redis::Connection::kCloseAfterReply)          // close after reply
          && saved_current_command_ == nullptr                            // not executing blocking command like BLPOP
+         && !IsFlagEnabled(redis::Connection::kWritePaused)              // not parked by the write pause
          && subscribe_channels_.empty() && subscribe_patterns_.empty();  // not subscribing any channel
 }
 
@@ -300,6 +322,31 @@ void Connection::RecordProfilingSampleIfNeed(const std::string &cmd, uint64_t du
   srv_->GetPerfLog()->PushEntry(std::move(entry));
 }
 
+void Connection::RecordKeyAccesses(const CommandAttributes *attributes, const std::vector<std::string> &cmd_tokens) {
+  Config *config = srv_->GetConfig();
+  bool track_hot_keys = config->hot_keys_tracking;
+  // the key accesses of clients in the no-touch mode are not tracked, so that debugging doesn't pollute them
+  bool track_key_access =
+      config->key_access_tracking && !(attributes->flags & kCmdNoTouch) && !IsFlagEnabled(kNoTouch);
+  if (!track_hot_keys && !track_key_access) return;
+
+  std::vector<int> keys_index;
+  // commands without keys are not tracked
+  if (!redis::CommandTable::GetKeysFromCommand(attributes, cmd_tokens, &keys_index).IsOK()) return;
+
+  uint64_t now_ms = util::GetTimeStampMS();
+  for (int index : keys_index) {
+    const auto &key = cmd_tokens[index];
+    if (track_hot_keys) srv_->hot_keys.Record(ns_, key, now_ms);
+    if (track_key_access && !srv_->key_access.Touch(ns_, key, now_ms)) {
+      // the misses aren't tracked, so only the keys which aren't tracked yet are looked up
+      redis::Database db(srv_->storage, ns_);
+      int cnt = 0;
+      if (db.Exists({key}, &cnt).ok() && cnt > 0) srv_->key_access.Record(ns_, key, now_ms);
+    }
+  }
+}
+
 void Connection::ExecuteCommands(std::deque<CommandTokens> *to_process_cmds) {
   Config *config = srv_->GetConfig();
   std::string reply, password = config->requirepass;
@@ -322,7 +369,8 @@ void Connection::ExecuteCommands(std::deque<CommandTokens> *to_process_cmds) {
 
     if (GetNamespace().empty()) {
       if (!password.empty() && util::ToLower(cmd_tokens.front()) != "auth" &&
-          util::ToLower(cmd_tokens.front()) != "hello") {
+          util::ToLower(cmd_tokens.front()) != "hello" && util::ToLower(cmd_tokens.front()) != "reset") {
+        srv_->stats.IncrRejectedCalls(current_cmd->GetAttributes()->name);
         Reply(redis::Error("NOAUTH Authentication required."));
         continue;
       }
@@ -361,6 +409,7 @@ void Connection::ExecuteCommands(std::deque<CommandTokens> *to_process_cmds) {
     }
 
     if (srv_->IsLoading() && !(cmd_flags & kCmdLoading)) {
+      srv_->stats.IncrRejectedCalls(cmd_name);
       Reply(redis::Error("LOADING kvrocks is restoring the db from backup"));
       if (is_multi_exec) multi_error_ = true;
       continue;
@@ -370,6 +419,7 @@ void Connection::ExecuteCommands(std::deque<CommandTokens> *to_process_cmds) {
     int tokens = static_cast<int>(cmd_tokens.size());
     if ((arity > 0 && tokens != arity) || (arity < 0 && tokens < -arity)) {
       if (is_multi_exec) multi_error_ = true;
+      srv_->stats.IncrRejectedCalls(cmd_name);
       Reply(redis::Error("ERR wrong number of arguments"));
       continue;
     }
@@ -378,12 +428,14 @@ void Connection::ExecuteCommands(std::deque<CommandTokens> *to_process_cmds) {
     s = current_cmd->Parse();
     if (!s.IsOK()) {
       if (is_multi_exec) multi_error_ = true;
+      srv_->stats.IncrRejectedCalls(cmd_name);
       Reply(redis::Error("ERR " + s.Msg()));
       continue;
     }
 
     if (is_multi_exec && (cmd_flags & kCmdNoMulti)) {
       std::string no_multi_err = "ERR Can't execute " + attributes->name + " in MULTI";
+      srv_->stats.IncrRejectedCalls(cmd_name);
       Reply(redis::Error(no_multi_err));
       multi_error_ = true;
       continue;
@@ -393,6 +445,7 @@ void Connection::ExecuteCommands(std::deque<CommandTokens> *to_process_cmds) {
       s = srv_->cluster->CanExecByMySelf(attributes, cmd_tokens, this);
       if (!s.IsOK()) {
         if (is_multi_exec) multi_error_ = true;
+        srv_->stats.IncrRejectedCalls(cmd_name);
         Reply(redis::Error("ERR " + s.Msg()));
         continue;
       }
@@ -401,17 +454,32 @@ void Connection::ExecuteCommands(std::deque<CommandTokens> *to_process_cmds) {
     // We don't execute commands, but queue them, ant then execute in EXEC command
     if (is_multi_exec && !in_exec_ && !(cmd_flags & kCmdMulti)) {
       multi_cmds_.emplace_back(cmd_tokens);
+      multi_depth_ = multi_cmds_.size();
+      if (cmd_flags & kCmdWrite) multi_write_ = true;
       Reply(redis::SimpleString("QUEUED"));
       continue;
     }
 
+    // Park the connection while the writes are paused by FAILOVER, the command will be executed again
+    // once the pause is over. It should be checked before the read-only check since the server may
+    // become a replica after the failover. The commands in EXEC are checked by the EXEC itself.
+    bool is_write = (cmd_flags & kCmdWrite) || (cmd_name == "exec" && multi_write_);
+    if (is_write && !in_exec_ && srv_->ParkIfWritePaused(this)) {
+      to_process_cmds->push_front(std::move(cmd_tokens));
+      EnableFlag(kWritePaused);
+      bufferevent_disable(bev_, EV_READ);
+      break;
+    }
+
     if (config->slave_readonly && srv_->IsSlave() && (cmd_flags & kCmdWrite)) {
+      srv_->stats.IncrRejectedCalls(cmd_name);
       Reply(redis::Error("READONLY You can't write against a read only slave."));
       continue;
     }
 
     if (!config->slave_serve_stale_data && srv_->IsSlave() && cmd_name != "info" && cmd_name != "slaveof" &&
         srv_->GetReplicationState() != kReplConnected) {
+      srv_->stats.IncrRejectedCalls(cmd_name);
       Reply(
           redis::Error("MASTERDOWN Link with MASTER is down "
                        "and slave-serve-stale-data is set to 'no'."));
@@ -421,6 +489,19 @@ void Connection::ExecuteCommands(std::deque<CommandTokens> *to_process_cmds) {
     SetLastCmd(cmd_name);
     srv_->stats.IncrCalls(cmd_name);
 
+    tracing::Span span(srv_->GetTracer(), "kvrocks." + cmd_name, tracing::SpanKind::kServer);
+    if (span.IsRecording()) {
+      std::vector<int> keys_index;
+      // commands without keys are counted as zero
+      (void)redis::CommandTable::GetKeysFromCommand(attributes, cmd_tokens, &keys_index);
+      span.SetAttribute("db.system", std::string("kvrocks"));
+      span.SetAttribute("db.namespace", ns_);
+      span.SetAttribute("db.operation.name", cmd_name);
+      span.SetAttribute("kvrocks.key_count", static_cast<int64_t>(keys_index.size()));
+    }
+
+    // The command is recorded by the audit log if it's enabled when the command starts
+    bool is_audited = srv_->GetAuditLog()->IsEnabled() && AuditLog::IsAuditedCommand(cmd_name, cmd_tokens);
     auto start = std::chrono::high_resolution_clock::now();
     bool is_profiling = IsProfilingEnabled(cmd_name);
     s = current_cmd->Execute(srv_, this, &reply);
@@ -429,7 +510,16 @@ void Connection::ExecuteCommands(std::deque<CommandTokens> *to_process_cmds) {
     if (is_profiling) RecordProfilingSampleIfNeed(cmd_name, duration);
 
     srv_->SlowlogPushEntryIfNeeded(&cmd_tokens, duration, this);
+    srv_->storage->LatencyAddSampleIfNeeded("command", duration / 1000);
     srv_->stats.IncrLatency(static_cast<uint64_t>(duration), cmd_name);
+    RecordKeyAccesses(attributes, cmd_tokens);
+    if (cmd_flags & kCmdWrite) last_write_seq_ = srv_->storage->LatestSeqNumber();
+    if (is_audited) {
+      // some commands reply errors without returning a failed status
+      bool ok = s.IsOK() && (reply.empty() || reply[0] != '-');
+      srv_->GetAuditLog()->Write(
+          AuditLog::FormatEntry(util::GetTimeStampUS(), ns_, GetAddr(), id_, name_, ok, cmd_tokens));
+    }
     srv_->FeedMonitorConns(this, cmd_tokens);
 
     // Break the execution loop when occurring the blocking command like BLPOP or BRPOP,
@@ -445,10 +535,18 @@ void Connection::ExecuteCommands(std::deque<CommandTokens> *to_process_cmds) {
 
     // Reply for MULTI
     if (!s.IsOK()) {
+      srv_->stats.IncrFailedCalls(cmd_name);
+      span.SetError(s.Msg());
       Reply(redis::Error("ERR " + s.Msg()));
       continue;
     }
 
+    // some commands reply errors without returning a failed status
+    if (!reply.empty() && reply[0] == '-') {
+      srv_->stats.IncrFailedCalls(cmd_name);
+      span.SetError(reply.substr(1, reply.find('\r') - 1));
+    }
+
     srv_->UpdateWatchedKeysFromArgs(cmd_tokens, *attributes);
 
     if (!reply.empty()) Reply(reply);
@@ -459,8 +557,27 @@ void Connection::ExecuteCommands(std::deque<CommandTokens> *to_process_cmds) {
 void Connection::ResetMultiExec() {
   in_exec_ = false;
   multi_error_ = false;
+  multi_write_ = false;
   multi_cmds_.clear();
+  multi_depth_ = 0;
   DisableFlag(Connection::kMultiExec);
 }
 
+void Connection::Reset() {
+  ResetMultiExec();
+  srv_->ResetWatchedKeys(this);
+  UnsubscribeAll();
+  PUnsubscribeAll();
+  if (IsFlagEnabled(kMonitor)) owner_->QuitMonitorConn(this);
+  DisableFlag(kNoTouch);
+  DisableFlag(kNoEvict);
+  name_.clear();
+  // de-authenticate the connection, so that the next user of a pooled connection
+  // doesn't inherit the namespace or the admin permission of the previous one
+  if (!srv_->GetConfig()->requirepass.empty()) {
+    SetNamespace("");
+    BecomeUser();
+  }
+}
+
 }  // namespace redis
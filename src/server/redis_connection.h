@@ -45,6 +45,9 @@ class Connection : public EvbufCallbackBase<Connection> {
     kCloseAfterReply = 1 << 6,
     kCloseAsync = 1 << 7,
     kMultiExec = 1 << 8,
+    kNoTouch = 1 << 9,
+    kNoEvict = 1 << 10,
+    kWritePaused = 1 << 11,
   };
 
   explicit Connection(bufferevent *bev, Worker *owner);
@@ -86,7 +89,16 @@ class Connection : public EvbufCallbackBase<Connection> {
   void SetName(std::string name) { name_ = std::move(name); }
   std::string GetAddr() const { return addr_; }
   void SetAddr(std::string ip, uint32_t port);
+  std::string GetLocalAddr() const { return laddr_; }
+  void SetLocalAddr(const std::string &ip, uint32_t port) { laddr_ = ip + ":" + std::to_string(port); }
   void SetLastCmd(std::string cmd) { last_cmd_ = std::move(cmd); }
+  std::string GetLibName() const { return lib_name_; }
+  void SetLibName(std::string name) { lib_name_ = std::move(name); }
+  std::string GetLibVer() const { return lib_ver_; }
+  void SetLibVer(std::string ver) { lib_ver_ = std::move(ver); }
+  uint64_t GetNetInputBytes() const { return net_input_bytes_; }
+  uint64_t GetNetOutputBytes() const { return net_output_bytes_; }
+  void IncrNetOutputBytes(uint64_t bytes) { net_output_bytes_.fetch_add(bytes, std::memory_order_relaxed); }
   std::string GetIP() const { return ip_; }
   uint32_t GetPort() const { return port_; }
   void SetListeningPort(int port) { listening_port_ = port; }
@@ -117,20 +129,29 @@ class Connection : public EvbufCallbackBase<Connection> {
   void ExecuteCommands(std::deque<CommandTokens> *to_process_cmds);
   bool IsProfilingEnabled(const std::string &cmd);
   void RecordProfilingSampleIfNeed(const std::string &cmd, uint64_t duration);
+  void RecordKeyAccesses(const CommandAttributes *attributes, const std::vector<std::string> &cmd_tokens);
   void SetImporting() { importing_ = true; }
   bool IsImporting() const { return importing_; }
   bool CanMigrate() const;
+  // the latest sequence after executing the last write command, used by WAIT
+  uint64_t GetLastWriteSeq() const { return last_write_seq_; }
 
   // Multi exec
   void SetInExec() { in_exec_ = true; }
   bool IsInExec() const { return in_exec_; }
   bool IsMultiError() const { return multi_error_; }
+  // the number of queued commands in MULTI, or -1 if not in MULTI
+  int64_t GetMultiDepth() const { return IsFlagEnabled(kMultiExec) ? static_cast<int64_t>(multi_depth_.load()) : -1; }
   void ResetMultiExec();
+  // return the connection to its initial state, used by RESET
+  void Reset();
   std::deque<redis::CommandTokens> *GetMultiExecCommands() { return &multi_cmds_; }
 
   std::function<void(int)> close_cb = nullptr;
 
   std::set<std::string> watched_keys;
+  // the size of watched_keys, which can be read by other threads, e.g. CLIENT LIST
+  std::atomic<size_t> watched_keys_cnt = 0;
   std::atomic<bool> watched_keys_modified = false;
 
  private:
@@ -142,10 +163,16 @@ class Connection : public EvbufCallbackBase<Connection> {
   std::string announce_ip_;
   uint32_t port_ = 0;
   std::string addr_;
+  std::string laddr_;
   int listening_port_ = 0;
   bool is_admin_ = false;
   bool need_free_bev_ = true;
   std::string last_cmd_;
+  std::string lib_name_;
+  std::string lib_ver_;
+  // replies may be written by other threads, e.g. the messages of PUBLISH
+  std::atomic<uint64_t> net_input_bytes_ = 0;
+  std::atomic<uint64_t> net_output_bytes_ = 0;
   int64_t create_time_;
   int64_t last_interaction_;
 
@@ -159,11 +186,15 @@ class Connection : public EvbufCallbackBase<Connection> {
 
   Server *srv_;
   bool in_exec_ = false;
+  bool multi_write_ = false;
   bool multi_error_ = false;
   std::atomic<bool> is_running_ = false;
   std::deque<redis::CommandTokens> multi_cmds_;
+  // the size of multi_cmds_ before EXEC, which can be read by other threads, e.g. CLIENT LIST
+  std::atomic<size_t> multi_depth_ = 0;
 
   bool importing_ = false;
+  uint64_t last_write_seq_ = 0;
 };
 
 }  // namespace redis
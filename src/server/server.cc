@@ -40,7 +40,9 @@
 #include "commands/commander.h"
 #include "config.h"
 #include "fmt/format.h"
+#include "io_util.h"
 #include "redis_connection.h"
+#include "scope_exit.h"
 #include "storage/compaction_checker.h"
 #include "storage/redis_db.h"
 #include "storage/scripting.h"
@@ -48,9 +50,14 @@
 #include "string_util.h"
 #include "thread_util.h"
 #include "time_util.h"
+#include "unique_fd.h"
 #include "version.h"
 #include "worker.h"
 
+#ifdef ENABLE_JEMALLOC
+#include <jemalloc/jemalloc.h>
+#endif
+
 constexpr const char *REDIS_VERSION = "4.0.0";
 
 Server::Server(engine::Storage *storage, Config *config)
@@ -60,11 +67,15 @@ Server::Server(engine::Storage *storage, Config *config)
   for (const auto &iter : *commands) {
     stats.commands_stats[iter.first].calls = 0;
     stats.commands_stats[iter.first].latency = 0;
+    stats.commands_stats[iter.first].rejected_calls = 0;
+    stats.commands_stats[iter.first].failed_calls = 0;
   }
 
   // init cursor_dict_
   cursor_dict_ = std::make_unique<CursorDictType>();
 
+  key_access.Reset(util::GetTimeStampMS());
+
 #ifdef ENABLE_OPENSSL
   // init ssl context
   if (config->tls_port || config->tls_replication) {
@@ -138,6 +149,10 @@ Status Server::Start() {
   if (!s.IsOK()) {
     return s;
   }
+  s = audit_log_.Open(config_->audit_log);
+  if (!s.IsOK()) {
+    return s;
+  }
   if (!config_->master_host.empty()) {
     s = AddMaster(config_->master_host, static_cast<uint32_t>(config_->master_port), false);
     if (!s.IsOK()) return s;
@@ -174,6 +189,23 @@ Status Server::Start() {
   if (auto s = task_runner_.Start(); !s) {
     LOG(WARNING) << "Failed to start task runner: " << s.Msg();
   }
+  if (!config_->tracing_otlp_endpoint.empty()) {
+    auto instance_id = fmt::format("{}:{}", config_->binds.empty() ? config_->unixsocket : config_->binds.front(),
+                                   config_->port);
+    tracer_ = std::make_unique<tracing::Tracer>(config_->tracing_otlp_endpoint, config_->tracing_sample_percent,
+                                                std::move(instance_id));
+    if (auto s = tracer_->Start(); !s) {
+      return s.Prefixed("failed to start the tracing");
+    }
+  }
+
+  if (config_->metrics_port > 0) {
+    metrics_server_ = std::make_unique<MetricsServer>(this, config_->metrics_binds, config_->metrics_port);
+    if (auto s = metrics_server_->Start(); !s) {
+      return s.Prefixed("failed to start the metrics server");
+    }
+  }
+
   // setup server cron thread
   cron_thread_ = GET_OR_RET(util::CreateThread("server-cron", [this] { this->cron(); }));
 
@@ -229,8 +261,12 @@ void Server::Stop() {
     worker->Stop(0 /* immediately terminate  */);
   }
 
+  if (metrics_server_) metrics_server_->Stop();
+  if (tracer_) tracer_->Stop();
+
   rocksdb::CancelAllBackgroundWork(storage->GetDB(), true);
   task_runner_.Cancel();
+  big_key_scan_stop_ = true;
 }
 
 void Server::Join() {
@@ -243,9 +279,17 @@ void Server::Join() {
   if (auto s = task_runner_.Join(); !s) {
     LOG(WARNING) << s.Msg();
   }
+  stopBigKeyScan();
   for (const auto &worker : worker_threads_) {
     worker->Join();
   }
+  if (metrics_server_) metrics_server_->Join();
+  if (tracer_) tracer_->Join();
+
+  std::lock_guard<std::mutex> guard(failover_mu_);
+  if (auto s = util::ThreadJoin(failover_thread_); !s) {
+    LOG(WARNING) << "Failover thread operation failed: " << s.Msg();
+  }
 }
 
 Status Server::AddMaster(const std::string &host, uint32_t port, bool force_reconnect) {
@@ -497,6 +541,19 @@ void Server::PUnsubscribeChannel(const std::string &pattern, redis::Connection *
   }
 }
 
+size_t Server::GetPubSubClientsNum() {
+  std::lock_guard<std::mutex> guard(pubsub_channels_mu_);
+
+  // A client may subscribe to many channels and patterns, so count the distinct ones
+  std::set<ConnContext> clients;
+  for (const auto &pubsub_map : {&pubsub_channels_, &pubsub_patterns_}) {
+    for (const auto &iter : *pubsub_map) {
+      clients.insert(iter.second.begin(), iter.second.end());
+    }
+  }
+  return clients.size();
+}
+
 void Server::BlockOnKey(const std::string &key, redis::Connection *conn) {
   std::lock_guard<std::mutex> guard(blocking_keys_mu_);
 
@@ -507,8 +564,6 @@ void Server::BlockOnKey(const std::string &key, redis::Connection *conn) {
   } else {
     iter->second.emplace_back(conn_ctx);
   }
-
-  IncrBlockedClientNum();
 }
 
 void Server::UnblockOnKey(const std::string &key, redis::Connection *conn) {
@@ -528,8 +583,6 @@ void Server::UnblockOnKey(const std::string &key, redis::Connection *conn) {
       break;
     }
   }
-
-  DecrBlockedClientNum();
 }
 
 void Server::BlockOnStreams(const std::vector<std::string> &keys, const std::vector<redis::StreamEntryID> &entry_ids,
@@ -769,19 +822,37 @@ void Server::GetRocksDBInfo(std::string *info) {
   db->GetAggregatedIntProperty("rocksdb.compaction-pending", &compaction_pending);
   db->GetAggregatedIntProperty("rocksdb.num-live-versions", &num_live_versions);
 
+  uint64_t is_write_stopped = 0, actual_delayed_write_rate = 0;
+  db->GetIntProperty(rocksdb::DB::Properties::kIsWriteStopped, &is_write_stopped);
+  db->GetIntProperty(rocksdb::DB::Properties::kActualDelayedWriteRate, &actual_delayed_write_rate);
+
+  // the block cache is shared by all column families, so the usage is only reported once
+  uint64_t block_cache_usage = 0, block_cache_pinned_usage = 0;
+  db->GetIntProperty(rocksdb::DB::Properties::kBlockCacheUsage, &block_cache_usage);
+  db->GetIntProperty(rocksdb::DB::Properties::kBlockCachePinnedUsage, &block_cache_pinned_usage);
+
   string_stream << "# RocksDB\r\n";
   for (const auto &cf_handle : *storage->GetCFHandles()) {
-    uint64_t estimate_keys = 0, block_cache_usage = 0, block_cache_pinned_usage = 0, index_and_filter_cache_usage = 0;
+    uint64_t estimate_keys = 0, index_and_filter_cache_usage = 0;
     std::map<std::string, std::string> cf_stats_map;
     db->GetIntProperty(cf_handle, "rocksdb.estimate-num-keys", &estimate_keys);
     string_stream << "estimate_keys[" << cf_handle->GetName() << "]:" << estimate_keys << "\r\n";
-    db->GetIntProperty(cf_handle, "rocksdb.block-cache-usage", &block_cache_usage);
-    string_stream << "block_cache_usage[" << cf_handle->GetName() << "]:" << block_cache_usage << "\r\n";
-    db->GetIntProperty(cf_handle, "rocksdb.block-cache-pinned-usage", &block_cache_pinned_usage);
-    string_stream << "block_cache_pinned_usage[" << cf_handle->GetName() << "]:" << block_cache_pinned_usage << "\r\n";
     db->GetIntProperty(cf_handle, "rocksdb.estimate-table-readers-mem", &index_and_filter_cache_usage);
     string_stream << "index_and_filter_cache_usage[" << cf_handle->GetName() << "]:" << index_and_filter_cache_usage
                   << "\r\n";
+
+    // the disk usage of the column family, the total size includes the obsolete files which are still
+    // referenced by the iterators or snapshots, and the live data size excludes the overwritten data
+    uint64_t live_sst_files_size = 0, total_sst_files_size = 0, live_data_size = 0, memtables_size = 0;
+    db->GetIntProperty(cf_handle, rocksdb::DB::Properties::kLiveSstFilesSize, &live_sst_files_size);
+    string_stream << "live_sst_files_size[" << cf_handle->GetName() << "]:" << live_sst_files_size << "\r\n";
+    db->GetIntProperty(cf_handle, rocksdb::DB::Properties::kTotalSstFilesSize, &total_sst_files_size);
+    string_stream << "total_sst_files_size[" << cf_handle->GetName() << "]:" << total_sst_files_size << "\r\n";
+    db->GetIntProperty(cf_handle, rocksdb::DB::Properties::kEstimateLiveDataSize, &live_data_size);
+    string_stream << "estimate_live_data_size[" << cf_handle->GetName() << "]:" << live_data_size << "\r\n";
+    db->GetIntProperty(cf_handle, rocksdb::DB::Properties::kSizeAllMemTables, &memtables_size);
+    string_stream << "all_mem_tables[" << cf_handle->GetName() << "]:" << memtables_size << "\r\n";
+
     db->GetMapProperty(cf_handle, rocksdb::DB::Properties::kCFStats, &cf_stats_map);
     string_stream << "level0_file_limit_slowdown[" << cf_handle->GetName()
                   << "]:" << cf_stats_map["l0-file-count-limit-delays"] << "\r\n";
@@ -799,7 +870,38 @@ void Server::GetRocksDBInfo(std::string *info) {
                   << "]:" << cf_stats_map["memtable-limit-delays"] << "\r\n";
     string_stream << "memtable_count_limit_stop[" << cf_handle->GetName()
                   << "]:" << cf_stats_map["memtable-limit-stops"] << "\r\n";
+    string_stream << "write_stall_delays[" << cf_handle->GetName() << "]:" << cf_stats_map["total-delays"] << "\r\n";
+    string_stream << "write_stall_stops[" << cf_handle->GetName() << "]:" << cf_stats_map["total-stops"] << "\r\n";
+
+    uint64_t cf_compaction_pending = 0, pending_compaction_bytes = 0;
+    db->GetIntProperty(cf_handle, rocksdb::DB::Properties::kCompactionPending, &cf_compaction_pending);
+    string_stream << "compaction_pending[" << cf_handle->GetName() << "]:" << cf_compaction_pending << "\r\n";
+    db->GetIntProperty(cf_handle, rocksdb::DB::Properties::kEstimatePendingCompactionBytes, &pending_compaction_bytes);
+    string_stream << "estimate_pending_compaction_bytes[" << cf_handle->GetName() << "]:" << pending_compaction_bytes
+                  << "\r\n";
+
+    uint64_t active_memtable_size = 0, active_memtable_entries = 0, imm_memtables = 0, imm_memtable_entries = 0;
+    db->GetIntProperty(cf_handle, rocksdb::DB::Properties::kCurSizeActiveMemTable, &active_memtable_size);
+    string_stream << "active_memtable_size[" << cf_handle->GetName() << "]:" << active_memtable_size << "\r\n";
+    db->GetIntProperty(cf_handle, rocksdb::DB::Properties::kNumEntriesActiveMemTable, &active_memtable_entries);
+    string_stream << "active_memtable_entries[" << cf_handle->GetName() << "]:" << active_memtable_entries << "\r\n";
+    db->GetIntProperty(cf_handle, rocksdb::DB::Properties::kNumImmutableMemTable, &imm_memtables);
+    string_stream << "immutable_memtables[" << cf_handle->GetName() << "]:" << imm_memtables << "\r\n";
+    db->GetIntProperty(cf_handle, rocksdb::DB::Properties::kNumEntriesImmMemTables, &imm_memtable_entries);
+    string_stream << "immutable_memtable_entries[" << cf_handle->GetName() << "]:" << imm_memtable_entries << "\r\n";
+
+    rocksdb::ColumnFamilyMetaData cf_meta;
+    db->GetColumnFamilyMetaData(cf_handle, &cf_meta);
+    string_stream << "levels[" << cf_handle->GetName() << "]:";
+    for (const auto &level : cf_meta.levels) {
+      if (level.level > 0) string_stream << ",";
+      string_stream << "l" << level.level << "_files=" << level.files.size() << ",l" << level.level
+                    << "_size=" << level.size;
+    }
+    string_stream << "\r\n";
   }
+  string_stream << "block_cache_usage:" << block_cache_usage << "\r\n";
+  string_stream << "block_cache_pinned_usage:" << block_cache_pinned_usage << "\r\n";
   string_stream << "all_mem_tables:" << memtable_sizes << "\r\n";
   string_stream << "cur_mem_tables:" << cur_memtable_sizes << "\r\n";
   string_stream << "snapshots:" << num_snapshots << "\r\n";
@@ -811,6 +913,8 @@ void Server::GetRocksDBInfo(std::string *info) {
   string_stream << "num_live_versions:" << num_live_versions << "\r\n";
   string_stream << "num_super_version:" << num_super_version << "\r\n";
   string_stream << "num_background_errors:" << num_background_errors << "\r\n";
+  string_stream << "is_write_stopped:" << is_write_stopped << "\r\n";
+  string_stream << "actual_delayed_write_rate:" << actual_delayed_write_rate << "\r\n";
   string_stream << "flush_count:" << storage->GetFlushCount() << "\r\n";
   string_stream << "compaction_count:" << storage->GetCompactionCount() << "\r\n";
   string_stream << "put_per_sec:" << stats.GetInstantaneousMetric(STATS_METRIC_ROCKSDB_PUT) << "\r\n";
@@ -869,6 +973,8 @@ void Server::GetClientsInfo(std::string *info) {
   string_stream << "connected_clients:" << connected_clients_ << "\r\n";
   string_stream << "monitor_clients:" << monitor_clients_ << "\r\n";
   string_stream << "blocked_clients:" << blocked_clients_ << "\r\n";
+  string_stream << "pubsub_clients:" << GetPubSubClientsNum() << "\r\n";
+  string_stream << "watching_clients:" << GetWatchingClientsNum() << "\r\n";
   *info = string_stream.str();
 }
 
@@ -888,6 +994,142 @@ void Server::GetMemoryInfo(std::string *info) {
   *info = string_stream.str();
 }
 
+std::vector<std::pair<std::string, uint64_t>> Server::GetMemoryStats() {
+  std::vector<std::pair<std::string, uint64_t>> stats;
+  stats.emplace_back("rss", Stats::GetMemoryRSS());
+  stats.emplace_back("startup.rss", memory_startup_use_.load(std::memory_order_relaxed));
+
+#ifdef ENABLE_JEMALLOC
+  // refresh the cached statistics of jemalloc before reading them
+  uint64_t epoch = 1;
+  size_t len = sizeof(epoch);
+  mallctl("epoch", &epoch, &len, &epoch, len);
+  for (const auto &name : {"allocated", "active", "resident", "mapped", "retained", "metadata"}) {
+    size_t value = 0;
+    len = sizeof(value);
+    if (mallctl(fmt::format("stats.{}", name).c_str(), &value, &len, nullptr, 0) == 0) {
+      stats.emplace_back(fmt::format("allocator.{}", name), value);
+    }
+  }
+#endif
+
+  rocksdb::DB *db = storage->GetDB();
+  uint64_t block_cache_capacity = 0, block_cache_usage = 0, block_cache_pinned_usage = 0;
+  // the block cache is shared by all column families
+  db->GetIntProperty("rocksdb.block-cache-capacity", &block_cache_capacity);
+  db->GetIntProperty("rocksdb.block-cache-usage", &block_cache_usage);
+  db->GetIntProperty("rocksdb.block-cache-pinned-usage", &block_cache_pinned_usage);
+  stats.emplace_back("rocksdb.block-cache.capacity", block_cache_capacity);
+  stats.emplace_back("rocksdb.block-cache.usage", block_cache_usage);
+  stats.emplace_back("rocksdb.block-cache.pinned-usage", block_cache_pinned_usage);
+
+  uint64_t memtables = 0, table_readers = 0;
+  db->GetAggregatedIntProperty("rocksdb.size-all-mem-tables", &memtables);
+  db->GetAggregatedIntProperty("rocksdb.estimate-table-readers-mem", &table_readers);
+  stats.emplace_back("rocksdb.memtables", memtables);
+  stats.emplace_back("rocksdb.table-readers", table_readers);
+
+  size_t query_buffers = 0, output_buffers = 0;
+  for (const auto &t : worker_threads_) {
+    t->GetWorker()->GetClientBuffersSize(&query_buffers, &output_buffers);
+  }
+  stats.emplace_back("clients.query-buffers", query_buffers);
+  stats.emplace_back("clients.output-buffers", output_buffers);
+
+  // replicas are fed from the WAL, so the buffers are only the pending output of their connections
+  size_t replication_buffers = 0, replicas = 0;
+  {
+    std::lock_guard<std::mutex> guard(slave_threads_mu_);
+    for (const auto &st : slave_threads_) {
+      replication_buffers += evbuffer_get_length(st->GetConn()->Output());
+      replicas++;
+    }
+  }
+  stats.emplace_back("replication.replicas", replicas);
+  stats.emplace_back("replication.buffers", replication_buffers);
+
+  stats.emplace_back("lua", static_cast<uint64_t>(lua_gc(lua_, LUA_GCCOUNT, 0)) * 1024);
+  return stats;
+}
+
+std::string Server::GetMemoryDoctorReport() {
+  constexpr uint64_t kBigBuffersSize = 64 * MiB;
+  constexpr uint64_t kMinFragmentationBytes = 100 * MiB;
+
+  std::map<std::string, uint64_t> stats;
+  for (const auto &[name, value] : GetMemoryStats()) {
+    stats[name] = value;
+  }
+  auto ratio = [](uint64_t a, uint64_t b) { return b == 0 ? 0.0 : static_cast<double>(a) / static_cast<double>(b); };
+
+  std::vector<std::string> issues;
+  uint64_t rss = stats["rss"];
+  if (stats.count("allocator.allocated") > 0) {
+    uint64_t allocated = stats["allocator.allocated"], resident = stats["allocator.resident"];
+    if (ratio(resident, allocated) > 1.5 && resident - allocated > kMinFragmentationBytes) {
+      issues.emplace_back(fmt::format(
+          "High allocator fragmentation: {} is resident but only {} is allocated (ratio {:.2f}). "
+          "It's usually caused by freeing lots of memory after a peak, like big memtables or client buffers.",
+          util::BytesToHuman(resident), util::BytesToHuman(allocated), ratio(resident, allocated)));
+    }
+  }
+
+  uint64_t memtables = stats["rocksdb.memtables"];
+  if (ratio(memtables, rss) > 0.5) {
+    issues.emplace_back(fmt::format(
+        "Memtables use {} which is {:.0f}% of the RSS. Consider lowering rocksdb.write_buffer_size "
+        "or rocksdb.max_write_buffer_number if the memory is tight.",
+        util::BytesToHuman(memtables), ratio(memtables, rss) * 100));
+  }
+
+  uint64_t table_readers = stats["rocksdb.table-readers"];
+  if (ratio(table_readers, rss) > 0.25) {
+    issues.emplace_back(fmt::format(
+        "Index and filter blocks outside the block cache use {} which is {:.0f}% of the RSS. "
+        "Consider enabling rocksdb.cache_index_and_filter_blocks to bound them by the block cache.",
+        util::BytesToHuman(table_readers), ratio(table_readers, rss) * 100));
+  }
+
+  uint64_t capacity = stats["rocksdb.block-cache.capacity"], pinned = stats["rocksdb.block-cache.pinned-usage"];
+  if (capacity > 0 && ratio(pinned, capacity) > 0.5) {
+    issues.emplace_back(fmt::format(
+        "{} of the block cache is pinned, which can't be evicted and may exceed the capacity {}. "
+        "It's usually pinned by long running iterators like big range queries or scans.",
+        util::BytesToHuman(pinned), util::BytesToHuman(capacity)));
+  }
+
+  if (stats["clients.output-buffers"] > kBigBuffersSize) {
+    issues.emplace_back(fmt::format(
+        "Output buffers of clients use {}, some clients read the replies slowly or ask for big replies. "
+        "Check the obuf of CLIENT LIST.",
+        util::BytesToHuman(stats["clients.output-buffers"])));
+  }
+  if (stats["clients.query-buffers"] > kBigBuffersSize) {
+    issues.emplace_back(fmt::format(
+        "Query buffers of clients use {}, some clients send big commands or pipelines faster than they're executed. "
+        "Check the qbuf of CLIENT LIST.",
+        util::BytesToHuman(stats["clients.query-buffers"])));
+  }
+  if (stats["replication.buffers"] > kBigBuffersSize) {
+    issues.emplace_back(fmt::format("Output buffers of replicas use {}, some replicas can't keep up with the writes.",
+                                    util::BytesToHuman(stats["replication.buffers"])));
+  }
+  if (stats["lua"] > kBigBuffersSize) {
+    issues.emplace_back(fmt::format("Lua uses {}, check if there are too many or too big scripts.",
+                                    util::BytesToHuman(stats["lua"])));
+  }
+
+  if (issues.empty()) {
+    return fmt::format("No memory issues found, the RSS is {}.", util::BytesToHuman(rss));
+  }
+  std::string report =
+      fmt::format("Found {} memory issue(s), the RSS is {}:\n", issues.size(), util::BytesToHuman(rss));
+  for (size_t i = 0; i < issues.size(); i++) {
+    report += fmt::format("\n{}. {}\n", i + 1, issues[i]);
+  }
+  return report;
+}
+
 void Server::GetReplicationInfo(std::string *info) {
   std::ostringstream string_stream;
   string_stream << "# Replication\r\n";
@@ -905,8 +1147,21 @@ void Server::GetReplicationInfo(std::string *info) {
     string_stream << "slave_priority:" << config_->slave_priority << "\r\n";
   }
 
+  switch (failover_state_.load()) {
+    case kFailoverNone:
+      string_stream << "master_failover_state:no-failover\r\n";
+      break;
+    case kFailoverWaitForSync:
+      string_stream << "master_failover_state:waiting-for-sync\r\n";
+      break;
+    case kFailoverInProgress:
+      string_stream << "master_failover_state:failover-in-progress\r\n";
+      break;
+  }
+
   int idx = 0;
   rocksdb::SequenceNumber latest_seq = storage->LatestSeqNumber();
+  uint64_t now_ms = util::GetTimeStampMS();
 
   slave_threads_mu_.lock();
   string_stream << "connected_slaves:" << slave_threads_.size() << "\r\n";
@@ -914,8 +1169,10 @@ void Server::GetReplicationInfo(std::string *info) {
     if (slave->IsStopped()) continue;
 
     string_stream << "slave" << std::to_string(idx) << ":";
+    auto lag = storage->GetWriteHistory()->GetLag(slave->GetCurrentReplSeq(), now_ms);
     string_stream << "ip=" << slave->GetConn()->GetAnnounceIP() << ",port=" << slave->GetConn()->GetAnnouncePort()
                   << ",offset=" << slave->GetCurrentReplSeq() << ",lag=" << latest_seq - slave->GetCurrentReplSeq()
+                  << ",lag_bytes=" << lag.bytes << ",lag_seconds=" << fmt::format("{:.3f}", lag.ms / 1000.0)
                   << "\r\n";
     ++idx;
   }
@@ -971,18 +1228,6 @@ void Server::GetRoleInfo(std::string *info) {
   }
 }
 
-std::string Server::GetLastRandomKeyCursor() {
-  std::string cursor;
-  std::lock_guard<std::mutex> guard(last_random_key_cursor_mu_);
-  cursor = last_random_key_cursor_;
-  return cursor;
-}
-
-void Server::SetLastRandomKeyCursor(const std::string &cursor) {
-  std::lock_guard<std::mutex> guard(last_random_key_cursor_mu_);
-  last_random_key_cursor_ = cursor;
-}
-
 int64_t Server::GetCachedUnixTime() {
   if (unix_time.load() == 0) {
     updateCachedTime();
@@ -1010,6 +1255,17 @@ void Server::GetStatsInfo(std::string *info) {
   string_stream << "sync_full:" << stats.fullsync_counter << "\r\n";
   string_stream << "sync_partial_ok:" << stats.psync_ok_counter << "\r\n";
   string_stream << "sync_partial_err:" << stats.psync_err_counter << "\r\n";
+  string_stream << "total_error_replies:" << stats.total_error_replies << "\r\n";
+  string_stream << "expired_key_lookups:" << storage->GetExpiredKeyLookups() << "\r\n";
+  string_stream << "reclaimed_expired_keys:" << storage->GetReclaimedExpiredKeys() << "\r\n";
+  string_stream << "reclaimed_subkeys:" << storage->GetReclaimedSubkeys() << "\r\n";
+  {
+    // the expired keys which are not reclaimed yet, as of the latest DBSIZE SCAN of all namespaces
+    KeyNumStats key_num_stats;
+    GetLatestKeyNumStats(kDefaultNamespace, &key_num_stats);
+    string_stream << "pending_expired_keys:" << key_num_stats.n_expired << "\r\n";
+    string_stream << "pending_expired_keys_scan_time:" << GetLastScanTime(kDefaultNamespace) << "\r\n";
+  }
   {
     std::lock_guard<std::mutex> lg(pubsub_channels_mu_);
     string_stream << "pubsub_channels:" << pubsub_channels_.size() << "\r\n";
@@ -1025,11 +1281,41 @@ void Server::GetCommandsStatsInfo(std::string *info) {
 
   for (const auto &cmd_stat : stats.commands_stats) {
     auto calls = cmd_stat.second.calls.load();
-    if (calls == 0) continue;
+    auto rejected_calls = cmd_stat.second.rejected_calls.load();
+    if (calls == 0 && rejected_calls == 0) continue;
 
     auto latency = cmd_stat.second.latency.load();
+    auto usec_per_call = (calls == 0) ? 0 : static_cast<double>(latency) / static_cast<double>(calls);
     string_stream << "cmdstat_" << cmd_stat.first << ":calls=" << calls << ",usec=" << latency
-                  << ",usec_per_call=" << ((calls == 0) ? 0 : static_cast<float>(latency / calls)) << "\r\n";
+                  << ",usec_per_call=" << fmt::format("{:.2f}", usec_per_call) << ",rejected_calls=" << rejected_calls
+                  << ",failed_calls=" << cmd_stat.second.failed_calls.load() << "\r\n";
+  }
+
+  *info = string_stream.str();
+}
+
+void Server::GetErrorStatsInfo(std::string *info) {
+  std::ostringstream string_stream;
+  string_stream << "# Errorstats\r\n";
+
+  std::lock_guard<std::mutex> lock(stats.error_stats_mutex);
+  for (const auto &error_stat : stats.error_stats) {
+    string_stream << "errorstat_" << error_stat.first << ":count=" << error_stat.second << "\r\n";
+  }
+
+  *info = string_stream.str();
+}
+
+void Server::GetLatencyStatsInfo(std::string *info) {
+  std::ostringstream string_stream;
+  string_stream << "# Latencystats\r\n";
+
+  for (const auto &cmd_stat : stats.commands_stats) {
+    const auto &histogram = cmd_stat.second.latency_histogram;
+    if (histogram.Count() == 0) continue;
+
+    string_stream << "latency_percentiles_usec_" << cmd_stat.first << ":p50=" << histogram.Percentile(50)
+                  << ",p99=" << histogram.Percentile(99) << ",p99.9=" << histogram.Percentile(99.9) << "\r\n";
   }
 
   *info = string_stream.str();
@@ -1081,11 +1367,28 @@ void Server::GetInfo(const std::string &ns, const std::string &section, std::str
     string_stream << "# Persistence\r\n";
     string_stream << "loading:" << is_loading_ << "\r\n";
 
-    std::lock_guard<std::mutex> lg(db_job_mu_);
-    string_stream << "bgsave_in_progress:" << (is_bgsave_in_progress_ ? 1 : 0) << "\r\n";
-    string_stream << "last_bgsave_time:" << (last_bgsave_time_ == -1 ? start_time_ : last_bgsave_time_) << "\r\n";
-    string_stream << "last_bgsave_status:" << last_bgsave_status_ << "\r\n";
-    string_stream << "last_bgsave_time_sec:" << last_bgsave_time_sec_ << "\r\n";
+    {
+      std::lock_guard<std::mutex> lg(db_job_mu_);
+      string_stream << "bgsave_in_progress:" << (is_bgsave_in_progress_ ? 1 : 0) << "\r\n";
+      string_stream << "last_bgsave_time:" << (last_bgsave_time_ == -1 ? start_time_ : last_bgsave_time_) << "\r\n";
+      string_stream << "last_bgsave_status:" << last_bgsave_status_ << "\r\n";
+      string_stream << "last_bgsave_time_sec:" << last_bgsave_time_sec_ << "\r\n";
+    }
+
+    int backups = 0;
+    uint64_t backup_size = 0;
+    time_t backup_create_time = -1;
+    storage->GetBackupInfo(&backups, &backup_size, &backup_create_time);
+    string_stream << "backups:" << backups << "\r\n";
+    string_stream << "backup_dir_size:" << backup_size << "\r\n";
+    string_stream << "last_backup_age_sec:"
+                  << (backup_create_time == -1 ? -1 : util::GetTimeStamp() - backup_create_time) << "\r\n";
+
+    uint64_t wal_size = 0, wal_files = 0;
+    if (!is_loading_ && storage->GetWALSize(&wal_size, &wal_files).IsOK()) {
+      string_stream << "wal_size:" << wal_size << "\r\n";
+      string_stream << "wal_files:" << wal_files << "\r\n";
+    }
   }
 
   if (all || section == "stats") {
@@ -1125,6 +1428,20 @@ void Server::GetInfo(const std::string &ns, const std::string &section, std::str
     string_stream << commands_stats_info;
   }
 
+  if (all || section == "errorstats") {
+    std::string error_stats_info;
+    GetErrorStatsInfo(&error_stats_info);
+    if (section_cnt++) string_stream << "\r\n";
+    string_stream << error_stats_info;
+  }
+
+  if (all || section == "latencystats") {
+    std::string latency_stats_info;
+    GetLatencyStatsInfo(&latency_stats_info);
+    if (section_cnt++) string_stream << "\r\n";
+    string_stream << latency_stats_info;
+  }
+
   if (all || section == "cluster") {
     std::string cluster_info;
     GetClusterInfo(&cluster_info);
@@ -1163,6 +1480,19 @@ void Server::GetInfo(const std::string &ns, const std::string &section, std::str
       double used_disk_percent = static_cast<double>(used_disk_size * 100) / static_cast<double>(disk_capacity);
       string_stream << "used_disk_percent: " << used_disk_percent << "%\r\n";
     }
+
+    // The admin can see the breakdown of namespaces, the key numbers are as of the latest DBSIZE SCAN in the namespace
+    if (ns == kDefaultNamespace) {
+      for (const auto &iter : namespace_.List()) {
+        const auto &name = iter.second;
+        KeyNumStats ns_stats;
+        GetLatestKeyNumStats(name, &ns_stats);
+        string_stream << "namespace_" << name << ":keys=" << ns_stats.n_key << ",expires=" << ns_stats.n_expires
+                      << ",avg_ttl=" << ns_stats.avg_ttl << ",expired=" << ns_stats.n_expired
+                      << ",used_db_size=" << storage->GetTotalSize(name) << ",last_scan_time=" << GetLastScanTime(name)
+                      << "\r\n";
+      }
+    }
   }
 
   // In rocksdb section, we access DB, so we can't do that when loading
@@ -1211,6 +1541,10 @@ void Server::PrepareRestoreDB() {
     LOG(WARNING) << "[server] " << s.Msg();
   }
 
+  // Stop the big key scan, since it iterates the DB which is going to be restored
+  LOG(INFO) << "[server] Stopping the big key scan...";
+  stopBigKeyScan();
+
   // If the DB is restored, the object 'db_' will be destroyed, but
   // 'db_' will be accessed in data migration task. To avoid wrong
   // accessing, data migration task should be stopped before restoring DB
@@ -1278,8 +1612,10 @@ Status Server::AsyncBgSaveDB() {
 
   return task_runner_.TryPublish([this] {
     auto start_bgsave_time = util::GetTimeStamp();
+    auto start_bgsave_ms = util::GetTimeStampMS();
     Status s = storage->CreateBackup();
     auto stop_bgsave_time = util::GetTimeStamp();
+    storage->LatencyAddSampleIfNeeded("bgsave", util::GetTimeStampMS() - start_bgsave_ms);
 
     std::lock_guard<std::mutex> lg(db_job_mu_);
     is_bgsave_in_progress_ = false;
@@ -1325,6 +1661,65 @@ Status Server::AsyncScanDBSize(const std::string &ns) {
   });
 }
 
+Status Server::AsyncScanBigKeys(const std::string &ns, uint64_t count, uint64_t keys_per_sec) {
+  std::lock_guard<std::mutex> guard(big_key_scan_mu_);
+
+  // The rate limited scan may take a long time, so it runs in a dedicated thread
+  // instead of the task runner, and only one scan is allowed at the same time.
+  if (big_key_scanning_) {
+    return {Status::NotOK, "scanning big keys now"};
+  }
+
+  // the thread of the last scan has exited since it's not scanning
+  if (auto s = util::ThreadJoin(big_key_scan_thread_); !s) {
+    LOG(WARNING) << "Big key scan thread operation failed: " << s.Msg();
+  }
+
+  {
+    std::lock_guard<std::mutex> lg(db_job_mu_);
+    big_key_scan_infos_[ns].is_scanning = true;
+  }
+  big_key_scanning_ = true;
+  big_key_scan_stop_ = false;
+
+  auto t = util::CreateThread("big-key-scan", [ns, count, keys_per_sec, this] {
+    redis::Database db(storage, ns);
+
+    BigKeyStats stats;
+    auto s = db.GetBigKeyStats(count, keys_per_sec, big_key_scan_stop_, &stats);
+    if (!s.ok()) {
+      LOG(ERROR) << "failed to scan big keys: " << s.ToString();
+    }
+
+    {
+      std::lock_guard<std::mutex> lg(db_job_mu_);
+
+      big_key_scan_infos_[ns].big_key_stats = std::move(stats);
+      big_key_scan_infos_[ns].last_scan_time = util::GetTimeStamp();
+      big_key_scan_infos_[ns].is_scanning = false;
+    }
+    big_key_scanning_ = false;
+  });
+  if (!t) {
+    std::lock_guard<std::mutex> lg(db_job_mu_);
+    big_key_scan_infos_[ns].is_scanning = false;
+    big_key_scanning_ = false;
+    return std::move(t);
+  }
+
+  big_key_scan_thread_ = std::move(*t);
+  return Status::OK();
+}
+
+void Server::stopBigKeyScan() {
+  std::lock_guard<std::mutex> guard(big_key_scan_mu_);
+
+  big_key_scan_stop_ = true;
+  if (auto s = util::ThreadJoin(big_key_scan_thread_); !s) {
+    LOG(WARNING) << "Big key scan thread operation failed: " << s.Msg();
+  }
+}
+
 Status Server::autoResizeBlockAndSST() {
   auto total_size = storage->GetTotalSize(kDefaultNamespace);
   uint64_t total_keys = 0, estimate_keys = 0;
@@ -1413,6 +1808,15 @@ void Server::GetLatestKeyNumStats(const std::string &ns, KeyNumStats *stats) {
   }
 }
 
+BigKeyScanInfo Server::GetBigKeyScanInfo(const std::string &ns) {
+  std::lock_guard<std::mutex> lg(db_job_mu_);
+
+  if (auto iter = big_key_scan_infos_.find(ns); iter != big_key_scan_infos_.end()) {
+    return iter->second;
+  }
+  return {};
+}
+
 time_t Server::GetLastScanTime(const std::string &ns) {
   auto iter = db_scan_infos_.find(ns);
   if (iter != db_scan_infos_.end()) {
@@ -1446,6 +1850,7 @@ void Server::SlowlogPushEntryIfNeeded(const std::vector<std::string> *args, uint
   entry->client_name = conn->GetName();
   entry->ip = conn->GetIP();
   entry->port = conn->GetPort();
+  entry->ns = conn->GetNamespace();
   slow_log_.PushEntry(std::move(entry));
 }
 
@@ -1464,32 +1869,44 @@ std::string Server::GetClientsStr() {
   return clients;
 }
 
-void Server::KillClient(int64_t *killed, const std::string &addr, uint64_t id, uint64_t type, bool skipme,
-                        redis::Connection *conn) {
+bool ClientKillFilter::Match(const redis::Connection *conn) const {
+  if (type != 0 && !(type & conn->GetClientType())) return false;
+  if (!addr.empty() && conn->GetAddr() != addr && conn->GetAnnounceAddr() != addr) return false;
+  if (!laddr.empty() && conn->GetLocalAddr() != laddr) return false;
+  if (id != 0 && conn->GetID() != id) return false;
+  if (!ns.empty() && conn->GetNamespace() != ns) return false;
+  if (max_age != 0 && conn->GetAge() <= max_age) return false;
+  return true;
+}
+
+void Server::KillClient(int64_t *killed, const ClientKillFilter &filter, redis::Connection *conn) {
   *killed = 0;
 
   // Normal clients and pubsub clients
   for (const auto &t : worker_threads_) {
     int64_t killed_in_worker = 0;
-    t->GetWorker()->KillClient(conn, id, addr, type, skipme, &killed_in_worker);
+    t->GetWorker()->KillClient(conn, filter, &killed_in_worker);
     *killed += killed_in_worker;
   }
 
   // Slave clients
   slave_threads_mu_.lock();
   for (const auto &st : slave_threads_) {
-    if ((type & kTypeSlave) ||
-        (!addr.empty() && (st->GetConn()->GetAddr() == addr || st->GetConn()->GetAnnounceAddr() == addr)) ||
-        (id != 0 && st->GetConn()->GetID() == id)) {
+    if (filter.Match(st->GetConn())) {
       st->Stop();
       (*killed)++;
     }
   }
   slave_threads_mu_.unlock();
 
-  // Master client
-  if (IsSlave() &&
-      (type & kTypeMaster || (!addr.empty() && addr == master_host_ + ":" + std::to_string(master_port_)))) {
+  // Master client, it's only killed when being targeted by its type or address explicitly,
+  // since it has no connection id, local address, namespace or age.
+  if (!IsSlave()) return;
+  std::string master_addr = master_host_ + ":" + std::to_string(master_port_);
+  bool targeted = (filter.type & kTypeMaster) || !filter.addr.empty();
+  if (targeted && (filter.type == 0 || (filter.type & kTypeMaster)) &&
+      (filter.addr.empty() || filter.addr == master_addr) && filter.laddr.empty() && filter.id == 0 &&
+      filter.ns.empty() && filter.max_age == 0) {
     // Stop replication thread and start a new one to replicate
     if (auto s = AddMaster(master_host_, master_port_, true); !s.IsOK()) {
       LOG(ERROR) << "[server] Failed to add master " << master_host_ << ":" << master_port_
@@ -1843,10 +2260,21 @@ void Server::WatchKey(redis::Connection *conn, const std::vector<std::string> &k
 
     conn->watched_keys.insert(key);
   }
+  conn->watched_keys_cnt = conn->watched_keys.size();
 
   watched_key_size_ = watched_key_map_.size();
 }
 
+size_t Server::GetWatchingClientsNum() {
+  std::shared_lock lock(watched_key_mutex_);
+
+  std::set<redis::Connection *> clients;
+  for (const auto &iter : watched_key_map_) {
+    clients.insert(iter.second.begin(), iter.second.end());
+  }
+  return clients.size();
+}
+
 bool Server::IsWatchedKeysModified(redis::Connection *conn) { return conn->watched_keys_modified; }
 
 void Server::ResetWatchedKeys(redis::Connection *conn) {
@@ -1864,11 +2292,171 @@ void Server::ResetWatchedKeys(redis::Connection *conn) {
     }
 
     conn->watched_keys.clear();
+    conn->watched_keys_cnt = 0;
     conn->watched_keys_modified = false;
     watched_key_size_ = watched_key_map_.size();
   }
 }
 
+size_t Server::GetAckedSlavesNum(rocksdb::SequenceNumber seq) {
+  size_t acked = 0;
+  std::lock_guard<std::mutex> guard(slave_threads_mu_);
+  for (const auto &slave : slave_threads_) {
+    if (slave->IsStopped()) continue;
+    if (slave->GetAckReplSeq() >= seq) acked++;
+  }
+  return acked;
+}
+
+Status Server::StartFailover(const std::string &host, uint32_t port, uint64_t timeout_ms) {
+  std::lock_guard<std::mutex> guard(failover_mu_);
+
+  if (failover_state_ != kFailoverNone) {
+    return {Status::NotOK, "FAILOVER already in progress."};
+  }
+
+  // the thread of the last failover has exited since the state was reset
+  if (auto s = util::ThreadJoin(failover_thread_); !s) {
+    LOG(WARNING) << "Failover thread operation failed: " << s.Msg();
+  }
+
+  // never pause the writes forever, the replica may not catch up at all
+  if (timeout_ms == 0) timeout_ms = kFailoverDefaultTimeoutMs;
+
+  failover_abort_ = false;
+  failover_state_ = kFailoverWaitForSync;
+  auto t = util::CreateThread("failover", [this, host, port, timeout_ms] { failover(host, port, timeout_ms); });
+  if (!t) {
+    failover_state_ = kFailoverNone;
+    return std::move(t);
+  }
+
+  failover_thread_ = std::move(*t);
+  return Status::OK();
+}
+
+Status Server::AbortFailover() {
+  if (failover_state_ == kFailoverNone) {
+    return {Status::NotOK, "No failover in progress."};
+  }
+
+  failover_abort_ = true;
+  return Status::OK();
+}
+
+// Ask the replica to become the new master, it uses the 'masterauth' to authenticate
+// since the replica is usually configured with the same password as its master.
+static Status PromoteReplica(const std::string &host, uint32_t port, const std::string &auth) {
+  constexpr int kPromoteTimeoutMs = 3000;
+  auto fd = UniqueFD(GET_OR_RET(util::SockConnect(host, port, kPromoteTimeoutMs, kPromoteTimeoutMs)));
+
+  auto send_command = [&fd](const std::vector<std::string> &args) -> Status {
+    if (auto s = util::SockSend(*fd, redis::MultiBulkString(args, false)); !s.IsOK()) {
+      return s;
+    }
+    auto line = GET_OR_RET(util::SockReadLine(*fd));
+    if (line.empty() || line[0] == '-') {
+      return {Status::NotOK, fmt::format("the replica replied with error: {}", line.empty() ? line : line.substr(1))};
+    }
+    return Status::OK();
+  };
+
+  if (!auth.empty()) {
+    if (auto s = send_command({"AUTH", auth}); !s.IsOK()) {
+      return s;
+    }
+  }
+  return send_command({"SLAVEOF", "NO", "ONE"});
+}
+
+bool Server::ParkIfWritePaused(redis::Connection *conn) {
+  if (!write_paused_) return false;
+
+  std::lock_guard<std::mutex> guard(write_pause_mu_);
+  if (!write_paused_) return false;
+
+  write_paused_conns_.emplace_back(conn->Owner(), conn->GetFD());
+  return true;
+}
+
+void Server::pauseWrites() {
+  {
+    std::lock_guard<std::mutex> guard(write_pause_mu_);
+    write_paused_ = true;
+  }
+  // Wait for the in-flight writes, they're started before the pause and still hold the concurrency guard
+  auto exclusivity = WorkExclusivityGuard();
+}
+
+void Server::resumeWrites() {
+  std::lock_guard<std::mutex> guard(write_pause_mu_);
+  write_paused_ = false;
+  // The parked connections will continue to process their commands in the write callback
+  for (const auto &conn_ctx : write_paused_conns_) {
+    auto s = conn_ctx.owner->EnableWriteEvent(conn_ctx.fd);
+    if (!s.IsOK()) {
+      LOG(WARNING) << "[server] Failed to resume the paused client " << conn_ctx.fd << ": " << s.Msg();
+    }
+  }
+  write_paused_conns_.clear();
+}
+
+void Server::failover(const std::string &host, uint32_t port, uint64_t timeout_ms) {
+  auto reset = MakeScopeExit([this] { failover_state_ = kFailoverNone; });
+  uint64_t deadline_ms = util::GetTimeStampMS() + timeout_ms;
+
+  // Pause the writes until the replica catches up and the roles are swapped,
+  // so the new master won't lose any tail writes. The write commands are parked
+  // at the connection level instead of blocking the worker threads.
+  pauseWrites();
+  auto resume = MakeScopeExit([this] { resumeWrites(); });
+  auto target_seq = storage->LatestSeqNumber();
+  LOG(INFO) << "[failover] Writes were paused, waiting for the replica to catch up the sequence: " << target_seq;
+
+  std::string target_host;
+  uint32_t target_port = 0;
+  while (target_host.empty()) {
+    if (IsStopped() || failover_abort_) {
+      LOG(WARNING) << "[failover] The failover was aborted";
+      return;
+    }
+    if (util::GetTimeStampMS() >= deadline_ms) {
+      LOG(WARNING) << "[failover] The failover was aborted since the replica didn't catch up in " << timeout_ms
+                   << " ms";
+      return;
+    }
+
+    {
+      std::lock_guard<std::mutex> guard(slave_threads_mu_);
+      for (const auto &slave : slave_threads_) {
+        if (slave->IsStopped() || slave->GetAckReplSeq() < target_seq) continue;
+
+        auto slave_host = slave->GetConn()->GetAnnounceIP();
+        auto slave_port = static_cast<uint32_t>(slave->GetConn()->GetListeningPort());
+        if (!host.empty() && (slave_host != host || slave_port != port)) continue;
+        target_host = slave_host;
+        target_port = slave_port;
+        break;
+      }
+    }
+    if (target_host.empty()) std::this_thread::sleep_for(std::chrono::milliseconds(10));
+  }
+
+  failover_state_ = kFailoverInProgress;
+  LOG(INFO) << "[failover] The replica " << target_host << ":" << target_port << " caught up, promote it to master";
+  if (auto s = PromoteReplica(target_host, target_port, config_->masterauth); !s.IsOK()) {
+    LOG(ERROR) << "[failover] Failed to promote the replica " << target_host << ":" << target_port << ": " << s.Msg();
+    return;
+  }
+
+  if (auto s = AddMaster(target_host, target_port, false); !s.IsOK()) {
+    LOG(ERROR) << "[failover] Failed to replicate the new master " << target_host << ":" << target_port << ": "
+               << s.Msg();
+    return;
+  }
+  LOG(WARNING) << "[failover] The failover was done, SLAVE OF " << target_host << ":" << target_port << " enabled";
+}
+
 std::list<std::pair<std::string, uint32_t>> Server::GetSlaveHostAndPort() {
   std::list<std::pair<std::string, uint32_t>> result;
   slave_threads_mu_.lock();
@@ -43,10 +43,15 @@
 #include "cluster/slot_migrate.h"
 #include "commands/commander.h"
 #include "lua.hpp"
+#include "metrics_server.h"
 #include "namespace.h"
+#include "server/audit_log.h"
 #include "server/redis_connection.h"
+#include "stats/hot_keys.h"
+#include "stats/key_access.h"
 #include "stats/log_collector.h"
 #include "stats/stats.h"
+#include "stats/tracing.h"
 #include "storage/redis_metadata.h"
 #include "storage/storage.h"
 #include "task_runner.h"
@@ -59,6 +64,12 @@ struct DBScanInfo {
   bool is_scanning = false;
 };
 
+struct BigKeyScanInfo {
+  time_t last_scan_time = 0;
+  BigKeyStats big_key_stats;
+  bool is_scanning = false;
+};
+
 struct ConnContext {
   Worker *owner;
   int fd;
@@ -135,6 +146,28 @@ enum ClientType {
   kTypeSlave = (1ULL << 3),   // slave client
 };
 
+// Filters of CLIENT KILL, a client is killed only if it matches all the given filters
+struct ClientKillFilter {
+  std::string addr;
+  std::string laddr;
+  uint64_t id = 0;
+  uint64_t type = 0;  // bitmask of ClientType
+  std::string ns;
+  uint64_t max_age = 0;
+  bool skipme = false;
+
+  bool Match(const redis::Connection *conn) const;
+};
+
+enum FailoverState {
+  kFailoverNone,
+  kFailoverWaitForSync,
+  kFailoverInProgress,
+};
+
+// the writes are paused during the failover, so it's aborted after the timeout if not specified
+constexpr const uint64_t kFailoverDefaultTimeoutMs = 10000;
+
 enum ServerLogType { kServerLogNone, kReplIdLog };
 
 class ServerLogData {
@@ -177,6 +210,8 @@ class Server {
   bool IsStopped() const { return stop_; }
   bool IsLoading() const { return is_loading_; }
   Config *GetConfig() { return config_; }
+  // GetTracer returns null if the tracing is disabled
+  tracing::Tracer *GetTracer() { return tracer_.get(); }
   static Status LookupAndCreateCommand(const std::string &cmd_name, std::unique_ptr<redis::Commander> *cmd);
   void AdjustOpenFilesLimit();
   void AdjustWorkerThreads();
@@ -186,6 +221,12 @@ class Server {
   Status AddSlave(redis::Connection *conn, rocksdb::SequenceNumber next_repl_seq);
   void DisconnectSlaves();
   void CleanupExitedSlaves();
+  size_t GetAckedSlavesNum(rocksdb::SequenceNumber seq);
+  Status StartFailover(const std::string &host, uint32_t port, uint64_t timeout_ms);
+  Status AbortFailover();
+  FailoverState GetFailoverState() const { return failover_state_.load(); }
+  bool IsWritePaused() const { return write_paused_; }
+  bool ParkIfWritePaused(redis::Connection *conn);
   bool IsSlave() const { return !master_host_.empty(); }
   void FeedMonitorConns(redis::Connection *conn, const std::vector<std::string> &tokens);
   void IncrFetchFileThread() { fetch_file_threads_num_++; }
@@ -201,6 +242,7 @@ class Server {
   void PSubscribeChannel(const std::string &pattern, redis::Connection *conn);
   void PUnsubscribeChannel(const std::string &pattern, redis::Connection *conn);
   size_t GetPubSubPatternSize() const { return pubsub_patterns_.size(); }
+  size_t GetPubSubClientsNum();
 
   void BlockOnKey(const std::string &key, redis::Connection *conn);
   void UnblockOnKey(const std::string &key, redis::Connection *conn);
@@ -210,19 +252,20 @@ class Server {
   void WakeupBlockingConns(const std::string &key, size_t n_conns);
   void OnEntryAddedToStream(const std::string &ns, const std::string &key, const redis::StreamEntryID &entry_id);
 
-  std::string GetLastRandomKeyCursor();
-  void SetLastRandomKeyCursor(const std::string &cursor);
-
   static int64_t GetCachedUnixTime();
   int64_t GetLastBgsaveTime();
   void GetStatsInfo(std::string *info);
   void GetServerInfo(std::string *info);
   void GetMemoryInfo(std::string *info);
+  std::vector<std::pair<std::string, uint64_t>> GetMemoryStats();
+  std::string GetMemoryDoctorReport();
   void GetRocksDBInfo(std::string *info);
   void GetClientsInfo(std::string *info);
   void GetReplicationInfo(std::string *info);
   void GetRoleInfo(std::string *info);
   void GetCommandsStatsInfo(std::string *info);
+  void GetErrorStatsInfo(std::string *info);
+  void GetLatencyStatsInfo(std::string *info);
   void GetClusterInfo(std::string *info);
   void GetInfo(const std::string &ns, const std::string &section, std::string *info);
   std::string GetRocksDBStatsJson() const;
@@ -236,6 +279,8 @@ class Server {
   Status AsyncScanDBSize(const std::string &ns);
   void GetLatestKeyNumStats(const std::string &ns, KeyNumStats *stats);
   time_t GetLastScanTime(const std::string &ns);
+  Status AsyncScanBigKeys(const std::string &ns, uint64_t count, uint64_t keys_per_sec);
+  BigKeyScanInfo GetBigKeyScanInfo(const std::string &ns);
 
   std::string GenerateCursorFromKeyName(const std::string &key_name, CursorType cursor_type, const char *prefix = "");
   std::string GetKeyNameFromCursor(const std::string &cursor, CursorType cursor_type);
@@ -248,8 +293,7 @@ class Server {
   int DecrBlockedClientNum();
   std::string GetClientsStr();
   uint64_t GetClientID();
-  void KillClient(int64_t *killed, const std::string &addr, uint64_t id, uint64_t type, bool skipme,
-                  redis::Connection *conn);
+  void KillClient(int64_t *killed, const ClientKillFilter &filter, redis::Connection *conn);
 
   lua_State *Lua() { return lua_; }
   Status ScriptExists(const std::string &sha);
@@ -272,12 +316,15 @@ class Server {
 
   LogCollector<PerfEntry> *GetPerfLog() { return &perf_log_; }
   LogCollector<SlowEntry> *GetSlowLog() { return &slow_log_; }
+  AuditLog *GetAuditLog() { return &audit_log_; }
   void SlowlogPushEntryIfNeeded(const std::vector<std::string> *args, uint64_t duration, const redis::Connection *conn);
 
   std::shared_lock<std::shared_mutex> WorkConcurrencyGuard();
   std::unique_lock<std::shared_mutex> WorkExclusivityGuard();
 
   Stats stats;
+  HotKeys hot_keys;
+  KeyAccessTracker key_access;
   engine::Storage *storage;
   std::unique_ptr<Cluster> cluster;
   static inline std::atomic<int64_t> unix_time = 0;
@@ -288,6 +335,7 @@ class Server {
   void UpdateWatchedKeysManually(const std::vector<std::string> &keys);
   void WatchKey(redis::Connection *conn, const std::vector<std::string> &keys);
   static bool IsWatchedKeysModified(redis::Connection *conn);
+  size_t GetWatchingClientsNum();
   void ResetWatchedKeys(redis::Connection *conn);
   std::list<std::pair<std::string, uint32_t>> GetSlaveHostAndPort();
   Namespace *GetNamespace() { return &namespace_; }
@@ -298,6 +346,10 @@ class Server {
 
  private:
   void cron();
+  void failover(const std::string &host, uint32_t port, uint64_t timeout_ms);
+  void stopBigKeyScan();
+  void pauseWrites();
+  void resumeWrites();
   void recordInstantaneousMetrics();
   static void updateCachedTime();
   Status autoResizeBlockAndSST();
@@ -314,8 +366,6 @@ class Server {
   std::string master_host_;
   uint32_t master_port_ = 0;
   Config *config_ = nullptr;
-  std::string last_random_key_cursor_;
-  std::mutex last_random_key_cursor_mu_;
 
   std::atomic<lua_State *> lua_;
 
@@ -332,6 +382,15 @@ class Server {
   std::list<std::unique_ptr<FeedSlaveThread>> slave_threads_;
   std::atomic<int> fetch_file_threads_num_ = 0;
 
+  // failover
+  std::mutex failover_mu_;
+  std::thread failover_thread_;
+  std::atomic<FailoverState> failover_state_ = kFailoverNone;
+  std::atomic<bool> failover_abort_ = false;
+  std::mutex write_pause_mu_;
+  std::atomic<bool> write_paused_ = false;
+  std::vector<ConnContext> write_paused_conns_;
+
   // namespace
   Namespace namespace_;
 
@@ -344,9 +403,15 @@ class Server {
   int64_t last_bgsave_time_sec_ = -1;
 
   std::map<std::string, DBScanInfo> db_scan_infos_;
+  std::map<std::string, BigKeyScanInfo> big_key_scan_infos_;
+  std::mutex big_key_scan_mu_;
+  std::thread big_key_scan_thread_;
+  std::atomic<bool> big_key_scanning_ = false;
+  std::atomic<bool> big_key_scan_stop_ = false;
 
   LogCollector<SlowEntry> slow_log_;
   LogCollector<PerfEntry> perf_log_;
+  AuditLog audit_log_;
 
   std::map<std::string, std::list<ConnContext>> pubsub_channels_;
   std::map<std::string, std::list<ConnContext>> pubsub_patterns_;
@@ -363,6 +428,8 @@ class Server {
   std::shared_mutex works_concurrency_rw_lock_;
   std::thread cron_thread_;
   std::thread compaction_checker_thread_;
+  std::unique_ptr<MetricsServer> metrics_server_;
+  std::unique_ptr<tracing::Tracer> tracer_;
   TaskRunner task_runner_;
   std::vector<std::unique_ptr<WorkerThread>> worker_threads_;
   std::unique_ptr<ReplicationThread> replication_thread_;
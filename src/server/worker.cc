@@ -180,6 +180,10 @@ void Worker::newTCPConnection(evconnlistener *listener, evutil_socket_t fd, sock
     auto [ip, port] = std::move(*s);
     conn->SetAddr(ip, port);
   }
+  if (auto s = util::GetLocalAddr(fd)) {
+    auto [ip, port] = std::move(*s);
+    conn->SetLocalAddr(ip, port);
+  }
 
   if (rate_limit_group_) {
     bufferevent_add_to_rate_limit_group(bev, rate_limit_group_);
@@ -210,6 +214,7 @@ void Worker::newUnixSocketConnection(evconnlistener *listener, evutil_socket_t f
   }
 
   conn->SetAddr(srv->GetConfig()->unixsocket, 0);
+  conn->SetLocalAddr(srv->GetConfig()->unixsocket, 0);
   if (rate_limit_group_) {
     bufferevent_add_to_rate_limit_group(bev, rate_limit_group_);
   }
@@ -447,6 +452,7 @@ Status Worker::Reply(int fd, const std::string &reply) {
   auto iter = conns_.find(fd);
   if (iter != conns_.end()) {
     iter->second->SetLastInteraction();
+    iter->second->IncrNetOutputBytes(reply.size());
     redis::Reply(iter->second->Output(), reply);
     return Status::OK();
   }
@@ -464,6 +470,16 @@ void Worker::BecomeMonitorConn(redis::Connection *conn) {
   conn->EnableFlag(redis::Connection::kMonitor);
 }
 
+void Worker::QuitMonitorConn(redis::Connection *conn) {
+  {
+    std::lock_guard<std::mutex> guard(conns_mu_);
+    monitor_conns_.erase(conn->GetFD());
+    conns_[conn->GetFD()] = conn;
+  }
+  srv->DecrMonitorClientNum();
+  conn->DisableFlag(redis::Connection::kMonitor);
+}
+
 void Worker::FeedMonitorConns(redis::Connection *conn, const std::string &response) {
   std::unique_lock<std::mutex> lock(conns_mu_);
 
@@ -488,22 +504,29 @@ std::string Worker::GetClientsStr() {
   return output;
 }
 
-void Worker::KillClient(redis::Connection *self, uint64_t id, const std::string &addr, uint64_t type, bool skipme,
-                        int64_t *killed) {
+void Worker::GetClientBuffersSize(size_t *query_buffers, size_t *output_buffers) {
+  std::lock_guard<std::mutex> guard(conns_mu_);
+
+  for (const auto &iter : conns_) {
+    redis::Connection *conn = iter.second;
+    *query_buffers += evbuffer_get_length(conn->Input());
+    *output_buffers += evbuffer_get_length(conn->Output());
+  }
+}
+
+void Worker::KillClient(redis::Connection *self, const ClientKillFilter &filter, int64_t *killed) {
   std::lock_guard<std::mutex> guard(conns_mu_);
 
   for (const auto &iter : conns_) {
     redis::Connection *conn = iter.second;
-    if (skipme && self == conn) continue;
+    if (filter.skipme && self == conn) continue;
 
     // no need to kill the client again if the kCloseAfterReply flag is set
     if (conn->IsFlagEnabled(redis::Connection::kCloseAfterReply)) {
       continue;
     }
 
-    if ((type & conn->GetClientType()) ||
-        (!addr.empty() && (conn->GetAddr() == addr || conn->GetAnnounceAddr() == addr)) ||
-        (id != 0 && conn->GetID() == id)) {
+    if (filter.Match(conn)) {
       conn->EnableFlag(redis::Connection::kCloseAfterReply);
       // enable write event to notify worker wake up ASAP, and remove the connection
       if (!conn->IsFlagEnabled(redis::Connection::kSlave)) {  // don't enable any event in slave connection
@@ -41,6 +41,7 @@
 #include "storage/storage.h"
 
 class Server;
+struct ClientKillFilter;
 
 class Worker : EventCallbackBase<Worker>, EvconnlistenerBase<Worker> {
  public:
@@ -62,11 +63,12 @@ class Worker : EventCallbackBase<Worker>, EvconnlistenerBase<Worker> {
   Status EnableWriteEvent(int fd);
   Status Reply(int fd, const std::string &reply);
   void BecomeMonitorConn(redis::Connection *conn);
+  void QuitMonitorConn(redis::Connection *conn);
   void FeedMonitorConns(redis::Connection *conn, const std::string &response);
 
   std::string GetClientsStr();
-  void KillClient(redis::Connection *self, uint64_t id, const std::string &addr, uint64_t type, bool skipme,
-                  int64_t *killed);
+  void GetClientBuffersSize(size_t *query_buffers, size_t *output_buffers);
+  void KillClient(redis::Connection *self, const ClientKillFilter &filter, int64_t *killed);
   void KickoutIdleClients(int timeout);
 
   Status ListenUnixSocket(const std::string &path, int perm, int backlog);
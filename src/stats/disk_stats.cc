@@ -22,6 +22,7 @@
 
 #include <memory>
 #include <string>
+#include <vector>
 
 #include "db_util.h"
 #include "rocksdb/status.h"
@@ -30,6 +31,9 @@
 
 namespace redis {
 
+// the internal key of RocksDB has an 8-byte trailer of the sequence number and the value type
+constexpr uint64_t kEntryOverhead = 8;
+
 rocksdb::Status Disk::GetApproximateSizes(const Metadata &metadata, const Slice &ns_key,
                                           rocksdb::ColumnFamilyHandle *column_family, uint64_t *key_size,
                                           Slice subkeyleft, Slice subkeyright) {
@@ -135,4 +139,81 @@ rocksdb::Status Disk::GetStreamSize(const Slice &ns_key, uint64_t *key_size) {
   return GetApproximateSizes(metadata, ns_key, storage_->GetCFHandle(engine::kStreamColumnFamilyName), key_size);
 }
 
+// EstimateKeyUsage estimates the footprint of the key by the sizes of its metadata and subkey entries.
+// If the samples isn't 0, only that many subkey entries are read from each column family for the types
+// whose size is the number of elements, and the usage of the rest elements is extrapolated from them.
+rocksdb::Status Disk::EstimateKeyUsage(const Slice &user_key, uint64_t samples, uint64_t *usage) {
+  *usage = 0;
+  std::string ns_key = AppendNamespacePrefix(user_key);
+  std::string raw_metadata;
+  auto s = GetRawMetadata(ns_key, &raw_metadata);
+  if (!s.ok()) return s;
+
+  Metadata metadata(kRedisNone, false);
+  s = metadata.Decode(raw_metadata);
+  if (!s.ok()) return s;
+  if (metadata.Expired()) return rocksdb::Status::NotFound(kErrMsgKeyExpired);
+  if (metadata.size == 0 && !metadata.IsEmptyableType()) return rocksdb::Status::NotFound("no elements");
+
+  *usage = ns_key.size() + raw_metadata.size() + kEntryOverhead;
+  if (metadata.IsSingleKVType()) return rocksdb::Status::OK();
+
+  std::vector<rocksdb::ColumnFamilyHandle *> column_families;
+  switch (metadata.Type()) {
+    case kRedisZSet:
+      column_families = {storage_->GetCFHandle(engine::kSubkeyColumnFamilyName),
+                         storage_->GetCFHandle(engine::kZSetScoreColumnFamilyName)};
+      break;
+    case kRedisStream:
+      column_families = {storage_->GetCFHandle(engine::kStreamColumnFamilyName)};
+      break;
+    case kRedisHash:
+    case kRedisList:
+    case kRedisSet:
+    case kRedisSortedint:
+      column_families = {storage_->GetCFHandle(engine::kSubkeyColumnFamilyName)};
+      break;
+    default:
+      // the size of other types isn't the number of subkeys, so all of them are read
+      column_families = {storage_->GetCFHandle(engine::kSubkeyColumnFamilyName)};
+      samples = 0;
+      break;
+  }
+
+  for (auto column_family : column_families) {
+    s = estimateSubkeysUsage(ns_key, metadata, column_family, samples, usage);
+    if (!s.ok()) return s;
+  }
+  return rocksdb::Status::OK();
+}
+
+rocksdb::Status Disk::estimateSubkeysUsage(const Slice &ns_key, const Metadata &metadata,
+                                           rocksdb::ColumnFamilyHandle *column_family, uint64_t samples,
+                                           uint64_t *usage) {
+  std::string prefix_key = InternalKey(ns_key, "", metadata.version, storage_->IsSlotIdEncoded()).Encode();
+  std::string next_version_prefix_key =
+      InternalKey(ns_key, "", metadata.version + 1, storage_->IsSlotIdEncoded()).Encode();
+
+  rocksdb::ReadOptions read_options = storage_->DefaultScanOptions();
+  LatestSnapShot ss(storage_);
+  read_options.snapshot = ss.GetSnapShot();
+  rocksdb::Slice upper_bound(next_version_prefix_key);
+  read_options.iterate_upper_bound = &upper_bound;
+
+  uint64_t count = 0, size = 0;
+  auto iter = util::UniqueIterator(storage_, read_options, column_family);
+  for (iter->Seek(prefix_key); iter->Valid() && iter->key().starts_with(prefix_key); iter->Next()) {
+    size += iter->key().size() + iter->value().size() + kEntryOverhead;
+    if (++count == samples) break;
+  }
+  if (!iter->status().ok()) return iter->status();
+
+  if (samples > 0 && count == samples && metadata.size > count) {
+    size = static_cast<uint64_t>(static_cast<double>(size) / static_cast<double>(count) *
+                                 static_cast<double>(metadata.size));
+  }
+  *usage += size;
+  return rocksdb::Status::OK();
+}
+
 }  // namespace redis
@@ -45,8 +45,12 @@ class Disk : public Database {
   rocksdb::Status GetSortedintSize(const Slice &ns_key, uint64_t *key_size);
   rocksdb::Status GetStreamSize(const Slice &ns_key, uint64_t *key_size);
   rocksdb::Status GetKeySize(const Slice &user_key, RedisType type, uint64_t *key_size);
+  rocksdb::Status EstimateKeyUsage(const Slice &user_key, uint64_t samples, uint64_t *usage);
 
  private:
+  rocksdb::Status estimateSubkeysUsage(const Slice &ns_key, const Metadata &metadata,
+                                       rocksdb::ColumnFamilyHandle *column_family, uint64_t samples, uint64_t *usage);
+
   rocksdb::SizeApproximationOptions option_;
 };
 
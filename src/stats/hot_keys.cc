@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "hot_keys.h"
+
+#include <algorithm>
+#include <functional>
+#include <utility>
+
+HotKeys::HotKeys(size_t capacity, uint64_t window_ms, size_t shards)
+    : capacity_(capacity), window_ms_(window_ms), shards_(shards) {}
+
+void HotKeys::Record(const std::string &ns, const std::string &key, uint64_t now_ms) {
+  // the hashes of the rows are derived from two independent hashes, and the high bits
+  // which aren't used by the rows of the sketch pick the shard
+  uint64_t h1 = std::hash<std::string>{}(key);
+  uint64_t h2 = std::hash<std::string>{}(ns) * 0x9E3779B97F4A7C15ULL + (h1 >> 32 | h1 << 32) + 1;
+  auto &shard = shards_[(h1 >> 32) % shards_.size()];
+
+  std::lock_guard<std::mutex> guard(shard.mu);
+  shard.rotate(now_ms, window_ms_);
+  shard.record(ns, key, shard.incrSketch(h1, h2), capacity_);
+}
+
+std::vector<HotKey> HotKeys::GetTop(const std::string &ns, size_t count, uint64_t now_ms) {
+  std::vector<HotKey> candidates;
+  for (auto &shard : shards_) {
+    std::lock_guard<std::mutex> guard(shard.mu);
+    shard.rotate(now_ms, window_ms_);
+    candidates.insert(candidates.end(), shard.last_hot_keys.begin(), shard.last_hot_keys.end());
+  }
+  std::sort(candidates.begin(), candidates.end(), [](const HotKey &a, const HotKey &b) { return a.qps > b.qps; });
+  // only the hottest keys of all namespaces are tracked
+  if (candidates.size() > capacity_) candidates.resize(capacity_);
+
+  std::vector<HotKey> hot_keys;
+  for (auto &hot_key : candidates) {
+    if (hot_keys.size() >= count) break;
+    if (hot_key.ns == ns) hot_keys.emplace_back(std::move(hot_key));
+  }
+  return hot_keys;
+}
+
+void HotKeys::Reset() {
+  for (auto &shard : shards_) {
+    std::lock_guard<std::mutex> guard(shard.mu);
+    shard.reset();
+    shard.last_hot_keys.clear();
+  }
+}
+
+void HotKeys::Shard::record(const std::string &ns, const std::string &key, uint64_t count, size_t capacity) {
+  auto iter = candidates.find({ns, key});
+  if (iter != candidates.end()) {
+    iter->second = count;
+    return;
+  }
+  if (candidates.size() < capacity) {
+    candidates.emplace(std::make_pair(ns, key), count);
+    min_candidate_count = candidates.size() == 1 ? count : std::min(min_candidate_count, count);
+    return;
+  }
+  // the counts of the candidates only grow, so the cached minimum is a lower bound
+  // and the candidates are scanned only if the key may be hotter than one of them
+  if (count <= min_candidate_count) return;
+
+  auto count_less = [](const auto &a, const auto &b) { return a.second < b.second; };
+  auto coldest = std::min_element(candidates.begin(), candidates.end(), count_less);
+  if (count > coldest->second) {
+    candidates.erase(coldest);
+    candidates.emplace(std::make_pair(ns, key), count);
+    coldest = std::min_element(candidates.begin(), candidates.end(), count_less);
+  }
+  min_candidate_count = coldest->second;
+}
+
+void HotKeys::Shard::rotate(uint64_t now_ms, uint64_t window_ms) {
+  if (now_ms < window_start_ms + window_ms) return;
+
+  last_hot_keys.clear();
+  // the accesses in the current window are reported only if it's just completed
+  if (now_ms < window_start_ms + 2 * window_ms) {
+    for (const auto &[ns_key, count] : candidates) {
+      last_hot_keys.emplace_back(HotKey{ns_key.first, ns_key.second, count * 1000 / window_ms});
+    }
+  }
+
+  reset();
+  window_start_ms = now_ms - now_ms % window_ms;
+}
+
+uint64_t HotKeys::Shard::incrSketch(uint64_t h1, uint64_t h2) {
+  uint64_t count = UINT64_MAX;
+  for (size_t i = 0; i < kSketchDepth; i++) {
+    auto &counter = sketch[i][(h1 + i * h2) % kSketchWidth];
+    if (counter < UINT32_MAX) counter++;
+    count = std::min<uint64_t>(count, counter);
+  }
+  return count;
+}
+
+void HotKeys::Shard::reset() {
+  for (auto &row : sketch) row.fill(0);
+  candidates.clear();
+  min_candidate_count = 0;
+}
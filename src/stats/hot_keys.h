@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#pragma once
+
+#include <array>
+#include <cstdint>
+#include <map>
+#include <mutex>
+#include <string>
+#include <utility>
+#include <vector>
+
+// the max number of keys which can be reported by HOTKEYS
+constexpr const size_t kHotKeysMaxCount = 1000;
+
+struct HotKey {
+  std::string ns;
+  std::string key;
+  uint64_t qps;
+};
+
+// HotKeys tracks the most frequently accessed keys in fixed time windows. The access counts of a window
+// are estimated by a count-min sketch, which is cleared when the next window begins, so only the top candidates
+// of the window are kept in memory, and the hot keys of the last complete window are reported with their
+// approximate QPS. The keys are sharded by their hashes to reduce the lock contention of the workers.
+class HotKeys {
+ public:
+  explicit HotKeys(size_t capacity = kHotKeysMaxCount, uint64_t window_ms = 1000, size_t shards = 16);
+
+  void Record(const std::string &ns, const std::string &key, uint64_t now_ms);
+  // GetTop returns at most count hottest keys of the namespace in the last complete window,
+  // ordered by the QPS descending
+  std::vector<HotKey> GetTop(const std::string &ns, size_t count, uint64_t now_ms);
+  void Reset();
+
+ private:
+  static constexpr size_t kSketchDepth = 4;
+  static constexpr size_t kSketchWidth = 2048;
+
+  struct Shard {
+    void record(const std::string &ns, const std::string &key, uint64_t count, size_t capacity);
+    void rotate(uint64_t now_ms, uint64_t window_ms);
+    uint64_t incrSketch(uint64_t h1, uint64_t h2);
+    void reset();
+
+    std::mutex mu;
+    uint64_t window_start_ms = 0;
+    std::array<std::array<uint32_t, kSketchWidth>, kSketchDepth> sketch{};
+    // the estimated access counts of the candidates in the current window,
+    // each shard keeps as many candidates as the capacity, since the hottest keys may be in the same shard
+    std::map<std::pair<std::string, std::string>, uint64_t> candidates;
+    uint64_t min_candidate_count = 0;
+    std::vector<HotKey> last_hot_keys;
+  };
+
+  size_t capacity_;
+  uint64_t window_ms_;
+  std::vector<Shard> shards_;
+};
@@ -0,0 +1,106 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "key_access.h"
+
+#include <algorithm>
+
+#include "random_util.h"
+
+KeyAccessTracker::KeyAccessTracker(size_t capacity, size_t shards)
+    : shard_capacity_(std::max<size_t>(capacity / shards, 1)), shards_(shards) {}
+
+void KeyAccessTracker::Record(const std::string &ns, const std::string &key, uint64_t now_ms) {
+  NamespaceKey ns_key{ns, key};
+  auto &shard = getShard(ns_key);
+  std::lock_guard<std::mutex> guard(shard.mu);
+
+  if (auto iter = shard.index.find(ns_key); iter != shard.index.end()) {
+    shard.access(iter->second, now_ms);
+    return;
+  }
+
+  // the counter of a new key starts from the initial value, and it's incremented like other accesses
+  shard.entries.push_front(Entry{ns_key, now_ms, kInitFrequency});
+  shard.index.emplace(std::move(ns_key), shard.entries.begin());
+  shard.access(shard.entries.begin(), now_ms);
+
+  if (shard.entries.size() > shard_capacity_) {
+    // the tracking of the evicted keys starts over, so that their idle time isn't overestimated
+    shard.start_ms = std::max(shard.start_ms, shard.entries.back().last_access_ms);
+    shard.index.erase(shard.entries.back().ns_key);
+    shard.entries.pop_back();
+  }
+}
+
+bool KeyAccessTracker::Touch(const std::string &ns, const std::string &key, uint64_t now_ms) {
+  NamespaceKey ns_key{ns, key};
+  auto &shard = getShard(ns_key);
+  std::lock_guard<std::mutex> guard(shard.mu);
+
+  auto iter = shard.index.find(ns_key);
+  if (iter == shard.index.end()) return false;
+  shard.access(iter->second, now_ms);
+  return true;
+}
+
+uint64_t KeyAccessTracker::IdleTime(const std::string &ns, const std::string &key, uint64_t now_ms) {
+  NamespaceKey ns_key{ns, key};
+  auto &shard = getShard(ns_key);
+  std::lock_guard<std::mutex> guard(shard.mu);
+
+  auto iter = shard.index.find(ns_key);
+  uint64_t last_access_ms = iter != shard.index.end() ? iter->second->last_access_ms : shard.start_ms;
+  return now_ms > last_access_ms ? now_ms - last_access_ms : 0;
+}
+
+uint8_t KeyAccessTracker::Frequency(const std::string &ns, const std::string &key, uint64_t now_ms) {
+  NamespaceKey ns_key{ns, key};
+  auto &shard = getShard(ns_key);
+  std::lock_guard<std::mutex> guard(shard.mu);
+
+  auto iter = shard.index.find(ns_key);
+  return iter != shard.index.end() ? decayedFrequency(*iter->second, now_ms) : 0;
+}
+
+void KeyAccessTracker::Reset(uint64_t now_ms) {
+  for (auto &shard : shards_) {
+    std::lock_guard<std::mutex> guard(shard.mu);
+    shard.entries.clear();
+    shard.index.clear();
+    shard.start_ms = now_ms;
+  }
+}
+
+void KeyAccessTracker::Shard::access(std::list<Entry>::iterator iter, uint64_t now_ms) {
+  uint8_t frequency = decayedFrequency(*iter, now_ms);
+  // the more frequently the key was accessed, the less likely the counter is incremented
+  uint64_t base = frequency > kInitFrequency ? frequency - kInitFrequency : 0;
+  if (frequency < UINT8_MAX && util::RandomInt(0, base * kFrequencyLogFactor) == 0) frequency++;
+
+  iter->last_access_ms = now_ms;
+  iter->frequency = frequency;
+  entries.splice(entries.begin(), entries, iter);
+}
+
+uint8_t KeyAccessTracker::decayedFrequency(const Entry &entry, uint64_t now_ms) {
+  uint64_t periods = now_ms > entry.last_access_ms ? (now_ms - entry.last_access_ms) / kFrequencyDecayMs : 0;
+  return periods >= entry.frequency ? 0 : static_cast<uint8_t>(entry.frequency - periods);
+}
@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#pragma once
+
+#include <cstdint>
+#include <functional>
+#include <list>
+#include <mutex>
+#include <string>
+#include <unordered_map>
+#include <utility>
+#include <vector>
+
+// KeyAccessTracker tracks the last access time and the access frequency of keys, which are reported
+// by OBJECT IDLETIME and OBJECT FREQ. The frequency is a logarithmic counter which decays by one every
+// minute without accesses, like the LFU counter of Redis. At most `capacity` keys are tracked, and the
+// least recently accessed keys are evicted first. The keys are sharded by their hashes, so the accesses
+// of the workers rarely contend for the same lock, and the eviction happens in each shard.
+class KeyAccessTracker {
+ public:
+  explicit KeyAccessTracker(size_t capacity = 1000000, size_t shards = 16);
+
+  // Record tracks the access of the key, which should be an existing key
+  void Record(const std::string &ns, const std::string &key, uint64_t now_ms);
+  // Touch records the access of the key only if it's tracked, and returns whether it's tracked
+  bool Touch(const std::string &ns, const std::string &key, uint64_t now_ms);
+  // IdleTime returns the milliseconds since the last access of the key. If the key isn't tracked,
+  // it's a lower bound since the tracking started, as the key may have been evicted before.
+  uint64_t IdleTime(const std::string &ns, const std::string &key, uint64_t now_ms);
+  // Frequency returns the access frequency counter of the key, which is 0 if the key isn't tracked
+  uint8_t Frequency(const std::string &ns, const std::string &key, uint64_t now_ms);
+  // Reset drops all tracked keys and starts the tracking over since now_ms
+  void Reset(uint64_t now_ms);
+
+ private:
+  static constexpr uint8_t kInitFrequency = 5;
+  static constexpr uint64_t kFrequencyLogFactor = 10;
+  static constexpr uint64_t kFrequencyDecayMs = 60 * 1000;
+
+  using NamespaceKey = std::pair<std::string, std::string>;
+
+  struct NamespaceKeyHash {
+    size_t operator()(const NamespaceKey &ns_key) const {
+      return std::hash<std::string>{}(ns_key.first) * 31 + std::hash<std::string>{}(ns_key.second);
+    }
+  };
+
+  struct Entry {
+    NamespaceKey ns_key;
+    uint64_t last_access_ms;
+    uint8_t frequency;
+  };
+
+  struct Shard {
+    void access(std::list<Entry>::iterator iter, uint64_t now_ms);
+
+    std::mutex mu;
+    uint64_t start_ms = 0;
+    // the entries are ordered by the last access time descending
+    std::list<Entry> entries;
+    std::unordered_map<NamespaceKey, std::list<Entry>::iterator, NamespaceKeyHash> index;
+  };
+
+  static uint8_t decayedFrequency(const Entry &entry, uint64_t now_ms);
+  Shard &getShard(const NamespaceKey &ns_key) { return shards_[NamespaceKeyHash{}(ns_key) % shards_.size()]; }
+
+  size_t shard_capacity_;
+  std::vector<Shard> shards_;
+};
@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "latency_monitor.h"
+
+#include <algorithm>
+
+#include "time_util.h"
+
+void LatencyMonitor::AddSample(const std::string &event, uint64_t latency_ms) {
+  auto now = util::GetTimeStamp();
+  std::lock_guard<std::mutex> guard(mu_);
+
+  auto &series = events_[event];
+  series.max_latency_ms = std::max(series.max_latency_ms, latency_ms);
+  // only keep the max latency of the same second
+  auto &prev = series.samples[(series.next + kLatencyHistorySamples - 1) % kLatencyHistorySamples];
+  if (prev.time == now) {
+    prev.latency_ms = std::max(prev.latency_ms, latency_ms);
+    return;
+  }
+  series.samples[series.next] = {now, latency_ms};
+  series.next = (series.next + 1) % kLatencyHistorySamples;
+}
+
+std::vector<LatencyEventStats> LatencyMonitor::GetLatest() {
+  std::lock_guard<std::mutex> guard(mu_);
+
+  std::vector<LatencyEventStats> latest;
+  for (const auto &[event, series] : events_) {
+    const auto &sample = series.samples[(series.next + kLatencyHistorySamples - 1) % kLatencyHistorySamples];
+    latest.push_back({event, sample, series.max_latency_ms});
+  }
+  return latest;
+}
+
+std::vector<LatencySample> LatencyMonitor::GetHistory(const std::string &event) {
+  std::lock_guard<std::mutex> guard(mu_);
+
+  std::vector<LatencySample> history;
+  auto iter = events_.find(event);
+  if (iter == events_.end()) return history;
+
+  // from the oldest to the latest, the unused slots have the zero time
+  const auto &series = iter->second;
+  for (size_t i = 0; i < kLatencyHistorySamples; i++) {
+    const auto &sample = series.samples[(series.next + i) % kLatencyHistorySamples];
+    if (sample.time != 0) history.push_back(sample);
+  }
+  return history;
+}
+
+size_t LatencyMonitor::Reset(const std::vector<std::string> &events) {
+  std::lock_guard<std::mutex> guard(mu_);
+
+  if (events.empty()) {
+    size_t n = events_.size();
+    events_.clear();
+    return n;
+  }
+  size_t n = 0;
+  for (const auto &event : events) {
+    n += events_.erase(event);
+  }
+  return n;
+}
@@ -0,0 +1,63 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#pragma once
+
+#include <array>
+#include <cstdint>
+#include <map>
+#include <mutex>
+#include <string>
+#include <vector>
+
+// the same as Redis, the latest samples of one per second are kept for each event
+constexpr const size_t kLatencyHistorySamples = 160;
+
+struct LatencySample {
+  int64_t time;
+  uint64_t latency_ms;
+};
+
+struct LatencyEventStats {
+  std::string event;
+  LatencySample latest;
+  uint64_t max_latency_ms;
+};
+
+// LatencyMonitor keeps the history of the latency spikes of events like commands and write stalls,
+// the callers only add the samples which exceed the `latency-monitor-threshold`.
+class LatencyMonitor {
+ public:
+  void AddSample(const std::string &event, uint64_t latency_ms);
+  std::vector<LatencyEventStats> GetLatest();
+  std::vector<LatencySample> GetHistory(const std::string &event);
+  // Reset clears the history of the events, or all events if it's empty, and returns the number of cleared events
+  size_t Reset(const std::vector<std::string> &events);
+
+ private:
+  struct TimeSeries {
+    size_t next = 0;
+    uint64_t max_latency_ms = 0;
+    std::array<LatencySample, kLatencyHistorySamples> samples{};
+  };
+
+  std::mutex mu_;
+  std::map<std::string, TimeSeries> events_;
+};
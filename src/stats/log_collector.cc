@@ -26,15 +26,16 @@
 #include "server/redis_reply.h"
 #include "time_util.h"
 
-std::string SlowEntry::ToRedisString() const {
+std::string SlowEntry::ToRedisString(bool with_namespace) const {
   std::string output;
-  output.append(redis::MultiLen(6));
+  output.append(redis::MultiLen(with_namespace ? 7 : 6));
   output.append(redis::Integer(id));
   output.append(redis::Integer(time));
   output.append(redis::Integer(duration));
   output.append(redis::MultiBulkString(args));
   output.append(redis::BulkString(ip + ":" + std::to_string(port)));
   output.append(redis::BulkString(client_name));
+  if (with_namespace) output.append(redis::BulkString(ns));
   return output;
 }
 
@@ -56,18 +57,22 @@ LogCollector<T>::~LogCollector() {
 }
 
 template <class T>
-ssize_t LogCollector<T>::Size() {
+ssize_t LogCollector<T>::Size(const Filter &filter) {
   std::lock_guard<std::mutex> guard(mu_);
-  ssize_t n = entries_.size();
-  return n;
+  if (!filter) return static_cast<ssize_t>(entries_.size());
+  return std::count_if(entries_.begin(), entries_.end(), [&filter](const auto &entry) { return filter(*entry); });
 }
 
 template <class T>
-void LogCollector<T>::Reset() {
+void LogCollector<T>::Reset(const Filter &filter) {
   std::lock_guard<std::mutex> guard(mu_);
-  while (!entries_.empty()) {
-    entries_.pop_front();
+  if (!filter) {
+    entries_.clear();
+    return;
   }
+  entries_.erase(
+      std::remove_if(entries_.begin(), entries_.end(), [&filter](const auto &entry) { return filter(*entry); }),
+      entries_.end());
 }
 
 template <class T>
@@ -109,5 +114,18 @@ std::string LogCollector<T>::GetLatestEntries(int64_t cnt) {
   return output;
 }
 
+template <class T>
+std::vector<T> LogCollector<T>::GetLatest(int64_t cnt, const Filter &filter) {
+  std::vector<T> entries;
+
+  std::lock_guard<std::mutex> guard(mu_);
+  for (const auto &entry : entries_) {
+    if (cnt > 0 && static_cast<int64_t>(entries.size()) >= cnt) break;
+    if (filter && !filter(*entry)) continue;
+    entries.emplace_back(*entry);
+  }
+  return entries;
+}
+
 template class LogCollector<SlowEntry>;
 template class LogCollector<PerfEntry>;
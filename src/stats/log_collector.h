@@ -40,7 +40,9 @@ class SlowEntry {
   std::string client_name;
   std::string ip;
   uint32_t port;
-  std::string ToRedisString() const;
+  std::string ns;
+  // the namespace is only appended as the 7th field if required, since clients may expect 6 fields
+  std::string ToRedisString(bool with_namespace = false) const;
 };
 
 class PerfEntry {
@@ -62,11 +64,16 @@ class LogCollector {
   LogCollector(const LogCollector &) = delete;
   LogCollector &operator=(const LogCollector &) = delete;
   ~LogCollector();
-  ssize_t Size();
-  void Reset();
+
+  // Filter selects the entries to operate on, all entries are selected if it's empty
+  using Filter = std::function<bool(const T &)>;
+
+  ssize_t Size(const Filter &filter = nullptr);
+  void Reset(const Filter &filter = nullptr);
   void SetMaxEntries(int64_t max_entries);
   void PushEntry(std::unique_ptr<T> &&entry);
   std::string GetLatestEntries(int64_t cnt);
+  std::vector<T> GetLatest(int64_t cnt, const Filter &filter = nullptr);
 
  private:
   std::mutex mu_;
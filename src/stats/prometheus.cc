@@ -0,0 +1,133 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "prometheus.h"
+
+#include <cctype>
+#include <cstdlib>
+#include <string_view>
+#include <utility>
+#include <vector>
+
+#include "string_util.h"
+
+namespace {
+
+using Labels = std::vector<std::pair<std::string, std::string>>;
+
+// Fields with these prefixes are grouped into one metric family, the rest of the field name is the label value
+struct LabeledPrefix {
+  std::string_view prefix;
+  std::string_view family;
+  std::string_view label;
+};
+
+constexpr LabeledPrefix kLabeledPrefixes[] = {
+    {"cmdstat_", "commandstats", "cmd"},
+    {"errorstat_", "errorstats", "error"},
+    {"latency_percentiles_usec_", "latency_percentiles_usec", "cmd"},
+    {"namespace_", "namespace", "namespace"},
+};
+
+std::string sanitizeName(std::string_view name) {
+  std::string result(name);
+  for (auto &c : result) {
+    if (!std::isalnum(static_cast<unsigned char>(c)) && c != '_') c = '_';
+  }
+  return result;
+}
+
+std::string escapeLabelValue(std::string_view value) {
+  std::string result;
+  for (auto c : value) {
+    if (c == '\\' || c == '"') {
+      result += '\\';
+      result += c;
+    } else if (c == '\n') {
+      result += "\\n";
+    } else {
+      result += c;
+    }
+  }
+  return result;
+}
+
+bool isNumber(const std::string &value) {
+  if (value.empty()) return false;
+  char *end = nullptr;
+  std::strtod(value.c_str(), &end);
+  return *end == '\0';
+}
+
+void appendMetric(std::string *output, std::string_view name, const Labels &labels, const std::string &value) {
+  *output += "kvrocks_";
+  *output += sanitizeName(name);
+  if (!labels.empty()) {
+    *output += '{';
+    for (size_t i = 0; i < labels.size(); i++) {
+      if (i > 0) *output += ',';
+      *output += labels[i].first + "=\"" + escapeLabelValue(labels[i].second) + "\"";
+    }
+    *output += '}';
+  }
+  *output += ' ';
+  *output += value;
+  *output += '\n';
+}
+
+}  // namespace
+
+std::string PrometheusMetricsFromInfo(const std::string &info) {
+  std::string output;
+  for (const auto &raw_line : util::Split(info, "\r\n")) {
+    auto line = util::Trim(raw_line, " ");
+    if (line.empty() || line[0] == '#') continue;
+
+    auto pos = line.find(':');
+    if (pos == std::string::npos) continue;
+    std::string name = line.substr(0, pos);
+    std::string value = util::Trim(line.substr(pos + 1), " ");
+
+    Labels labels;
+    if (auto begin = name.find('['); begin != std::string::npos && name.back() == ']') {
+      labels.emplace_back("cf", name.substr(begin + 1, name.size() - begin - 2));
+      name = name.substr(0, begin);
+    }
+    for (const auto &labeled : kLabeledPrefixes) {
+      if (name.size() > labeled.prefix.size() && name.compare(0, labeled.prefix.size(), labeled.prefix) == 0) {
+        labels.emplace_back(labeled.label, name.substr(labeled.prefix.size()));
+        name = labeled.family;
+        break;
+      }
+    }
+
+    if (value.find('=') == std::string::npos) {
+      if (isNumber(value)) appendMetric(&output, name, labels, value);
+      continue;
+    }
+    for (const auto &item : util::Split(value, ",")) {
+      auto eq = item.find('=');
+      if (eq == std::string::npos) continue;
+      auto item_value = item.substr(eq + 1);
+      if (isNumber(item_value)) appendMetric(&output, name + "_" + item.substr(0, eq), labels, item_value);
+    }
+  }
+  return output;
+}
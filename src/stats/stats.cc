@@ -21,6 +21,7 @@
 #include "stats.h"
 
 #include <chrono>
+#include <cmath>
 #include <mutex>
 
 #include "fmt/format.h"
@@ -83,6 +84,71 @@ int64_t Stats::GetMemoryRSS() {
 }
 #endif
 
+int LatencyHistogram::bucketIndex(uint64_t latency) {
+  if (latency < kExactBuckets) return static_cast<int>(latency);
+
+  int msb = 63 - __builtin_clzll(latency);
+  if (msb >= kMaxBits) return kBuckets - 1;
+  int sub = static_cast<int>(latency >> (msb - kSubBucketBits)) & ((1 << kSubBucketBits) - 1);
+  return kExactBuckets + (msb - 4) * (1 << kSubBucketBits) + sub;
+}
+
+uint64_t LatencyHistogram::bucketUpperBound(int index) {
+  if (index < kExactBuckets) return index;
+
+  int msb = (index - kExactBuckets) / (1 << kSubBucketBits) + 4;
+  uint64_t sub = (index - kExactBuckets) % (1 << kSubBucketBits);
+  uint64_t width = uint64_t(1) << (msb - kSubBucketBits);
+  return ((1 << kSubBucketBits) + sub) * width + width - 1;
+}
+
+void LatencyHistogram::Record(uint64_t latency) {
+  buckets_[bucketIndex(latency)].fetch_add(1, std::memory_order_relaxed);
+}
+
+uint64_t LatencyHistogram::Count() const {
+  uint64_t count = 0;
+  for (const auto &bucket : buckets_) count += bucket.load(std::memory_order_relaxed);
+  return count;
+}
+
+uint64_t LatencyHistogram::Percentile(double p) const {
+  std::array<uint64_t, kBuckets> counts{};
+  uint64_t total = 0;
+  for (int i = 0; i < kBuckets; i++) {
+    counts[i] = buckets_[i].load(std::memory_order_relaxed);
+    total += counts[i];
+  }
+  if (total == 0) return 0;
+
+  auto rank = static_cast<uint64_t>(std::ceil(p / 100 * static_cast<double>(total)));
+  if (rank == 0) rank = 1;
+  uint64_t seen = 0;
+  for (int i = 0; i < kBuckets; i++) {
+    seen += counts[i];
+    if (seen >= rank) return bucketUpperBound(i);
+  }
+  return bucketUpperBound(kBuckets - 1);
+}
+
+std::vector<std::pair<uint64_t, uint64_t>> LatencyHistogram::CumulativeBuckets() const {
+  std::vector<std::pair<uint64_t, uint64_t>> result;
+  uint64_t cumulative = 0, reported = 0;
+  for (int i = 0; i < kBuckets; i++) {
+    cumulative += buckets_[i].load(std::memory_order_relaxed);
+    uint64_t bound = bucketUpperBound(i) + 1;
+    // Every power of two is the exclusive upper bound of a bucket, so the count below it is exact
+    if ((bound & (bound - 1)) != 0 || cumulative == reported) continue;
+    result.emplace_back(bound, cumulative);
+    reported = cumulative;
+  }
+  return result;
+}
+
+void LatencyHistogram::Reset() {
+  for (auto &bucket : buckets_) bucket.store(0, std::memory_order_relaxed);
+}
+
 void Stats::IncrCalls(const std::string &command_name) {
   total_calls.fetch_add(1, std::memory_order_relaxed);
   commands_stats[command_name].calls.fetch_add(1, std::memory_order_relaxed);
@@ -90,13 +156,79 @@ void Stats::IncrCalls(const std::string &command_name) {
 
 void Stats::IncrLatency(uint64_t latency, const std::string &command_name) {
   commands_stats[command_name].latency.fetch_add(latency, std::memory_order_relaxed);
+  commands_stats[command_name].latency_histogram.Record(latency);
+}
+
+void Stats::IncrRejectedCalls(const std::string &command_name) {
+  commands_stats[command_name].rejected_calls.fetch_add(1, std::memory_order_relaxed);
+}
+
+void Stats::IncrFailedCalls(const std::string &command_name) {
+  commands_stats[command_name].failed_calls.fetch_add(1, std::memory_order_relaxed);
+}
+
+// IncrErrorReplies counts the error reply by its prefix, i.e. the first word like ERR or WRONGTYPE.
+void Stats::IncrErrorReplies(const std::string &reply) {
+  total_error_replies.fetch_add(1, std::memory_order_relaxed);
+
+  auto begin = reply.find_first_not_of('-');
+  if (begin == std::string::npos) return;
+  auto end = reply.find_first_of(" \r\n", begin);
+  std::string prefix = reply.substr(begin, end == std::string::npos ? std::string::npos : end - begin);
+  // cluster redirections are replied like "ERR MOVED 1 127.0.0.1:6666", so they are counted by the second word
+  if (prefix == "ERR" && end != std::string::npos && reply[end] == ' ') {
+    auto next_end = reply.find_first_of(" \r\n", end + 1);
+    auto next = reply.substr(end + 1, next_end == std::string::npos ? std::string::npos : next_end - end - 1);
+    if (next == "MOVED" || next == "ASK" || next == "TRYAGAIN" || next == "CLUSTERDOWN") prefix = next;
+  }
+
+  std::lock_guard<std::mutex> lock(error_stats_mutex);
+  // stop adding new prefixes to prevent unbounded growth from commands which put user input into errors
+  auto iter = error_stats.find(prefix);
+  if (iter != error_stats.end()) {
+    iter->second++;
+  } else if (error_stats.size() < MAX_ERROR_STATS) {
+    error_stats[prefix] = 1;
+  }
+}
+
+// Reset clears the counters like Redis CONFIG RESETSTAT, the command names are kept
+// in commands_stats since it's read and updated without locks.
+void Stats::Reset() {
+  total_calls.store(0, std::memory_order_relaxed);
+  in_bytes.store(0, std::memory_order_relaxed);
+  out_bytes.store(0, std::memory_order_relaxed);
+  fullsync_counter.store(0, std::memory_order_relaxed);
+  psync_err_counter.store(0, std::memory_order_relaxed);
+  psync_ok_counter.store(0, std::memory_order_relaxed);
+  for (auto &iter : commands_stats) {
+    iter.second.calls.store(0, std::memory_order_relaxed);
+    iter.second.latency.store(0, std::memory_order_relaxed);
+    iter.second.rejected_calls.store(0, std::memory_order_relaxed);
+    iter.second.failed_calls.store(0, std::memory_order_relaxed);
+    iter.second.latency_histogram.Reset();
+  }
+
+  total_error_replies.store(0, std::memory_order_relaxed);
+  {
+    std::lock_guard<std::mutex> lock(error_stats_mutex);
+    error_stats.clear();
+  }
+
+  std::unique_lock<std::shared_mutex> lock(inst_metrics_mutex);
+  for (auto &im : inst_metrics) {
+    im.last_sample_count = 0;
+  }
 }
 
 void Stats::TrackInstantaneousMetric(int metric, uint64_t current_reading) {
   uint64_t curr_time = util::GetTimeStampMS();
   std::unique_lock<std::shared_mutex> lock(inst_metrics_mutex);
   uint64_t t = curr_time - inst_metrics[metric].last_sample_time;
-  uint64_t ops = current_reading - inst_metrics[metric].last_sample_count;
+  // the reading may go backwards after the counters are reset
+  uint64_t ops = current_reading >= inst_metrics[metric].last_sample_count
+                     ? current_reading - inst_metrics[metric].last_sample_count
+                     : 0;
   uint64_t ops_sec = t > 0 ? (ops * 1000 / t) : 0;
   inst_metrics[metric].samples[inst_metrics[metric].idx] = ops_sec;
   inst_metrics[metric].idx++;
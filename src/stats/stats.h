@@ -22,8 +22,10 @@
 
 #include <unistd.h>
 
+#include <array>
 #include <atomic>
 #include <map>
+#include <mutex>
 #include <shared_mutex>
 #include <string>
 #include <vector>
@@ -42,10 +44,39 @@ enum StatsMetricFlags {
 };
 
 const int STATS_METRIC_SAMPLES = 16;  // Number of samples per metric
+const size_t MAX_ERROR_STATS = 128;   // Number of distinct error prefixes counted in errorstats
+
+// LatencyHistogram counts latencies in microseconds into log-linear buckets without locks,
+// values below 16 are exact and larger ones are bucketed with a relative error below 12.5%.
+class LatencyHistogram {
+ public:
+  void Record(uint64_t latency);
+  uint64_t Count() const;
+  // Percentile returns the upper bound of the bucket where the p-th percentile (0 < p <= 100) falls in
+  uint64_t Percentile(double p) const;
+  // CumulativeBuckets returns pairs of <bound, count of latencies below the bound> in the same shape
+  // as the Redis 7 LATENCY HISTOGRAM, bounds are powers of two and only the changed ones are reported.
+  std::vector<std::pair<uint64_t, uint64_t>> CumulativeBuckets() const;
+  void Reset();
+
+ private:
+  static constexpr int kExactBuckets = 16;
+  static constexpr int kSubBucketBits = 3;
+  static constexpr int kMaxBits = 40;  // Latencies above ~12 days fall into the last bucket
+  static constexpr int kBuckets = kExactBuckets + (kMaxBits - 4) * (1 << kSubBucketBits);
+
+  static int bucketIndex(uint64_t latency);
+  static uint64_t bucketUpperBound(int index);
+
+  std::array<std::atomic<uint64_t>, kBuckets> buckets_{};
+};
 
 struct CommandStat {
   std::atomic<uint64_t> calls;
   std::atomic<uint64_t> latency;
+  std::atomic<uint64_t> rejected_calls;  // Rejected before the execution, e.g. wrong arity or parse errors
+  std::atomic<uint64_t> failed_calls;    // Failed in the execution
+  LatencyHistogram latency_histogram;
 };
 
 struct InstMetric {
@@ -69,9 +100,17 @@ class Stats {
   std::atomic<uint64_t> psync_ok_counter = {0};
   std::map<std::string, CommandStat> commands_stats;
 
+  std::atomic<uint64_t> total_error_replies = {0};
+  mutable std::mutex error_stats_mutex;
+  std::map<std::string, uint64_t> error_stats;
+
   Stats();
   void IncrCalls(const std::string &command_name);
   void IncrLatency(uint64_t latency, const std::string &command_name);
+  void IncrRejectedCalls(const std::string &command_name);
+  void IncrFailedCalls(const std::string &command_name);
+  void IncrErrorReplies(const std::string &reply);
+  void Reset();
   void IncrInbondBytes(uint64_t bytes) { in_bytes.fetch_add(bytes, std::memory_order_relaxed); }
   void IncrOutbondBytes(uint64_t bytes) { out_bytes.fetch_add(bytes, std::memory_order_relaxed); }
   void IncrFullSyncCounter() { fullsync_counter.fetch_add(1, std::memory_order_relaxed); }
@@ -0,0 +1,327 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "tracing.h"
+
+#include <event2/buffer.h>
+#include <event2/event.h>
+#include <event2/http.h>
+#include <event2/keyvalq_struct.h>
+#include <glog/logging.h>
+
+#include <chrono>
+#include <jsoncons/json.hpp>
+#include <memory>
+#include <random>
+
+#include "fmt/format.h"
+#include "thread_util.h"
+#include "version.h"
+
+namespace tracing {
+
+namespace {
+
+thread_local Span *current_span = nullptr;
+
+uint64_t nowNS() {
+  auto now = std::chrono::system_clock::now().time_since_epoch();
+  return std::chrono::duration_cast<std::chrono::nanoseconds>(now).count();
+}
+
+std::mt19937_64 &rng() {
+  thread_local std::mt19937_64 gen(std::random_device{}());
+  return gen;
+}
+
+std::string randomID(size_t bytes) {
+  std::string id;
+  while (id.size() < bytes * 2) {
+    id += fmt::format("{:016x}", rng()());
+  }
+  id.resize(bytes * 2);
+  return id;
+}
+
+jsoncons::json encodeAttribute(const std::string &key, const AttributeValue &value) {
+  jsoncons::json any_value(jsoncons::json_object_arg);
+  if (auto v = std::get_if<int64_t>(&value)) {
+    // int64 values are encoded as strings in the JSON encoding of OTLP
+    any_value["intValue"] = std::to_string(*v);
+  } else {
+    any_value["stringValue"] = std::get<std::string>(value);
+  }
+
+  jsoncons::json attribute(jsoncons::json_object_arg);
+  attribute["key"] = key;
+  attribute["value"] = std::move(any_value);
+  return attribute;
+}
+
+}  // namespace
+
+Tracer::Tracer(std::string endpoint, int sample_percent, std::string instance_id)
+    : endpoint_(std::move(endpoint)), sample_percent_(sample_percent), instance_id_(std::move(instance_id)) {}
+
+Status Tracer::Start() {
+  std::unique_ptr<evhttp_uri, decltype(&evhttp_uri_free)> uri(evhttp_uri_parse(endpoint_.c_str()), evhttp_uri_free);
+  if (!uri || !evhttp_uri_get_scheme(uri.get()) || std::string(evhttp_uri_get_scheme(uri.get())) != "http" ||
+      !evhttp_uri_get_host(uri.get())) {
+    return {Status::NotOK, fmt::format("invalid OTLP endpoint '{}', only http://<host>[:<port>]/<path> is supported",
+                                       endpoint_)};
+  }
+  host_ = evhttp_uri_get_host(uri.get());
+  port_ = evhttp_uri_get_port(uri.get());
+  // the default port of OTLP/HTTP
+  if (port_ < 0) port_ = 4318;
+  const char *path = evhttp_uri_get_path(uri.get());
+  path_ = path && *path ? path : "/v1/traces";
+  if (const char *query = evhttp_uri_get_query(uri.get())) {
+    path_ = path_ + "?" + query;
+  }
+
+  thread_ = GET_OR_RET(util::CreateThread("tracing", [this] { loop(); }));
+  LOG(INFO) << "[tracing] Exporting " << sample_percent_ << "% of the traces to " << endpoint_;
+  return Status::OK();
+}
+
+void Tracer::Stop() {
+  {
+    std::lock_guard<std::mutex> guard(mu_);
+    stop_ = true;
+  }
+  cond_.notify_all();
+}
+
+void Tracer::Join() {
+  if (!thread_.joinable()) return;
+  if (auto s = util::ThreadJoin(thread_); !s) {
+    LOG(WARNING) << "Tracing thread operation failed: " << s.Msg();
+  }
+}
+
+bool Tracer::Sample() const {
+  if (sample_percent_ >= 100) return true;
+  return static_cast<int>(rng()() % 100) < sample_percent_;
+}
+
+void Tracer::Export(SpanData &&span) {
+  {
+    std::lock_guard<std::mutex> guard(mu_);
+    if (queue_.size() >= kMaxQueuedSpans) {
+      dropped_spans_++;
+      return;
+    }
+    queue_.emplace_back(std::move(span));
+    if (queue_.size() < kMaxBatchSpans) return;
+  }
+  cond_.notify_one();
+}
+
+void Tracer::loop() {
+  bool last_export_failed = false;
+  while (true) {
+    std::vector<SpanData> batch;
+    bool stopped = false;
+    {
+      std::unique_lock<std::mutex> lock(mu_);
+      cond_.wait_for(lock, std::chrono::seconds(1), [this] { return stop_ || queue_.size() >= kMaxBatchSpans; });
+      stopped = stop_;
+      // flush all queued spans before exiting
+      size_t n = stopped ? queue_.size() : std::min(queue_.size(), kMaxBatchSpans);
+      batch.reserve(n);
+      for (size_t i = 0; i < n; i++) {
+        batch.emplace_back(std::move(queue_.front()));
+        queue_.pop_front();
+      }
+    }
+
+    if (!batch.empty()) {
+      auto s = send(encode(batch));
+      // only log when the state changes to avoid flooding the log while the collector is down
+      if (!s && !last_export_failed) {
+        LOG(WARNING) << "[tracing] Failed to export spans to " << endpoint_ << ", err: " << s.Msg();
+      } else if (s && last_export_failed) {
+        LOG(INFO) << "[tracing] Resumed exporting spans to " << endpoint_;
+      }
+      last_export_failed = !s;
+    }
+    if (stopped) return;
+  }
+}
+
+std::string Tracer::encode(const std::vector<SpanData> &spans) const {
+  jsoncons::json json_spans(jsoncons::json_array_arg);
+  for (const auto &span : spans) {
+    jsoncons::json json_span(jsoncons::json_object_arg);
+    json_span["traceId"] = span.trace_id;
+    json_span["spanId"] = span.span_id;
+    if (!span.parent_span_id.empty()) json_span["parentSpanId"] = span.parent_span_id;
+    json_span["name"] = span.name;
+    json_span["kind"] = static_cast<int>(span.kind);
+    json_span["startTimeUnixNano"] = std::to_string(span.start_time_ns);
+    json_span["endTimeUnixNano"] = std::to_string(span.end_time_ns);
+
+    jsoncons::json attributes(jsoncons::json_array_arg);
+    for (const auto &[key, value] : span.attributes) {
+      attributes.push_back(encodeAttribute(key, value));
+    }
+    json_span["attributes"] = std::move(attributes);
+
+    if (span.is_error) {
+      jsoncons::json status(jsoncons::json_object_arg);
+      // STATUS_CODE_ERROR
+      status["code"] = 2;
+      status["message"] = span.status_message;
+      json_span["status"] = std::move(status);
+    }
+    json_spans.push_back(std::move(json_span));
+  }
+
+  jsoncons::json resource_attributes(jsoncons::json_array_arg);
+  resource_attributes.push_back(encodeAttribute("service.name", std::string("kvrocks")));
+  resource_attributes.push_back(encodeAttribute("service.version", std::string(VERSION)));
+  resource_attributes.push_back(encodeAttribute("service.instance.id", instance_id_));
+
+  jsoncons::json scope(jsoncons::json_object_arg);
+  scope["name"] = "kvrocks";
+  jsoncons::json scope_spans(jsoncons::json_object_arg);
+  scope_spans["scope"] = std::move(scope);
+  scope_spans["spans"] = std::move(json_spans);
+
+  jsoncons::json resource(jsoncons::json_object_arg);
+  resource["attributes"] = std::move(resource_attributes);
+  jsoncons::json resource_spans(jsoncons::json_object_arg);
+  resource_spans["resource"] = std::move(resource);
+  resource_spans["scopeSpans"] = jsoncons::json(jsoncons::json_array_arg, {std::move(scope_spans)});
+
+  jsoncons::json request(jsoncons::json_object_arg);
+  request["resourceSpans"] = jsoncons::json(jsoncons::json_array_arg, {std::move(resource_spans)});
+  return request.to_string();
+}
+
+Status Tracer::send(const std::string &body) const {
+  std::unique_ptr<event_base, decltype(&event_base_free)> base(event_base_new(), event_base_free);
+  if (!base) return {Status::NotOK, "failed to create the event base"};
+  std::unique_ptr<evhttp_connection, decltype(&evhttp_connection_free)> conn(
+      evhttp_connection_base_new(base.get(), nullptr, host_.c_str(), port_), evhttp_connection_free);
+  if (!conn) return {Status::NotOK, "failed to create the connection"};
+  evhttp_connection_set_timeout(conn.get(), kExportTimeoutSeconds);
+
+  int response_code = 0;
+  evhttp_request *req = evhttp_request_new(
+      [](evhttp_request *req, void *arg) {
+        // the request is null if the connection failed or timed out
+        *static_cast<int *>(arg) = req ? evhttp_request_get_response_code(req) : 0;
+      },
+      &response_code);
+  if (!req) return {Status::NotOK, "failed to create the request"};
+  evhttp_add_header(evhttp_request_get_output_headers(req), "Host", host_.c_str());
+  evhttp_add_header(evhttp_request_get_output_headers(req), "Content-Type", "application/json");
+  evbuffer_add(evhttp_request_get_output_buffer(req), body.data(), body.size());
+
+  // the request is owned and freed by the connection from now on
+  if (evhttp_make_request(conn.get(), req, EVHTTP_REQ_POST, path_.c_str()) != 0) {
+    return {Status::NotOK, "failed to make the request"};
+  }
+  event_base_dispatch(base.get());
+
+  if (response_code == 0) return {Status::NotOK, "failed to connect or no response"};
+  if (response_code < 200 || response_code >= 300) {
+    return {Status::NotOK, fmt::format("unexpected response code {}", response_code)};
+  }
+  return Status::OK();
+}
+
+Span::Span(Tracer *tracer, std::string name, SpanKind kind) {
+  if (!tracer || !tracer->Sample()) return;
+  start(tracer, randomID(16), "", std::move(name), kind);
+}
+
+Span::Span(std::string name) {
+  auto parent = current_span;
+  if (!parent) return;
+  start(parent->tracer_, parent->data_.trace_id, parent->data_.span_id, std::move(name), SpanKind::kInternal);
+}
+
+void Span::start(Tracer *tracer, std::string trace_id, std::string parent_span_id, std::string name,
+                 SpanKind kind) {
+  tracer_ = tracer;
+  data_.trace_id = std::move(trace_id);
+  data_.span_id = randomID(8);
+  data_.parent_span_id = std::move(parent_span_id);
+  data_.name = std::move(name);
+  data_.kind = kind;
+  data_.start_time_ns = nowNS();
+
+  prev_current_ = current_span;
+  current_span = this;
+}
+
+void Span::SetAttribute(std::string key, AttributeValue value) {
+  if (!IsRecording()) return;
+  data_.attributes.emplace_back(std::move(key), std::move(value));
+}
+
+void Span::SetError(std::string message) {
+  if (!IsRecording()) return;
+  data_.is_error = true;
+  data_.status_message = std::move(message);
+}
+
+void Span::RecordRead(uint64_t start_time_ns, uint64_t end_time_ns) {
+  if (read_count_ == 0) read_start_time_ns_ = start_time_ns;
+  read_end_time_ns_ = end_time_ns;
+  read_time_ns_ += end_time_ns - start_time_ns;
+  read_count_++;
+}
+
+void Span::End() {
+  if (!IsRecording()) return;
+  data_.end_time_ns = nowNS();
+  current_span = prev_current_;
+
+  if (read_count_ > 0) {
+    SpanData read_span;
+    read_span.trace_id = data_.trace_id;
+    read_span.span_id = randomID(8);
+    read_span.parent_span_id = data_.span_id;
+    read_span.name = "rocksdb.read";
+    read_span.start_time_ns = read_start_time_ns_;
+    read_span.end_time_ns = read_end_time_ns_;
+    read_span.attributes.emplace_back("kvrocks.read.count", static_cast<int64_t>(read_count_));
+    read_span.attributes.emplace_back("kvrocks.read.time_us", static_cast<int64_t>(read_time_ns_ / 1000));
+    tracer_->Export(std::move(read_span));
+  }
+  tracer_->Export(std::move(data_));
+  tracer_ = nullptr;
+}
+
+Span *Span::Current() { return current_span; }
+
+ScopedRead::ScopedRead() : span_(Span::Current()) {
+  if (span_) start_time_ns_ = nowNS();
+}
+
+ScopedRead::~ScopedRead() {
+  if (span_) span_->RecordRead(start_time_ns_, nowNS());
+}
+
+}  // namespace tracing
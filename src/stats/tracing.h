@@ -0,0 +1,153 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#pragma once
+
+#include <atomic>
+#include <condition_variable>
+#include <cstdint>
+#include <deque>
+#include <mutex>
+#include <string>
+#include <thread>
+#include <utility>
+#include <variant>
+#include <vector>
+
+#include "status.h"
+
+namespace tracing {
+
+// the values are the same as the span kinds of OTLP
+enum class SpanKind {
+  kInternal = 1,
+  kServer = 2,
+  kClient = 3,
+  kProducer = 4,
+};
+
+using AttributeValue = std::variant<int64_t, std::string>;
+
+struct SpanData {
+  std::string trace_id;
+  std::string span_id;
+  std::string parent_span_id;
+  std::string name;
+  SpanKind kind = SpanKind::kInternal;
+  uint64_t start_time_ns = 0;
+  uint64_t end_time_ns = 0;
+  std::vector<std::pair<std::string, AttributeValue>> attributes;
+  bool is_error = false;
+  std::string status_message;
+};
+
+// Tracer samples the spans and exports them in batches to an OTLP/HTTP collector
+// in JSON encoding, e.g. `http://127.0.0.1:4318/v1/traces`, from its own thread.
+// Spans are dropped rather than blocking the callers if the collector can't keep up.
+class Tracer {
+ public:
+  Tracer(std::string endpoint, int sample_percent, std::string instance_id);
+  ~Tracer() = default;
+  Tracer(const Tracer &) = delete;
+  Tracer &operator=(const Tracer &) = delete;
+
+  Status Start();
+  void Stop();
+  void Join();
+
+  bool Sample() const;
+  void Export(SpanData &&span);
+  uint64_t DroppedSpans() const { return dropped_spans_; }
+
+ private:
+  void loop();
+  std::string encode(const std::vector<SpanData> &spans) const;
+  Status send(const std::string &body) const;
+
+  static constexpr size_t kMaxQueuedSpans = 16384;
+  static constexpr size_t kMaxBatchSpans = 512;
+  static constexpr int kExportTimeoutSeconds = 5;
+
+  std::string endpoint_;
+  int sample_percent_;
+  std::string instance_id_;
+  std::string host_;
+  int port_ = 0;
+  std::string path_;
+
+  std::mutex mu_;
+  std::condition_variable cond_;
+  std::deque<SpanData> queue_;
+  bool stop_ = false;
+  std::atomic<uint64_t> dropped_spans_ = 0;
+  std::thread thread_;
+};
+
+// Span records an operation until it's destroyed or ended. A recording span is the current
+// span of the thread during its lifetime, so the nested operations like RocksDB reads and writes
+// can be attached to it without passing it through. Spans must be ended in the reverse order.
+class Span {
+ public:
+  // Starts a root span if the tracer isn't null and the trace is sampled, otherwise the span is a no-op.
+  Span(Tracer *tracer, std::string name, SpanKind kind);
+  // Starts a child span of the current span of the thread, it's a no-op if there is no current span.
+  explicit Span(std::string name);
+  ~Span() { End(); }
+  Span(const Span &) = delete;
+  Span &operator=(const Span &) = delete;
+
+  bool IsRecording() const { return tracer_ != nullptr; }
+  void SetAttribute(std::string key, AttributeValue value);
+  void SetError(std::string message);
+  void End();
+
+  // RecordRead accumulates a RocksDB read into the read phase, which is exported
+  // as one child span covering all reads of the span instead of a span per read.
+  void RecordRead(uint64_t start_time_ns, uint64_t end_time_ns);
+
+  static Span *Current();
+
+ private:
+  void start(Tracer *tracer, std::string trace_id, std::string parent_span_id, std::string name, SpanKind kind);
+
+  Tracer *tracer_ = nullptr;
+  Span *prev_current_ = nullptr;
+  SpanData data_;
+
+  uint64_t read_count_ = 0;
+  uint64_t read_time_ns_ = 0;
+  uint64_t read_start_time_ns_ = 0;
+  uint64_t read_end_time_ns_ = 0;
+};
+
+// ScopedRead records the enclosing RocksDB read into the current span if any.
+class ScopedRead {
+ public:
+  ScopedRead();
+  ~ScopedRead();
+  ScopedRead(const ScopedRead &) = delete;
+  ScopedRead &operator=(const ScopedRead &) = delete;
+
+ private:
+  Span *span_;
+  uint64_t start_time_ns_ = 0;
+};
+
+}  // namespace tracing
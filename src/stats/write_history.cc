@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "write_history.h"
+
+#include <algorithm>
+
+void WriteHistory::Record(uint64_t seq, uint64_t bytes, uint64_t now_ms) {
+  if (now_ms >= next_sample_ms_) {
+    std::lock_guard<std::mutex> guard(mu_);
+    // the interval may have been started by another write while waiting for the lock
+    if (now_ms >= next_sample_ms_) {
+      samples_.push_back({max_seq_, total_bytes_, now_ms});
+      if (samples_.size() > max_samples_) samples_.pop_front();
+      next_sample_ms_ = now_ms + interval_ms_;
+    }
+  }
+
+  total_bytes_ += bytes;
+  // the writes may be recorded out of order, since they're recorded after being written by multiple threads
+  uint64_t max_seq = max_seq_;
+  while (max_seq < seq && !max_seq_.compare_exchange_weak(max_seq, seq)) {
+  }
+}
+
+ReplicationLag WriteHistory::GetLag(uint64_t seq, uint64_t now_ms) {
+  if (seq >= max_seq_) return {};
+
+  std::lock_guard<std::mutex> guard(mu_);
+  if (samples_.empty()) return {};
+  // the sample of the interval which contains the writes right after the sequence number
+  auto iter = std::upper_bound(samples_.begin(), samples_.end(), seq,
+                               [](uint64_t seq, const Sample &sample) { return seq < sample.seq; });
+  uint64_t applied_bytes = 0;
+  if (iter != samples_.begin()) {
+    iter = std::prev(iter);
+    applied_bytes = iter->total_bytes;
+  }
+
+  ReplicationLag lag;
+  lag.bytes = total_bytes_ - applied_bytes;
+  lag.ms = now_ms > iter->time_ms ? now_ms - iter->time_ms : 0;
+  return lag;
+}
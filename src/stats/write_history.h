@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#pragma once
+
+#include <atomic>
+#include <cstdint>
+#include <deque>
+#include <mutex>
+
+// the writes are sampled every 100ms, and the samples of the last 10 minutes are kept
+constexpr const uint64_t kWriteHistoryIntervalMs = 100;
+constexpr const size_t kWriteHistoryMaxSamples = 6000;
+
+struct ReplicationLag {
+  uint64_t bytes = 0;
+  uint64_t ms = 0;
+};
+
+// WriteHistory samples the sequence numbers and the accumulated size of the write batches over time,
+// so that the replication lag of a replica can be estimated in bytes and time by its sequence number.
+class WriteHistory {
+ public:
+  explicit WriteHistory(uint64_t interval_ms = kWriteHistoryIntervalMs, size_t max_samples = kWriteHistoryMaxSamples)
+      : interval_ms_(interval_ms), max_samples_(max_samples) {}
+
+  // Record adds a write batch of the size which ends at the sequence number,
+  // it only takes the lock when a new interval begins, since it's called on every write.
+  void Record(uint64_t seq, uint64_t bytes, uint64_t now_ms);
+  // GetLag estimates the lag of the replica which has applied the writes until the sequence number,
+  // the lag may be overestimated by the sampling interval, and is at least the age of
+  // the oldest sample if the sequence number is older than all samples.
+  ReplicationLag GetLag(uint64_t seq, uint64_t now_ms);
+
+ private:
+  struct Sample {
+    // the last sequence number and the accumulated bytes of the writes before the interval
+    uint64_t seq;
+    uint64_t total_bytes;
+    // the time of the first write in the interval
+    uint64_t time_ms;
+  };
+
+  uint64_t interval_ms_;
+  size_t max_samples_;
+
+  std::atomic<uint64_t> max_seq_ = 0;
+  std::atomic<uint64_t> total_bytes_ = 0;
+  // the writes before it belong to the last sample
+  std::atomic<uint64_t> next_sample_ms_ = 0;
+
+  std::mutex mu_;
+  std::deque<Sample> samples_;
+};
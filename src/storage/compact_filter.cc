@@ -45,8 +45,14 @@ bool MetadataFilter::Filter(int level, const Slice &key, const Slice &value, std
   }
   DLOG(INFO) << "[compact_filter/metadata] "
              << "namespace: " << ns << ", key: " << user_key
-             << ", result: " << (metadata.Expired() ? "deleted" : "reserved");
-  return metadata.Expired();
+             << ", result: " << (metadata.ExpireAt(util::GetTimeStampMS()) ? "deleted" : "reserved");
+  // judge by the real clock rather than the expiration clock which is moved by DEBUG SET-CLOCK-OFFSET,
+  // so that the keys which only look expired are never reclaimed
+  if (metadata.ExpireAt(util::GetTimeStampMS())) {
+    stor_->IncrReclaimedExpiredKeys(1);
+    return true;
+  }
+  return false;
 }
 
 Status SubKeyFilter::GetMetadata(const InternalKey &ikey, Metadata *metadata) const {
@@ -113,8 +119,11 @@ rocksdb::CompactionFilter::Decision SubKeyFilter::FilterBlobByKey(int level, con
     return rocksdb::CompactionFilter::Decision::kUndetermined;
   }
 
-  bool result = IsMetadataExpired(ikey, metadata);
-  return result ? rocksdb::CompactionFilter::Decision::kRemove : rocksdb::CompactionFilter::Decision::kKeep;
+  if (IsMetadataExpired(ikey, metadata)) {
+    stor_->IncrReclaimedSubkeys(1);
+    return rocksdb::CompactionFilter::Decision::kRemove;
+  }
+  return rocksdb::CompactionFilter::Decision::kKeep;
 }
 
 bool SubKeyFilter::Filter(int level, const Slice &key, const Slice &value, std::string *new_value,
@@ -123,6 +132,7 @@ bool SubKeyFilter::Filter(int level, const Slice &key, const Slice &value, std::
   Metadata metadata(kRedisNone, false);
   Status s = GetMetadata(ikey, &metadata);
   if (s.Is<Status::NotFound>()) {
+    stor_->IncrReclaimedSubkeys(1);
     return true;
   }
   if (!s.IsOK()) {
@@ -131,7 +141,11 @@ bool SubKeyFilter::Filter(int level, const Slice &key, const Slice &value, std::
     return false;
   }
 
-  return IsMetadataExpired(ikey, metadata) || (metadata.Type() == kRedisBitmap && redis::Bitmap::IsEmptySegment(value));
+  if (IsMetadataExpired(ikey, metadata) || (metadata.Type() == kRedisBitmap && redis::Bitmap::IsEmptySegment(value))) {
+    stor_->IncrReclaimedSubkeys(1);
+    return true;
+  }
+  return false;
 }
 
 }  // namespace engine
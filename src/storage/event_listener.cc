@@ -24,6 +24,7 @@
 #include <vector>
 
 #include "fmt/format.h"
+#include "time_util.h"
 
 std::string BackgroundErrorReason2String(const rocksdb::BackgroundErrorReason reason) {
   std::vector<std::string> background_error_reason = {
@@ -75,6 +76,16 @@ bool IsDiskQuotaExceeded(const rocksdb::Status &bg_error) {
   return err_msg.find(exceeded_quota_str) != std::string::npos;
 }
 
+void EventListener::OnCompactionBegin(rocksdb::DB *db, const rocksdb::CompactionJobInfo &ci) {
+  VLOG(1) << "[event_listener/compaction_begin] column family: " << ci.cf_name << ", job_id: " << ci.job_id
+          << ", compaction reason: " << static_cast<int>(ci.compaction_reason)
+          << ", base input level(files): " << ci.base_input_level << "(" << ci.input_files.size() << ")"
+          << ", output level: " << ci.output_level;
+  for (const auto &file : ci.input_files) {
+    VLOG(2) << "[event_listener/compaction_begin] job_id: " << ci.job_id << ", input file: " << file;
+  }
+}
+
 void EventListener::OnCompactionCompleted(rocksdb::DB *db, const rocksdb::CompactionJobInfo &ci) {
   LOG(INFO) << "[event_listener/compaction_completed] column family: " << ci.cf_name << ", job_id: " << ci.job_id
             << ", compaction reason: " << static_cast<int>(ci.compaction_reason)
@@ -145,6 +156,15 @@ void EventListener::OnStallConditionsChanged(const rocksdb::WriteStallInfo &info
   LOG(WARNING) << "[event_listener/stall_cond_changed] column family: " << info.cf_name
                << " write stall condition was changed, from " << StallConditionType2String(info.condition.prev)
                << " to " << StallConditionType2String(info.condition.cur);
+
+  // a stall lasts from leaving the normal condition until returning to it, including the changes in between
+  std::lock_guard<std::mutex> guard(stall_mu_);
+  if (info.condition.cur != rocksdb::WriteStallCondition::kNormal) {
+    stall_begin_ms_.emplace(info.cf_name, util::GetTimeStampMS());
+  } else if (auto iter = stall_begin_ms_.find(info.cf_name); iter != stall_begin_ms_.end()) {
+    storage_->LatencyAddSampleIfNeeded("write-stall", util::GetTimeStampMS() - iter->second);
+    stall_begin_ms_.erase(iter);
+  }
 }
 
 void EventListener::OnTableFileCreated(const rocksdb::TableFileCreationInfo &info) {
@@ -23,6 +23,10 @@
 #include <glog/logging.h>
 #include <rocksdb/listener.h>
 
+#include <map>
+#include <mutex>
+#include <string>
+
 #include "storage.h"
 
 class EventListener : public rocksdb::EventListener {
@@ -31,6 +35,7 @@ class EventListener : public rocksdb::EventListener {
   ~EventListener() override = default;
   void OnFlushBegin(rocksdb::DB *db, const rocksdb::FlushJobInfo &fi) override;
   void OnFlushCompleted(rocksdb::DB *db, const rocksdb::FlushJobInfo &fi) override;
+  void OnCompactionBegin(rocksdb::DB *db, const rocksdb::CompactionJobInfo &ci) override;
   void OnCompactionCompleted(rocksdb::DB *db, const rocksdb::CompactionJobInfo &ci) override;
   void OnBackgroundError(rocksdb::BackgroundErrorReason reason, rocksdb::Status *status) override;
   void OnTableFileDeleted(const rocksdb::TableFileDeletionInfo &info) override;
@@ -39,4 +44,8 @@ class EventListener : public rocksdb::EventListener {
 
  private:
   engine::Storage *storage_ = nullptr;
+
+  std::mutex stall_mu_;
+  // the time in milliseconds when the write stall of the column family began
+  std::map<std::string, uint64_t> stall_begin_ms_;
 };
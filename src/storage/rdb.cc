@@ -22,6 +22,8 @@
 
 #include <glog/logging.h>
 
+#include <limits>
+
 #include "common/encoding.h"
 #include "common/rdb_stream.h"
 #include "common/time_util.h"
@@ -30,6 +32,7 @@
 #include "rdb_ziplist.h"
 #include "rdb_zipmap.h"
 #include "time_util.h"
+#include "types/redis_bitmap.h"
 #include "types/redis_hash.h"
 #include "types/redis_list.h"
 #include "types/redis_set.h"
@@ -70,6 +73,9 @@ constexpr const int RDBCheckSumLen = 8;                                        /
 constexpr const int RestoreRdbVersionLen = 2;                                  // rdb version len in restore string
 constexpr const int RestoreFooterLen = RestoreRdbVersionLen + RDBCheckSumLen;  // 10 = ver len  + checksum len
 constexpr const int MinRdbVersionToVerifyChecksum = 5;
+// DUMP only emits the plain object encodings which have been loadable since RDB version 8 (Redis 4.0),
+// so the payload is also accepted by older Redis servers
+constexpr const int DumpRDBVersion = 8;
 
 template <typename T>
 T LogWhenError(T &&s) {
@@ -397,6 +403,140 @@ Status RDB::Restore(const std::string &key, std::string_view payload, uint64_t t
   return saveRdbObject(type, key, value, ttl_ms);  // NOLINT
 }
 
+Status RDB::Dump(const std::string &key, RedisType type) {
+  // The payload is made of the object type, the serialized object and the footer:
+  // ----------------+---------------------+---------------+
+  // ... RDB payload | 2 bytes RDB version | 8 bytes CRC64 |
+  // ----------------+---------------------+---------------+
+  // both the RDB version and the CRC64 are in little endian.
+  GET_OR_RET(saveObjectType(type));
+  GET_OR_RET(saveObject(key, type));
+
+  unsigned char version[RestoreRdbVersionLen] = {DumpRDBVersion & 0xff, (DumpRDBVersion >> 8) & 0xff};
+  GET_OR_RET(stream_->Write(reinterpret_cast<const char *>(version), RestoreRdbVersionLen));
+
+  auto string_stream = dynamic_cast<RdbStringStream *>(stream_.get());
+  if (!string_stream) {
+    return {Status::NotOK, "dump is only supported by the string stream"};
+  }
+  const auto &payload = string_stream->GetInput();
+  uint64_t crc = crc64(0, reinterpret_cast<const unsigned char *>(payload.data()), payload.size());
+  memrev64ifbe(&crc);
+  return stream_->Write(reinterpret_cast<const char *>(&crc), RDBCheckSumLen);
+}
+
+Status RDB::saveObjectType(RedisType type) {
+  unsigned char rdb_type = 0;
+  switch (type) {
+    case kRedisString:
+    case kRedisBitmap:
+      rdb_type = RDBTypeString;
+      break;
+    case kRedisList:
+      rdb_type = RDBTypeList;
+      break;
+    case kRedisSet:
+      rdb_type = RDBTypeSet;
+      break;
+    case kRedisZSet:
+      rdb_type = RDBTypeZSet2;
+      break;
+    case kRedisHash:
+      rdb_type = RDBTypeHash;
+      break;
+    default:
+      return {Status::RedisExecErr, fmt::format("the {} type can't be dumped", RedisTypeNames[type])};
+  }
+  return stream_->Write(reinterpret_cast<const char *>(&rdb_type), 1);
+}
+
+Status RDB::saveObject(const std::string &key, RedisType type) {
+  rocksdb::Status db_status;
+  if (type == kRedisString) {
+    std::string value;
+    db_status = redis::String(storage_, ns_).Get(key, &value);
+    if (!db_status.ok()) return {Status::RedisExecErr, db_status.ToString()};
+    return saveString(value);
+  } else if (type == kRedisBitmap) {
+    // Bitmaps are strings in Redis
+    std::string value;
+    db_status = redis::Bitmap(storage_, ns_).GetString(key, std::numeric_limits<uint32_t>::max(), &value);
+    if (!db_status.ok()) return {Status::RedisExecErr, db_status.ToString()};
+    return saveString(value);
+  } else if (type == kRedisList || type == kRedisSet) {
+    std::vector<std::string> elems;
+    if (type == kRedisList) {
+      db_status = redis::List(storage_, ns_).Range(key, 0, -1, &elems);
+    } else {
+      db_status = redis::Set(storage_, ns_).Members(key, &elems);
+    }
+    if (!db_status.ok()) return {Status::RedisExecErr, db_status.ToString()};
+
+    GET_OR_RET(saveLen(elems.size()));
+    for (const auto &elem : elems) {
+      GET_OR_RET(saveString(elem));
+    }
+  } else if (type == kRedisZSet) {
+    std::vector<MemberScore> member_scores;
+    RangeRankSpec spec;
+    db_status = redis::ZSet(storage_, ns_).RangeByRank(key, spec, &member_scores, nullptr);
+    if (!db_status.ok()) return {Status::RedisExecErr, db_status.ToString()};
+
+    GET_OR_RET(saveLen(member_scores.size()));
+    for (const auto &member_score : member_scores) {
+      GET_OR_RET(saveString(member_score.member));
+      GET_OR_RET(saveBinaryDouble(member_score.score));
+    }
+  } else if (type == kRedisHash) {
+    std::vector<FieldValue> field_values;
+    db_status = redis::Hash(storage_, ns_).GetAll(key, &field_values);
+    if (!db_status.ok()) return {Status::RedisExecErr, db_status.ToString()};
+
+    GET_OR_RET(saveLen(field_values.size()));
+    for (const auto &field_value : field_values) {
+      GET_OR_RET(saveString(field_value.field));
+      GET_OR_RET(saveString(field_value.value));
+    }
+  } else {
+    return {Status::RedisExecErr, fmt::format("the {} type can't be dumped", RedisTypeNames[type])};
+  }
+  return Status::OK();
+}
+
+// Save the length with the same variable length encoding as loadObjectLen
+Status RDB::saveLen(uint64_t len) {
+  unsigned char buf[2];
+  if (len < (1 << 6)) {
+    buf[0] = (len & 0xFF) | (RDB6BitLen << 6);
+    return stream_->Write(reinterpret_cast<const char *>(buf), 1);
+  } else if (len < (1 << 14)) {
+    buf[0] = ((len >> 8) & 0xFF) | (RDB14BitLen << 6);
+    buf[1] = len & 0xFF;
+    return stream_->Write(reinterpret_cast<const char *>(buf), 2);
+  } else if (len <= UINT32_MAX) {
+    buf[0] = RDB32BitLen;
+    GET_OR_RET(stream_->Write(reinterpret_cast<const char *>(buf), 1));
+    uint32_t len32 = htonl(len);
+    return stream_->Write(reinterpret_cast<const char *>(&len32), sizeof(uint32_t));
+  } else {
+    buf[0] = RDB64BitLen;
+    GET_OR_RET(stream_->Write(reinterpret_cast<const char *>(buf), 1));
+    len = htonu64(len);
+    return stream_->Write(reinterpret_cast<const char *>(&len), sizeof(uint64_t));
+  }
+}
+
+Status RDB::saveString(const std::string &value) {
+  GET_OR_RET(saveLen(value.size()));
+  if (value.empty()) return Status::OK();
+  return stream_->Write(value.data(), value.size());
+}
+
+Status RDB::saveBinaryDouble(double value) {
+  memrev64ifbe(&value);
+  return stream_->Write(reinterpret_cast<const char *>(&value), sizeof(value));
+}
+
 StatusOr<int> RDB::loadRdbType() {
   auto type = GET_OR_RET(stream_->ReadByte());
   return type;
@@ -509,7 +649,7 @@ Status RDB::saveRdbObject(int type, const std::string &key, const RedisObjValue
   // String type will use the SETEX, so just only set the ttl for other types
   if (ttl_ms > 0 && type != RDBTypeString) {
     redis::Database db(storage_, ns_);
-    db_status = db.Expire(key, ttl_ms + util::GetTimeStampMS());
+    db_status = db.Expire(key, ttl_ms + util::GetExpireTimeStampMS());
   }
   return db_status.ok() ? Status::OK() : Status{Status::RedisExecErr, db_status.ToString()};
 }
@@ -568,7 +708,7 @@ Status RDB::LoadRdb(uint32_t db_index, bool overwrite_exist_key) {
   int64_t expire_keys = 0;
   int64_t load_keys = 0;
   int64_t empty_keys_skipped = 0;
-  auto now = util::GetTimeStampMS();
+  auto now = util::GetExpireTimeStampMS();
   uint32_t db_id = 0;
   uint64_t skip_exist_keys = 0;
   while (true) {
@@ -72,6 +72,7 @@ class RDB {
   Status VerifyPayloadChecksum(const std::string_view &payload);
   StatusOr<int> LoadObjectType();
   Status Restore(const std::string &key, std::string_view payload, uint64_t ttl_ms);
+  Status Dump(const std::string &key, RedisType type);
 
   // String
   StatusOr<std::string> LoadStringObject();
@@ -117,6 +118,12 @@ class RDB {
   StatusOr<uint32_t> loadExpiredTimeSeconds();
   StatusOr<uint64_t> loadExpiredTimeMilliseconds(int rdb_version);
 
+  Status saveObjectType(RedisType type);
+  Status saveObject(const std::string &key, RedisType type);
+  Status saveLen(uint64_t len);
+  Status saveString(const std::string &value);
+  Status saveBinaryDouble(double value);
+
   /*0-5 is the basic type of Redis objects and 9-21 is the encoding type of Redis objects.
    Redis allow basic is 0-7 and 6/7 is for the module type which we don't support here.*/
   static bool isObjectType(int type) { return (type >= 0 && type <= 5) || (type >= 9 && type <= 21); };
@@ -20,17 +20,29 @@
 
 #include "redis_db.h"
 
+#include <algorithm>
+#include <chrono>
+#include <cmath>
 #include <ctime>
+#include <iterator>
 #include <map>
+#include <thread>
+#include <tuple>
 #include <utility>
 
 #include "cluster/redis_slot.h"
 #include "db_util.h"
 #include "parse_util.h"
+#include "random_util.h"
 #include "rocksdb/iterator.h"
 #include "server/server.h"
 #include "storage/redis_metadata.h"
 #include "time_util.h"
+#include "types/redis_hash.h"
+#include "types/redis_list.h"
+#include "types/redis_set.h"
+#include "types/redis_string.h"
+#include "types/redis_zset.h"
 
 namespace redis {
 
@@ -46,6 +58,7 @@ rocksdb::Status Database::ParseMetadata(RedisType type, Slice *bytes, Metadata *
   if (!s.ok()) return s;
 
   if (metadata->Expired()) {
+    storage_->IncrExpiredKeyLookups(1);
     // error discarded here since it already failed
     auto _ [[maybe_unused]] = metadata->Decode(old_metadata);
     return rocksdb::Status::NotFound(kErrMsgKeyExpired);
@@ -90,7 +103,7 @@ rocksdb::Status Database::GetRawMetadataByUserKey(const Slice &user_key, std::st
   return GetRawMetadata(AppendNamespacePrefix(user_key), bytes);
 }
 
-rocksdb::Status Database::Expire(const Slice &user_key, uint64_t timestamp) {
+rocksdb::Status Database::Expire(const Slice &user_key, uint64_t timestamp, uint8_t flags) {
   std::string ns_key = AppendNamespacePrefix(user_key);
 
   std::string value;
@@ -107,6 +120,12 @@ rocksdb::Status Database::Expire(const Slice &user_key, uint64_t timestamp) {
   if (!metadata.IsEmptyableType() && metadata.size == 0) {
     return rocksdb::Status::NotFound("no elements");
   }
+  // a key without expiration is regarded as having an infinite TTL by GT and LT
+  if (((flags & kExpireNX) && metadata.expire != 0) || ((flags & kExpireXX) && metadata.expire == 0) ||
+      ((flags & kExpireGT) && (metadata.expire == 0 || timestamp <= metadata.expire)) ||
+      ((flags & kExpireLT) && metadata.expire != 0 && timestamp >= metadata.expire)) {
+    return rocksdb::Status::Aborted("the expire condition was not met");
+  }
   if (metadata.expire == timestamp) return rocksdb::Status::OK();
 
   // +1 to skip the flags
@@ -140,8 +159,6 @@ rocksdb::Status Database::Del(const Slice &user_key) {
 }
 
 rocksdb::Status Database::MDel(const std::vector<Slice> &keys, uint64_t *deleted_cnt) {
-  *deleted_cnt = 0;
-
   std::vector<std::string> lock_keys;
   lock_keys.reserve(keys.size());
   for (const auto &key : keys) {
@@ -149,14 +166,25 @@ rocksdb::Status Database::MDel(const std::vector<Slice> &keys, uint64_t *deleted
     lock_keys.emplace_back(std::move(ns_key));
   }
   MultiLockGuard guard(storage_->GetLockManager(), lock_keys);
+  return MDelWithoutLock(keys, deleted_cnt);
+}
+
+rocksdb::Status Database::MDelWithoutLock(const std::vector<Slice> &keys, uint64_t *deleted_cnt) {
+  *deleted_cnt = 0;
+
+  std::vector<std::string> ns_keys;
+  ns_keys.reserve(keys.size());
+  for (const auto &key : keys) {
+    ns_keys.emplace_back(AppendNamespacePrefix(key));
+  }
 
   auto batch = storage_->GetWriteBatchBase();
   WriteBatchLogData log_data(kRedisNone);
   batch->PutLogData(log_data.Encode());
 
   std::vector<Slice> slice_keys;
-  slice_keys.reserve(lock_keys.size());
-  for (const auto &ns_key : lock_keys) {
+  slice_keys.reserve(ns_keys.size());
+  for (const auto &ns_key : ns_keys) {
     slice_keys.emplace_back(ns_key);
   }
 
@@ -177,7 +205,7 @@ rocksdb::Status Database::MDel(const std::vector<Slice> &keys, uint64_t *deleted
     if (!s.ok()) continue;
     if (metadata.Expired()) continue;
 
-    batch->Delete(metadata_cf_handle_, lock_keys[i]);
+    batch->Delete(metadata_cf_handle_, ns_keys[i]);
     *deleted_cnt += 1;
   }
 
@@ -227,6 +255,26 @@ rocksdb::Status Database::TTL(const Slice &user_key, int64_t *ttl) {
   return rocksdb::Status::OK();
 }
 
+rocksdb::Status Database::GetExpireTime(const Slice &user_key, int64_t *timestamp) {
+  std::string ns_key = AppendNamespacePrefix(user_key);
+
+  *timestamp = -2;  // timestamp is -2 when the key does not exist or expired
+  LatestSnapShot ss(storage_);
+  rocksdb::ReadOptions read_options;
+  read_options.snapshot = ss.GetSnapShot();
+  std::string value;
+  rocksdb::Status s = storage_->Get(read_options, metadata_cf_handle_, ns_key, &value);
+  if (!s.ok()) return s.IsNotFound() ? rocksdb::Status::OK() : s;
+
+  Metadata metadata(kRedisNone, false);
+  s = metadata.Decode(value);
+  if (!s.ok()) return s;
+  if (metadata.Expired()) return rocksdb::Status::OK();
+
+  *timestamp = metadata.expire == 0 ? -1 : static_cast<int64_t>(metadata.expire);
+  return rocksdb::Status::OK();
+}
+
 rocksdb::Status Database::GetKeyNumStats(const std::string &prefix, KeyNumStats *stats) {
   return Keys(prefix, nullptr, stats);
 }
@@ -295,8 +343,66 @@ rocksdb::Status Database::Keys(const std::string &prefix, std::vector<std::strin
   return rocksdb::Status::OK();
 }
 
+rocksdb::Status Database::GetBigKeyStats(uint64_t count, uint64_t keys_per_sec, const std::atomic<bool> &stop,
+                                         BigKeyStats *stats) {
+  std::string ns_prefix = storage_->IsSlotIdEncoded() ? ComposeNamespaceKey(namespace_, "", false)
+                                                      : AppendNamespacePrefix("");
+
+  // the top N keys of each type are kept in a min-heap on the size
+  auto size_greater = [](const BigKey &a, const BigKey &b) { return a.size > b.size; };
+  std::map<RedisType, std::vector<BigKey>> heaps;
+
+  LatestSnapShot ss(storage_);
+  rocksdb::ReadOptions read_options = storage_->DefaultScanOptions();
+  read_options.snapshot = ss.GetSnapShot();
+  auto iter = util::UniqueIterator(storage_, read_options, metadata_cf_handle_);
+
+  // The scan checks the stop flag after every batch of keys, and sleeps if it goes faster
+  // than keys_per_sec, 0 means no limit. A batch takes about 100ms when it's rate limited.
+  constexpr uint64_t kUnlimitedBatchSize = 1024;
+  uint64_t batch_size = keys_per_sec > 0 ? std::max<uint64_t>(keys_per_sec / 10, 1) : kUnlimitedBatchSize;
+  auto batch_start = std::chrono::steady_clock::now();
+  for (iter->Seek(ns_prefix); iter->Valid() && iter->key().starts_with(ns_prefix); iter->Next()) {
+    if (++stats->n_scanned % batch_size == 0) {
+      if (stop) return rocksdb::Status::Aborted("the big key scan was stopped");
+      if (keys_per_sec > 0) {
+        auto expected = std::chrono::microseconds(batch_size * 1000 * 1000 / keys_per_sec);
+        auto elapsed = std::chrono::steady_clock::now() - batch_start;
+        if (elapsed < expected) std::this_thread::sleep_for(expected - elapsed);
+        batch_start = std::chrono::steady_clock::now();
+      }
+    }
+
+    Metadata metadata(kRedisNone, false);
+    auto s = metadata.Decode(iter->value());
+    if (!s.ok() || metadata.Expired()) continue;
+
+    uint64_t size = metadata.size;
+    if (metadata.IsSingleKVType()) {
+      size = iter->value().size() - Metadata::GetOffsetAfterExpire(iter->value()[0]);
+    }
+    auto &heap = heaps[metadata.Type()];
+    if (heap.size() == count && heap.front().size >= size) continue;
+
+    auto [_, user_key] = ExtractNamespaceKey(iter->key(), storage_->IsSlotIdEncoded());
+    heap.emplace_back(BigKey{metadata.Type(), user_key.ToString(), size});
+    std::push_heap(heap.begin(), heap.end(), size_greater);
+    if (heap.size() > count) {
+      std::pop_heap(heap.begin(), heap.end(), size_greater);
+      heap.pop_back();
+    }
+  }
+  if (!iter->status().ok()) return iter->status();
+
+  for (auto &[_, heap] : heaps) {
+    std::sort_heap(heap.begin(), heap.end(), size_greater);
+    std::move(heap.begin(), heap.end(), std::back_inserter(stats->big_keys));
+  }
+  return rocksdb::Status::OK();
+}
+
 rocksdb::Status Database::Scan(const std::string &cursor, uint64_t limit, const std::string &prefix,
-                               std::vector<std::string> *keys, std::string *end_cursor) {
+                               std::vector<std::string> *keys, std::string *end_cursor, RedisType type) {
   end_cursor->clear();
   uint64_t cnt = 0;
   uint16_t slot_start = 0;
@@ -343,11 +449,13 @@ rocksdb::Status Database::Scan(const std::string &cursor, uint64_t limit, const
 
       if (metadata.Expired()) continue;
       std::tie(std::ignore, user_key) = ExtractNamespaceKey<std::string>(iter->key(), storage_->IsSlotIdEncoded());
-      keys->emplace_back(user_key);
+      // keys of other types are still counted, so that the type filter doesn't make the scan unbounded
       cnt++;
+      if (type != kRedisNone && metadata.Type() != type) continue;
+      keys->emplace_back(user_key);
     }
     if (!storage_->IsSlotIdEncoded() || prefix.empty()) {
-      if (!keys->empty() && cnt >= limit) {
+      if (cnt >= limit) {
         end_cursor->append(user_key);
       }
       break;
@@ -363,11 +471,13 @@ rocksdb::Status Database::Scan(const std::string &cursor, uint64_t limit, const
     }
 
     if (slot_id > slot_start + HASH_SLOTS_MAX_ITERATIONS) {
-      if (keys->empty()) {
+      if (cnt == 0) {
         if (iter->Valid()) {
           std::tie(std::ignore, user_key) = ExtractNamespaceKey<std::string>(iter->key(), storage_->IsSlotIdEncoded());
           auto res = std::mismatch(prefix.begin(), prefix.end(), user_key.begin());
-          if (res.first == prefix.end()) {
+          Metadata metadata(kRedisNone, false);
+          if (res.first == prefix.end() &&
+              (type == kRedisNone || (metadata.Decode(iter->value()).ok() && metadata.Type() == type))) {
             keys->emplace_back(user_key);
           }
 
@@ -387,27 +497,105 @@ rocksdb::Status Database::Scan(const std::string &cursor, uint64_t limit, const
   return rocksdb::Status::OK();
 }
 
-rocksdb::Status Database::RandomKey(const std::string &cursor, std::string *key) {
+rocksdb::Status Database::RandomKey(std::string *key) {
   key->clear();
 
-  std::string end_cursor;
-  std::vector<std::string> keys;
-  auto s = Scan(cursor, RANDOM_KEY_SCAN_LIMIT, "", &keys, &end_cursor);
-  if (!s.ok()) {
-    return s;
-  }
-  if (keys.empty() && !cursor.empty()) {
-    // if reach the end, restart from beginning
-    s = Scan("", RANDOM_KEY_SCAN_LIMIT, "", &keys, &end_cursor);
-    if (!s.ok()) {
-      return s;
+  LatestSnapShot ss(storage_);
+  rocksdb::ReadOptions read_options = storage_->DefaultScanOptions();
+  read_options.snapshot = ss.GetSnapShot();
+  auto iter = util::UniqueIterator(storage_, read_options, metadata_cf_handle_);
+
+  auto is_alive = [](const rocksdb::Slice &value) {
+    Metadata metadata(kRedisNone, false);
+    return metadata.Decode(value).ok() && !metadata.Expired();
+  };
+  auto extract_user_key = [this](const rocksdb::Slice &ns_key) {
+    std::string user_key;
+    std::tie(std::ignore, user_key) = ExtractNamespaceKey<std::string>(ns_key, storage_->IsSlotIdEncoded());
+    return user_key;
+  };
+
+  // the end of the keys with the prefix
+  auto prefix_end = [](std::string prefix) {
+    while (!prefix.empty() && static_cast<uint8_t>(prefix.back()) == UINT8_MAX) prefix.pop_back();
+    if (!prefix.empty()) prefix.back()++;
+    return prefix;
+  };
+
+  // Walk down the key space byte by byte and pick one of the existing next bytes, until there are
+  // few enough keys with the picked prefix to pick one of them directly. Each next byte is picked
+  // with the probability in proportion to the approximate size of its keys, so the keys under a
+  // sparse branch aren't more likely to be picked. If the picked keys were all expired, it starts
+  // over for a bounded number of times instead of scanning the whole key space.
+  std::string ns_prefix = ComposeNamespaceKey(namespace_, "", false);
+  rocksdb::SizeApproximationOptions size_options;
+  size_options.include_memtables = true;
+  for (uint64_t tries = 0; tries < RANDOM_KEY_MAX_TRIES; tries++) {
+    std::string prefix = ns_prefix;
+    while (true) {
+      uint64_t cnt = 0;
+      std::vector<std::string> keys;
+      for (iter->Seek(prefix); iter->Valid() && iter->key().starts_with(prefix) && cnt <= RANDOM_KEY_SCAN_LIMIT;
+           iter->Next(), cnt++) {
+        if (is_alive(iter->value())) keys.emplace_back(extract_user_key(iter->key()));
+      }
+      if (!iter->status().ok()) return iter->status();
+      if (cnt == 0 && prefix == ns_prefix) return rocksdb::Status::NotFound();
+      if (cnt <= RANDOM_KEY_SCAN_LIMIT) {
+        if (keys.empty()) break;
+        *key = keys[util::RandomInt(0, keys.size() - 1)];
+        return rocksdb::Status::OK();
+      }
+
+      // the prefix itself may be a key as well, which is regarded as one of the branches
+      std::vector<std::string> branches;
+      iter->Seek(prefix);
+      while (iter->Valid() && iter->key().starts_with(prefix)) {
+        if (iter->key().size() == prefix.size()) {
+          branches.emplace_back(prefix);
+          iter->Next();
+          continue;
+        }
+        branches.emplace_back(prefix + iter->key()[prefix.size()]);
+        std::string next_branch = branches.back();
+        if (static_cast<uint8_t>(next_branch.back()) == UINT8_MAX) break;
+        next_branch.back()++;
+        iter->Seek(next_branch);
+      }
+      if (!iter->status().ok()) return iter->status();
+
+      std::vector<std::string> limits;
+      limits.reserve(branches.size());
+      for (const auto &branch : branches) {
+        limits.emplace_back(branch.size() == prefix.size() ? branch + '\0' : prefix_end(branch));
+      }
+      std::vector<rocksdb::Range> ranges;
+      ranges.reserve(branches.size());
+      for (size_t i = 0; i < branches.size(); i++) {
+        ranges.emplace_back(branches[i], limits[i]);
+      }
+      std::vector<uint64_t> sizes(ranges.size());
+      auto s = storage_->GetDB()->GetApproximateSizes(size_options, metadata_cf_handle_, ranges.data(),
+                                                      static_cast<int>(ranges.size()), sizes.data());
+      if (!s.ok()) return s;
+
+      // the branches which are too small to be estimated still have a chance to be picked
+      uint64_t total = 0;
+      for (auto &size : sizes) total += ++size;
+      uint64_t weight = util::RandomInt(0, total - 1);
+      size_t picked = 0;
+      while (weight >= sizes[picked]) weight -= sizes[picked++];
+
+      if (branches[picked].size() == prefix.size()) {
+        iter->Seek(prefix);
+        if (!iter->Valid() || iter->key() != prefix || !is_alive(iter->value())) break;
+        *key = extract_user_key(iter->key());
+        return rocksdb::Status::OK();
+      }
+      prefix = std::move(branches[picked]);
     }
   }
-  if (!keys.empty()) {
-    unsigned int seed = util::GetTimeStamp();
-    *key = keys.at(rand_r(&seed) % keys.size());
-  }
-  return rocksdb::Status::OK();
+  return rocksdb::Status::NotFound();
 }
 
 rocksdb::Status Database::FlushDB() {
@@ -501,6 +689,95 @@ rocksdb::Status Database::Dump(const Slice &user_key, std::vector<std::string> *
   return rocksdb::Status::OK();
 }
 
+rocksdb::Status Database::Details(const Slice &user_key, std::vector<std::string> *infos) {
+  auto s = Dump(user_key, infos);
+  if (!s.ok() || infos->empty()) return s;
+
+  std::string ns_key = AppendNamespacePrefix(user_key);
+  std::string raw_metadata;
+  s = GetRawMetadata(ns_key, &raw_metadata);
+  if (!s.ok()) return s.IsNotFound() ? rocksdb::Status::OK() : s;
+  Metadata metadata(kRedisNone, false);
+  s = metadata.Decode(raw_metadata);
+  if (!s.ok()) return s;
+
+  // the key ranges of the key in each column family, the end of the range is exclusive
+  std::vector<std::tuple<std::string, std::string, std::string>> ranges;
+  ranges.emplace_back(engine::kMetadataColumnFamilyName, ns_key, ns_key + '\0');
+  std::string prefix_key = InternalKey(ns_key, "", metadata.version, storage_->IsSlotIdEncoded()).Encode();
+  std::string next_version_prefix_key =
+      InternalKey(ns_key, "", metadata.version + 1, storage_->IsSlotIdEncoded()).Encode();
+  if (metadata.Type() == kRedisStream) {
+    ranges.emplace_back(engine::kStreamColumnFamilyName, prefix_key, next_version_prefix_key);
+  } else if (!metadata.IsSingleKVType()) {
+    ranges.emplace_back(engine::kSubkeyColumnFamilyName, prefix_key, next_version_prefix_key);
+    if (metadata.Type() == kRedisZSet) {
+      ranges.emplace_back(engine::kZSetScoreColumnFamilyName, prefix_key, next_version_prefix_key);
+    }
+  }
+
+  // the number of subkeys is counted on the data column family only, since
+  // the score column family of sorted sets contains the same members
+  uint64_t subkeys = 0;
+  if (ranges.size() > 1) {
+    rocksdb::ReadOptions read_options = storage_->DefaultScanOptions();
+    LatestSnapShot ss(storage_);
+    read_options.snapshot = ss.GetSnapShot();
+    rocksdb::Slice upper_bound(next_version_prefix_key);
+    read_options.iterate_upper_bound = &upper_bound;
+
+    auto iter = util::UniqueIterator(storage_, read_options, storage_->GetCFHandle(std::get<0>(ranges[1])));
+    for (iter->Seek(prefix_key); iter->Valid() && iter->key().starts_with(prefix_key); iter->Next()) {
+      subkeys++;
+    }
+    if (!iter->status().ok()) return iter->status();
+  }
+
+  uint64_t approximate_size = 0;
+  rocksdb::SizeApproximationOptions size_options;
+  size_options.include_memtables = true;
+  size_options.include_files = true;
+  for (const auto &[cf_name, begin, end] : ranges) {
+    rocksdb::Range range(begin, end);
+    uint64_t size = 0;
+    s = storage_->GetDB()->GetApproximateSizes(size_options, storage_->GetCFHandle(cf_name), &range, 1, &size);
+    if (!s.ok()) return s;
+    approximate_size += size;
+  }
+
+  // SST files whose key range overlaps with the key, it's approximate since
+  // the key may not exist in the file even if it's in the range of the file
+  std::vector<rocksdb::LiveFileMetaData> files;
+  storage_->GetDB()->GetLiveFilesMetaData(&files);
+  std::map<int, uint64_t> files_per_level;
+  uint64_t sst_files = 0;
+  for (const auto &file : files) {
+    for (const auto &[cf_name, begin, end] : ranges) {
+      if (file.column_family_name == cf_name && file.smallestkey < end && file.largestkey >= begin) {
+        files_per_level[file.level]++;
+        sst_files++;
+      }
+    }
+  }
+  std::string sst_levels;
+  for (const auto &[level, n] : files_per_level) {
+    if (!sst_levels.empty()) sst_levels += ",";
+    sst_levels += "L" + std::to_string(level) + ":" + std::to_string(n);
+  }
+
+  infos->emplace_back("encoding");
+  infos->emplace_back(metadata.IsSingleKVType() ? "inline" : "subkeys");
+  infos->emplace_back("subkeys");
+  infos->emplace_back(std::to_string(subkeys));
+  infos->emplace_back("approximate_size");
+  infos->emplace_back(std::to_string(approximate_size));
+  infos->emplace_back("sst_files");
+  infos->emplace_back(std::to_string(sst_files));
+  infos->emplace_back("sst_levels");
+  infos->emplace_back(sst_levels);
+  return rocksdb::Status::OK();
+}
+
 rocksdb::Status Database::Type(const Slice &user_key, RedisType *type) {
   std::string ns_key = AppendNamespacePrefix(user_key);
 
@@ -640,6 +917,101 @@ rocksdb::Status Database::KeyExist(const std::string &key) {
   return rocksdb::Status::OK();
 }
 
+rocksdb::Status Database::Copy(const std::string &key, const std::string &new_key, bool nx, CopyResult *res) {
+  std::string ns_key = AppendNamespacePrefix(key);
+  std::string new_ns_key = AppendNamespacePrefix(new_key);
+
+  std::vector<std::string> lock_keys = {ns_key, new_ns_key};
+  MultiLockGuard guard(storage_->GetLockManager(), lock_keys);
+
+  LatestSnapShot ss(storage_);
+  rocksdb::ReadOptions read_options;
+  read_options.snapshot = ss.GetSnapShot();
+
+  auto is_alive = [](const Metadata &metadata) {
+    return !metadata.Expired() && (metadata.IsEmptyableType() || metadata.size > 0);
+  };
+
+  std::string raw_metadata;
+  auto s = storage_->Get(read_options, metadata_cf_handle_, ns_key, &raw_metadata);
+  if (!s.ok() && !s.IsNotFound()) return s;
+  Metadata metadata(kRedisNone, false);
+  Slice rest(raw_metadata);
+  if (s.ok()) {
+    s = metadata.Decode(&rest);
+    if (!s.ok()) return s;
+  }
+  if (s.IsNotFound() || !is_alive(metadata)) {
+    *res = CopyResult::KEY_NOT_EXIST;
+    return rocksdb::Status::OK();
+  }
+
+  if (nx) {
+    std::string new_raw_metadata;
+    s = storage_->Get(read_options, metadata_cf_handle_, new_ns_key, &new_raw_metadata);
+    if (!s.ok() && !s.IsNotFound()) return s;
+    if (s.ok()) {
+      Metadata new_metadata(kRedisNone, false);
+      s = new_metadata.Decode(new_raw_metadata);
+      if (!s.ok()) return s;
+      if (is_alive(new_metadata)) {
+        *res = CopyResult::KEY_ALREADY_EXIST;
+        return rocksdb::Status::OK();
+      }
+    }
+  }
+
+  auto batch = storage_->GetWriteBatchBase();
+  WriteBatchLogData log_data(metadata.Type());
+  batch->PutLogData(log_data.Encode());
+
+  if (metadata.IsSingleKVType()) {
+    batch->Put(metadata_cf_handle_, new_ns_key, raw_metadata);
+    *res = CopyResult::DONE;
+    return storage_->Write(storage_->DefaultWriteOptions(), batch->GetWriteBatch());
+  }
+
+  // the copy uses a new version, so the subkeys of the overwritten key
+  // (if any) are left to the compaction filter like a deleted key
+  uint64_t version = metadata.version;
+  metadata.version = Metadata(metadata.Type()).version;
+  std::string new_raw_metadata;
+  metadata.Encode(&new_raw_metadata);
+  new_raw_metadata.append(rest.data(), rest.size());
+  batch->Put(metadata_cf_handle_, new_ns_key, new_raw_metadata);
+
+  std::vector<std::string> cf_names;
+  if (metadata.Type() == kRedisStream) {
+    cf_names.emplace_back(engine::kStreamColumnFamilyName);
+  } else {
+    cf_names.emplace_back(engine::kSubkeyColumnFamilyName);
+    if (metadata.Type() == kRedisZSet) {
+      cf_names.emplace_back(engine::kZSetScoreColumnFamilyName);
+    }
+  }
+
+  std::string prefix_key = InternalKey(ns_key, "", version, storage_->IsSlotIdEncoded()).Encode();
+  std::string next_version_prefix_key = InternalKey(ns_key, "", version + 1, storage_->IsSlotIdEncoded()).Encode();
+  rocksdb::ReadOptions scan_options = storage_->DefaultScanOptions();
+  scan_options.snapshot = ss.GetSnapShot();
+  rocksdb::Slice upper_bound(next_version_prefix_key);
+  scan_options.iterate_upper_bound = &upper_bound;
+  for (const auto &cf_name : cf_names) {
+    auto cf_handle = storage_->GetCFHandle(cf_name);
+    auto iter = util::UniqueIterator(storage_, scan_options, cf_handle);
+    for (iter->Seek(prefix_key); iter->Valid() && iter->key().starts_with(prefix_key); iter->Next()) {
+      InternalKey ikey(iter->key(), storage_->IsSlotIdEncoded());
+      std::string new_sub_key =
+          InternalKey(new_ns_key, ikey.GetSubKey(), metadata.version, storage_->IsSlotIdEncoded()).Encode();
+      batch->Put(cf_handle, new_sub_key, iter->value());
+    }
+    if (!iter->status().ok()) return iter->status();
+  }
+
+  *res = CopyResult::DONE;
+  return storage_->Write(storage_->DefaultWriteOptions(), batch->GetWriteBatch());
+}
+
 rocksdb::Status SubKeyScanner::Scan(RedisType type, const Slice &user_key, const std::string &cursor, uint64_t limit,
                                     const std::string &subkey_prefix, std::vector<std::string> *keys,
                                     std::vector<std::string> *values) {
@@ -684,6 +1056,68 @@ rocksdb::Status SubKeyScanner::Scan(RedisType type, const Slice &user_key, const
   return rocksdb::Status::OK();
 }
 
+rocksdb::Status SubKeyScanner::RandomSubKeys(const std::string &ns_key, const Metadata &metadata, int64_t count,
+                                             std::vector<std::string> *keys, std::vector<std::string> *values) {
+  // pick positions first to avoid biasing towards the sub keys which are stored first
+  auto indices = util::RandomIndices(metadata.size, count);
+  if (indices.empty()) return rocksdb::Status::OK();
+
+  std::vector<uint64_t> positions = indices;
+  std::sort(positions.begin(), positions.end());
+  positions.erase(std::unique(positions.begin(), positions.end()), positions.end());
+
+  std::string prefix_key = InternalKey(ns_key, "", metadata.version, storage_->IsSlotIdEncoded()).Encode();
+  std::string next_version_prefix_key =
+      InternalKey(ns_key, "", metadata.version + 1, storage_->IsSlotIdEncoded()).Encode();
+
+  rocksdb::ReadOptions read_options = storage_->DefaultScanOptions();
+  LatestSnapShot ss(storage_);
+  read_options.snapshot = ss.GetSnapShot();
+  rocksdb::Slice lower_bound(prefix_key);
+  read_options.iterate_lower_bound = &lower_bound;
+  rocksdb::Slice upper_bound(next_version_prefix_key);
+  read_options.iterate_upper_bound = &upper_bound;
+
+  std::vector<std::pair<std::string, std::string>> picked(positions.size());
+  std::vector<bool> found(positions.size(), false);
+  auto fetch = [&](const rocksdb::Iterator *iter, size_t i) {
+    InternalKey ikey(iter->key(), storage_->IsSlotIdEncoded());
+    picked[i].first = ikey.GetSubKey().ToString();
+    if (values) picked[i].second = iter->value().ToString();
+    found[i] = true;
+  };
+
+  // The positions in the lower half are fetched from the head, and the others are fetched
+  // from the tail, so it never iterates to the largest picked position from the head.
+  size_t split = std::lower_bound(positions.begin(), positions.end(), metadata.size / 2) - positions.begin();
+  auto iter = util::UniqueIterator(storage_, read_options);
+  size_t i = 0;
+  uint64_t index = 0;
+  for (iter->Seek(prefix_key); iter->Valid() && i < split; iter->Next(), index++) {
+    if (index != positions[i]) continue;
+    fetch(iter.get(), i++);
+  }
+  if (!iter->status().ok()) return iter->status();
+
+  size_t j = positions.size();
+  index = metadata.size - 1;
+  for (iter->SeekToLast(); iter->Valid() && j > split; iter->Prev(), index--) {
+    if (index != positions[j - 1]) continue;
+    fetch(iter.get(), --j);
+  }
+  if (!iter->status().ok()) return iter->status();
+
+  keys->reserve(keys->size() + indices.size());
+  if (values) values->reserve(values->size() + indices.size());
+  for (auto idx : indices) {
+    auto k = std::lower_bound(positions.begin(), positions.end(), idx) - positions.begin();
+    if (!found[k]) continue;
+    keys->emplace_back(picked[k].first);
+    if (values) values->emplace_back(picked[k].second);
+  }
+  return rocksdb::Status::OK();
+}
+
 RedisType WriteBatchLogData::GetRedisType() const { return type_; }
 
 std::vector<std::string> *WriteBatchLogData::GetArguments() { return &args_; }
@@ -708,4 +1142,180 @@ Status WriteBatchLogData::Decode(const rocksdb::Slice &blob) {
 
   return Status::OK();
 }
+std::optional<std::string> Database::lookupKeyByPattern(const std::string &pattern, const std::string &subst) {
+  if (pattern == "#") return subst;
+
+  // Only the first '*' is substituted, and a "->field" suffix looks up a hash field instead of a string
+  auto match_pos = pattern.find('*');
+  if (match_pos == std::string::npos) return std::nullopt;
+
+  std::string field;
+  size_t key_end = pattern.size();
+  auto arrow_pos = pattern.find("->", match_pos + 1);
+  if (arrow_pos != std::string::npos && arrow_pos + 2 < pattern.size()) {
+    field = pattern.substr(arrow_pos + 2);
+    key_end = arrow_pos;
+  }
+  std::string key = pattern.substr(0, match_pos) + subst + pattern.substr(match_pos + 1, key_end - match_pos - 1);
+
+  std::string value;
+  rocksdb::Status s;
+  if (field.empty()) {
+    redis::String string_db(storage_, namespace_);
+    s = string_db.Get(key, &value);
+  } else {
+    redis::Hash hash_db(storage_, namespace_);
+    s = hash_db.Get(key, field, &value);
+  }
+  if (!s.ok()) return std::nullopt;
+  return value;
+}
+
+rocksdb::Status Database::Sort(const std::string &key, const SortArgument &args,
+                               std::vector<std::optional<std::string>> *elems, SortResult *res) {
+  elems->clear();
+
+  RedisType type = kRedisNone;
+  auto s = Type(key, &type);
+  if (!s.ok()) return s;
+  if (type == kRedisNone) {
+    *res = SortResult::DONE;
+    return rocksdb::Status::OK();
+  }
+  if (type != kRedisList && type != kRedisSet && type != kRedisZSet) {
+    *res = SortResult::UNKNOWN_TYPE;
+    return rocksdb::Status::OK();
+  }
+
+  uint64_t size = 0;
+  if (type == kRedisList) {
+    s = redis::List(storage_, namespace_).Size(key, &size);
+  } else if (type == kRedisSet) {
+    s = redis::Set(storage_, namespace_).Card(key, &size);
+  } else {
+    s = redis::ZSet(storage_, namespace_).Card(key, &size);
+  }
+  if (!s.ok()) return s;
+
+  // Clamp LIMIT into [start, end] of the whole collection, same as Redis
+  auto len = static_cast<int64_t>(size);
+  int64_t start = args.offset < 0 ? 0 : args.offset;
+  int64_t end = args.count < 0 ? len - 1 : start + args.count - 1;
+  if (start >= len) {
+    start = len - 1;
+    end = len - 2;
+  }
+  if (end >= len) end = len - 1;
+  if (end < start) {
+    *res = SortResult::DONE;
+    return rocksdb::Status::OK();
+  }
+
+  // Lists and sorted sets are already ordered on disk, so only the requested range
+  // needs to be loaded when there's nothing to sort
+  std::vector<std::string> values;
+  bool ranged = false;
+  if (type == kRedisList) {
+    redis::List list_db(storage_, namespace_);
+    if (args.dontsort) {
+      s = list_db.Range(key, static_cast<int>(start), static_cast<int>(end), &values);
+      ranged = true;
+    } else {
+      s = list_db.Range(key, 0, -1, &values);
+    }
+  } else if (type == kRedisSet) {
+    s = redis::Set(storage_, namespace_).Members(key, &values);
+  } else {
+    redis::ZSet zset_db(storage_, namespace_);
+    RangeRankSpec spec;
+    if (args.dontsort) {
+      spec.start = static_cast<int>(start);
+      spec.stop = static_cast<int>(end);
+      spec.reversed = args.desc;
+      ranged = true;
+    }
+    std::vector<MemberScore> member_scores;
+    s = zset_db.RangeByRank(key, spec, &member_scores, nullptr);
+    for (auto &ms : member_scores) {
+      values.emplace_back(std::move(ms.member));
+    }
+  }
+  if (!s.ok()) return s;
+
+  // The collection may have been changed since its size was taken
+  if (!ranged && end >= static_cast<int64_t>(values.size())) {
+    end = static_cast<int64_t>(values.size()) - 1;
+  }
+
+  if (!args.dontsort) {
+    struct SortObject {
+      std::string obj;
+      std::optional<std::string> cmp_value;
+      double score = 0;
+    };
+
+    std::vector<SortObject> sort_objects;
+    sort_objects.reserve(values.size());
+    for (auto &value : values) {
+      SortObject so{std::move(value), std::nullopt, 0};
+      if (!args.sortby.empty()) {
+        so.cmp_value = lookupKeyByPattern(args.sortby, so.obj);
+      }
+      // Missing weights sort as 0 for numeric sorting
+      if (!args.alpha) {
+        const std::string &weight = args.sortby.empty() ? so.obj : so.cmp_value.value_or("0");
+        auto score = ParseFloat(weight);
+        if (!score || std::isnan(*score)) {
+          *res = SortResult::DOUBLE_CONVERT_ERROR;
+          return rocksdb::Status::OK();
+        }
+        so.score = *score;
+      }
+      sort_objects.emplace_back(std::move(so));
+    }
+
+    auto compare = [&args](const SortObject &a, const SortObject &b) {
+      int cmp = 0;
+      if (!args.alpha) {
+        cmp = a.score < b.score ? -1 : (a.score > b.score ? 1 : 0);
+      } else if (!args.sortby.empty()) {
+        // Elements without a weight sort before the others
+        if (!a.cmp_value || !b.cmp_value) {
+          cmp = a.cmp_value ? 1 : (b.cmp_value ? -1 : 0);
+        } else {
+          cmp = a.cmp_value->compare(*b.cmp_value);
+        }
+      }
+      // Fall back to the elements themselves to keep the order deterministic
+      if (cmp == 0) cmp = a.obj.compare(b.obj);
+      return args.desc ? cmp > 0 : cmp < 0;
+    };
+    std::sort(sort_objects.begin(), sort_objects.end(), compare);
+
+    values.clear();
+    for (int64_t i = start; i <= end; i++) {
+      values.emplace_back(std::move(sort_objects[i].obj));
+    }
+  } else if (!ranged) {
+    std::vector<std::string> range;
+    for (int64_t i = start; i <= end; i++) {
+      range.emplace_back(std::move(values[i]));
+    }
+    values = std::move(range);
+  }
+
+  for (const auto &value : values) {
+    if (args.getpatterns.empty()) {
+      elems->emplace_back(value);
+      continue;
+    }
+    for (const auto &pattern : args.getpatterns) {
+      elems->emplace_back(lookupKeyByPattern(pattern, value));
+    }
+  }
+
+  *res = SortResult::DONE;
+  return rocksdb::Status::OK();
+}
+
 }  // namespace redis
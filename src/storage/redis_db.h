@@ -20,7 +20,9 @@
 
 #pragma once
 
+#include <atomic>
 #include <map>
+#include <optional>
 #include <string>
 #include <utility>
 #include <vector>
@@ -28,33 +30,62 @@
 #include "redis_metadata.h"
 #include "storage.h"
 
+enum ExpireFlags {
+  kExpireNX = 1,
+  kExpireXX = 1 << 1,
+  kExpireGT = 1 << 2,
+  kExpireLT = 1 << 3,
+};
+
 namespace redis {
+
+struct SortArgument {
+  std::string sortby;  // the BY pattern, empty to sort by the elements themselves
+  bool dontsort = false;
+  int offset = 0;
+  int count = -1;
+  std::vector<std::string> getpatterns;
+  bool desc = false;
+  bool alpha = false;
+};
+
 class Database {
  public:
   static constexpr uint64_t RANDOM_KEY_SCAN_LIMIT = 60;
+  static constexpr uint64_t RANDOM_KEY_MAX_TRIES = 100;
+
+  enum class CopyResult { KEY_NOT_EXIST, KEY_ALREADY_EXIST, DONE };
+  enum class SortResult { UNKNOWN_TYPE, DOUBLE_CONVERT_ERROR, DONE };
 
   explicit Database(engine::Storage *storage, std::string ns = "");
-  [[nodiscard]] static rocksdb::Status ParseMetadata(RedisType type, Slice *bytes, Metadata *metadata);
+  [[nodiscard]] rocksdb::Status ParseMetadata(RedisType type, Slice *bytes, Metadata *metadata);
   [[nodiscard]] rocksdb::Status GetMetadata(RedisType type, const Slice &ns_key, Metadata *metadata);
   [[nodiscard]] rocksdb::Status GetMetadata(RedisType type, const Slice &ns_key, std::string *raw_value,
                                             Metadata *metadata, Slice *rest);
   [[nodiscard]] rocksdb::Status GetRawMetadata(const Slice &ns_key, std::string *bytes);
   [[nodiscard]] rocksdb::Status GetRawMetadataByUserKey(const Slice &user_key, std::string *bytes);
-  [[nodiscard]] rocksdb::Status Expire(const Slice &user_key, uint64_t timestamp);
+  [[nodiscard]] rocksdb::Status Expire(const Slice &user_key, uint64_t timestamp, uint8_t flags = 0);
   [[nodiscard]] rocksdb::Status Del(const Slice &user_key);
   [[nodiscard]] rocksdb::Status MDel(const std::vector<Slice> &keys, uint64_t *deleted_cnt);
+  // the caller must hold the locks of the keys
+  [[nodiscard]] rocksdb::Status MDelWithoutLock(const std::vector<Slice> &keys, uint64_t *deleted_cnt);
   [[nodiscard]] rocksdb::Status Exists(const std::vector<Slice> &keys, int *ret);
   [[nodiscard]] rocksdb::Status TTL(const Slice &user_key, int64_t *ttl);
+  [[nodiscard]] rocksdb::Status GetExpireTime(const Slice &user_key, int64_t *timestamp);
   [[nodiscard]] rocksdb::Status Type(const Slice &user_key, RedisType *type);
   [[nodiscard]] rocksdb::Status Dump(const Slice &user_key, std::vector<std::string> *infos);
+  [[nodiscard]] rocksdb::Status Details(const Slice &user_key, std::vector<std::string> *infos);
   [[nodiscard]] rocksdb::Status FlushDB();
   [[nodiscard]] rocksdb::Status FlushAll();
   [[nodiscard]] rocksdb::Status GetKeyNumStats(const std::string &prefix, KeyNumStats *stats);
   [[nodiscard]] rocksdb::Status Keys(const std::string &prefix, std::vector<std::string> *keys = nullptr,
                                      KeyNumStats *stats = nullptr);
   [[nodiscard]] rocksdb::Status Scan(const std::string &cursor, uint64_t limit, const std::string &prefix,
-                                     std::vector<std::string> *keys, std::string *end_cursor = nullptr);
-  [[nodiscard]] rocksdb::Status RandomKey(const std::string &cursor, std::string *key);
+                                     std::vector<std::string> *keys, std::string *end_cursor = nullptr,
+                                     RedisType type = kRedisNone);
+  [[nodiscard]] rocksdb::Status GetBigKeyStats(uint64_t count, uint64_t keys_per_sec, const std::atomic<bool> &stop,
+                                               BigKeyStats *stats);
+  [[nodiscard]] rocksdb::Status RandomKey(std::string *key);
   std::string AppendNamespacePrefix(const Slice &user_key);
   [[nodiscard]] rocksdb::Status FindKeyRangeWithPrefix(const std::string &prefix, const std::string &prefix_end,
                                                        std::string *begin, std::string *end,
@@ -63,6 +94,9 @@ class Database {
   [[nodiscard]] rocksdb::Status GetSlotKeysInfo(int slot, std::map<int, uint64_t> *slotskeys,
                                                 std::vector<std::string> *keys, int count);
   [[nodiscard]] rocksdb::Status KeyExist(const std::string &key);
+  [[nodiscard]] rocksdb::Status Copy(const std::string &key, const std::string &new_key, bool nx, CopyResult *res);
+  [[nodiscard]] rocksdb::Status Sort(const std::string &key, const SortArgument &args,
+                                     std::vector<std::optional<std::string>> *elems, SortResult *res);
 
  protected:
   engine::Storage *storage_;
@@ -70,6 +104,9 @@ class Database {
   std::string namespace_;
 
   friend class LatestSnapShot;
+
+ private:
+  std::optional<std::string> lookupKeyByPattern(const std::string &pattern, const std::string &subst);
 };
 
 class LatestSnapShot {
@@ -92,6 +129,10 @@ class SubKeyScanner : public redis::Database {
   rocksdb::Status Scan(RedisType type, const Slice &user_key, const std::string &cursor, uint64_t limit,
                        const std::string &subkey_prefix, std::vector<std::string> *keys,
                        std::vector<std::string> *values = nullptr);
+  // Pick random sub keys with the semantics of the count argument of SRANDMEMBER, see util::RandomIndices.
+  // The sub keys (and values) are returned in the order of the picked indices, including the repeated ones.
+  rocksdb::Status RandomSubKeys(const std::string &ns_key, const Metadata &metadata, int64_t count,
+                                std::vector<std::string> *keys, std::vector<std::string> *values = nullptr);
 };
 
 class WriteBatchLogData {
@@ -289,7 +289,7 @@ int64_t Metadata::TTL() const {
     return -1;
   }
 
-  auto now = util::GetTimeStampMS();
+  auto now = util::GetExpireTimeStampMS();
   if (expire < now) {
     return -2;
   }
@@ -320,7 +320,7 @@ bool Metadata::IsEmptyableType() const {
   return IsSingleKVType() || Type() == kRedisStream || Type() == kRedisBloomFilter;
 }
 
-bool Metadata::Expired() const { return ExpireAt(util::GetTimeStampMS()); }
+bool Metadata::Expired() const { return ExpireAt(util::GetExpireTimeStampMS()); }
 
 ListMetadata::ListMetadata(bool generate_version)
     : Metadata(kRedisList, generate_version), head(UINT64_MAX / 2), tail(head) {}
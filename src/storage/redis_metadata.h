@@ -80,6 +80,19 @@ struct KeyNumStats {
   uint64_t avg_ttl = 0;
 };
 
+// BigKey is the size of a key, which is the length of the value for strings and
+// JSON, and the number of elements for other types.
+struct BigKey {
+  RedisType type;
+  std::string key;
+  uint64_t size;
+};
+
+struct BigKeyStats {
+  uint64_t n_scanned = 0;
+  std::vector<BigKey> big_keys;  // ordered by type and then the size descending
+};
+
 template <typename T = Slice>
 [[nodiscard]] std::tuple<T, T> ExtractNamespaceKey(Slice ns_key, bool slot_id_encoded);
 [[nodiscard]] std::string ComposeNamespaceKey(const Slice &ns, const Slice &key, bool slot_id_encoded);
@@ -38,12 +38,14 @@
 
 #include "compact_filter.h"
 #include "db_util.h"
+#include "encoding.h"
 #include "event_listener.h"
 #include "event_util.h"
 #include "redis_db.h"
 #include "redis_metadata.h"
 #include "rocksdb_crc32c.h"
 #include "server/server.h"
+#include "stats/tracing.h"
 #include "table_properties_collector.h"
 #include "time_util.h"
 #include "unique_fd.h"
@@ -56,6 +58,15 @@ const int64_t kIORateLimitMaxMb = 1024000;
 
 using rocksdb::Slice;
 
+// the last sequence number of a written batch, rocksdb puts the sequence number of the first update
+// into the fixed 64-bit little-endian header of the batch when writing it, and each update uses one
+static uint64_t lastSequenceOfBatch(const rocksdb::WriteBatch &batch) {
+  uint64_t seq = 0;
+  __builtin_memcpy(&seq, batch.Data().data(), sizeof(seq));
+  if constexpr (IsBigEndian()) seq = BitSwap(seq);
+  return seq + batch.Count() - 1;
+}
+
 Storage::Storage(Config *config)
     : backup_creating_time_(util::GetTimeStamp()), env_(rocksdb::Env::Default()), config_(config), lock_mgr_(16) {
   Metadata::InitVersionCounter();
@@ -508,6 +519,44 @@ void Storage::PurgeOldBackups(uint32_t num_backups_to_keep, uint32_t backup_max_
   }
 }
 
+void Storage::GetBackupInfo(int *backups, uint64_t *size, time_t *create_time) {
+  *backups = 0;
+  *size = 0;
+  *create_time = -1;
+
+  // 'backup_mu' isn't held since creating a backup may take long, the files are only inspected,
+  // and the result is the best effort if the backup is being created or purged at the same time
+  std::string task_backup_dir = config_->GetBackupDir();
+  std::vector<std::string> files;
+  if (!env_->GetChildren(task_backup_dir, &files).ok()) return;
+
+  // the backup is a checkpoint whose files are in the directory directly
+  for (const auto &file : files) {
+    uint64_t file_size = 0;
+    if (env_->GetFileSize(task_backup_dir + "/" + file, &file_size).ok()) *size += file_size;
+  }
+  // use the modification time of the backup instead of 'backup_creating_time_', which is
+  // unknown if the backup was created before the server started
+  uint64_t mtime = 0;
+  if (env_->GetFileModificationTime(task_backup_dir + "/CURRENT", &mtime).ok()) {
+    *backups = 1;
+    *create_time = static_cast<time_t>(mtime);
+  }
+}
+
+Status Storage::GetWALSize(uint64_t *size, uint64_t *files) {
+  rocksdb::VectorLogPtr wal_files;
+  auto s = db_->GetSortedWalFiles(wal_files);
+  if (!s.ok()) return {Status::NotOK, s.ToString()};
+
+  *size = 0;
+  *files = wal_files.size();
+  for (const auto &wal_file : wal_files) {
+    *size += wal_file->SizeFileBytes();
+  }
+  return Status::OK();
+}
+
 Status Storage::GetWALIter(rocksdb::SequenceNumber seq, std::unique_ptr<rocksdb::TransactionLogIterator> *iter) {
   auto s = db_->GetUpdatesSince(seq, iter);
   if (!s.ok()) return {Status::DBGetWALErr, s.ToString()};
@@ -525,6 +574,7 @@ rocksdb::Status Storage::Get(const rocksdb::ReadOptions &options, const rocksdb:
 
 rocksdb::Status Storage::Get(const rocksdb::ReadOptions &options, rocksdb::ColumnFamilyHandle *column_family,
                              const rocksdb::Slice &key, std::string *value) {
+  tracing::ScopedRead read;
   if (is_txn_mode_ && txn_write_batch_->GetWriteBatch()->Count() > 0) {
     return txn_write_batch_->GetFromBatchAndDB(db_.get(), options, column_family, key, value);
   }
@@ -538,6 +588,7 @@ rocksdb::Status Storage::Get(const rocksdb::ReadOptions &options, const rocksdb:
 
 rocksdb::Status Storage::Get(const rocksdb::ReadOptions &options, rocksdb::ColumnFamilyHandle *column_family,
                              const rocksdb::Slice &key, rocksdb::PinnableSlice *value) {
+  tracing::ScopedRead read;
   if (is_txn_mode_ && txn_write_batch_->GetWriteBatch()->Count() > 0) {
     return txn_write_batch_->GetFromBatchAndDB(db_.get(), options, column_family, key, value);
   }
@@ -560,6 +611,7 @@ rocksdb::Iterator *Storage::NewIterator(const rocksdb::ReadOptions &options,
 void Storage::MultiGet(const rocksdb::ReadOptions &options, rocksdb::ColumnFamilyHandle *column_family,
                        const size_t num_keys, const rocksdb::Slice *keys, rocksdb::PinnableSlice *values,
                        rocksdb::Status *statuses) {
+  tracing::ScopedRead read;
   if (is_txn_mode_ && txn_write_batch_->GetWriteBatch()->Count() > 0) {
     txn_write_batch_->MultiGetFromBatchAndDB(db_.get(), options, column_family, num_keys, keys, values, statuses,
                                              false);
@@ -586,7 +638,23 @@ rocksdb::Status Storage::writeToDB(const rocksdb::WriteOptions &options, rocksdb
     updates->PutLogData(ServerLogData(kReplIdLog, replid_).Encode());
   }
 
-  return db_->Write(options, updates);
+  tracing::Span span("rocksdb.write");
+  span.SetAttribute("kvrocks.batch.count", static_cast<int64_t>(updates->Count()));
+  span.SetAttribute("kvrocks.batch.size", static_cast<int64_t>(updates->GetDataSize()));
+  auto begin = options.sync ? util::GetTimeStampMS() : 0;
+  auto s = db_->Write(options, updates);
+  if (!s.ok()) span.SetError(s.ToString());
+  if (s.ok()) write_history_.Record(lastSequenceOfBatch(*updates), updates->GetDataSize(), util::GetTimeStampMS());
+  // the WAL is synced on each write with the sync option
+  if (options.sync) LatencyAddSampleIfNeeded("fsync", util::GetTimeStampMS() - begin);
+  return s;
+}
+
+void Storage::LatencyAddSampleIfNeeded(const std::string &event, uint64_t latency_ms) {
+  int threshold = config_->latency_monitor_threshold;
+  if (threshold > 0 && latency_ms >= static_cast<uint64_t>(threshold)) {
+    latency_monitor_.AddSample(event, latency_ms);
+  }
 }
 
 rocksdb::Status Storage::Delete(const rocksdb::WriteOptions &options, rocksdb::ColumnFamilyHandle *cf_handle,
@@ -637,6 +705,8 @@ Status Storage::ReplicaApplyWriteBatch(std::string &&raw_batch) {
   if (!s.ok()) {
     return {Status::NotOK, s.ToString()};
   }
+  // the replicas of the replica also need the history to estimate their lag
+  write_history_.Record(lastSequenceOfBatch(batch), batch.GetDataSize(), util::GetTimeStampMS());
 
   return Status::OK();
 }
@@ -38,6 +38,8 @@
 #include "config/config.h"
 #include "lock_manager.h"
 #include "observer_or_unique.h"
+#include "stats/latency_monitor.h"
+#include "stats/write_history.h"
 #include "status.h"
 
 const int kReplIdLength = 16;
@@ -138,6 +140,11 @@ class Storage {
   std::vector<rocksdb::ColumnFamilyHandle *> *GetCFHandles() { return &cf_handles_; }
   LockManager *GetLockManager() { return &lock_mgr_; }
   void PurgeOldBackups(uint32_t num_backups_to_keep, uint32_t backup_max_keep_hours);
+  // GetBackupInfo returns the number of retained backups, their size on disk, and the time when the latest
+  // one was created, the create time is -1 if there's no backup
+  void GetBackupInfo(int *backups, uint64_t *size, time_t *create_time);
+  // GetWALSize returns the size of the live and archived WAL files, which are retained for replication
+  Status GetWALSize(uint64_t *size, uint64_t *files);
   uint64_t GetTotalSize(const std::string &ns = kDefaultNamespace);
   void CheckDBSizeLimit();
   void SetIORateLimit(int64_t max_io_mb);
@@ -149,8 +156,20 @@ class Storage {
   void IncrFlushCount(uint64_t n) { flush_count_.fetch_add(n); }
   uint64_t GetCompactionCount() const { return compaction_count_; }
   void IncrCompactionCount(uint64_t n) { compaction_count_.fetch_add(n); }
+  // the lookups which found keys logically expired, the same key is counted again
+  // if it's accessed before being reclaimed by the compaction filter
+  uint64_t GetExpiredKeyLookups() const { return expired_key_lookups_; }
+  void IncrExpiredKeyLookups(uint64_t n) { expired_key_lookups_.fetch_add(n, std::memory_order_relaxed); }
+  uint64_t GetReclaimedExpiredKeys() const { return reclaimed_expired_keys_; }
+  void IncrReclaimedExpiredKeys(uint64_t n) { reclaimed_expired_keys_.fetch_add(n, std::memory_order_relaxed); }
+  uint64_t GetReclaimedSubkeys() const { return reclaimed_subkeys_; }
+  void IncrReclaimedSubkeys(uint64_t n) { reclaimed_subkeys_.fetch_add(n, std::memory_order_relaxed); }
   bool IsSlotIdEncoded() const { return config_->slot_id_encoded; }
   Config *GetConfig() const { return config_; }
+  LatencyMonitor *GetLatencyMonitor() { return &latency_monitor_; }
+  // LatencyAddSampleIfNeeded adds the sample of the event if the latency exceeds `latency-monitor-threshold`
+  void LatencyAddSampleIfNeeded(const std::string &event, uint64_t latency_ms);
+  WriteHistory *GetWriteHistory() { return &write_history_; }
 
   Status BeginTxn();
   Status CommitTxn();
@@ -216,6 +235,11 @@ class Storage {
   bool db_size_limit_reached_ = false;
   std::atomic<uint64_t> flush_count_{0};
   std::atomic<uint64_t> compaction_count_{0};
+  std::atomic<uint64_t> expired_key_lookups_{0};
+  std::atomic<uint64_t> reclaimed_expired_keys_{0};
+  std::atomic<uint64_t> reclaimed_subkeys_{0};
+  LatencyMonitor latency_monitor_;
+  WriteHistory write_history_;
 
   std::shared_mutex db_rw_lock_;
   bool db_closing_ = true;
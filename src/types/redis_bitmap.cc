@@ -66,6 +66,7 @@ rocksdb::Status Bitmap::GetMetadata(const Slice &ns_key, BitmapMetadata *metadat
   if (!s.ok()) return s;
 
   if (metadata->Expired()) {
+    storage_->IncrExpiredKeyLookups(1);
     // error discarded here since it already failed
     auto _ [[maybe_unused]] = metadata->Decode(old_metadata);
     return rocksdb::Status::NotFound(kErrMsgKeyExpired);
@@ -86,6 +86,28 @@ rocksdb::Status List::push(const Slice &user_key, const std::vector<Slice> &elem
   return storage_->Write(storage_->DefaultWriteOptions(), batch->GetWriteBatch());
 }
 
+rocksdb::Status List::Overwrite(const Slice &user_key, const std::vector<std::string> &elems) {
+  std::string ns_key = AppendNamespacePrefix(user_key);
+
+  LockGuard guard(storage_->GetLockManager(), ns_key);
+  ListMetadata metadata;
+  auto batch = storage_->GetWriteBatchBase();
+  WriteBatchLogData log_data(kRedisList, {std::to_string(kRedisCmdRPush)});
+  batch->PutLogData(log_data.Encode());
+  for (const auto &elem : elems) {
+    std::string index_buf;
+    PutFixed64(&index_buf, metadata.tail);
+    std::string sub_key = InternalKey(ns_key, index_buf, metadata.version, storage_->IsSlotIdEncoded()).Encode();
+    batch->Put(sub_key, elem);
+    metadata.tail++;
+  }
+  metadata.size = elems.size();
+  std::string bytes;
+  metadata.Encode(&bytes);
+  batch->Put(metadata_cf_handle_, ns_key, bytes);
+  return storage_->Write(storage_->DefaultWriteOptions(), batch->GetWriteBatch());
+}
+
 rocksdb::Status List::Pop(const Slice &user_key, bool left, std::string *elem) {
   elem->clear();
 
@@ -446,7 +468,7 @@ rocksdb::Status List::Pos(const Slice &user_key, const Slice &elem, const PosSpe
     offset++;
     !reversed ? iter->Next() : iter->Prev();
   }
-  return rocksdb::Status::OK();
+  return iter->status();
 }
 
 rocksdb::Status List::Set(const Slice &user_key, int index, Slice elem) {
@@ -20,6 +20,7 @@
 
 #include "redis_set.h"
 
+#include <algorithm>
 #include <map>
 #include <memory>
 #include <optional>
@@ -178,19 +179,27 @@ rocksdb::Status Set::MIsMember(const Slice &user_key, const std::vector<Slice> &
   rocksdb::Status s = GetMetadata(ns_key, &metadata);
   if (!s.ok()) return s;
 
-  rocksdb::ReadOptions read_options;
   LatestSnapShot ss(storage_);
+  rocksdb::ReadOptions read_options = storage_->DefaultMultiGetOptions();
   read_options.snapshot = ss.GetSnapShot();
-  std::string value;
+
+  // look up all members in one batch instead of one Get per member
+  std::vector<std::string> sub_keys;
+  sub_keys.reserve(members.size());
+  std::vector<rocksdb::Slice> keys;
+  keys.reserve(members.size());
   for (const auto &member : members) {
-    std::string sub_key = InternalKey(ns_key, member, metadata.version, storage_->IsSlotIdEncoded()).Encode();
-    s = storage_->Get(read_options, sub_key, &value);
-    if (!s.ok() && !s.IsNotFound()) return s;
-    if (s.IsNotFound()) {
-      exists->emplace_back(0);
-    } else {
-      exists->emplace_back(1);
-    }
+    sub_keys.emplace_back(InternalKey(ns_key, member, metadata.version, storage_->IsSlotIdEncoded()).Encode());
+    keys.emplace_back(sub_keys.back());
+  }
+
+  std::vector<rocksdb::PinnableSlice> values(keys.size());
+  std::vector<rocksdb::Status> statuses(keys.size());
+  storage_->MultiGet(read_options, storage_->GetDB()->DefaultColumnFamily(), keys.size(), keys.data(), values.data(),
+                     statuses.data());
+  for (const auto &status : statuses) {
+    if (!status.ok() && !status.IsNotFound()) return status;
+    exists->emplace_back(status.ok() ? 1 : 0);
   }
   return rocksdb::Status::OK();
 }
@@ -238,6 +247,17 @@ rocksdb::Status Set::Take(const Slice &user_key, std::vector<std::string> *membe
   return storage_->Write(storage_->DefaultWriteOptions(), batch->GetWriteBatch());
 }
 
+rocksdb::Status Set::RandMember(const Slice &user_key, int64_t command_count, std::vector<std::string> *members) {
+  members->clear();
+
+  std::string ns_key = AppendNamespacePrefix(user_key);
+  SetMetadata metadata(false);
+  rocksdb::Status s = GetMetadata(ns_key, &metadata);
+  if (!s.ok()) return s.IsNotFound() ? rocksdb::Status::OK() : s;
+
+  return RandomSubKeys(ns_key, metadata, command_count, members);
+}
+
 rocksdb::Status Set::Move(const Slice &src, const Slice &dst, const Slice &member, bool *flag) {
   RedisType type = kRedisNone;
   rocksdb::Status s = Type(dst, &type);
@@ -381,45 +401,55 @@ rocksdb::Status Set::Inter(const std::vector<Slice> &keys, std::vector<std::stri
 rocksdb::Status Set::InterCard(const std::vector<Slice> &keys, uint64_t limit, uint64_t *cardinality) {
   *cardinality = 0;
 
-  std::map<std::string, size_t> member_counters;
-  std::vector<std::string> target_members;
-
-  auto s = Members(keys[0], &target_members);
-  if (!s.ok() || target_members.empty()) return s;
-  for (const auto &member : target_members) {
-    member_counters[member] = 1;
-  }
-  if (limit == 0) {
-    limit = target_members.size();
+  std::vector<std::string> ns_keys;
+  std::vector<SetMetadata> metadatas;
+  for (const auto &key : keys) {
+    ns_keys.emplace_back(AppendNamespacePrefix(key));
+    metadatas.emplace_back(false);
+    auto s = GetMetadata(ns_keys.back(), &metadatas.back());
+    // the intersection is empty if any set doesn't exist
+    if (!s.ok()) return s.IsNotFound() ? rocksdb::Status::OK() : s;
   }
 
-  size_t keys_size = keys.size();
-  if (keys_size == 1) {
-    *cardinality = std::min(static_cast<uint64_t>(target_members.size()), limit);
-    return rocksdb::Status::OK();
+  // the members of the smallest set are looked up in the other sets one by one,
+  // so neither the sets nor the intersection are loaded into memory
+  size_t smallest = 0;
+  for (size_t i = 1; i < metadatas.size(); i++) {
+    if (metadatas[i].size < metadatas[smallest].size) smallest = i;
   }
 
-  bool limit_reached = false;
-  for (size_t i = 1; i < keys_size; i++) {
-    s = Members(keys[i], &target_members);
-    if (!s.ok() || target_members.empty()) {
-      return s;
-    }
+  LatestSnapShot ss(storage_);
+  rocksdb::ReadOptions read_options;
+  read_options.snapshot = ss.GetSnapShot();
 
-    for (const auto &member : target_members) {
-      auto iter = member_counters.find(member);
-      if (iter == member_counters.end()) continue;
-      if (++iter->second == keys_size) {
-        *cardinality += 1;
-        if (--limit == 0) {
-          limit_reached = true;
-          break;
-        }
-      }
+  const auto &metadata = metadatas[smallest];
+  std::string prefix = InternalKey(ns_keys[smallest], "", metadata.version, storage_->IsSlotIdEncoded()).Encode();
+  std::string next_version_prefix =
+      InternalKey(ns_keys[smallest], "", metadata.version + 1, storage_->IsSlotIdEncoded()).Encode();
+  rocksdb::ReadOptions scan_options = storage_->DefaultScanOptions();
+  scan_options.snapshot = ss.GetSnapShot();
+  rocksdb::Slice upper_bound(next_version_prefix);
+  scan_options.iterate_upper_bound = &upper_bound;
+
+  std::string value;
+  auto iter = util::UniqueIterator(storage_, scan_options);
+  for (iter->Seek(prefix); iter->Valid() && iter->key().starts_with(prefix); iter->Next()) {
+    InternalKey ikey(iter->key(), storage_->IsSlotIdEncoded());
+    bool in_all_sets = true;
+    for (size_t i = 0; i < keys.size() && in_all_sets; i++) {
+      if (i == smallest) continue;
+      std::string sub_key = InternalKey(ns_keys[i], ikey.GetSubKey(), metadatas[i].version,
+                                        storage_->IsSlotIdEncoded()).Encode();
+      auto s = storage_->Get(read_options, sub_key, &value);
+      if (!s.ok() && !s.IsNotFound()) return s;
+      in_all_sets = s.ok();
     }
+    if (!in_all_sets) continue;
 
-    if (limit_reached) break;
+    *cardinality += 1;
+    if (limit != 0 && *cardinality >= limit) break;
   }
+  if (!iter->status().ok()) return iter->status();
 
   return rocksdb::Status::OK();
 }
@@ -40,6 +40,7 @@ class Set : public SubKeyScanner {
   rocksdb::Status Members(const Slice &user_key, std::vector<std::string> *members);
   rocksdb::Status Move(const Slice &src, const Slice &dst, const Slice &member, bool *flag);
   rocksdb::Status Take(const Slice &user_key, std::vector<std::string> *members, int count, bool pop);
+  rocksdb::Status RandMember(const Slice &user_key, int64_t command_count, std::vector<std::string> *members);
   rocksdb::Status Diff(const std::vector<Slice> &keys, std::vector<std::string> *members);
   rocksdb::Status Union(const std::vector<Slice> &keys, std::vector<std::string> *members);
   rocksdb::Status Inter(const std::vector<Slice> &keys, std::vector<std::string> *members);
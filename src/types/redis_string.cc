@@ -20,6 +20,7 @@
 
 #include "redis_string.h"
 
+#include <algorithm>
 #include <cmath>
 #include <cstddef>
 #include <cstdint>
@@ -55,6 +56,7 @@ std::vector<rocksdb::Status> String::getRawValues(const std::vector<Slice> &keys
       continue;
     }
     if (metadata.Expired()) {
+      storage_->IncrExpiredKeyLookups(1);
       (*raw_values)[i].clear();
       statuses[i] = rocksdb::Status::NotFound(kErrMsgKeyExpired);
       continue;
@@ -81,6 +83,7 @@ rocksdb::Status String::getRawValue(const std::string &ns_key, std::string *raw_
   s = metadata.Decode(*raw_value);
   if (!s.ok()) return s;
   if (metadata.Expired()) {
+    storage_->IncrExpiredKeyLookups(1);
     raw_value->clear();
     return rocksdb::Status::NotFound(kErrMsgKeyExpired);
   }
@@ -159,7 +162,7 @@ rocksdb::Status String::Get(const std::string &user_key, std::string *value) {
 rocksdb::Status String::GetEx(const std::string &user_key, std::string *value, uint64_t ttl, bool persist) {
   uint64_t expire = 0;
   if (ttl > 0) {
-    uint64_t now = util::GetTimeStampMS();
+    uint64_t now = util::GetExpireTimeStampMS();
     expire = now + ttl;
   }
   std::string ns_key = AppendNamespacePrefix(user_key);
@@ -209,7 +212,11 @@ rocksdb::Status String::GetDel(const std::string &user_key, std::string *value)
   rocksdb::Status s = getValue(ns_key, value);
   if (!s.ok()) return s;
 
-  return storage_->Delete(storage_->DefaultWriteOptions(), metadata_cf_handle_, ns_key);
+  auto batch = storage_->GetWriteBatchBase();
+  WriteBatchLogData log_data(kRedisString);
+  batch->PutLogData(log_data.Encode());
+  batch->Delete(metadata_cf_handle_, ns_key);
+  return storage_->Write(storage_->DefaultWriteOptions(), batch->GetWriteBatch());
 }
 
 rocksdb::Status String::Set(const std::string &user_key, const std::string &value) {
@@ -232,7 +239,7 @@ rocksdb::Status String::SetXX(const std::string &user_key, const std::string &va
   int exists = 0;
   uint64_t expire = 0;
   if (ttl > 0) {
-    uint64_t now = util::GetTimeStampMS();
+    uint64_t now = util::GetExpireTimeStampMS();
     expire = now + ttl;
   }
 
@@ -364,7 +371,7 @@ rocksdb::Status String::IncrByFloat(const std::string &user_key, double incremen
 rocksdb::Status String::MSet(const std::vector<StringPair> &pairs, uint64_t ttl, bool lock) {
   uint64_t expire = 0;
   if (ttl > 0) {
-    uint64_t now = util::GetTimeStampMS();
+    uint64_t now = util::GetExpireTimeStampMS();
     expire = now + ttl;
   }
 
@@ -454,7 +461,7 @@ rocksdb::Status String::CAS(const std::string &user_key, const std::string &old_
     uint64_t expire = 0;
     Metadata metadata(kRedisString, false);
     if (ttl > 0) {
-      uint64_t now = util::GetTimeStampMS();
+      uint64_t now = util::GetExpireTimeStampMS();
       expire = now + ttl;
     }
     metadata.expire = expire;
@@ -502,4 +509,104 @@ rocksdb::Status String::CAD(const std::string &user_key, const std::string &valu
   return rocksdb::Status::OK();
 }
 
+rocksdb::Status String::LCS(const std::string &user_key1, const std::string &user_key2, StringLCSArgs args,
+                            StringLCSResult *rst) {
+  // The dp table below takes (alen+1)*(blen+1) cells, so refuse inputs that would allocate too much
+  static constexpr uint64_t kMaxLCSTableSize = 512ULL * 1024 * 1024;
+
+  std::string a;
+  std::string b;
+  std::string ns_key1 = AppendNamespacePrefix(user_key1);
+  std::string ns_key2 = AppendNamespacePrefix(user_key2);
+  auto s1 = getValue(ns_key1, &a);
+  auto s2 = getValue(ns_key2, &b);
+
+  // Missing keys are treated as empty strings
+  if (!s1.ok() && !s1.IsNotFound()) return s1;
+  if (!s2.ok() && !s2.IsNotFound()) return s2;
+
+  auto alen = static_cast<uint32_t>(a.size());
+  auto blen = static_cast<uint32_t>(b.size());
+  if ((static_cast<uint64_t>(alen) + 1) * (static_cast<uint64_t>(blen) + 1) * sizeof(uint32_t) > kMaxLCSTableSize) {
+    return rocksdb::Status::InvalidArgument("Insufficient memory, transient memory for LCS exceeds 512MB");
+  }
+
+  // dp[i][j] is the length of the LCS between a[0, i) and b[0, j)
+  std::vector<uint32_t> dp((static_cast<size_t>(alen) + 1) * (static_cast<size_t>(blen) + 1), 0);
+  auto lcs = [&dp, blen](uint32_t i, uint32_t j) -> uint32_t & { return dp[i * (static_cast<size_t>(blen) + 1) + j]; };
+  for (uint32_t i = 1; i <= alen; i++) {
+    for (uint32_t j = 1; j <= blen; j++) {
+      if (a[i - 1] == b[j - 1]) {
+        lcs(i, j) = lcs(i - 1, j - 1) + 1;
+      } else {
+        lcs(i, j) = std::max(lcs(i - 1, j), lcs(i, j - 1));
+      }
+    }
+  }
+
+  uint32_t idx = lcs(alen, blen);
+  if (args.type == StringLCSType::LEN) {
+    *rst = idx;
+    return rocksdb::Status::OK();
+  }
+
+  std::string result;
+  StringLCSIdxResult idx_result{{}, idx};
+  if (args.type == StringLCSType::NONE) {
+    result.resize(idx);
+  }
+
+  // Walk the dp table backwards to reconstruct the LCS and the matched ranges,
+  // alen is used as the sentinel of "no range in progress"
+  uint32_t i = alen, j = blen;
+  uint32_t arange_start = alen, arange_end = 0, brange_start = 0, brange_end = 0;
+  while (i > 0 && j > 0) {
+    bool emit_range = false;
+    if (a[i - 1] == b[j - 1]) {
+      if (args.type == StringLCSType::NONE) {
+        result[idx - 1] = a[i - 1];
+      }
+      if (arange_start == alen) {
+        arange_start = i - 1;
+        arange_end = i - 1;
+        brange_start = j - 1;
+        brange_end = j - 1;
+      } else if (arange_start == i && brange_start == j) {
+        // Extend the range backward since it's contiguous
+        arange_start--;
+        brange_start--;
+      } else {
+        emit_range = true;
+      }
+      // Emit the range once we reach the first byte of either string
+      if (arange_start == 0 || brange_start == 0) emit_range = true;
+      idx--;
+      i--;
+      j--;
+    } else {
+      if (lcs(i - 1, j) > lcs(i, j - 1)) {
+        i--;
+      } else {
+        j--;
+      }
+      if (arange_start != alen) emit_range = true;
+    }
+
+    if (emit_range) {
+      uint32_t match_len = arange_end - arange_start + 1;
+      if (args.type == StringLCSType::IDX && static_cast<int64_t>(match_len) >= args.min_match_len) {
+        idx_result.matches.push_back({{arange_start, arange_end}, {brange_start, brange_end}, match_len});
+      }
+      arange_start = alen;
+    }
+  }
+
+  if (args.type == StringLCSType::NONE) {
+    *rst = std::move(result);
+  } else {
+    *rst = std::move(idx_result);
+  }
+  return rocksdb::Status::OK();
+}
+
 }  // namespace redis
@@ -22,6 +22,7 @@
 
 #include <cstdint>
 #include <string>
+#include <variant>
 #include <vector>
 
 #include "storage/redis_db.h"
@@ -32,6 +33,32 @@ struct StringPair {
   Slice value;
 };
 
+enum class StringLCSType { NONE, LEN, IDX };
+
+struct StringLCSArgs {
+  StringLCSType type;
+  int64_t min_match_len;
+};
+
+struct StringLCSRange {
+  uint32_t start;
+  uint32_t end;
+};
+
+struct StringLCSMatchedRange {
+  StringLCSRange a;
+  StringLCSRange b;
+  uint32_t match_len;
+};
+
+struct StringLCSIdxResult {
+  std::vector<StringLCSMatchedRange> matches;
+  uint32_t len;
+};
+
+// The matched string for LCS without options, its length for LEN, and the matched ranges for IDX
+using StringLCSResult = std::variant<std::string, uint32_t, StringLCSIdxResult>;
+
 namespace redis {
 
 class String : public Database {
@@ -55,6 +82,8 @@ class String : public Database {
   rocksdb::Status CAS(const std::string &user_key, const std::string &old_value, const std::string &new_value,
                       uint64_t ttl, int *flag);
   rocksdb::Status CAD(const std::string &user_key, const std::string &value, int *flag);
+  rocksdb::Status LCS(const std::string &user_key1, const std::string &user_key2, StringLCSArgs args,
+                      StringLCSResult *rst);
 
  private:
   rocksdb::Status getValue(const std::string &ns_key, std::string *value);
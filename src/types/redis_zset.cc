@@ -20,6 +20,7 @@
 
 #include "redis_zset.h"
 
+#include <algorithm>
 #include <cmath>
 #include <limits>
 #include <map>
@@ -90,6 +91,9 @@ rocksdb::Status ZSet::Add(const Slice &user_key, ZAddFlags flags, MemberScores *
               InternalKey(ns_key, new_score_bytes, metadata.version, storage_->IsSlotIdEncoded()).Encode();
           batch->Put(score_cf_handle_, new_score_key, Slice());
           changed++;
+        } else if (flags.HasIncr()) {
+          // an increment by zero is still applied, so it's counted as changed
+          changed++;
         }
         continue;
       }
@@ -805,4 +809,23 @@ rocksdb::Status ZSet::MGet(const Slice &user_key, const std::vector<Slice> &memb
   return rocksdb::Status::OK();
 }
 
+rocksdb::Status ZSet::RandMember(const Slice &user_key, int64_t command_count, MemberScores *mscores) {
+  mscores->clear();
+
+  std::string ns_key = AppendNamespacePrefix(user_key);
+  ZSetMetadata metadata(false);
+  rocksdb::Status s = GetMetadata(ns_key, &metadata);
+  if (!s.ok()) return s;
+
+  std::vector<std::string> members, scores;
+  s = RandomSubKeys(ns_key, metadata, command_count, &members, &scores);
+  if (!s.ok()) return s;
+
+  mscores->reserve(members.size());
+  for (size_t i = 0; i < members.size(); i++) {
+    mscores->emplace_back(MemberScore{std::move(members[i]), DecodeDouble(scores[i].data())});
+  }
+  return rocksdb::Status::OK();
+}
+
 }  // namespace redis
@@ -116,6 +116,7 @@ class ZSet : public SubKeyScanner {
   rocksdb::Status Union(const std::vector<KeyWeight> &keys_weights, AggregateMethod aggregate_method,
                         std::vector<MemberScore> *members);
   rocksdb::Status MGet(const Slice &user_key, const std::vector<Slice> &members, std::map<std::string, double> *scores);
+  rocksdb::Status RandMember(const Slice &user_key, int64_t command_count, MemberScores *mscores);
   rocksdb::Status GetMetadata(const Slice &ns_key, ZSetMetadata *metadata);
 
   rocksdb::Status Count(const Slice &user_key, const RangeScoreSpec &spec, uint64_t *size);
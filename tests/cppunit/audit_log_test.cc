@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "server/audit_log.h"
+
+#include <gtest/gtest.h>
+#include <unistd.h>
+
+#include <fstream>
+#include <iterator>
+
+TEST(AuditLog, IsAuditedCommand) {
+  EXPECT_TRUE(AuditLog::IsAuditedCommand("flushall", {"FLUSHALL"}));
+  EXPECT_TRUE(AuditLog::IsAuditedCommand("config", {"CONFIG", "SET", "maxclients", "100"}));
+  EXPECT_TRUE(AuditLog::IsAuditedCommand("namespace", {"namespace", "del", "ns1"}));
+  EXPECT_TRUE(AuditLog::IsAuditedCommand("failover", {"FAILOVER", "ABORT"}));
+  EXPECT_FALSE(AuditLog::IsAuditedCommand("config", {"CONFIG", "GET", "maxclients"}));
+  EXPECT_FALSE(AuditLog::IsAuditedCommand("namespace", {"namespace", "Get", "*"}));
+  EXPECT_FALSE(AuditLog::IsAuditedCommand("get", {"get", "key"}));
+}
+
+TEST(AuditLog, FormatEntry) {
+  EXPECT_EQ(AuditLog::FormatEntry(1700000000000123, "__namespace", "127.0.0.1:6666", 3, "admin", true,
+                                  {"FLUSHALL"}),
+            R"x(1700000000.000123 [__namespace 127.0.0.1:6666] id=3 name=admin result=ok "FLUSHALL")x");
+  EXPECT_EQ(AuditLog::FormatEntry(1700000000000000, "__namespace", "127.0.0.1:6666", 3, "", false,
+                                  {"config", "set", "maxclients", "100", "requirepass", "secret", "masterauth", "x"}),
+            R"x(1700000000.000000 [__namespace 127.0.0.1:6666] id=3 name= result=err "config" "set" "maxclients" )x"
+            R"x("100" "requirepass" "(redacted)" "masterauth" "(redacted)")x");
+  EXPECT_EQ(AuditLog::FormatEntry(1700000000000000, "__namespace", "127.0.0.1:6666", 3, "", true,
+                                  {"namespace", "add", "ns1", "token1"}),
+            R"x(1700000000.000000 [__namespace 127.0.0.1:6666] id=3 name= result=ok "namespace" "add" "ns1" )x"
+            R"x("(redacted)")x");
+}
+
+TEST(AuditLog, Write) {
+  std::string path = "/tmp/kvrocks_audit_log_test.log";
+  unlink(path.c_str());
+  AuditLog audit_log;
+  EXPECT_FALSE(audit_log.IsEnabled());
+  ASSERT_TRUE(audit_log.Open(path).IsOK());
+  EXPECT_TRUE(audit_log.IsEnabled());
+  audit_log.Write("entry1");
+  audit_log.Write("entry2");
+  ASSERT_TRUE(audit_log.Open("").IsOK());
+  EXPECT_FALSE(audit_log.IsEnabled());
+  EXPECT_FALSE(audit_log.Open("/not-exist-dir/audit.log").IsOK());
+
+  std::ifstream file(path);
+  std::string content((std::istreambuf_iterator<char>(file)), std::istreambuf_iterator<char>());
+  EXPECT_EQ(content, "entry1\nentry2\n");
+  unlink(path.c_str());
+}
@@ -109,6 +109,7 @@ TEST(Config, GetAndSet) {
       {"daemonize", "yes"},
       {"bind", "0.0.0.0"},
       {"repl-bind", "0.0.0.0"},
+      {"metrics-bind", "0.0.0.0"},
       {"repl-workers", "8"},
       {"tcp-backlog", "500"},
       {"slaveof", "no one"},
@@ -116,6 +117,7 @@ TEST(Config, GetAndSet) {
       {"dir", "test_dir"},
       {"pidfile", "test.pid"},
       {"supervised", "no"},
+      {"log-format", "json"},
       {"rocksdb.block_size", "1234"},
       {"rocksdb.max_background_flushes", "-1"},
       {"rocksdb.wal_ttl_seconds", "10000"},
@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "stats/hot_keys.h"
+
+#include <gtest/gtest.h>
+
+TEST(HotKeys, GetTop) {
+  HotKeys hot_keys(3, 1000);
+  EXPECT_TRUE(hot_keys.GetTop("ns", 10, 1000).empty());
+
+  for (int i = 0; i < 100; i++) {
+    hot_keys.Record("ns", "hot", 1000 + i);
+    if (i % 2 == 0) hot_keys.Record("ns", "warm", 1000 + i);
+    hot_keys.Record("ns", "cold" + std::to_string(i), 1000 + i);
+    hot_keys.Record("other", "hot", 1000 + i);
+  }
+  // the keys are reported only after the window is completed
+  EXPECT_TRUE(hot_keys.GetTop("ns", 10, 1999).empty());
+
+  auto top = hot_keys.GetTop("ns", 10, 2000);
+  ASSERT_EQ(top.size(), 2);
+  EXPECT_EQ(top[0].key, "hot");
+  EXPECT_EQ(top[0].qps, 100);
+  EXPECT_EQ(top[1].key, "warm");
+  EXPECT_EQ(top[1].qps, 50);
+  ASSERT_EQ(hot_keys.GetTop("ns", 1, 2500).size(), 1);
+  ASSERT_EQ(hot_keys.GetTop("other", 10, 2500).size(), 1);
+
+  // the keys are gone if there is no access in the last window
+  EXPECT_TRUE(hot_keys.GetTop("ns", 10, 3000).empty());
+
+  hot_keys.Record("ns", "hot", 3000);
+  hot_keys.Reset();
+  EXPECT_TRUE(hot_keys.GetTop("ns", 10, 4000).empty());
+}
+
+TEST(HotKeys, MergeShards) {
+  HotKeys hot_keys(10, 1000, 4);
+  // the keys are spread over the shards, and the hottest ones of all shards are reported
+  for (int i = 1; i <= 50; i++) {
+    for (int j = 0; j < i; j++) {
+      hot_keys.Record("ns", "key" + std::to_string(i), 1000 + j);
+    }
+  }
+
+  auto top = hot_keys.GetTop("ns", 20, 2000);
+  ASSERT_EQ(top.size(), 10);
+  for (int i = 0; i < 10; i++) {
+    EXPECT_EQ(top[i].key, "key" + std::to_string(50 - i));
+    EXPECT_EQ(top[i].qps, 50 - i);
+  }
+}
@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "stats/key_access.h"
+
+#include <gtest/gtest.h>
+
+TEST(KeyAccessTracker, IdleTime) {
+  KeyAccessTracker tracker(2, 1);
+  tracker.Reset(1000);
+  // the keys without accesses are idle since the tracking started
+  EXPECT_EQ(tracker.IdleTime("ns", "a", 5000), 4000);
+
+  tracker.Record("ns", "a", 2000);
+  tracker.Record("ns", "b", 3000);
+  EXPECT_EQ(tracker.IdleTime("ns", "a", 5000), 3000);
+  EXPECT_EQ(tracker.IdleTime("ns", "b", 5000), 2000);
+  EXPECT_EQ(tracker.IdleTime("other", "a", 5000), 4000);
+
+  tracker.Record("ns", "a", 4000);
+  EXPECT_EQ(tracker.IdleTime("ns", "a", 5000), 1000);
+
+  // "b" is the least recently accessed key, so it's evicted and its idle time isn't overestimated
+  tracker.Record("ns", "c", 4500);
+  EXPECT_EQ(tracker.IdleTime("ns", "b", 5000), 2000);
+  EXPECT_EQ(tracker.IdleTime("ns", "a", 5000), 1000);
+  EXPECT_EQ(tracker.IdleTime("ns", "c", 5000), 500);
+
+  tracker.Reset(6000);
+  EXPECT_EQ(tracker.IdleTime("ns", "a", 7000), 1000);
+}
+
+TEST(KeyAccessTracker, Frequency) {
+  KeyAccessTracker tracker;
+  tracker.Reset(0);
+  EXPECT_EQ(tracker.Frequency("ns", "a", 0), 0);
+
+  tracker.Record("ns", "a", 0);
+  EXPECT_EQ(tracker.Frequency("ns", "a", 0), 6);
+
+  for (int i = 0; i < 1000; i++) {
+    tracker.Record("ns", "hot", 0);
+  }
+  auto hot = tracker.Frequency("ns", "hot", 0);
+  // the counter is logarithmic, so it grows slowly
+  EXPECT_GT(hot, 10);
+  EXPECT_LT(hot, 30);
+
+  // the counter decays by one every minute without accesses
+  EXPECT_EQ(tracker.Frequency("ns", "hot", 60 * 1000), hot - 1);
+  EXPECT_EQ(tracker.Frequency("ns", "hot", 5 * 60 * 1000 + 1), hot - 5);
+  EXPECT_EQ(tracker.Frequency("ns", "a", 60 * 60 * 1000), 0);
+}
+
+TEST(KeyAccessTracker, Touch) {
+  KeyAccessTracker tracker;
+  tracker.Reset(1000);
+  // only the accesses of the tracked keys are recorded
+  EXPECT_FALSE(tracker.Touch("ns", "a", 2000));
+  EXPECT_EQ(tracker.Frequency("ns", "a", 2000), 0);
+  EXPECT_EQ(tracker.IdleTime("ns", "a", 3000), 2000);
+
+  tracker.Record("ns", "a", 2000);
+  EXPECT_TRUE(tracker.Touch("ns", "a", 2500));
+  EXPECT_EQ(tracker.IdleTime("ns", "a", 3000), 500);
+  EXPECT_GT(tracker.Frequency("ns", "a", 3000), 5);
+}
@@ -0,0 +1,62 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "stats/latency_monitor.h"
+
+#include <gtest/gtest.h>
+
+TEST(LatencyMonitor, AddSample) {
+  LatencyMonitor monitor;
+  EXPECT_TRUE(monitor.GetLatest().empty());
+  EXPECT_TRUE(monitor.GetHistory("command").empty());
+
+  // samples in the same second are merged into the max one
+  monitor.AddSample("command", 10);
+  monitor.AddSample("command", 30);
+  monitor.AddSample("command", 20);
+  monitor.AddSample("fsync", 5);
+
+  auto latest = monitor.GetLatest();
+  ASSERT_EQ(latest.size(), 2);
+  EXPECT_EQ(latest[0].event, "command");
+  EXPECT_GT(latest[0].latest.time, 0);
+  EXPECT_EQ(latest[0].latest.latency_ms, 30);
+  EXPECT_EQ(latest[0].max_latency_ms, 30);
+  EXPECT_EQ(latest[1].event, "fsync");
+  EXPECT_EQ(latest[1].latest.latency_ms, 5);
+
+  auto history = monitor.GetHistory("command");
+  ASSERT_EQ(history.size(), 1);
+  EXPECT_EQ(history[0].latency_ms, 30);
+}
+
+TEST(LatencyMonitor, Reset) {
+  LatencyMonitor monitor;
+  monitor.AddSample("command", 10);
+  monitor.AddSample("fsync", 10);
+  monitor.AddSample("bgsave", 10);
+
+  EXPECT_EQ(monitor.Reset({"fsync", "unknown"}), 1);
+  EXPECT_TRUE(monitor.GetHistory("fsync").empty());
+  EXPECT_EQ(monitor.GetLatest().size(), 2);
+
+  EXPECT_EQ(monitor.Reset({}), 2);
+  EXPECT_TRUE(monitor.GetLatest().empty());
+}
@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "log_util.h"
+
+#include <dirent.h>
+#include <gtest/gtest.h>
+#include <sys/stat.h>
+#include <unistd.h>
+
+#include <string>
+#include <vector>
+
+TEST(LogUtil, FormatJsonLogLine) {
+  // 2023-11-14T22:13:20.000123Z
+  int64_t ts = 1700000000000123;
+  EXPECT_EQ(util::FormatJsonLogLine("INFO", ts, "worker", "server.cc", 42, "[replication] Connected to the master"),
+            R"({"ts":"2023-11-14T22:13:20.000123Z","level":"INFO","thread":"worker","component":"replication",)"
+            R"("msg":"Connected to the master","fields":{"file":"server.cc","line":42}})");
+
+  // the component falls back to the file name without the tag
+  EXPECT_EQ(util::FormatJsonLogLine("WARNING", ts, "kvrocks", "storage.cc", 7, "Failed to open"),
+            R"({"ts":"2023-11-14T22:13:20.000123Z","level":"WARNING","thread":"kvrocks","component":"storage",)"
+            R"("msg":"Failed to open","fields":{"file":"storage.cc","line":7}})");
+}
+
+TEST(LogUtil, FormatJsonLogLineEscape) {
+  auto line = util::FormatJsonLogLine("ERROR", 0, "t", "a.cc", 1, "quote\" backslash\\ newline\n tab\t ctrl\x01");
+  EXPECT_EQ(line, R"({"ts":"1970-01-01T00:00:00.000000Z","level":"ERROR","thread":"t","component":"a",)"
+                  R"("msg":"quote\" backslash\\ newline\n tab\t ctrl\u0001","fields":{"file":"a.cc","line":1}})");
+
+  // a bracket which isn't a tag is kept in the message
+  line = util::FormatJsonLogLine("INFO", 0, "t", "a.cc", 1, "[] empty tag");
+  EXPECT_NE(line.find(R"("component":"a","msg":"[] empty tag")"), std::string::npos);
+}
+
+TEST(LogUtil, ParseLogComponentVerbosity) {
+  auto empty = util::ParseLogComponentVerbosity("");
+  ASSERT_TRUE(empty.IsOK());
+  EXPECT_TRUE(empty->empty());
+
+  auto verbosity = util::ParseLogComponentVerbosity("replication:2, Migration rocksdb:0");
+  ASSERT_TRUE(verbosity.IsOK());
+  std::map<std::string, int> expected = {{"replication", 2}, {"migration", 1}, {"rocksdb", 0}};
+  EXPECT_EQ(*verbosity, expected);
+
+  EXPECT_FALSE(util::ParseLogComponentVerbosity("server").IsOK());
+  EXPECT_FALSE(util::ParseLogComponentVerbosity("replication:x").IsOK());
+  EXPECT_FALSE(util::ParseLogComponentVerbosity("replication:10").IsOK());
+  EXPECT_FALSE(util::ParseLogComponentVerbosity("replication:-1").IsOK());
+}
+
+static std::vector<std::string> listLogFiles(const std::string &dir) {
+  std::vector<std::string> files;
+  DIR *d = opendir(dir.c_str());
+  while (auto entry = readdir(d)) {
+    std::string name = entry->d_name;
+    if (name != "." && name != "..") files.emplace_back(name);
+  }
+  closedir(d);
+  return files;
+}
+
+TEST(LogUtil, JsonLogSinkRotate) {
+  std::string dir = "/tmp/kvrocks_json_log_test";
+  mkdir(dir.c_str(), 0755);
+  for (const auto &name : listLogFiles(dir)) unlink((dir + "/" + name).c_str());
+
+  {
+    auto sink = util::JsonLogSink::Open(dir, 100);
+    ASSERT_TRUE(sink.IsOK());
+    std::string line(60, 'x');
+    line.push_back('\n');
+    // the log file is renamed once it reaches the max size
+    for (int i = 0; i < 3; i++) (*sink)->WriteLine(line);
+  }
+  auto files = listLogFiles(dir);
+  ASSERT_EQ(files.size(), 2);
+  struct stat st {};
+  ASSERT_EQ(stat((dir + "/kvrocks.json.log").c_str(), &st), 0);
+  EXPECT_EQ(st.st_size, 61);
+
+  {
+    // the renamed files are removed after the retention days
+    util::JsonLogSink::SetRetentionDays(0);
+    auto sink = util::JsonLogSink::Open(dir, 100);
+    ASSERT_TRUE(sink.IsOK());
+    (*sink)->WriteLine(std::string(60, 'x') + "\n");
+    util::JsonLogSink::SetRetentionDays(-1);
+  }
+  files = listLogFiles(dir);
+  ASSERT_EQ(files.size(), 1);
+  EXPECT_EQ(files[0], "kvrocks.json.log");
+  ASSERT_EQ(stat((dir + "/kvrocks.json.log").c_str(), &st), 0);
+  EXPECT_EQ(st.st_size, 0);
+
+  unlink((dir + "/kvrocks.json.log").c_str());
+  rmdir(dir.c_str());
+}
@@ -0,0 +1,50 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "stats/prometheus.h"
+
+#include <gtest/gtest.h>
+
+TEST(Prometheus, MetricsFromInfo) {
+  std::string info =
+      "# Server\r\nredis_version:4.0.0\r\nuptime_in_seconds:12\r\n\r\n"
+      "# Commandstats\r\ncmdstat_get:calls=2,usec=10,usec_per_call=5.00,rejected_calls=0,failed_calls=1\r\n\r\n"
+      "# Errorstats\r\nerrorstat_WRONGTYPE:count=1\r\n\r\n"
+      "# Keyspace\r\ndb0:keys=3,expires=0,avg_ttl=0,expired=0\r\n\r\n"
+      "# RocksDB\r\nestimate_keys[default]:5\r\n";
+  std::string expected =
+      "kvrocks_uptime_in_seconds 12\n"
+      "kvrocks_commandstats_calls{cmd=\"get\"} 2\n"
+      "kvrocks_commandstats_usec{cmd=\"get\"} 10\n"
+      "kvrocks_commandstats_usec_per_call{cmd=\"get\"} 5.00\n"
+      "kvrocks_commandstats_rejected_calls{cmd=\"get\"} 0\n"
+      "kvrocks_commandstats_failed_calls{cmd=\"get\"} 1\n"
+      "kvrocks_errorstats_count{error=\"WRONGTYPE\"} 1\n"
+      "kvrocks_db0_keys 3\n"
+      "kvrocks_db0_expires 0\n"
+      "kvrocks_db0_avg_ttl 0\n"
+      "kvrocks_db0_expired 0\n"
+      "kvrocks_estimate_keys{cf=\"default\"} 5\n";
+  EXPECT_EQ(PrometheusMetricsFromInfo(info), expected);
+}
+
+TEST(Prometheus, EscapeLabelValue) {
+  EXPECT_EQ(PrometheusMetricsFromInfo("namespace_a\"b:keys=1\r\n"), "kvrocks_namespace_keys{namespace=\"a\\\"b\"} 1\n");
+}
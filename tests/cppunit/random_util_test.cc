@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "random_util.h"
+
+#include <gtest/gtest.h>
+
+#include <map>
+#include <set>
+
+TEST(RandomUtil, DistinctIndices) {
+  ASSERT_TRUE(util::RandomIndices(0, 10).empty());
+  ASSERT_TRUE(util::RandomIndices(10, 0).empty());
+
+  for (int64_t count : {1, 5, 9, 10, 20}) {
+    auto indices = util::RandomIndices(10, count);
+    ASSERT_EQ(indices.size(), std::min<uint64_t>(count, 10));
+    std::set<uint64_t> distinct(indices.begin(), indices.end());
+    ASSERT_EQ(distinct.size(), indices.size());
+    for (auto index : indices) {
+      ASSERT_LT(index, 10);
+    }
+  }
+
+  ASSERT_EQ(util::RandomIndices(3, 3), std::vector<uint64_t>({0, 1, 2}));
+}
+
+TEST(RandomUtil, RepeatedIndices) {
+  auto indices = util::RandomIndices(3, -100);
+  ASSERT_EQ(indices.size(), 100);
+  std::set<uint64_t> distinct(indices.begin(), indices.end());
+  ASSERT_EQ(distinct, std::set<uint64_t>({0, 1, 2}));
+
+  ASSERT_EQ(util::RandomIndices(1, -5), std::vector<uint64_t>(5, 0));
+
+  // the repeated indices are capped to bound the reply
+  ASSERT_EQ(util::RandomIndices(3, util::kMinRandomCount).size(), util::kMaxRandomRepeatedCount);
+  ASSERT_EQ(util::RandomIndices(3, INT64_MIN).size(), util::kMaxRandomRepeatedCount);
+}
+
+TEST(RandomUtil, Distribution) {
+  std::map<uint64_t, int> hits;
+  for (int i = 0; i < 10000; i++) {
+    for (auto index : util::RandomIndices(10, 2)) {
+      hits[index]++;
+    }
+  }
+  ASSERT_EQ(hits.size(), 10);
+  for (const auto &[index, n] : hits) {
+    // each index is expected to be picked 2000 times
+    ASSERT_GT(n, 1500) << index;
+    ASSERT_LT(n, 2500) << index;
+  }
+}
+
+TEST(RandomUtil, RandomInt) {
+  ASSERT_EQ(util::RandomInt(7, 7), 7);
+
+  std::set<uint64_t> values;
+  for (int i = 0; i < 1000; i++) {
+    auto value = util::RandomInt(3, 6);
+    ASSERT_GE(value, 3);
+    ASSERT_LE(value, 6);
+    values.insert(value);
+  }
+  ASSERT_EQ(values, std::set<uint64_t>({3, 4, 5, 6}));
+}
@@ -309,4 +309,45 @@ TEST_F(RDBTest, LoadEmptyKeys) {
 
   s = keyExist("zset_listpack");
   ASSERT_TRUE(s.IsNotFound());
-}
\ No newline at end of file
+}
+TEST_F(RDBTest, DumpAndRestore) {
+  auto dump = [this](const std::string &key, RedisType type) {
+    auto stream_ptr = std::make_unique<RdbStringStream>();
+    auto stream = stream_ptr.get();
+    RDB rdb(storage_, ns_, std::move(stream_ptr));
+    auto s = rdb.Dump(key, type);
+    EXPECT_TRUE(s.IsOK());
+    return stream->GetInput();
+  };
+  auto restore = [this](const std::string &key, const std::string &payload) {
+    RDB rdb(storage_, ns_, std::make_unique<RdbStringStream>(payload));
+    auto s = rdb.Restore(key, payload, 0);
+    ASSERT_TRUE(s.IsOK());
+  };
+
+  redis::String string_db(storage_, ns_);
+  ASSERT_TRUE(string_db.Set("string", "bar").ok());
+  auto payload = dump("string", kRedisString);
+  ASSERT_EQ(std::string("\x00\x03" "bar" "\x08\x00", 6), payload.substr(0, 6));
+  restore("restored_string", payload);
+  stringCheck("restored_string", "bar");
+
+  redis::List list_db(storage_, ns_);
+  uint64_t size = 0;
+  std::vector<std::string> list_expect(100, "element");
+  ASSERT_TRUE(list_db.Push("list", std::vector<Slice>(list_expect.begin(), list_expect.end()), false, &size).ok());
+  restore("restored_list", dump("list", kRedisList));
+  listCheck("restored_list", list_expect);
+
+  redis::ZSet zset_db(storage_, ns_);
+  std::vector<MemberScore> zset_expect = {{"a", -1.5}, {"b", 2}, {"c", 1e10}};
+  auto member_scores = zset_expect;
+  ASSERT_TRUE(zset_db.Add("zset", ZAddFlags(0), &member_scores, &size).ok());
+  restore("restored_zset", dump("zset", kRedisZSet));
+  zsetCheck("restored_zset", zset_expect);
+
+  redis::Hash hash_db(storage_, ns_);
+  ASSERT_TRUE(hash_db.MSet("hash", {{"f1", "v1"}, {"f2", std::string(20000, 'x')}}, false, &size).ok());
+  restore("restored_hash", dump("hash", kRedisHash));
+  hashCheck("restored_hash", {{"f1", "v1"}, {"f2", std::string(20000, 'x')}});
+}
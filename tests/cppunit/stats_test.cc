@@ -0,0 +1,62 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "stats/stats.h"
+
+#include <gtest/gtest.h>
+
+TEST(LatencyHistogram, Percentile) {
+  LatencyHistogram histogram;
+  EXPECT_EQ(histogram.Count(), 0);
+  EXPECT_EQ(histogram.Percentile(50), 0);
+
+  for (uint64_t i = 1; i <= 10; i++) histogram.Record(i);
+  EXPECT_EQ(histogram.Count(), 10);
+  EXPECT_EQ(histogram.Percentile(50), 5);
+  EXPECT_EQ(histogram.Percentile(100), 10);
+
+  histogram.Reset();
+  for (uint64_t i = 1; i <= 1000; i++) histogram.Record(i);
+  for (double p : {50.0, 99.0, 99.9}) {
+    auto expected = static_cast<uint64_t>(p * 10);
+    EXPECT_GE(histogram.Percentile(p), expected);
+    EXPECT_LE(histogram.Percentile(p), expected + expected / 8);
+  }
+
+  histogram.Reset();
+  histogram.Record(uint64_t(1) << 60);
+  EXPECT_GT(histogram.Percentile(50), uint64_t(1) << 39);
+}
+
+TEST(LatencyHistogram, CumulativeBuckets) {
+  LatencyHistogram histogram;
+  EXPECT_TRUE(histogram.CumulativeBuckets().empty());
+
+  for (uint64_t latency : {0, 1, 3, 3, 100, 1000, 1023}) histogram.Record(latency);
+  std::vector<std::pair<uint64_t, uint64_t>> expected = {{1, 1}, {2, 2}, {4, 4}, {128, 5}, {1024, 7}};
+  EXPECT_EQ(histogram.CumulativeBuckets(), expected);
+
+  histogram.Record(1024);
+  histogram.Record(uint64_t(1) << 60);
+  auto buckets = histogram.CumulativeBuckets();
+  ASSERT_EQ(buckets.size(), 7);
+  EXPECT_EQ(buckets[5], std::make_pair(uint64_t(2048), uint64_t(8)));
+  EXPECT_EQ(buckets[6].second, 9);
+}
@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+#include "stats/write_history.h"
+
+#include <gtest/gtest.h>
+
+TEST(WriteHistory, GetLag) {
+  WriteHistory history(100, 3);
+  EXPECT_EQ(history.GetLag(0, 1000).bytes, 0);
+  EXPECT_EQ(history.GetLag(0, 1000).ms, 0);
+
+  // the writes in the same interval are merged into one sample
+  history.Record(10, 100, 1000);
+  history.Record(20, 100, 1050);
+  history.Record(30, 100, 1200);
+
+  auto lag = history.GetLag(30, 1500);
+  EXPECT_EQ(lag.bytes, 0);
+  EXPECT_EQ(lag.ms, 0);
+
+  lag = history.GetLag(20, 1500);
+  EXPECT_EQ(lag.bytes, 100);
+  EXPECT_EQ(lag.ms, 300);
+
+  lag = history.GetLag(5, 1500);
+  EXPECT_EQ(lag.bytes, 300);
+  EXPECT_EQ(lag.ms, 500);
+}
+
+TEST(WriteHistory, MaxSamples) {
+  WriteHistory history(100, 3);
+  for (uint64_t i = 1; i <= 5; i++) {
+    history.Record(i * 10, 100, i * 1000);
+  }
+
+  // the samples of the sequence 10 and 20 are evicted, so the lag is counted from the oldest one
+  auto lag = history.GetLag(10, 6000);
+  EXPECT_EQ(lag.bytes, 500);
+  EXPECT_EQ(lag.ms, 3000);
+
+  lag = history.GetLag(40, 6000);
+  EXPECT_EQ(lag.bytes, 100);
+  EXPECT_EQ(lag.ms, 1000);
+}
+
+TEST(WriteHistory, OutOfOrder) {
+  WriteHistory history(100, 3);
+  // the concurrent writes may be recorded in a different order from their sequence numbers
+  history.Record(20, 100, 1000);
+  history.Record(10, 100, 1010);
+
+  auto lag = history.GetLag(20, 1500);
+  EXPECT_EQ(lag.bytes, 0);
+  EXPECT_EQ(lag.ms, 0);
+
+  lag = history.GetLag(10, 1500);
+  EXPECT_EQ(lag.bytes, 200);
+  EXPECT_EQ(lag.ms, 500);
+}
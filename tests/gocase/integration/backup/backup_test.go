@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package backup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneFromBackup(t *testing.T) {
+	util.SkipIfExternal(t)
+
+	src := util.StartServer(t, map[string]string{})
+	defer src.Close()
+
+	ctx := context.Background()
+	srcClient := src.NewClient()
+	defer func() { require.NoError(t, srcClient.Close()) }()
+
+	spec := &util.DataSpec{Seed: 1, Prefix: "backup:", Strings: 100, Hashes: 10, Lists: 10, Sets: 10, ZSets: 10, Elements: 16, ValueSize: 32}
+	spec.Populate(t, srcClient)
+
+	t.Run("Backup is a RocksDB checkpoint on disk", func(t *testing.T) {
+		backup := src.TriggerBackup(t)
+		require.Equal(t, src.BackupDir(), backup.Dir)
+		require.Contains(t, backup.Files, "CURRENT")
+		require.True(t, backup.HasFile("MANIFEST-"), "files: %v", backup.Files)
+		require.True(t, backup.HasFile("OPTIONS-"), "files: %v", backup.Files)
+		require.Greater(t, backup.Size, int64(0))
+	})
+
+	clone := util.CloneFromBackup(t, src, map[string]string{})
+	defer clone.Close()
+	cloneClient := clone.NewClient()
+	defer func() { require.NoError(t, cloneClient.Close()) }()
+
+	t.Run("Clone has the data of the backup", func(t *testing.T) {
+		spec.Verify(t, cloneClient)
+	})
+
+	t.Run("Clone is independent from the source", func(t *testing.T) {
+		require.NoError(t, srcClient.Set(ctx, "after-backup", "1", 0).Err())
+		require.NoError(t, cloneClient.Set(ctx, "clone-only", "1", 0).Err())
+		require.EqualValues(t, 0, cloneClient.Exists(ctx, "after-backup").Val())
+		require.EqualValues(t, 0, srcClient.Exists(ctx, "clone-only").Val())
+		require.NoError(t, cloneClient.Del(ctx, "clone-only").Err())
+	})
+
+	t.Run("Replica seeded from the backup catches up with the master", func(t *testing.T) {
+		util.SlaveOf(t, cloneClient, src)
+		util.WaitForSync(t, cloneClient)
+		util.WaitForOffsetSync(t, srcClient, cloneClient)
+		spec.Verify(t, cloneClient)
+		require.Equal(t, "1", cloneClient.Get(ctx, "after-backup").Val())
+	})
+}
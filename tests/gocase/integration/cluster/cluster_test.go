@@ -22,8 +22,11 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/apache/kvrocks/tests/gocase/util"
 	"github.com/redis/go-redis/v9"
@@ -370,3 +373,116 @@ func TestClusterMultiple(t *testing.T) {
 		require.Equal(t, "no-multi", rdb[1].Get(ctx, util.SlotTable[0]).Val())
 	})
 }
+
+func TestClusterBootstrap(t *testing.T) {
+	ctx := context.Background()
+
+	cluster := util.StartCluster(t, 3, 0, map[string]string{})
+	defer cluster.Close()
+	cluster.WaitForClusterOK()
+
+	t.Run("slots are split evenly across the nodes", func(t *testing.T) {
+		for _, node := range cluster.Nodes {
+			r := node.Client.ClusterInfo(ctx).Val()
+			require.Contains(t, r, "cluster_slots_assigned:16384")
+			require.Contains(t, r, "cluster_known_nodes:3")
+			require.Contains(t, r, "cluster_current_epoch:1")
+		}
+		require.Equal(t, cluster.Nodes[0], cluster.NodeBySlot(0))
+		require.Equal(t, cluster.Nodes[2], cluster.NodeBySlot(16383))
+	})
+
+	t.Run("requests are served or redirected by the topology", func(t *testing.T) {
+		owner := cluster.NodeBySlot(16383)
+		require.NoError(t, owner.Client.Set(ctx, util.SlotTable[16383], "v", 0).Err())
+		util.ErrorRegexp(t, cluster.Nodes[0].Client.Get(ctx, util.SlotTable[16383]).Err(),
+			fmt.Sprintf(".*MOVED 16383.*%d.*", owner.Server.Port()))
+	})
+
+	t.Run("the state of every node can be dumped", func(t *testing.T) {
+		dir := cluster.DumpTopology()
+		require.NotEmpty(t, dir)
+		defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+		topology, err := os.ReadFile(filepath.Join(dir, "topology.txt"))
+		require.NoError(t, err)
+		require.Contains(t, string(topology), cluster.NodesString())
+
+		for i, node := range cluster.Nodes {
+			dump, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("node-%d-%d.txt", i, node.Server.Port())))
+			require.NoError(t, err)
+			require.Contains(t, string(dump), node.ID)
+			require.Contains(t, string(dump), "# CLUSTERX VERSION\n1\n")
+			require.Contains(t, string(dump), "# Server")
+		}
+	})
+
+	t.Run("topology changes are applied to all nodes", func(t *testing.T) {
+		cluster.Nodes[2].Slots = nil
+		cluster.Nodes[1].Slots[0].End = util.ClusterSlots - 1
+		cluster.SetTopology()
+		cluster.WaitForClusterOK()
+		require.Equal(t, cluster.Nodes[1], cluster.NodeBySlot(16383))
+		util.ErrorRegexp(t, cluster.Nodes[2].Client.Get(ctx, util.SlotTable[16383]).Err(),
+			fmt.Sprintf(".*MOVED 16383.*%d.*", cluster.Nodes[1].Server.Port()))
+	})
+}
+
+func TestClusterChaos(t *testing.T) {
+	ctx := context.Background()
+
+	cluster := util.StartCluster(t, 3, 0, map[string]string{})
+	defer cluster.Close()
+	cluster.WaitForClusterOK()
+
+	chaos := util.NewChaos(t, cluster, util.DefaultChaosOptions())
+	defer chaos.Close()
+
+	clients := make(map[*util.ClusterNode]*redis.Client)
+	for _, node := range cluster.Nodes {
+		clients[node] = chaos.Client(node)
+	}
+	defer func() {
+		for _, c := range clients {
+			require.NoError(t, c.Close())
+		}
+	}()
+
+	// every key is written once, so an acknowledged write can't be overwritten
+	// by a later write whose reply was lost
+	var acked []string
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			slot := i % util.ClusterSlots
+			key := fmt.Sprintf("{%s}:%d", util.SlotTable[slot], i)
+			if clients[cluster.NodeBySlot(slot)].Set(ctx, key, i, 0).Err() == nil {
+				acked = append(acked, key)
+			}
+		}
+	}()
+
+	chaos.Run(10 * time.Second)
+	close(stop)
+	<-done
+	t.Logf("chaos events: %v", chaos.Events)
+
+	t.Run("cluster converges after the chaos", func(t *testing.T) {
+		chaos.WaitForConverge()
+	})
+
+	t.Run("no acknowledged write is lost", func(t *testing.T) {
+		require.NotEmpty(t, acked)
+		for _, key := range acked {
+			slot := int(cluster.Nodes[0].Client.ClusterKeySlot(ctx, key).Val())
+			require.EqualValues(t, 1, cluster.NodeBySlot(slot).Client.Exists(ctx, key).Val(), "key %s is lost", key)
+		}
+	})
+}
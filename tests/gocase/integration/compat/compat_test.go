@@ -0,0 +1,78 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package compat
+
+import (
+	"testing"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/stretchr/testify/require"
+)
+
+var spec = &util.DataSpec{Seed: 1, Prefix: "compat:", Strings: 100, Hashes: 10, Lists: 10, Sets: 10, ZSets: 10, Elements: 16, ValueSize: 32}
+
+func TestReplicationFromOldRelease(t *testing.T) {
+	master := util.StartOldServer(t, map[string]string{})
+	defer master.Close()
+	masterClient := master.NewClient()
+	defer func() { require.NoError(t, masterClient.Close()) }()
+	spec.Populate(t, masterClient)
+
+	replica := util.StartServer(t, map[string]string{})
+	defer replica.Close()
+	replicaClient := replica.NewClient()
+	defer func() { require.NoError(t, replicaClient.Close()) }()
+
+	t.Run("New release can replicate from an older release", func(t *testing.T) {
+		util.SlaveOf(t, replicaClient, master)
+		util.WaitForSync(t, replicaClient)
+		util.WaitForOffsetSync(t, masterClient, replicaClient)
+		spec.Verify(t, replicaClient)
+	})
+}
+
+func TestBackupFromOldRelease(t *testing.T) {
+	old := util.StartOldServer(t, map[string]string{})
+	defer old.Close()
+	oldClient := old.NewClient()
+	defer func() { require.NoError(t, oldClient.Close()) }()
+	spec.Populate(t, oldClient)
+
+	t.Run("New release can load the backup of an older release", func(t *testing.T) {
+		srv := util.CloneFromBackup(t, old, map[string]string{})
+		defer srv.Close()
+		rdb := srv.NewClient()
+		defer func() { require.NoError(t, rdb.Close()) }()
+		spec.Verify(t, rdb)
+	})
+}
+
+func TestUpgradeInPlace(t *testing.T) {
+	srv := util.StartOldServer(t, map[string]string{})
+	defer srv.Close()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+	spec.Populate(t, rdb)
+
+	t.Run("New release can start on the data directory of an older release", func(t *testing.T) {
+		srv.Upgrade()
+		spec.Verify(t, rdb)
+	})
+}
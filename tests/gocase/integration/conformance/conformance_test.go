@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package conformance
+
+import (
+	"testing"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+)
+
+// divergences are the documented differences from Redis.
+var divergences = []util.Divergence{
+	{Command: "OBJECT", Reason: "kvrocks has no object encodings"},
+	{Command: "DEBUG", Reason: "kvrocks supports only a few DEBUG subcommands"},
+}
+
+func TestRedisConformance(t *testing.T) {
+	redisSrv := util.StartRedisServer(t)
+	defer redisSrv.Close()
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+
+	for name, cmds := range map[string][][]string{
+		"String": {
+			{"SET", "s", "hello"}, {"GET", "s"}, {"APPEND", "s", " world"}, {"STRLEN", "s"},
+			{"GETRANGE", "s", "0", "4"}, {"SETRANGE", "s", "6", "redis"}, {"GET", "s"},
+			{"INCR", "n"}, {"INCRBY", "n", "10"}, {"DECR", "n"}, {"INCR", "s"},
+			{"MSET", "a", "1", "b", "2"}, {"MGET", "a", "b", "missing"}, {"SETNX", "a", "3"},
+		},
+		"Hash": {
+			{"HSET", "h", "f1", "v1", "f2", "v2"}, {"HGET", "h", "f1"}, {"HGETALL", "h"},
+			{"HINCRBY", "h", "n", "5"}, {"HDEL", "h", "f1", "missing"}, {"HLEN", "h"}, {"HKEYS", "h"},
+		},
+		"List": {
+			{"RPUSH", "l", "a", "b", "c"}, {"LPUSH", "l", "z"}, {"LRANGE", "l", "0", "-1"},
+			{"LINDEX", "l", "-1"}, {"LPOP", "l"}, {"RPOP", "l", "2"}, {"LLEN", "l"}, {"LPOP", "empty"},
+		},
+		"Set": {
+			{"SADD", "set", "a", "b", "c"}, {"SISMEMBER", "set", "a"}, {"SREM", "set", "a"},
+			{"SMEMBERS", "set"}, {"SCARD", "set"}, {"SADD", "set2", "c", "d"}, {"SINTER", "set", "set2"},
+		},
+		"ZSet": {
+			{"ZADD", "z", "1", "a", "2", "b", "3", "c"}, {"ZRANGE", "z", "0", "-1", "WITHSCORES"},
+			{"ZSCORE", "z", "b"}, {"ZRANK", "z", "c"}, {"ZREM", "z", "a"}, {"ZCARD", "z"},
+			{"ZRANGEBYSCORE", "z", "(1", "+inf"}, {"ZINCRBY", "z", "1.5", "b"},
+		},
+		"Keyspace": {
+			{"SET", "k", "v"}, {"EXISTS", "k", "missing"}, {"TYPE", "k"}, {"EXPIRE", "k", "100"},
+			{"PERSIST", "k"}, {"TTL", "k"}, {"RENAME", "k", "k2"}, {"DEL", "k2"}, {"TYPE", "k2"},
+		},
+	} {
+		cmds := cmds
+		t.Run(name, func(t *testing.T) {
+			c := util.NewConformance(t, srv, redisSrv, divergences...)
+			defer c.Close()
+			c.Run(cmds...)
+			c.RequireConformant()
+		})
+	}
+}
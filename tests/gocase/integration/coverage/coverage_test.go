@@ -0,0 +1,46 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package coverage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCommandCoverageReport prints the commands which no test case exercised. It should
+// run after the whole suite with the same -commandCoverage directory, e.g.
+//
+//	go test ./... -commandCoverage=/tmp/cov && go test ./integration/coverage -count=1 -commandCoverage=/tmp/cov -v
+func TestCommandCoverageReport(t *testing.T) {
+	if util.CommandCoverageDir() == "" {
+		t.Skip("command coverage is not enabled")
+	}
+
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	uncovered := util.UncoveredCommands(t, rdb)
+	t.Logf("%d commands have no test coverage: %s", len(uncovered), strings.Join(uncovered, ", "))
+}
@@ -0,0 +1,80 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package recovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrashRecovery(t *testing.T) {
+	util.SkipIfExternal(t)
+
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("Acknowledged writes survive a crash", func(t *testing.T) {
+		require.NoError(t, rdb.Set(ctx, "string", "v", 0).Err())
+		require.NoError(t, rdb.HSet(ctx, "hash", "f1", "v1", "f2", "v2").Err())
+		require.NoError(t, rdb.RPush(ctx, "list", "a", "b", "c").Err())
+		require.NoError(t, rdb.ZAdd(ctx, "zset", redis.Z{Score: 1, Member: "m1"}, redis.Z{Score: 2, Member: "m2"}).Err())
+		util.Populate(t, rdb, "key", 1000, 16)
+
+		srv.Kill()
+		srv.Recover()
+
+		require.Equal(t, "v", rdb.Get(ctx, "string").Val())
+		require.Equal(t, map[string]string{"f1": "v1", "f2": "v2"}, rdb.HGetAll(ctx, "hash").Val())
+		require.Equal(t, []string{"a", "b", "c"}, rdb.LRange(ctx, "list", 0, -1).Val())
+		require.Equal(t, []string{"m1", "m2"}, rdb.ZRange(ctx, "zset", 0, -1).Val())
+		require.Len(t, rdb.Keys(ctx, "key*").Val(), 1000)
+	})
+
+	t.Run("Server keeps working after recovering from repeated crashes", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			require.NoError(t, rdb.Incr(ctx, "counter").Err())
+			srv.Kill()
+			srv.Recover()
+		}
+		require.Equal(t, "3", rdb.Get(ctx, "counter").Val())
+		require.NoError(t, rdb.Set(ctx, "after-crash", "ok", 0).Err())
+		require.Equal(t, "ok", rdb.Get(ctx, "after-crash").Val())
+	})
+
+	t.Run("Graceful shutdown exits cleanly and keeps all data", func(t *testing.T) {
+		util.Populate(t, rdb, "graceful", 1000, 16)
+
+		elapsed := srv.GracefulStop(10 * time.Second)
+		require.Zero(t, srv.ExitCode())
+		t.Logf("graceful shutdown took %v", elapsed)
+
+		srv.Recover()
+		require.Len(t, rdb.Keys(ctx, "graceful*").Val(), 1000)
+	})
+}
@@ -189,6 +189,77 @@ func TestReplicationBasics(t *testing.T) {
 	})
 }
 
+func TestReplicationDataConsistency(t *testing.T) {
+	master := util.StartServer(t, map[string]string{})
+	defer master.Close()
+	masterClient := master.NewClient()
+	defer func() { require.NoError(t, masterClient.Close()) }()
+
+	slave := util.StartServer(t, map[string]string{})
+	defer slave.Close()
+	slaveClient := slave.NewClient()
+	defer func() { require.NoError(t, slaveClient.Close()) }()
+
+	fullSyncSpec := &util.DataSpec{Seed: 1, Prefix: "full:", Strings: 500, Hashes: 50, Lists: 50, Sets: 50, ZSets: 50, Elements: 20, ValueSize: 32}
+	incrSyncSpec := &util.DataSpec{Seed: 2, Prefix: "incr:", Strings: 500, Hashes: 50, Lists: 50, Sets: 50, ZSets: 50, Elements: 20, ValueSize: 32}
+
+	t.Run("Data is consistent after full sync", func(t *testing.T) {
+		fullSyncSpec.Populate(t, masterClient)
+		util.SlaveOf(t, slaveClient, master)
+		util.WaitForSync(t, slaveClient)
+		util.WaitForOffsetSync(t, masterClient, slaveClient)
+		fullSyncSpec.Verify(t, slaveClient)
+	})
+
+	t.Run("Data is consistent after incremental sync", func(t *testing.T) {
+		incrSyncSpec.Populate(t, masterClient)
+		util.WaitForOffsetSync(t, masterClient, slaveClient)
+		incrSyncSpec.Verify(t, slaveClient)
+		fullSyncSpec.Verify(t, slaveClient)
+	})
+
+	t.Run("Key spaces are identical including TTLs and other types", func(t *testing.T) {
+		ctx := context.Background()
+		require.NoError(t, masterClient.Set(ctx, "ttl:string", "v", time.Hour).Err())
+		require.NoError(t, masterClient.SetBit(ctx, "ttl:bitmap", 100, 1).Err())
+		require.NoError(t, masterClient.Do(ctx, "SIADD", "ttl:sortedint", 1, 2, 3).Err())
+		require.NoError(t, masterClient.XAdd(ctx, &redis.XAddArgs{Stream: "ttl:stream", Values: []string{"f", "v"}}).Err())
+		util.WaitForOffsetSync(t, masterClient, slaveClient)
+		util.RequireSameKeyspace(t, master, slave)
+	})
+}
+
+func TestReplicationBreakAndRestoreLink(t *testing.T) {
+	master := util.StartServer(t, map[string]string{})
+	defer master.Close()
+	masterClient := master.NewClient()
+	defer func() { require.NoError(t, masterClient.Close()) }()
+
+	ctx := context.Background()
+	require.NoError(t, masterClient.Set(ctx, "before", "1", 0).Err())
+
+	pair := util.StartReplicaOf(t, master, map[string]string{})
+	defer pair.Close()
+
+	t.Run("Replica is synced when started", func(t *testing.T) {
+		require.Equal(t, "slave", util.FindInfoEntry(pair.ReplicaClient, "role"))
+		require.Equal(t, "1", pair.ReplicaClient.Get(ctx, "before").Val())
+	})
+
+	t.Run("Writes are not replicated while the link is broken", func(t *testing.T) {
+		pair.BreakLink()
+		require.NoError(t, masterClient.Set(ctx, "during", "2", 0).Err())
+		time.Sleep(500 * time.Millisecond)
+		require.Equal(t, "", pair.ReplicaClient.Get(ctx, "during").Val())
+	})
+
+	t.Run("Replica catches up after the link is restored", func(t *testing.T) {
+		pair.RestoreLink()
+		require.Equal(t, "2", pair.ReplicaClient.Get(ctx, "during").Val())
+		require.Equal(t, "1", pair.ReplicaClient.Get(ctx, "before").Val())
+	})
+}
+
 func TestReplicationWithMultiSlaves(t *testing.T) {
 	srvA := util.StartServer(t, map[string]string{})
 	defer srvA.Close()
@@ -258,14 +329,13 @@ func TestReplicationWithLimitSpeed(t *testing.T) {
 
 		// Restart master server, let the slave try to full sync with master again,
 		// because slave already received some SST files, so we will skip them.
+		offset := slave.LogOffset(t)
 		master.Restart()
 		masterClient.Close()
 		masterClient = master.NewClient()
 
 		require.NoError(t, masterClient.ConfigSet(ctx, "max-replication-mb", "0").Err())
-		require.Eventually(t, func() bool {
-			return slave.LogFileMatches(t, ".*skip count: 1.*")
-		}, 50*time.Second, 1000*time.Millisecond)
+		slave.WaitForLogPatternSince(t, offset, ".*skip count: 1.*", 50*time.Second)
 		util.WaitForSync(t, slaveClient)
 		require.Equal(t, "b", slaveClient.Get(ctx, "a").Val())
 	})
@@ -295,12 +365,11 @@ func TestReplicationShareCheckpoint(t *testing.T) {
 	util.Populate(t, slave2Client, "", 1026, 1)
 
 	t.Run("two slaves share one checkpoint for full replication", func(t *testing.T) {
+		offset := master.LogOffset(t)
 		util.SlaveOf(t, slave1Client, master)
 		util.SlaveOf(t, slave2Client, master)
 
-		require.Eventually(t, func() bool {
-			return master.LogFileMatches(t, ".*Using current existing checkpoint.*")
-		}, 50*time.Second, 100*time.Millisecond)
+		master.WaitForLogPatternSince(t, offset, ".*Using current existing checkpoint.*", 50*time.Second)
 		util.WaitForSync(t, slave1Client)
 		util.WaitForSync(t, slave2Client)
 		require.Equal(t, "b", slave1Client.Get(ctx, "a").Val())
@@ -451,3 +520,222 @@ func TestShouldNotReplicate(t *testing.T) {
 		require.Equal(t, "master", util.FindInfoEntry(masterClient, "role"))
 	})
 }
+
+func TestReplicationLag(t *testing.T) {
+	master := util.StartServer(t, map[string]string{})
+	defer master.Close()
+	masterClient := master.NewClient()
+	defer func() { require.NoError(t, masterClient.Close()) }()
+
+	slave := util.StartServer(t, map[string]string{})
+	defer slave.Close()
+	slaveClient := slave.NewClient()
+	defer func() { require.NoError(t, slaveClient.Close()) }()
+
+	util.SlaveOf(t, slaveClient, master)
+	util.WaitForSync(t, slaveClient)
+
+	parseLag := func() (int64, float64) {
+		value := util.FindInfoEntry(masterClient, "slave0")
+		fields := make(map[string]string)
+		for _, field := range strings.Split(value, ",") {
+			k, v, _ := strings.Cut(field, "=")
+			fields[k] = v
+		}
+		require.Contains(t, fields, "lag_bytes", "slave0: %s", value)
+		lagBytes, err := strconv.ParseInt(fields["lag_bytes"], 10, 64)
+		require.NoError(t, err)
+		lagSeconds, err := strconv.ParseFloat(fields["lag_seconds"], 64)
+		require.NoError(t, err)
+		return lagBytes, lagSeconds
+	}
+
+	t.Run("No lag once the replica catches up", func(t *testing.T) {
+		util.Populate(t, masterClient, "lag", 100, 100)
+		util.WaitForOffsetSync(t, masterClient, slaveClient)
+		require.Eventually(t, func() bool {
+			lagBytes, lagSeconds := parseLag()
+			return lagBytes == 0 && lagSeconds == 0
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("Lag in bytes and seconds when the replica falls behind", func(t *testing.T) {
+		slave.Pause()
+		// the writes exceed the socket buffers, so the master can't send all of them to the frozen replica
+		util.Populate(t, masterClient, "lag", 2000, 10240)
+		time.Sleep(time.Second)
+		lagBytes, lagSeconds := parseLag()
+		require.Greater(t, lagBytes, int64(0))
+		require.GreaterOrEqual(t, lagSeconds, 0.5)
+
+		slave.Resume()
+		util.WaitForOffsetSyncWithTimeout(t, masterClient, slaveClient, 30*time.Second)
+		require.Eventually(t, func() bool {
+			lagBytes, lagSeconds := parseLag()
+			return lagBytes == 0 && lagSeconds == 0
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+}
+
+func TestReplicationWait(t *testing.T) {
+	master := util.StartServer(t, map[string]string{})
+	defer master.Close()
+	masterClient := master.NewClient()
+	defer func() { require.NoError(t, masterClient.Close()) }()
+
+	slave := util.StartServer(t, map[string]string{})
+	defer slave.Close()
+	slaveClient := slave.NewClient()
+	defer func() { require.NoError(t, slaveClient.Close()) }()
+
+	util.SlaveOf(t, slaveClient, master)
+	util.WaitForSync(t, slaveClient)
+	ctx := context.Background()
+
+	t.Run("WAIT should acknowledge the write by the replica", func(t *testing.T) {
+		require.NoError(t, masterClient.Set(ctx, "wait-key", "v1", 0).Err())
+		require.EqualValues(t, 1, masterClient.Wait(ctx, 1, 5*time.Second).Val())
+		require.Equal(t, "v1", slaveClient.Get(ctx, "wait-key").Val())
+	})
+
+	t.Run("WAIT should time out when there are not enough replicas", func(t *testing.T) {
+		require.NoError(t, masterClient.Set(ctx, "wait-key", "v2", 0).Err())
+		start := time.Now()
+		require.EqualValues(t, 1, masterClient.Wait(ctx, 2, time.Second).Val())
+		require.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+
+	t.Run("WAIT should time out when the replica doesn't acknowledge", func(t *testing.T) {
+		slave.Pause()
+		require.NoError(t, masterClient.Set(ctx, "wait-key", "v3", 0).Err())
+		require.EqualValues(t, 0, masterClient.Wait(ctx, 1, time.Second).Val())
+		slave.Resume()
+		require.EqualValues(t, 1, masterClient.Wait(ctx, 1, 5*time.Second).Val())
+	})
+
+	t.Run("WAIT should fail with the invalid arguments or on replicas", func(t *testing.T) {
+		util.ErrorRegexp(t, masterClient.Do(ctx, "WAIT", "a", 0).Err(), ".*not an integer.*")
+		util.ErrorRegexp(t, masterClient.Do(ctx, "WAIT", 1, -1).Err(), ".*timeout is negative.*")
+		util.ErrorRegexp(t, slaveClient.Wait(ctx, 1, 0).Err(), ".*cannot be used with replica.*")
+	})
+}
+
+func TestReplicationFailover(t *testing.T) {
+	// a single worker makes sure the paused writes don't block the other clients
+	master := util.StartServer(t, map[string]string{"workers": "1"})
+	defer master.Close()
+	masterClient := master.NewClient()
+	defer func() { require.NoError(t, masterClient.Close()) }()
+
+	slave := util.StartServer(t, map[string]string{})
+	defer slave.Close()
+	slaveClient := slave.NewClient()
+	defer func() { require.NoError(t, slaveClient.Close()) }()
+
+	ctx := context.Background()
+
+	t.Run("FAILOVER should fail without replicas or with the invalid arguments", func(t *testing.T) {
+		util.ErrorRegexp(t, masterClient.Do(ctx, "FAILOVER").Err(), ".*requires connected replicas.*")
+		util.ErrorRegexp(t, masterClient.Do(ctx, "FAILOVER", "ABORT").Err(), ".*No failover in progress.*")
+		util.ErrorRegexp(t, masterClient.Do(ctx, "FAILOVER", "TIMEOUT", 0).Err(), ".*must be greater than 0.*")
+		util.ErrorRegexp(t, masterClient.Do(ctx, "FAILOVER", "ABORT", "TIMEOUT", 10).Err(),
+			".*abort cannot be used with other arguments.*")
+	})
+
+	util.SlaveOf(t, slaveClient, master)
+	util.WaitForSync(t, slaveClient)
+
+	t.Run("FAILOVER should fail on replicas or with an unknown target", func(t *testing.T) {
+		util.ErrorRegexp(t, slaveClient.Do(ctx, "FAILOVER").Err(), ".*not valid when server is a replica.*")
+		util.ErrorRegexp(t, masterClient.Do(ctx, "FAILOVER", "TO", master.Host(), master.Port()).Err(),
+			".*is not a replica.*")
+	})
+
+	t.Run("FAILOVER should be aborted when the replica doesn't catch up in time", func(t *testing.T) {
+		slave.Pause()
+		require.NoError(t, masterClient.Set(ctx, "failover-key", "v1", 0).Err())
+		require.NoError(t, masterClient.Do(ctx, "FAILOVER", "TIMEOUT", 500).Err())
+		require.Equal(t, "waiting-for-sync", util.FindInfoEntry(masterClient, "master_failover_state"))
+		util.ErrorRegexp(t, masterClient.Do(ctx, "FAILOVER").Err(), ".*already in progress.*")
+		require.Eventually(t, func() bool {
+			return util.FindInfoEntry(masterClient, "master_failover_state") == "no-failover"
+		}, 5*time.Second, 100*time.Millisecond)
+		slave.Resume()
+
+		require.Equal(t, "master", util.FindInfoEntry(masterClient, "role"))
+		require.NoError(t, masterClient.Set(ctx, "failover-key", "v2", 0).Err())
+	})
+
+	t.Run("FAILOVER ABORT should stop the failover in progress", func(t *testing.T) {
+		slave.Pause()
+		require.NoError(t, masterClient.Set(ctx, "failover-key", "v3", 0).Err())
+		require.NoError(t, masterClient.Do(ctx, "FAILOVER").Err())
+		require.NoError(t, masterClient.Do(ctx, "FAILOVER", "ABORT").Err())
+		require.Eventually(t, func() bool {
+			return util.FindInfoEntry(masterClient, "master_failover_state") == "no-failover"
+		}, 5*time.Second, 100*time.Millisecond)
+		slave.Resume()
+		require.Equal(t, "master", util.FindInfoEntry(masterClient, "role"))
+	})
+
+	t.Run("FAILOVER should park the paused writes without blocking the other clients", func(t *testing.T) {
+		slave.Pause()
+		require.NoError(t, masterClient.Do(ctx, "FAILOVER", "TIMEOUT", 1000).Err())
+
+		writeClient := master.NewClient()
+		defer func() { require.NoError(t, writeClient.Close()) }()
+		done := make(chan error, 1)
+		go func() {
+			_, err := writeClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, "failover-parked", "v1", 0)
+				return nil
+			})
+			done <- err
+		}()
+
+		// the reads are still served by the same worker while the transaction is parked
+		time.Sleep(200 * time.Millisecond)
+		require.Equal(t, "waiting-for-sync", util.FindInfoEntry(masterClient, "master_failover_state"))
+		require.Equal(t, int64(0), masterClient.Exists(ctx, "failover-parked").Val())
+		select {
+		case err := <-done:
+			require.FailNow(t, "the write should be paused", "err: %v", err)
+		default:
+		}
+
+		require.Eventually(t, func() bool {
+			return util.FindInfoEntry(masterClient, "master_failover_state") == "no-failover"
+		}, 5*time.Second, 100*time.Millisecond)
+		require.NoError(t, <-done)
+		slave.Resume()
+		require.Equal(t, "v1", masterClient.Get(ctx, "failover-parked").Val())
+	})
+
+	t.Run("FAILOVER should be aborted by the default timeout", func(t *testing.T) {
+		slave.Pause()
+		defer slave.Resume()
+		require.NoError(t, masterClient.Set(ctx, "failover-key", "v3", 0).Err())
+		require.NoError(t, masterClient.Do(ctx, "FAILOVER").Err())
+		require.Eventually(t, func() bool {
+			return util.FindInfoEntry(masterClient, "master_failover_state") == "no-failover"
+		}, 15*time.Second, 100*time.Millisecond)
+		require.Equal(t, "master", util.FindInfoEntry(masterClient, "role"))
+	})
+
+	t.Run("FAILOVER should swap the roles of the master and the replica", func(t *testing.T) {
+		require.NoError(t, masterClient.Set(ctx, "failover-key", "v4", 0).Err())
+		require.NoError(t, masterClient.Do(ctx, "FAILOVER", "TO", slave.Host(), slave.Port()).Err())
+		require.Eventually(t, func() bool {
+			return util.FindInfoEntry(masterClient, "role") == "slave" &&
+				util.FindInfoEntry(slaveClient, "role") == "master"
+		}, 10*time.Second, 100*time.Millisecond)
+		require.Equal(t, "v4", slaveClient.Get(ctx, "failover-key").Val())
+
+		util.WaitForSync(t, masterClient)
+		util.ErrorRegexp(t, masterClient.Set(ctx, "failover-key", "v5", 0).Err(), ".*READONLY.*")
+		require.NoError(t, slaveClient.Set(ctx, "failover-key", "v5", 0).Err())
+		require.Eventually(t, func() bool {
+			return masterClient.Get(ctx, "failover-key").Val() == "v5"
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+}
@@ -1107,3 +1107,31 @@ func waitForImportState(t testing.TB, client *redis.Client, n int, state SlotImp
 			strings.Contains(i, fmt.Sprintf("import_state: %s", state))
 	}, 10*time.Second, 100*time.Millisecond)
 }
+
+func TestSlotMigrateAndVerify(t *testing.T) {
+	ctx := context.Background()
+
+	cluster := util.StartCluster(t, 3, 0, map[string]string{})
+	defer cluster.Close()
+	cluster.WaitForClusterOK()
+
+	slot := 1
+	src, dst := cluster.Nodes[0], cluster.Nodes[1]
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("{%s}:%d", util.SlotTable[slot], i)
+		require.NoError(t, src.Client.Set(ctx, key, i, 0).Err())
+		require.NoError(t, src.Client.HSet(ctx, key+":hash", "field", i).Err())
+	}
+
+	t.Run("MIGRATE - Slot is moved to the destination", func(t *testing.T) {
+		util.MigrateSlotAndVerify(t, cluster, slot, src, dst)
+		require.Equal(t, dst, cluster.NodeBySlot(slot))
+		require.Len(t, util.KeysInSlot(t, dst.Client, slot), 200)
+	})
+
+	t.Run("MIGRATE - Slot can be moved back to the source", func(t *testing.T) {
+		util.MigrateSlotAndVerify(t, cluster, slot, dst, src)
+		require.Equal(t, src, cluster.NodeBySlot(slot))
+		cluster.WaitForClusterOK()
+	})
+}
@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package slowdisk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowDisk(t *testing.T) {
+	opts := &util.RocksDBOptions{WriteBufferSize: 1, MaxWriteBufferNumber: 2}
+	master := util.StartServer(t, opts.Apply(map[string]string{"rocksdb.write_options.sync": "yes"}))
+	defer master.Close()
+	disk := master.ThrottleDisk(t, 1<<20)
+	defer disk.Close()
+
+	ctx := context.Background()
+	rdb := master.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	slave := util.StartServer(t, map[string]string{})
+	defer slave.Close()
+	slaveClient := slave.NewClient()
+	defer func() { require.NoError(t, slaveClient.Close()) }()
+	util.SlaveOf(t, slaveClient, master)
+	util.WaitForSync(t, slaveClient)
+
+	t.Run("Server keeps serving under write stalls", func(t *testing.T) {
+		stallsBefore := writeStalls(t, rdb)
+		recorder := util.NewLatencyRecorder(t, rdb)
+		defer recorder.Stop()
+
+		spec := &util.DataSpec{Seed: 1, Prefix: "slow:", Strings: 2000, ValueSize: 1024}
+		spec.Populate(t, rdb)
+		for i := 0; i < 100; i++ {
+			require.NoError(t, rdb.Ping(ctx).Err())
+		}
+
+		recorder.RequireP99Below("ping", time.Second)
+		// the slow disk must have stalled the writes, otherwise the case tests nothing
+		require.Greater(t, writeStalls(t, rdb), stallsBefore, "writes are not stalled by the slow disk")
+	})
+
+	t.Run("Replication stays healthy after the disk recovers", func(t *testing.T) {
+		disk.SetLimit(0)
+		util.WaitForOffsetSync(t, rdb, slaveClient)
+		util.RequireSameKeyspace(t, master, slave)
+	})
+}
+
+// writeStalls sums up the write stalls of all column families, which are counted
+// either as delays or as slowdowns caused by too many immutable memtables.
+func writeStalls(t testing.TB, rdb *redis.Client) int64 {
+	var total int64
+	for _, field := range []string{"write_stall_delays", "memtable_count_limit_slowdown"} {
+		for _, n := range util.RocksDBStats(t, rdb, field) {
+			total += n
+		}
+	}
+	return total
+}
@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package workload
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMixedWorkload(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	opts := util.DefaultWorkloadOptions()
+	opts.Duration = 3 * time.Second
+	opts.Pipeline = 16
+
+	r := util.RunWorkload(t, rdb, opts)
+	t.Logf("mixed workload: %s", r)
+	require.Greater(t, r.Ops, int64(0))
+	require.Zero(t, r.Errors)
+
+	if path := util.WorkloadBaseline(); path != "" {
+		r.RequireNoRegression(t, path, util.WorkloadTolerance())
+	}
+}
+
+func TestSeededWorkload(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	// a failure can be replayed by hard coding the logged seed
+	seed := time.Now().UnixNano()
+	t.Logf("seed of the workload: %d", seed)
+
+	w := util.NewWorkload(seed, util.DefaultWorkloadMix())
+	w.Run(t, rdb, 5000)
+	w.Verify(t, rdb)
+
+	t.Run("Model still matches after restart", func(t *testing.T) {
+		util.SkipIfExternal(t)
+		srv.Restart()
+		w.Verify(t, rdb)
+		w.Run(t, rdb, 1000)
+		w.Verify(t, rdb)
+	})
+}
+
+func TestCommandLatency(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+	recorder := util.NewLatencyRecorder(t, rdb)
+	defer recorder.Stop()
+
+	for i := 0; i < 2000; i++ {
+		key := fmt.Sprintf("latency:%d", i%100)
+		require.NoError(t, rdb.Set(ctx, key, i, 0).Err())
+		require.NoError(t, rdb.Get(ctx, key).Err())
+		require.NoError(t, rdb.ZAdd(ctx, key+":zset", redis.Z{Score: float64(i), Member: i}).Err())
+	}
+	t.Logf("command latencies:\n%s", recorder)
+
+	// the limits are loose on purpose, they only catch regressions of an order of magnitude
+	for _, cmd := range []string{"SET", "GET", "ZADD"} {
+		require.EqualValues(t, 2000, recorder.Histogram(cmd).Count())
+		recorder.RequireP50Below(cmd, 20*time.Millisecond)
+		recorder.RequireP99Below(cmd, 200*time.Millisecond)
+	}
+}
+
+func TestWorkloadResourceUsage(t *testing.T) {
+	util.SkipIfExternal(t)
+
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+
+	sampler := srv.StartResourceSampler(100 * time.Millisecond)
+	defer sampler.Stop()
+
+	t.Run("No fd or thread leak after clients are gone", func(t *testing.T) {
+		rdb := srv.NewClientWithOption(&redis.Options{PoolSize: 64})
+		opts := util.DefaultWorkloadOptions()
+		opts.Clients = 64
+		opts.Duration = 3 * time.Second
+		r := util.RunWorkload(t, rdb, opts)
+		require.Zero(t, r.Errors)
+		require.NoError(t, rdb.Close())
+
+		// allow some files to be opened by RocksDB for new SST files
+		sampler.RequireNoFdLeak(16, 10*time.Second)
+		sampler.RequireNoThreadLeak(0, 10*time.Second)
+	})
+
+	t.Run("RSS stays bounded under load", func(t *testing.T) {
+		sampler.RequireRSSBelow(2 << 30)
+		require.NotEmpty(t, sampler.Samples())
+	})
+}
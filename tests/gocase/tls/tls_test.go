@@ -41,10 +41,7 @@ func TestTLS(t *testing.T) {
 	srv := util.StartTLSServer(t, map[string]string{})
 	defer srv.Close()
 
-	defaultTLSConfig, err := util.DefaultTLSConfig()
-	require.NoError(t, err)
-
-	rdb := srv.NewClientWithOption(&redis.Options{TLSConfig: defaultTLSConfig, Addr: srv.TLSAddr()})
+	rdb := srv.NewTLSClient()
 	defer func() { require.NoError(t, rdb.Close()) }()
 
 	doWithTLSClient := func(tlsConfig *tls.Config, f func(c *redis.Client)) {
@@ -151,10 +148,7 @@ func TestTLSReplica(t *testing.T) {
 	srv := util.StartTLSServer(t, map[string]string{})
 	defer srv.Close()
 
-	defaultTLSConfig, err := util.DefaultTLSConfig()
-	require.NoError(t, err)
-
-	sc := srv.NewClientWithOption(&redis.Options{TLSConfig: defaultTLSConfig, Addr: srv.TLSAddr()})
+	sc := srv.NewTLSClient()
 	defer func() { require.NoError(t, sc.Close()) }()
 
 	replica := util.StartTLSServer(t, map[string]string{
@@ -163,7 +157,7 @@ func TestTLSReplica(t *testing.T) {
 	})
 	defer replica.Close()
 
-	rc := replica.NewClientWithOption(&redis.Options{TLSConfig: defaultTLSConfig, Addr: replica.TLSAddr()})
+	rc := replica.NewTLSClient()
 	defer func() { require.NoError(t, rc.Close()) }()
 
 	t.Run("TLS: Replication (incremental)", func(t *testing.T) {
@@ -185,7 +179,7 @@ func TestTLSReplica(t *testing.T) {
 	})
 	defer replica2.Close()
 
-	rc2 := replica2.NewClientWithOption(&redis.Options{TLSConfig: defaultTLSConfig, Addr: replica2.TLSAddr()})
+	rc2 := replica2.NewTLSClient()
 	defer func() { require.NoError(t, rc2.Close()) }()
 
 	t.Run("TLS: Replication (full)", func(t *testing.T) {
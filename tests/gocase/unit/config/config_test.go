@@ -232,3 +232,135 @@ func TestDynamicChangeWorkerThread(t *testing.T) {
 		require.Equal(t, "bar", rdb.Get(ctx, "foo").Val())
 	})
 }
+
+func TestRestartWithConfig(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{"maxclients": "100"})
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+
+	srv.Restart()
+	require.Equal(t, "bar", rdb.Get(ctx, "foo").Val())
+	require.Equal(t, map[string]string{"maxclients": "100"}, rdb.ConfigGet(ctx, "maxclients").Val())
+
+	srv.RestartWithConfig(map[string]string{"maxclients": "200", "slowlog-max-len": "16"})
+	require.Equal(t, "bar", rdb.Get(ctx, "foo").Val())
+	require.Equal(t, map[string]string{"maxclients": "200"}, rdb.ConfigGet(ctx, "maxclients").Val())
+	require.Equal(t, map[string]string{"slowlog-max-len": "16"}, rdb.ConfigGet(ctx, "slowlog-max-len").Val())
+}
+
+func TestConfigRewrite(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{"maxclients": "100"})
+	defer srv.Close()
+
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	f, err := os.OpenFile(srv.ConfigFilePath(), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("# comments are kept by CONFIG REWRITE\n\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	diff := srv.RewriteConfig(t, rdb, map[string]string{"maxclients": "200", "slowlog-max-len": "16"})
+	require.Equal(t, [2]string{"100", "200"}, diff.Changed["maxclients"])
+	require.Equal(t, "16", diff.Added["slowlog-max-len"])
+	require.NotContains(t, diff.Changed, "port")
+	require.Empty(t, diff.Removed)
+	require.Empty(t, diff.LostLines)
+
+	t.Run("rewrite without changes is stable", func(t *testing.T) {
+		diff := srv.RewriteConfig(t, rdb, map[string]string{})
+		require.Empty(t, diff.Added)
+		require.Empty(t, diff.Changed)
+		require.Empty(t, diff.Removed)
+		require.Empty(t, diff.LostLines)
+	})
+}
+
+func TestConfigTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Start with includes and multi-line directives", func(t *testing.T) {
+		srv := util.StartServerWithConfigTemplate(t, &util.ConfigTemplate{
+			Content: `# a config file in the real world
+workers 4
+include renames.conf
+profiling-sample-commands get,set
+backup-dir "{{.Dir}}/my backup"
+`,
+			Includes: map[string]string{
+				"renames.conf": "rename-command KEYS KEYSNEW\nrename-command FLUSHALL \"\"\n",
+			},
+		}, map[string]string{})
+		defer srv.Close()
+
+		rdb := srv.NewClient()
+		defer func() { require.NoError(t, rdb.Close()) }()
+
+		require.ErrorContains(t, rdb.Keys(ctx, "*").Err(), "unknown command")
+		require.NoError(t, rdb.Do(ctx, "KEYSNEW", "*").Err())
+		require.ErrorContains(t, rdb.FlushAll(ctx).Err(), "unknown command")
+		require.Equal(t, map[string]string{"workers": "4"}, rdb.ConfigGet(ctx, "workers").Val())
+		require.Equal(t, map[string]string{"profiling-sample-commands": "get,set"}, rdb.ConfigGet(ctx, "profiling-sample-commands").Val())
+		require.Equal(t, filepath.Join(srv.Dir(), "my backup"), rdb.ConfigGet(ctx, "backup-dir").Val()["backup-dir"])
+	})
+
+	t.Run("Fail to start with invalid directives", func(t *testing.T) {
+		out := util.StartServerWithInvalidConfig(t, &util.ConfigTemplate{Content: "maxclients abc\n"}, map[string]string{})
+		require.Contains(t, out, "Failed to load config")
+		require.Contains(t, out, "maxclients")
+
+		out = util.StartServerWithInvalidConfig(t, &util.ConfigTemplate{
+			Content:  "include bad.conf\n",
+			Includes: map[string]string{"bad.conf": "requirepass \"unbalanced\n"},
+		}, map[string]string{})
+		require.Contains(t, out, "malformed line")
+	})
+}
+
+func TestRocksDBOptions(t *testing.T) {
+	opts := &util.RocksDBOptions{
+		WriteBufferSize:      16,
+		MaxWriteBufferNumber: 3,
+		TargetFileSizeBase:   32,
+		MaxBackgroundJobs:    2,
+		MaxIOMB:              100,
+		Extra:                map[string]string{"level0_slowdown_writes_trigger": "30"},
+	}
+	srv := util.StartServer(t, opts.Apply(map[string]string{}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("Options are effective after starting", func(t *testing.T) {
+		util.RequireRocksDBOptions(t, rdb, opts)
+	})
+
+	t.Run("Options are effective after CONFIG SET", func(t *testing.T) {
+		require.NoError(t, rdb.ConfigSet(ctx, "rocksdb.write_buffer_size", "32").Err())
+		require.NoError(t, rdb.ConfigSet(ctx, "max-io-mb", "0").Err())
+		opts.WriteBufferSize = 32
+		opts.MaxIOMB = 0
+		util.RequireRocksDBOptions(t, rdb, opts)
+		require.Equal(t, map[string]string{"max-io-mb": "0"}, rdb.ConfigGet(ctx, "max-io-mb").Val())
+	})
+
+	t.Run("Stats are reported per column family", func(t *testing.T) {
+		util.Populate(t, rdb, "rocksdb", 1000, 16)
+		stats := util.RocksDBStats(t, rdb, "estimate_keys")
+		require.Contains(t, stats, "default")
+		require.Contains(t, stats, "metadata")
+
+		// the block cache is shared by column families, so it's only reported once
+		info := util.ParseInfo(t, rdb, "rocksdb")
+		require.GreaterOrEqual(t, info.Int("rocksdb", "block_cache_usage"), int64(0))
+		require.NotContains(t, info.Sections["rocksdb"], "block_cache_usage[default]")
+	})
+}
@@ -157,4 +157,66 @@ func TestDisk(t *testing.T) {
 		_, err = rdb.MemoryUsage(ctx, "nonexistentkey").Result()
 		require.ErrorIs(t, err, redis.Nil)
 	})
+
+	t.Run("Memory usage of complex types sums the elements", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "memhash", "memzset").Err())
+		fieldsSize := 0
+		for i := 0; i < 1000; i++ {
+			field, value := "field"+strconv.Itoa(i), strings.Repeat("v", 100)
+			require.NoError(t, rdb.HSet(ctx, "memhash", field, value).Err())
+			require.NoError(t, rdb.ZAdd(ctx, "memzset", redis.Z{Score: float64(i), Member: field}).Err())
+			fieldsSize += len(field) + len(value)
+		}
+
+		all, err := rdb.MemoryUsage(ctx, "memhash", 0).Result()
+		require.NoError(t, err)
+		require.Greater(t, all, int64(fieldsSize))
+		require.Less(t, all, int64(fieldsSize)*2)
+
+		// the default samples are extrapolated to all fields of the same size
+		sampled, err := rdb.MemoryUsage(ctx, "memhash").Result()
+		require.NoError(t, err)
+		require.InDelta(t, all, sampled, float64(all)*0.1)
+
+		// members of sorted sets are stored twice, by the member and by the score
+		zsetUsage, err := rdb.MemoryUsage(ctx, "memzset", 0).Result()
+		require.NoError(t, err)
+		require.Greater(t, zsetUsage, int64(2*len("memzset")*1000))
+	})
+
+	t.Run("Memory usage with invalid arguments", func(t *testing.T) {
+		require.NoError(t, rdb.Set(ctx, "memkey", "value", 0).Err())
+		require.Error(t, rdb.Do(ctx, "MEMORY", "USAGE", "memkey", "SAMPLES").Err())
+		require.Error(t, rdb.Do(ctx, "MEMORY", "USAGE", "memkey", "SAMPLES", "-1").Err())
+		require.ErrorContains(t, rdb.Do(ctx, "MEMORY", "USAGE", "memkey", "FOO").Err(), "syntax error")
+		require.ErrorContains(t, rdb.Do(ctx, "MEMORY", "USAGES", "memkey").Err(), "Unknown operation")
+		require.ErrorContains(t, rdb.Do(ctx, "MEMORY", "USAGE").Err(), "wrong number of arguments")
+	})
+
+	t.Run("Memory stats", func(t *testing.T) {
+		v, err := rdb.Do(ctx, "MEMORY", "STATS").Slice()
+		require.NoError(t, err)
+		require.Zero(t, len(v)%2)
+		stats := make(map[string]int64)
+		for i := 0; i < len(v); i += 2 {
+			stats[v[i].(string)] = v[i+1].(int64)
+		}
+		require.Greater(t, stats["rss"], int64(0))
+		require.Greater(t, stats["rocksdb.block-cache.capacity"], int64(0))
+		for _, name := range []string{"rocksdb.block-cache.usage", "rocksdb.memtables", "rocksdb.table-readers",
+			"clients.query-buffers", "clients.output-buffers", "replication.replicas", "replication.buffers", "lua"} {
+			require.Contains(t, stats, name)
+		}
+		require.EqualValues(t, 0, stats["replication.replicas"])
+
+		require.ErrorContains(t, rdb.Do(ctx, "MEMORY", "STATS", "foo").Err(), "wrong number of arguments")
+	})
+
+	t.Run("Memory doctor", func(t *testing.T) {
+		report, err := rdb.Do(ctx, "MEMORY", "DOCTOR").Text()
+		require.NoError(t, err)
+		require.Regexp(t, "the RSS is", report)
+
+		require.ErrorContains(t, rdb.Do(ctx, "MEMORY", "DOCTOR", "foo").Err(), "wrong number of arguments")
+	})
 }
@@ -30,7 +30,7 @@ import (
 )
 
 func TestExpire(t *testing.T) {
-	srv := util.StartServer(t, map[string]string{})
+	srv := util.StartServer(t, map[string]string{"debug-clock-offset-enabled": "yes"})
 	defer srv.Close()
 
 	ctx := context.Background()
@@ -184,6 +184,37 @@ func TestExpire(t *testing.T) {
 		require.EqualValues(t, -2, rdb.PTTL(ctx, "x").Val())
 	})
 
+	t.Run("EXPIRETIME / PEXPIRETIME return the absolute expire timestamp", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "x").Err())
+		require.NoError(t, rdb.Set(ctx, "x", "somevalue", 0).Err())
+		require.NoError(t, rdb.ExpireAt(ctx, "x", time.Unix(2000000000, 0)).Err())
+		require.EqualValues(t, 2000000000, rdb.Do(ctx, "EXPIRETIME", "x").Val())
+		require.EqualValues(t, 2000000000000, rdb.Do(ctx, "PEXPIRETIME", "x").Val())
+
+		require.NoError(t, rdb.Do(ctx, "PEXPIREAT", "x", 2000000000123).Err())
+		require.EqualValues(t, 2000000000, rdb.Do(ctx, "EXPIRETIME", "x").Val())
+		require.EqualValues(t, 2000000000123, rdb.Do(ctx, "PEXPIRETIME", "x").Val())
+
+		require.NoError(t, rdb.Del(ctx, "myhash").Err())
+		require.NoError(t, rdb.HSet(ctx, "myhash", "f", "v").Err())
+		require.NoError(t, rdb.Do(ctx, "PEXPIREAT", "myhash", 2000000000123).Err())
+		require.EqualValues(t, 2000000000123, rdb.Do(ctx, "PEXPIRETIME", "myhash").Val())
+	})
+
+	t.Run("EXPIRETIME / PEXPIRETIME return -1 if key has no expire", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "x").Err())
+		require.NoError(t, rdb.Set(ctx, "x", "hello", 0).Err())
+		require.EqualValues(t, -1, rdb.Do(ctx, "EXPIRETIME", "x").Val())
+		require.EqualValues(t, -1, rdb.Do(ctx, "PEXPIRETIME", "x").Val())
+	})
+
+	t.Run("EXPIRETIME / PEXPIRETIME return -2 if key does not exist", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "x").Err())
+		require.EqualValues(t, -2, rdb.Do(ctx, "EXPIRETIME", "x").Val())
+		require.EqualValues(t, -2, rdb.Do(ctx, "PEXPIRETIME", "x").Val())
+		util.ErrorRegexp(t, rdb.Do(ctx, "EXPIRETIME", "x", "y").Err(), ".*wrong number of arguments.*")
+	})
+
 	t.Run("Redis should actively expire keys incrementally", func(t *testing.T) {
 		require.NoError(t, rdb.FlushDB(ctx).Err())
 		require.NoError(t, rdb.Do(ctx, "PSETEX", "key1", 1500, "a").Err())
@@ -198,6 +229,55 @@ func TestExpire(t *testing.T) {
 		require.EqualValues(t, 0, rdb.DBSize(ctx).Val())
 	})
 
+	t.Run("Keys expire when the expiration clock is advanced", func(t *testing.T) {
+		defer util.ResetExpireClock(t, srv)
+
+		require.NoError(t, rdb.FlushDB(ctx).Err())
+		require.NoError(t, rdb.SetEx(ctx, "foo", "bar", time.Hour).Err())
+		require.NoError(t, rdb.HSet(ctx, "hash", "field", "value").Err())
+		require.NoError(t, rdb.Expire(ctx, "hash", 2*time.Hour).Err())
+
+		util.AdvanceExpireClock(t, srv, 30*time.Minute)
+		util.BetweenValues(t, rdb.TTL(ctx, "foo").Val(), 29*time.Minute, 30*time.Minute)
+		require.Equal(t, "bar", rdb.Get(ctx, "foo").Val())
+
+		util.AdvanceExpireClock(t, srv, time.Hour)
+		require.EqualValues(t, -2, rdb.PTTL(ctx, "foo").Val())
+		require.Equal(t, "", rdb.Get(ctx, "foo").Val())
+		require.Equal(t, "value", rdb.HGet(ctx, "hash", "field").Val())
+
+		// the expiration time is set against the advanced clock as well
+		require.NoError(t, rdb.SetEx(ctx, "foo", "bar", time.Minute).Err())
+		util.BetweenValues(t, rdb.TTL(ctx, "foo").Val(), 59*time.Second, time.Minute)
+
+		util.AdvanceExpireClock(t, srv, time.Hour)
+		require.EqualValues(t, 0, rdb.Exists(ctx, "foo", "hash").Val())
+		require.NoError(t, rdb.Do(ctx, "DBSIZE", "scan").Err())
+		require.Eventually(t, func() bool {
+			return rdb.DBSize(ctx).Val() == 0
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("Compaction doesn't reclaim keys which only expire by the advanced clock", func(t *testing.T) {
+		defer util.ResetExpireClock(t, srv)
+
+		require.NoError(t, rdb.FlushDB(ctx).Err())
+		require.NoError(t, rdb.SetEx(ctx, "foo", "bar", time.Hour).Err())
+		require.NoError(t, rdb.HSet(ctx, "hash", "field", "value").Err())
+		require.NoError(t, rdb.Expire(ctx, "hash", time.Hour).Err())
+
+		util.AdvanceExpireClock(t, srv, 2*time.Hour)
+		require.EqualValues(t, 0, rdb.Exists(ctx, "foo", "hash").Val())
+		require.NoError(t, rdb.Do(ctx, "COMPACT").Err())
+		require.Eventually(t, func() bool {
+			return util.FindInfoEntry(rdb, "is_compacting", "rocksdb") == "no"
+		}, 10*time.Second, 100*time.Millisecond)
+
+		util.ResetExpireClock(t, srv)
+		require.Equal(t, "bar", rdb.Get(ctx, "foo").Val())
+		require.Equal(t, "value", rdb.HGet(ctx, "hash", "field").Val())
+	})
+
 	t.Run("5 keys in, 5 keys out", func(t *testing.T) {
 		require.NoError(t, rdb.FlushDB(ctx).Err())
 		require.NoError(t, rdb.Set(ctx, "a", "c", 0).Err())
@@ -216,4 +296,87 @@ func TestExpire(t *testing.T) {
 		util.ErrorRegexp(t, rdb.Do(ctx, "expire", "foo", "").Err(), ".*not started as an integer*.")
 	})
 
+	t.Run("EXPIRE with NX/XX options", func(t *testing.T) {
+		require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+		require.Equal(t, false, rdb.ExpireXX(ctx, "foo", 100*time.Second).Val())
+		require.EqualValues(t, -1, rdb.TTL(ctx, "foo").Val())
+		require.Equal(t, true, rdb.ExpireNX(ctx, "foo", 100*time.Second).Val())
+		require.Equal(t, false, rdb.ExpireNX(ctx, "foo", 200*time.Second).Val())
+		util.BetweenValues(t, rdb.TTL(ctx, "foo").Val(), 90*time.Second, 100*time.Second)
+		require.Equal(t, true, rdb.ExpireXX(ctx, "foo", 200*time.Second).Val())
+		util.BetweenValues(t, rdb.TTL(ctx, "foo").Val(), 190*time.Second, 200*time.Second)
+		require.Equal(t, false, rdb.ExpireNX(ctx, "nonexisting_key", 100*time.Second).Val())
+	})
+
+	t.Run("EXPIRE with GT/LT options", func(t *testing.T) {
+		// a key without expiration is regarded as having an infinite TTL
+		require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+		require.Equal(t, false, rdb.ExpireGT(ctx, "foo", 100*time.Second).Val())
+		require.EqualValues(t, -1, rdb.TTL(ctx, "foo").Val())
+		require.Equal(t, true, rdb.ExpireLT(ctx, "foo", 100*time.Second).Val())
+		util.BetweenValues(t, rdb.TTL(ctx, "foo").Val(), 90*time.Second, 100*time.Second)
+
+		require.Equal(t, false, rdb.ExpireLT(ctx, "foo", 200*time.Second).Val())
+		require.Equal(t, true, rdb.ExpireGT(ctx, "foo", 200*time.Second).Val())
+		util.BetweenValues(t, rdb.TTL(ctx, "foo").Val(), 190*time.Second, 200*time.Second)
+		require.Equal(t, false, rdb.ExpireGT(ctx, "foo", 100*time.Second).Val())
+		require.Equal(t, true, rdb.ExpireLT(ctx, "foo", 100*time.Second).Val())
+		util.BetweenValues(t, rdb.TTL(ctx, "foo").Val(), 90*time.Second, 100*time.Second)
+
+		// XX can be combined with GT or LT
+		require.EqualValues(t, 1, rdb.Do(ctx, "expire", "foo", 200, "xx", "gt").Val())
+		util.BetweenValues(t, rdb.TTL(ctx, "foo").Val(), 190*time.Second, 200*time.Second)
+		require.NoError(t, rdb.Persist(ctx, "foo").Err())
+		require.EqualValues(t, 0, rdb.Do(ctx, "expire", "foo", 100, "xx", "lt").Val())
+		require.EqualValues(t, -1, rdb.TTL(ctx, "foo").Val())
+	})
+
+	t.Run("PEXPIRE/EXPIREAT/PEXPIREAT with NX/XX/GT/LT options", func(t *testing.T) {
+		require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+		require.EqualValues(t, 1, rdb.Do(ctx, "pexpire", "foo", 100000, "nx").Val())
+		require.EqualValues(t, 0, rdb.Do(ctx, "pexpire", "foo", 200000, "lt").Val())
+
+		require.EqualValues(t, 1, rdb.Do(ctx, "expireat", "foo", 2000000000, "gt").Val())
+		require.EqualValues(t, 0, rdb.Do(ctx, "expireat", "foo", 1900000000, "gt").Val())
+		require.EqualValues(t, 2000000000, rdb.Do(ctx, "expiretime", "foo").Val())
+
+		require.EqualValues(t, 1, rdb.Do(ctx, "pexpireat", "foo", 1900000000000, "lt").Val())
+		require.EqualValues(t, 0, rdb.Do(ctx, "pexpireat", "foo", 1900000000000, "nx").Val())
+		require.EqualValues(t, 1900000000000, rdb.Do(ctx, "pexpiretime", "foo").Val())
+	})
+
+	t.Run("EXPIRE with conflicting or unknown options", func(t *testing.T) {
+		require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+		for _, cmd := range []string{"expire", "pexpire", "expireat", "pexpireat"} {
+			util.ErrorRegexp(t, rdb.Do(ctx, cmd, "foo", 100, "nx", "xx").Err(), ".*NX and XX, GT or LT options.*not compatible.*")
+			util.ErrorRegexp(t, rdb.Do(ctx, cmd, "foo", 100, "nx", "gt").Err(), ".*NX and XX, GT or LT options.*not compatible.*")
+			util.ErrorRegexp(t, rdb.Do(ctx, cmd, "foo", 100, "gt", "lt").Err(), ".*GT and LT options.*not compatible.*")
+			util.ErrorRegexp(t, rdb.Do(ctx, cmd, "foo", 100, "foo").Err(), ".*syntax error.*")
+		}
+		require.EqualValues(t, -1, rdb.TTL(ctx, "foo").Val())
+	})
+}
+
+func TestExpireClockOffset(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{"requirepass": "foobared"})
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewAdminClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("DEBUG SET-CLOCK-OFFSET is disabled by default", func(t *testing.T) {
+		util.ErrorRegexp(t, rdb.Do(ctx, "DEBUG", "SET-CLOCK-OFFSET", "1000").Err(), ".*is disabled.*")
+		require.Error(t, rdb.ConfigSet(ctx, "debug-clock-offset-enabled", "yes").Err())
+	})
+
+	t.Run("DEBUG SET-CLOCK-OFFSET requires the admin permission", func(t *testing.T) {
+		nsClient := srv.NewNamespaceClient("ns1")
+		defer func() { require.NoError(t, nsClient.Close()) }()
+		util.ErrorRegexp(t, nsClient.Do(ctx, "DEBUG", "SET-CLOCK-OFFSET", "1000").Err(), ".*admin permission.*")
+	})
+
+	t.Run("DEBUG SET-CLOCK-OFFSET rejects offsets out of range", func(t *testing.T) {
+		util.ErrorRegexp(t, rdb.Do(ctx, "DEBUG", "SET-CLOCK-OFFSET", "9223372036854775807").Err(), ".*invalid debug clock offset.*")
+	})
 }
@@ -0,0 +1,80 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package hotkeys
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHotKeys(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{"requirepass": "admin-token"})
+	defer srv.Close()
+	ctx := context.Background()
+	rdb := srv.NewClientWithOption(&redis.Options{Password: "admin-token"})
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("HOTKEYS - tracking is disabled by default", func(t *testing.T) {
+		require.ErrorContains(t, rdb.Do(ctx, "HOTKEYS").Err(), "hot keys tracking is disabled")
+	})
+
+	t.Run("HOTKEYS - the hottest keys of the last second", func(t *testing.T) {
+		require.NoError(t, rdb.ConfigSet(ctx, "hot-keys-tracking", "yes").Err())
+		defer func() { require.NoError(t, rdb.ConfigSet(ctx, "hot-keys-tracking", "no").Err()) }()
+
+		// keep accessing the keys for more than a complete window of one second
+		deadline := time.Now().Add(2500 * time.Millisecond)
+		for i := 0; time.Now().Before(deadline); i++ {
+			require.NoError(t, rdb.Get(ctx, "hot").Err())
+			if i%2 == 0 {
+				require.NoError(t, rdb.MGet(ctx, "warm", fmt.Sprintf("cold%d", i)).Err())
+			}
+		}
+
+		hotKeys, err := rdb.Do(ctx, "HOTKEYS", 2).Slice()
+		require.NoError(t, err)
+		require.Len(t, hotKeys, 2)
+		hot, warm := hotKeys[0].([]interface{}), hotKeys[1].([]interface{})
+		require.Equal(t, "hot", hot[0])
+		require.Equal(t, "warm", warm[0])
+		require.Greater(t, hot[1].(int64), warm[1].(int64))
+		require.Greater(t, warm[1].(int64), int64(0))
+
+		// the keys are tracked per namespace
+		require.NoError(t, rdb.Do(ctx, "NAMESPACE", "ADD", "hotkeys-ns", "hotkeys-token").Err())
+		nsClient := srv.NewClientWithOption(&redis.Options{Password: "hotkeys-token"})
+		defer func() { require.NoError(t, nsClient.Close()) }()
+		require.Empty(t, nsClient.Do(ctx, "HOTKEYS").Val())
+	})
+
+	t.Run("HOTKEYS - invalid arguments", func(t *testing.T) {
+		require.NoError(t, rdb.ConfigSet(ctx, "hot-keys-tracking", "yes").Err())
+		defer func() { require.NoError(t, rdb.ConfigSet(ctx, "hot-keys-tracking", "no").Err()) }()
+		require.ErrorContains(t, rdb.Do(ctx, "HOTKEYS", 0).Err(), "out of numeric range")
+		require.ErrorContains(t, rdb.Do(ctx, "HOTKEYS", "foo").Err(), "not started as an integer")
+		require.ErrorContains(t, rdb.Do(ctx, "HOTKEYS", 1, 2).Err(), "wrong number of arguments")
+	})
+}
@@ -23,10 +23,12 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 )
 
@@ -60,20 +62,56 @@ func TestInfo(t *testing.T) {
 			time.Sleep(time.Second)
 		}
 
-		r := util.FindInfoEntry(rdb, "put_per_sec", "rocksdb")
-		require.Greater(t, MustAtoi(t, r), 0)
-		r = util.FindInfoEntry(rdb, "get_per_sec", "rocksdb")
-		require.Greater(t, MustAtoi(t, r), 0)
-		r = util.FindInfoEntry(rdb, "seek_per_sec", "rocksdb")
-		require.Greater(t, MustAtoi(t, r), 0)
-		r = util.FindInfoEntry(rdb, "next_per_sec", "rocksdb")
-		require.Greater(t, MustAtoi(t, r), 0)
+		info := util.ParseInfo(t, rdb, "rocksdb")
+		require.Greater(t, info.Int("rocksdb", "put_per_sec"), int64(0))
+		require.Greater(t, info.Int("rocksdb", "get_per_sec"), int64(0))
+		require.Greater(t, info.Int("rocksdb", "seek_per_sec"), int64(0))
+		require.Greater(t, info.Int("rocksdb", "next_per_sec"), int64(0))
+	})
+
+	t.Run("get rocksdb levels and stalls by INFO", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			require.NoError(t, rdb.Set(ctx, fmt.Sprintf("level-key%d", i), "value", 0).Err())
+		}
+		require.Greater(t, util.ParseInfo(t, rdb, "rocksdb").Int("rocksdb", "active_memtable_entries[metadata]"), int64(0))
+
+		// compaction flushes memtables first
+		require.NoError(t, rdb.Do(ctx, "COMPACT").Err())
+		require.Eventually(t, func() bool {
+			info := util.ParseInfo(t, rdb, "rocksdb")
+			return info.Int("rocksdb", "num_running_compactions") == 0 && info.Int("rocksdb", "compaction_pending[default]") == 0
+		}, 10*time.Second, 100*time.Millisecond)
+
+		info := util.ParseInfo(t, rdb, "rocksdb")
+		levels := info.Map("rocksdb", "levels[metadata]")
+		require.Contains(t, levels, "l0_files")
+		var files int
+		for k, v := range levels {
+			if strings.HasSuffix(k, "_files") {
+				files += MustAtoi(t, v)
+			}
+		}
+		require.Greater(t, files, 0)
+		require.EqualValues(t, 0, info.Int("rocksdb", "is_write_stopped"))
+		require.GreaterOrEqual(t, info.Int("rocksdb", "estimate_pending_compaction_bytes[default]"), int64(0))
+		require.GreaterOrEqual(t, info.Int("rocksdb", "write_stall_stops[default]"), int64(0))
+
+		// the data is broken down by column families
+		require.Greater(t, info.Int("rocksdb", "estimate_keys[metadata]"), int64(0))
+		require.Greater(t, info.Int("rocksdb", "live_sst_files_size[metadata]"), int64(0))
+		require.GreaterOrEqual(t, info.Int("rocksdb", "total_sst_files_size[metadata]"),
+			info.Int("rocksdb", "live_sst_files_size[metadata]"))
+		require.Greater(t, info.Int("rocksdb", "estimate_live_data_size[metadata]"), int64(0))
+		require.GreaterOrEqual(t, info.Int("rocksdb", "all_mem_tables[metadata]"), int64(0))
 	})
 
 	t.Run("get bgsave information by INFO", func(t *testing.T) {
 		require.Equal(t, "0", util.FindInfoEntry(rdb, "bgsave_in_progress", "persistence"))
 		require.Equal(t, "ok", util.FindInfoEntry(rdb, "last_bgsave_status", "persistence"))
 		require.Equal(t, "-1", util.FindInfoEntry(rdb, "last_bgsave_time_sec", "persistence"))
+		require.Equal(t, "0", util.FindInfoEntry(rdb, "backups", "persistence"))
+		require.Equal(t, "0", util.FindInfoEntry(rdb, "backup_dir_size", "persistence"))
+		require.Equal(t, "-1", util.FindInfoEntry(rdb, "last_backup_age_sec", "persistence"))
 
 		r := rdb.Do(ctx, "bgsave")
 		v, err := r.Text()
@@ -91,6 +129,169 @@ func TestInfo(t *testing.T) {
 		lastBgsaveTimeSec := MustAtoi(t, util.FindInfoEntry(rdb, "last_bgsave_time_sec", "persistence"))
 		require.GreaterOrEqual(t, lastBgsaveTimeSec, 0)
 		require.Less(t, lastBgsaveTimeSec, 3)
+
+		require.Equal(t, "1", util.FindInfoEntry(rdb, "backups", "persistence"))
+		require.Greater(t, MustAtoi(t, util.FindInfoEntry(rdb, "backup_dir_size", "persistence")), 0)
+		backupAge := MustAtoi(t, util.FindInfoEntry(rdb, "last_backup_age_sec", "persistence"))
+		require.GreaterOrEqual(t, backupAge, 0)
+		require.Less(t, backupAge, 5)
+	})
+
+	t.Run("get WAL information by INFO", func(t *testing.T) {
+		walSize := MustAtoi(t, util.FindInfoEntry(rdb, "wal_size", "persistence"))
+		require.Greater(t, MustAtoi(t, util.FindInfoEntry(rdb, "wal_files", "persistence")), 0)
+		for i := 0; i < 100; i++ {
+			require.NoError(t, rdb.Set(ctx, fmt.Sprintf("wal-key%d", i), "value", 0).Err())
+		}
+		require.Greater(t, MustAtoi(t, util.FindInfoEntry(rdb, "wal_size", "persistence")), walSize)
+	})
+
+	t.Run("parse all sections of INFO", func(t *testing.T) {
+		info := util.ParseInfo(t, rdb)
+		for _, section := range []string{"server", "clients", "memory", "persistence", "stats",
+			"replication", "cpu", "commandstats", "errorstats", "latencystats", "cluster", "keyspace", "rocksdb"} {
+			require.Contains(t, info.Sections, section)
+		}
+		require.Equal(t, "master", info.String("replication", "role"))
+		require.EqualValues(t, srv.Port(), info.Int("server", "tcp_port"))
+		require.GreaterOrEqual(t, info.Duration("server", "uptime_in_seconds", time.Second), time.Duration(0))
+		require.GreaterOrEqual(t, info.Float("cpu", "used_cpu_user"), 0.0)
+		require.Contains(t, info.Map("keyspace", "db0"), "keys")
+		require.Equal(t, "0", info.String("", "cluster_enabled"))
+	})
+
+	t.Run("get command stats by INFO commandstats", func(t *testing.T) {
+		require.NoError(t, rdb.ConfigResetStat(ctx).Err())
+		require.NotContains(t, util.ParseInfo(t, rdb, "commandstats").Sections["commandstats"], "cmdstat_set")
+
+		require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+		require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+		require.Error(t, rdb.Do(ctx, "SET", "foo").Err())
+		require.NoError(t, rdb.LPush(ctx, "list", "a").Err())
+		require.Error(t, rdb.Get(ctx, "list").Err())
+
+		info := util.ParseInfo(t, rdb, "commandstats")
+		set := info.Map("commandstats", "cmdstat_set")
+		require.Equal(t, "2", set["calls"])
+		require.Equal(t, "1", set["rejected_calls"])
+		require.Equal(t, "0", set["failed_calls"])
+		require.Contains(t, set, "usec")
+		require.Regexp(t, `^\d+\.\d{2}$`, set["usec_per_call"])
+
+		get := info.Map("commandstats", "cmdstat_get")
+		require.Equal(t, "1", get["calls"])
+		require.Equal(t, "0", get["rejected_calls"])
+		require.Equal(t, "1", get["failed_calls"])
+
+		require.NoError(t, rdb.ConfigResetStat(ctx).Err())
+		stats := util.ParseInfo(t, rdb, "commandstats").Sections["commandstats"]
+		require.NotContains(t, stats, "cmdstat_set")
+		require.NotContains(t, stats, "cmdstat_get")
+	})
+
+	t.Run("get error stats by INFO errorstats", func(t *testing.T) {
+		require.NoError(t, rdb.ConfigResetStat(ctx).Err())
+		require.Empty(t, util.ParseInfo(t, rdb, "errorstats").Sections["errorstats"])
+
+		require.NoError(t, rdb.LPush(ctx, "list", "a").Err())
+		util.ErrorRegexp(t, rdb.Get(ctx, "list").Err(), "WRONGTYPE.*")
+		util.ErrorRegexp(t, rdb.Get(ctx, "list").Err(), "WRONGTYPE.*")
+		require.Error(t, rdb.Do(ctx, "SET", "foo").Err())
+		require.Error(t, rdb.Do(ctx, "EXEC").Err())
+
+		info := util.ParseInfo(t, rdb, "errorstats")
+		require.Equal(t, "count=2", info.String("errorstats", "errorstat_WRONGTYPE"))
+		require.Equal(t, "count=2", info.String("errorstats", "errorstat_ERR"))
+		require.Equal(t, "4", util.FindInfoEntry(rdb, "total_error_replies", "stats"))
+
+		require.NoError(t, rdb.ConfigResetStat(ctx).Err())
+		require.Empty(t, util.ParseInfo(t, rdb, "errorstats").Sections["errorstats"])
+	})
+
+	t.Run("get latency percentiles by INFO latencystats", func(t *testing.T) {
+		require.NoError(t, rdb.ConfigResetStat(ctx).Err())
+		for i := 0; i < 100; i++ {
+			require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+		}
+
+		info := util.ParseInfo(t, rdb, "latencystats")
+		set := info.Map("latencystats", "latency_percentiles_usec_set")
+		p50, err := strconv.ParseUint(set["p50"], 10, 64)
+		require.NoError(t, err)
+		p99, err := strconv.ParseUint(set["p99"], 10, 64)
+		require.NoError(t, err)
+		p999, err := strconv.ParseUint(set["p99.9"], 10, 64)
+		require.NoError(t, err)
+		require.LessOrEqual(t, p50, p99)
+		require.LessOrEqual(t, p99, p999)
+		require.NotContains(t, info.Sections["latencystats"], "latency_percentiles_usec_get")
+
+		require.NoError(t, rdb.ConfigResetStat(ctx).Err())
+		require.NotContains(t, util.ParseInfo(t, rdb, "latencystats").Sections["latencystats"], "latency_percentiles_usec_set")
+	})
+
+	t.Run("get expired and reclaimed keys by INFO", func(t *testing.T) {
+		infoNum := func(field string) int {
+			return MustAtoi(t, util.FindInfoEntry(rdb, field, "stats"))
+		}
+		lookups := infoNum("expired_key_lookups")
+		require.NoError(t, rdb.Set(ctx, "expired-key", "value", 100*time.Millisecond).Err())
+		require.NoError(t, rdb.HSet(ctx, "deleted-hash", "f1", "v1", "f2", "v2").Err())
+		require.NoError(t, rdb.Del(ctx, "deleted-hash").Err())
+		time.Sleep(200 * time.Millisecond)
+
+		require.Empty(t, rdb.Get(ctx, "expired-key").Val())
+		require.Equal(t, lookups+1, infoNum("expired_key_lookups"))
+
+		require.NoError(t, rdb.Do(ctx, "DBSIZE", "SCAN").Err())
+		require.Eventually(t, func() bool {
+			return infoNum("pending_expired_keys") == 1
+		}, 5*time.Second, 100*time.Millisecond)
+		require.NotZero(t, infoNum("pending_expired_keys_scan_time"))
+
+		require.NoError(t, rdb.Do(ctx, "COMPACT").Err())
+		require.Eventually(t, func() bool {
+			return infoNum("reclaimed_expired_keys") >= 1 && infoNum("reclaimed_subkeys") >= 2
+		}, 10*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("get blocked, pubsub and watching clients by INFO", func(t *testing.T) {
+		clientsNum := func(field string) func() bool {
+			return func() bool { return util.FindInfoEntry(rdb, field, "clients") == "1" }
+		}
+		require.Equal(t, "0", util.FindInfoEntry(rdb, "blocked_clients", "clients"))
+		require.Equal(t, "0", util.FindInfoEntry(rdb, "pubsub_clients", "clients"))
+		require.Equal(t, "0", util.FindInfoEntry(rdb, "watching_clients", "clients"))
+
+		blocked := srv.NewClient()
+		defer func() { require.NoError(t, blocked.Close()) }()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			blocked.BLPop(ctx, 0, "blocked-list1", "blocked-list2")
+		}()
+		// blocking on several keys is still counted as one client
+		require.Eventually(t, clientsNum("blocked_clients"), 5*time.Second, 100*time.Millisecond)
+		require.NoError(t, rdb.LPush(ctx, "blocked-list2", "a").Err())
+		<-done
+		require.Equal(t, "0", util.FindInfoEntry(rdb, "blocked_clients", "clients"))
+
+		pubsub := srv.NewClient()
+		defer func() { require.NoError(t, pubsub.Close()) }()
+		sub := pubsub.Subscribe(ctx, "info-chan1", "info-chan2")
+		require.NoError(t, sub.PSubscribe(ctx, "info-*"))
+		require.Eventually(t, clientsNum("pubsub_clients"), 5*time.Second, 100*time.Millisecond)
+		require.NoError(t, sub.Close())
+		require.Eventually(t, func() bool {
+			return util.FindInfoEntry(rdb, "pubsub_clients", "clients") == "0"
+		}, 5*time.Second, 100*time.Millisecond)
+
+		watching := srv.NewClientWithOption(&redis.Options{PoolSize: 1})
+		defer func() { require.NoError(t, watching.Close()) }()
+		require.NoError(t, watching.Do(ctx, "WATCH", "watch-key1", "watch-key2").Err())
+		require.Equal(t, "1", util.FindInfoEntry(rdb, "watching_clients", "clients"))
+		require.NoError(t, watching.Do(ctx, "UNWATCH").Err())
+		require.Equal(t, "0", util.FindInfoEntry(rdb, "watching_clients", "clients"))
 	})
 
 	t.Run("get cluster information by INFO - cluster not enabled", func(t *testing.T) {
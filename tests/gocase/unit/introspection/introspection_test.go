@@ -22,6 +22,7 @@ package introspection
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"testing"
@@ -110,12 +111,91 @@ func TestIntrospection(t *testing.T) {
 
 	t.Run("CLIENT LIST", func(t *testing.T) {
 		v := rdb.ClientList(ctx).Val()
-		require.Regexp(t, "id=.* addr=.*:.* fd=.* name=.* age=.* idle=.* flags=N namespace=.* qbuf=.* .*obuf=.* cmd=client.*", v)
+		require.Regexp(t, "id=.* addr=.*:.* laddr=.*:.* fd=.* name=.* age=.* idle=.* flags=N namespace=.* qbuf=.* .*obuf=.* cmd=client.*", v)
 	})
 
 	t.Run("CLIENT INFO", func(t *testing.T) {
 		v := rdb.Do(ctx, "CLIENT", "INFO").Val()
-		require.Regexp(t, "id=.* addr=.*:.* fd=.* name=.* age=.* idle=.* flags=N namespace=.* qbuf=.* .*obuf=.* cmd=client.*", v)
+		require.Regexp(t, "id=.* addr=.*:.* laddr=.*:.* fd=.* name=.* age=.* idle=.* flags=N namespace=.* qbuf=.* .*obuf=.* cmd=client.*", v)
+	})
+
+	t.Run("CLIENT LIST and CLIENT INFO show the protocol, lib info and network bytes", func(t *testing.T) {
+		c := srv.NewClientWithOption(&redis.Options{PoolSize: 1})
+		defer func() { require.NoError(t, c.Close()) }()
+
+		require.NoError(t, c.Do(ctx, "CLIENT", "SETINFO", "LIB-NAME", "go-redis").Err())
+		require.NoError(t, c.Do(ctx, "CLIENT", "SETINFO", "lib-ver", "9.0.4").Err())
+		info := parseClientInfo(t, c.Do(ctx, "CLIENT", "INFO").Val().(string))
+		require.Equal(t, "2", info["resp"])
+		require.Equal(t, "go-redis", info["lib-name"])
+		require.Equal(t, "9.0.4", info["lib-ver"])
+		require.Equal(t, "client", info["cmd"])
+
+		in, err := strconv.ParseInt(info["tot-net-in"], 10, 64)
+		require.NoError(t, err)
+		out, err := strconv.ParseInt(info["tot-net-out"], 10, 64)
+		require.NoError(t, err)
+		require.NoError(t, c.Set(ctx, "net-key", strings.Repeat("x", 1000), 0).Err())
+		require.NoError(t, c.Get(ctx, "net-key").Err())
+
+		info = parseClientInfo(t, c.Do(ctx, "CLIENT", "INFO").Val().(string))
+		newIn, err := strconv.ParseInt(info["tot-net-in"], 10, 64)
+		require.NoError(t, err)
+		newOut, err := strconv.ParseInt(info["tot-net-out"], 10, 64)
+		require.NoError(t, err)
+		require.Greater(t, newIn-in, int64(1000))
+		require.Greater(t, newOut-out, int64(1000))
+
+		id := c.ClientID(ctx).Val()
+		require.Regexp(t, fmt.Sprintf("id=%d .* lib-name=go-redis lib-ver=9.0.4 tot-net-in=\\d+ tot-net-out=\\d+", id),
+			rdb.ClientList(ctx).Val())
+	})
+
+	t.Run("CLIENT INFO shows the connection state", func(t *testing.T) {
+		c := srv.NewClientWithOption(&redis.Options{PoolSize: 1})
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.Do(ctx, "CLIENT", "SETNAME", "state").Err())
+
+		info := parseClientInfo(t, c.Do(ctx, "CLIENT", "INFO").Val().(string))
+		require.Equal(t, strconv.FormatInt(c.ClientID(ctx).Val(), 10), info["id"])
+		require.Equal(t, "state", info["name"])
+		require.Equal(t, "__namespace", info["namespace"])
+		require.Equal(t, "-1", info["multi"])
+		require.Equal(t, "0", info["watch"])
+		require.Contains(t, info, "age")
+		require.Contains(t, info, "idle")
+		require.Contains(t, info, "addr")
+
+		// the state of the connection in a transaction can be inspected by others
+		tc := srv.NewTCPClient()
+		defer func() { require.NoError(t, tc.Close()) }()
+		require.NoError(t, tc.WriteArgs("CLIENT", "SETNAME", "txn"))
+		tc.MustRead(t, "+OK")
+		require.NoError(t, tc.WriteArgs("WATCH", "a", "b"))
+		tc.MustRead(t, "+OK")
+		require.NoError(t, tc.WriteArgs("MULTI"))
+		tc.MustRead(t, "+OK")
+		require.NoError(t, tc.WriteArgs("SET", "a", "1"))
+		tc.MustRead(t, "+QUEUED")
+
+		var txn map[string]string
+		for _, line := range strings.Split(strings.TrimSpace(rdb.ClientList(ctx).Val()), "\n") {
+			if info := parseClientInfo(t, line); info["name"] == "txn" {
+				txn = info
+			}
+		}
+		require.NotNil(t, txn)
+		require.Equal(t, "1", txn["multi"])
+		require.Equal(t, "2", txn["watch"])
+
+		require.NoError(t, tc.WriteArgs("DISCARD"))
+		tc.MustRead(t, "+OK")
+	})
+
+	t.Run("CLIENT SETINFO rejects invalid attributes", func(t *testing.T) {
+		util.ErrorRegexp(t, rdb.Do(ctx, "CLIENT", "SETINFO", "lib-foo", "bar").Err(), ".*Unrecognized option.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "CLIENT", "SETINFO", "lib-name", "go redis").Err(), ".*cannot contain spaces.*")
+		require.Error(t, rdb.Do(ctx, "CLIENT", "SETINFO", "lib-name").Err())
 	})
 
 	t.Run("MONITOR can log executed commands", func(t *testing.T) {
@@ -239,6 +319,76 @@ func TestIntrospection(t *testing.T) {
 		}, 5*time.Second, 100*time.Millisecond)
 	})
 
+	t.Run("CLIENT NO-TOUCH and NO-EVICT set the client flags", func(t *testing.T) {
+		c := srv.NewClientWithOption(&redis.Options{PoolSize: 1})
+		defer func() { require.NoError(t, c.Close()) }()
+
+		require.Equal(t, "OK", c.Do(ctx, "CLIENT", "NO-TOUCH", "on").Val())
+		require.Regexp(t, ".* flags=T .*", c.Do(ctx, "CLIENT", "INFO").Val())
+		require.Equal(t, "OK", c.Do(ctx, "CLIENT", "NO-EVICT", "ON").Val())
+		require.Regexp(t, ".* flags=Te .*", c.Do(ctx, "CLIENT", "INFO").Val())
+		require.Equal(t, "OK", c.Do(ctx, "CLIENT", "NO-TOUCH", "off").Val())
+		require.Regexp(t, ".* flags=e .*", c.Do(ctx, "CLIENT", "INFO").Val())
+		require.Equal(t, "OK", c.Do(ctx, "CLIENT", "NO-EVICT", "off").Val())
+		require.Regexp(t, ".* flags=N .*", c.Do(ctx, "CLIENT", "INFO").Val())
+
+		util.ErrorRegexp(t, c.Do(ctx, "CLIENT", "NO-TOUCH", "yes").Err(), ".*syntax.*")
+		util.ErrorRegexp(t, c.Do(ctx, "CLIENT", "NO-EVICT").Err(), ".*Syntax error.*")
+	})
+
+	t.Run("Kill client by multiple filters", func(t *testing.T) {
+		c := srv.NewClientWithOption(&redis.Options{PoolSize: 1})
+		defer func() { require.NoError(t, c.Close()) }()
+
+		id := c.ClientID(ctx).Val()
+		info := c.Do(ctx, "CLIENT", "INFO").Val().(string)
+		laddr := regexp.MustCompile(`laddr=(\S+)`).FindStringSubmatch(info)[1]
+		ns := regexp.MustCompile(`namespace=(\S+)`).FindStringSubmatch(info)[1]
+		require.Equal(t, srv.HostPort(), laddr)
+
+		// a client is killed only if all the filters match
+		idStr := strconv.FormatInt(id, 10)
+		require.EqualValues(t, 0, rdb.ClientKillByFilter(ctx, "id", idStr, "type", "pubsub").Val())
+		require.EqualValues(t, 0, rdb.ClientKillByFilter(ctx, "id", idStr, "laddr", "127.0.0.1:1").Val())
+		require.EqualValues(t, 0, rdb.ClientKillByFilter(ctx, "id", idStr, "user", "no-such-namespace").Val())
+		require.EqualValues(t, 0, rdb.ClientKillByFilter(ctx, "id", idStr, "maxage", "100").Val())
+
+		// only the clients older than MAXAGE are killed, so a client of exactly MAXAGE seconds is kept
+		age := func() int64 {
+			info := c.Do(ctx, "CLIENT", "INFO").Val().(string)
+			v, err := strconv.ParseInt(regexp.MustCompile(`age=(\d+)`).FindStringSubmatch(info)[1], 10, 64)
+			require.NoError(t, err)
+			return v
+		}
+		time.Sleep(1100 * time.Millisecond)
+		for {
+			before := age()
+			killed := rdb.ClientKillByFilter(ctx, "id", idStr, "maxage", strconv.FormatInt(before, 10)).Val()
+			// retry if the age changed during the kill, since it's truncated to seconds
+			if age() == before {
+				require.EqualValues(t, 0, killed)
+				break
+			}
+		}
+
+		// the age is in whole seconds, so the client is older than 1 second after 2 seconds
+		time.Sleep(2100 * time.Millisecond)
+		require.EqualValues(t, 1, rdb.ClientKillByFilter(ctx, "id", idStr, "type", "normal", "laddr", laddr,
+			"user", ns, "maxage", "1").Val())
+
+		// now the client should no longer be listed
+		require.Eventually(t, func() bool {
+			r := rdb.ClientList(ctx).Val()
+			return !strings.Contains(r, fmt.Sprintf("id=%d ", id))
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("Kill client with invalid filters", func(t *testing.T) {
+		util.ErrorRegexp(t, rdb.Do(ctx, "CLIENT", "KILL", "id", "1", "laddr").Err(), ".*syntax.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "CLIENT", "KILL", "maxage", "abc").Err(), ".*not an integer.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "CLIENT", "KILL", "unknown", "1").Err(), ".*syntax.*")
+	})
+
 	t.Run("DEBUG will freeze server", func(t *testing.T) {
 		// use TCPClient to avoid waiting for reply
 		c := srv.NewTCPClient()
@@ -326,3 +476,13 @@ func TestMultiServerIntrospection(t *testing.T) {
 		}, 5*time.Second, 100*time.Millisecond)
 	})
 }
+
+func parseClientInfo(t testing.TB, line string) map[string]string {
+	info := make(map[string]string)
+	for _, field := range strings.Fields(line) {
+		k, v, ok := strings.Cut(field, "=")
+		require.True(t, ok, "invalid field %s in the client info", field)
+		info[k] = v
+	}
+	return info
+}
@@ -21,11 +21,15 @@ package keyspace
 
 import (
 	"context"
+	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 )
 
@@ -103,6 +107,22 @@ func TestKeyspace(t *testing.T) {
 		require.EqualValues(t, 0, rdb.Exists(ctx, emptyKey).Val())
 	})
 
+	t.Run("Binary-unsafe keys and values round trip", func(t *testing.T) {
+		for _, key := range util.EdgeCaseKeys() {
+			util.RequireRoundTrip(t, rdb, key, util.RandomBinaryValue(64))
+			util.RequireRoundTrip(t, rdb, key, util.RandomUnicodeString(1, 64))
+		}
+		for i := 0; i < 100; i++ {
+			util.RequireRoundTrip(t, rdb, util.RandomBinaryKey(), util.RandomBinaryValue(int(util.RandomInt(1024))))
+		}
+		require.NoError(t, rdb.FlushDB(ctx).Err())
+	})
+
+	t.Run("Huge value round trip", func(t *testing.T) {
+		util.RequireRoundTrip(t, rdb, "huge\x00key", util.HugeValue(32*1024*1024))
+		require.NoError(t, rdb.FlushDB(ctx).Err())
+	})
+
 	t.Run("Commands pipelining", func(t *testing.T) {
 		c := srv.NewTCPClient()
 		defer func() { require.NoError(t, c.Close()) }()
@@ -135,6 +155,46 @@ func TestKeyspace(t *testing.T) {
 	t.Run("RANDOMKEY against empty DB", func(t *testing.T) {
 		rdb.FlushDB(ctx)
 		require.Equal(t, "", rdb.RandomKey(ctx).Val())
+		require.Equal(t, redis.Nil, rdb.RandomKey(ctx).Err())
+	})
+
+	t.Run("RANDOMKEY samples the whole keyspace", func(t *testing.T) {
+		rdb.FlushDB(ctx)
+		for i := 0; i < 200; i++ {
+			require.NoError(t, rdb.Set(ctx, fmt.Sprintf("key:%03d", i), "v", 0).Err())
+		}
+
+		seen := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			seen[rdb.RandomKey(ctx).Val()] = true
+		}
+		// about 79 distinct keys are expected to be picked
+		require.Greater(t, len(seen), 50)
+
+		for i := 0; i < 199; i++ {
+			require.NoError(t, rdb.Del(ctx, fmt.Sprintf("key:%03d", i)).Err())
+		}
+		for i := 0; i < 10; i++ {
+			require.Equal(t, "key:199", rdb.RandomKey(ctx).Val())
+		}
+	})
+
+	t.Run("RANDOMKEY is not biased towards the sparse keys", func(t *testing.T) {
+		rdb.FlushDB(ctx)
+		require.NoError(t, rdb.Set(ctx, "a", "v", 0).Err())
+		for i := 0; i < 200; i++ {
+			require.NoError(t, rdb.Set(ctx, fmt.Sprintf("b:%03d", i), "v", 0).Err())
+		}
+
+		hits := 0
+		for i := 0; i < 300; i++ {
+			if rdb.RandomKey(ctx).Val() == "a" {
+				hits++
+			}
+		}
+		// "a" is expected to be picked about 1.5 times, but it would be about 150 times
+		// if the first byte was picked at random
+		require.Less(t, hits, 60)
 	})
 
 	t.Run("RANDOMKEY regression 1", func(t *testing.T) {
@@ -205,4 +265,250 @@ func TestKeyspace(t *testing.T) {
 		time.Sleep(2 * expireTime)
 		require.Equal(t, "none", rdb.Type(ctx, key).Val())
 	})
+
+	t.Run("OBJECT DETAILS shows the internal metadata of a key", func(t *testing.T) {
+		objectDetails := func(key string) map[string]string {
+			infos, err := rdb.Do(ctx, "OBJECT", "DETAILS", key).StringSlice()
+			require.NoError(t, err)
+			require.Zero(t, len(infos)%2)
+			details := make(map[string]string)
+			for i := 0; i < len(infos); i += 2 {
+				details[infos[i]] = infos[i+1]
+			}
+			return details
+		}
+
+		require.NoError(t, rdb.Del(ctx, "details-string", "details-hash", "details-zset").Err())
+		require.Empty(t, objectDetails("details-string"))
+
+		require.NoError(t, rdb.Set(ctx, "details-string", "value", time.Hour).Err())
+		details := objectDetails("details-string")
+		require.Equal(t, "string", details["type"])
+		require.Equal(t, "inline", details["encoding"])
+		require.Equal(t, "0", details["subkeys"])
+		require.NotEqual(t, "0", details["pexpire"])
+
+		for i := 0; i < 100; i++ {
+			require.NoError(t, rdb.HSet(ctx, "details-hash", fmt.Sprintf("field%d", i), "value").Err())
+			require.NoError(t, rdb.ZAdd(ctx, "details-zset", redis.Z{Score: float64(i), Member: i}).Err())
+		}
+		details = objectDetails("details-hash")
+		require.Equal(t, "hash", details["type"])
+		require.Equal(t, "subkeys", details["encoding"])
+		require.Equal(t, "100", details["size"])
+		require.Equal(t, "100", details["subkeys"])
+		require.Equal(t, "0", details["pexpire"])
+		require.Contains(t, details, "version")
+		require.Contains(t, details, "approximate_size")
+		require.Contains(t, details, "sst_files")
+		require.Equal(t, "100", objectDetails("details-zset")["subkeys"])
+
+		// the key is located in SST files after compaction
+		require.NoError(t, rdb.Do(ctx, "COMPACT").Err())
+		require.Eventually(t, func() bool {
+			return objectDetails("details-hash")["sst_files"] != "0"
+		}, 10*time.Second, 100*time.Millisecond)
+		details = objectDetails("details-hash")
+		require.Regexp(t, `^L\d+:\d+(,L\d+:\d+)*$`, details["sst_levels"])
+		size, err := strconv.ParseInt(details["approximate_size"], 10, 64)
+		require.NoError(t, err)
+		require.Greater(t, size, int64(0))
+
+		util.ErrorRegexp(t, rdb.Do(ctx, "OBJECT", "FOO", "details-hash").Err(), ".*dump, details or encoding.*")
+	})
+
+	t.Run("OBJECT ENCODING returns the encodings of the types", func(t *testing.T) {
+		require.NoError(t, rdb.Set(ctx, "encoding-int", "12345", 0).Err())
+		require.NoError(t, rdb.Set(ctx, "encoding-embstr", "value", 0).Err())
+		require.NoError(t, rdb.Set(ctx, "encoding-raw", strings.Repeat("x", 45), 0).Err())
+		require.NoError(t, rdb.HSet(ctx, "encoding-hash", "field", "value").Err())
+		require.NoError(t, rdb.RPush(ctx, "encoding-list", "a").Err())
+		require.NoError(t, rdb.SAdd(ctx, "encoding-set", "a").Err())
+		require.NoError(t, rdb.ZAdd(ctx, "encoding-zset", redis.Z{Score: 1, Member: "a"}).Err())
+		require.NoError(t, rdb.SetBit(ctx, "encoding-bitmap", 100, 1).Err())
+		require.NoError(t, rdb.XAdd(ctx, &redis.XAddArgs{Stream: "encoding-stream", Values: []string{"k", "v"}}).Err())
+
+		for key, encoding := range map[string]string{
+			"encoding-int":    "int",
+			"encoding-embstr": "embstr",
+			"encoding-raw":    "raw",
+			"encoding-hash":   "hashtable",
+			"encoding-list":   "quicklist",
+			"encoding-set":    "hashtable",
+			"encoding-zset":   "skiplist",
+			"encoding-bitmap": "raw",
+			"encoding-stream": "stream",
+		} {
+			require.Equal(t, encoding, rdb.ObjectEncoding(ctx, key).Val(), key)
+		}
+		require.ErrorIs(t, rdb.ObjectEncoding(ctx, "encoding-not-exist").Err(), redis.Nil)
+	})
+
+	t.Run("OBJECT FREQ and IDLETIME report the key accesses", func(t *testing.T) {
+		util.ErrorRegexp(t, rdb.Do(ctx, "OBJECT", "FREQ", "access-key").Err(), ".*key access tracking is disabled.*")
+		util.ErrorRegexp(t, rdb.ObjectIdleTime(ctx, "access-key").Err(), ".*key access tracking is disabled.*")
+
+		require.NoError(t, rdb.ConfigSet(ctx, "key-access-tracking", "yes").Err())
+		defer func() { require.NoError(t, rdb.ConfigSet(ctx, "key-access-tracking", "no").Err()) }()
+
+		require.ErrorIs(t, rdb.Do(ctx, "OBJECT", "FREQ", "access-not-exist").Err(), redis.Nil)
+		require.ErrorIs(t, rdb.ObjectIdleTime(ctx, "access-not-exist").Err(), redis.Nil)
+
+		require.NoError(t, rdb.Set(ctx, "access-key", "value", 0).Err())
+		require.NoError(t, rdb.Set(ctx, "access-cold", "value", 0).Err())
+		for i := 0; i < 100; i++ {
+			require.NoError(t, rdb.Get(ctx, "access-key").Err())
+		}
+		hotFreq, err := rdb.Do(ctx, "OBJECT", "FREQ", "access-key").Int()
+		require.NoError(t, err)
+		coldFreq, err := rdb.Do(ctx, "OBJECT", "FREQ", "access-cold").Int()
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, coldFreq, 5)
+		require.Greater(t, hotFreq, coldFreq)
+
+		time.Sleep(2 * time.Second)
+		require.NoError(t, rdb.Get(ctx, "access-key").Err())
+		// OBJECT itself and TTL don't count as accesses
+		require.NoError(t, rdb.TTL(ctx, "access-cold").Err())
+		require.EqualValues(t, 0, rdb.ObjectIdleTime(ctx, "access-key").Val())
+		require.GreaterOrEqual(t, rdb.ObjectIdleTime(ctx, "access-cold").Val(), time.Second)
+
+		util.ErrorRegexp(t, rdb.Do(ctx, "OBJECT", "foo", "access-key").Err(), ".*must be dump, details, encoding, freq or idletime.*")
+	})
+
+	t.Run("OBJECT FREQ ignores the accesses of clients in the no-touch mode", func(t *testing.T) {
+		require.NoError(t, rdb.ConfigSet(ctx, "key-access-tracking", "yes").Err())
+		defer func() { require.NoError(t, rdb.ConfigSet(ctx, "key-access-tracking", "no").Err()) }()
+
+		c := srv.NewClientWithOption(&redis.Options{PoolSize: 1})
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.Do(ctx, "CLIENT", "NO-TOUCH", "on").Err())
+
+		require.NoError(t, rdb.Set(ctx, "no-touch-key", "value", 0).Err())
+		freq, err := rdb.Do(ctx, "OBJECT", "FREQ", "no-touch-key").Int()
+		require.NoError(t, err)
+		for i := 0; i < 100; i++ {
+			require.NoError(t, c.Get(ctx, "no-touch-key").Err())
+		}
+		require.EqualValues(t, freq, rdb.Do(ctx, "OBJECT", "FREQ", "no-touch-key").Val())
+
+		require.NoError(t, c.Do(ctx, "CLIENT", "NO-TOUCH", "off").Err())
+		for i := 0; i < 100; i++ {
+			require.NoError(t, c.Get(ctx, "no-touch-key").Err())
+		}
+		newFreq, err := rdb.Do(ctx, "OBJECT", "FREQ", "no-touch-key").Int()
+		require.NoError(t, err)
+		require.Greater(t, newFreq, freq)
+	})
+
+	t.Run("BIGKEYS reports the largest keys of each type", func(t *testing.T) {
+		require.NoError(t, rdb.FlushDB(ctx).Err())
+		bigKeys := func() map[string]interface{} {
+			r, err := rdb.Do(ctx, "BIGKEYS").Slice()
+			require.NoError(t, err)
+			result := make(map[string]interface{})
+			for i := 0; i < len(r); i += 2 {
+				result[r[i].(string)] = r[i+1]
+			}
+			return result
+		}
+		require.Empty(t, bigKeys()["keys"])
+
+		for i := 1; i <= 5; i++ {
+			require.NoError(t, rdb.Set(ctx, fmt.Sprintf("big-string%d", i), strings.Repeat("x", i*10), 0).Err())
+			for j := 0; j < i; j++ {
+				require.NoError(t, rdb.RPush(ctx, fmt.Sprintf("big-list%d", i), j).Err())
+			}
+		}
+		require.NoError(t, rdb.Do(ctx, "BIGKEYS", "SCAN", "COUNT", 2, "RATE", 100).Err())
+		require.Eventually(t, func() bool {
+			info := bigKeys()
+			return info["scanning"] == int64(0) && info["last_scan_time"] != int64(0)
+		}, 5*time.Second, 100*time.Millisecond)
+
+		info := bigKeys()
+		require.EqualValues(t, 10, info["scanned_keys"])
+		require.Equal(t, []interface{}{
+			[]interface{}{"string", "big-string5", int64(50)},
+			[]interface{}{"string", "big-string4", int64(40)},
+			[]interface{}{"list", "big-list5", int64(5)},
+			[]interface{}{"list", "big-list4", int64(4)},
+		}, info["keys"])
+
+		util.ErrorRegexp(t, rdb.Do(ctx, "BIGKEYS", "FOO").Err(), ".*only supports scan.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "BIGKEYS", "SCAN", "COUNT", 0).Err(), ".*out of.*range.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "BIGKEYS", "SCAN", "FOO").Err(), ".*syntax.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "BIGKEYS", "SCAN", "RATE", "18446744073709551615").Err(), ".*out of.*range.*")
+
+		// the rate limited scan doesn't block the other background jobs
+		require.NoError(t, rdb.Do(ctx, "BIGKEYS", "SCAN", "RATE", 1).Err())
+		util.ErrorRegexp(t, rdb.Do(ctx, "BIGKEYS", "SCAN").Err(), ".*scanning big keys now.*")
+		require.NoError(t, rdb.Do(ctx, "DBSIZE", "SCAN").Err())
+		require.Eventually(t, func() bool {
+			return rdb.Do(ctx, "DBSIZE").Val() == int64(10)
+		}, 5*time.Second, 100*time.Millisecond)
+		require.EqualValues(t, 1, bigKeys()["scanning"])
+	})
+
+	t.Run("COPY duplicates keys of every type", func(t *testing.T) {
+		require.NoError(t, rdb.FlushDB(ctx).Err())
+		require.NoError(t, rdb.Set(ctx, "copy-string", "value", time.Hour).Err())
+		require.NoError(t, rdb.HSet(ctx, "copy-hash", "f1", "v1", "f2", "v2").Err())
+		require.NoError(t, rdb.RPush(ctx, "copy-list", "a", "b", "c").Err())
+		require.NoError(t, rdb.SAdd(ctx, "copy-set", "a", "b").Err())
+		require.NoError(t, rdb.ZAdd(ctx, "copy-zset", redis.Z{Score: 1, Member: "a"}, redis.Z{Score: 2, Member: "b"}).Err())
+		require.NoError(t, rdb.XAdd(ctx, &redis.XAddArgs{Stream: "copy-stream", ID: "1-1", Values: []string{"k", "v"}}).Err())
+
+		for _, key := range []string{"copy-string", "copy-hash", "copy-list", "copy-set", "copy-zset", "copy-stream"} {
+			require.EqualValues(t, 1, rdb.Copy(ctx, key, key+"-dst", 0, false).Val(), key)
+		}
+		require.Equal(t, "value", rdb.Get(ctx, "copy-string-dst").Val())
+		require.Greater(t, rdb.TTL(ctx, "copy-string-dst").Val(), time.Duration(0))
+		require.Equal(t, map[string]string{"f1": "v1", "f2": "v2"}, rdb.HGetAll(ctx, "copy-hash-dst").Val())
+		require.Equal(t, []string{"a", "b", "c"}, rdb.LRange(ctx, "copy-list-dst", 0, -1).Val())
+		require.ElementsMatch(t, []string{"a", "b"}, rdb.SMembers(ctx, "copy-set-dst").Val())
+		require.Equal(t, []redis.Z{{Score: 1, Member: "a"}, {Score: 2, Member: "b"}},
+			rdb.ZRangeByScoreWithScores(ctx, "copy-zset-dst", &redis.ZRangeBy{Min: "-inf", Max: "+inf"}).Val())
+		require.Equal(t, []redis.XMessage{{ID: "1-1", Values: map[string]interface{}{"k": "v"}}},
+			rdb.XRange(ctx, "copy-stream-dst", "-", "+").Val())
+
+		// the copy is independent of the source
+		require.NoError(t, rdb.RPush(ctx, "copy-list-dst", "d").Err())
+		require.EqualValues(t, 3, rdb.LLen(ctx, "copy-list").Val())
+		require.EqualValues(t, 4, rdb.LLen(ctx, "copy-list-dst").Val())
+	})
+
+	t.Run("COPY with REPLACE and DB options", func(t *testing.T) {
+		require.NoError(t, rdb.FlushDB(ctx).Err())
+		require.NoError(t, rdb.SAdd(ctx, "copy-src", "a", "b").Err())
+		require.NoError(t, rdb.SAdd(ctx, "copy-dst", "c").Err())
+
+		require.EqualValues(t, 0, rdb.Copy(ctx, "copy-not-exist", "copy-dst", 0, false).Val())
+		require.EqualValues(t, 0, rdb.Copy(ctx, "copy-src", "copy-dst", 0, false).Val())
+		require.Equal(t, []string{"c"}, rdb.SMembers(ctx, "copy-dst").Val())
+		require.EqualValues(t, 1, rdb.Copy(ctx, "copy-src", "copy-dst", 0, true).Val())
+		require.ElementsMatch(t, []string{"a", "b"}, rdb.SMembers(ctx, "copy-dst").Val())
+
+		util.ErrorRegexp(t, rdb.Copy(ctx, "copy-src", "copy-src", 0, true).Err(), ".*are the same.*")
+		util.ErrorRegexp(t, rdb.Copy(ctx, "copy-src", "copy-dst", 1, true).Err(), ".*DB index is out of range.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "COPY", "copy-src", "copy-dst", "FOO").Err(), ".*syntax.*")
+	})
+
+	t.Run("COPY wakes up the clients blocked on the destination", func(t *testing.T) {
+		require.NoError(t, rdb.FlushDB(ctx).Err())
+		require.NoError(t, rdb.RPush(ctx, "copy-list", "a", "b").Err())
+		require.NoError(t, rdb.ZAdd(ctx, "copy-zset", redis.Z{Score: 1, Member: "m"}).Err())
+
+		c := srv.NewTCPClient()
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.WriteArgs("BLPOP", "copy-list-dst", "0"))
+		time.Sleep(100 * time.Millisecond)
+		require.EqualValues(t, 1, rdb.Copy(ctx, "copy-list", "copy-list-dst", 0, false).Val())
+		c.MustReadStrings(t, []string{"copy-list-dst", "a"})
+
+		require.NoError(t, c.WriteArgs("BZPOPMIN", "copy-zset-dst", "0"))
+		time.Sleep(100 * time.Millisecond)
+		require.EqualValues(t, 1, rdb.Copy(ctx, "copy-zset", "copy-zset-dst", 0, false).Val())
+		c.MustReadStrings(t, []string{"copy-zset-dst", "m", "1"})
+	})
 }
@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package latency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatency(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+	ctx := context.Background()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("LATENCY - nothing is sampled when disabled", func(t *testing.T) {
+		require.NoError(t, rdb.Do(ctx, "debug", "sleep", 0.1).Err())
+		require.Empty(t, rdb.Do(ctx, "LATENCY", "LATEST").Val())
+	})
+
+	t.Run("LATENCY - slow commands are sampled", func(t *testing.T) {
+		require.NoError(t, rdb.ConfigSet(ctx, "latency-monitor-threshold", "50").Err())
+		require.NoError(t, rdb.Ping(ctx).Err())
+		require.Empty(t, rdb.Do(ctx, "LATENCY", "LATEST").Val())
+
+		begin := time.Now().Unix()
+		require.NoError(t, rdb.Do(ctx, "debug", "sleep", 0.1).Err())
+		latest, err := rdb.Do(ctx, "LATENCY", "LATEST").Slice()
+		require.NoError(t, err)
+		require.Len(t, latest, 1)
+		event := latest[0].([]interface{})
+		require.Equal(t, "command", event[0])
+		require.GreaterOrEqual(t, event[1].(int64), begin)
+		require.GreaterOrEqual(t, event[2].(int64), int64(100))
+		require.GreaterOrEqual(t, event[3].(int64), event[2].(int64))
+
+		history, err := rdb.Do(ctx, "LATENCY", "HISTORY", "command").Slice()
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		sample := history[0].([]interface{})
+		require.Equal(t, event[1], sample[0])
+		require.Equal(t, event[2], sample[1])
+
+		require.Empty(t, rdb.Do(ctx, "LATENCY", "HISTORY", "unknown").Val())
+	})
+
+	t.Run("LATENCY - reset the events", func(t *testing.T) {
+		require.EqualValues(t, 0, rdb.Do(ctx, "LATENCY", "RESET", "unknown").Val())
+		require.EqualValues(t, 1, rdb.Do(ctx, "LATENCY", "RESET").Val())
+		require.Empty(t, rdb.Do(ctx, "LATENCY", "LATEST").Val())
+		require.Empty(t, rdb.Do(ctx, "LATENCY", "HISTORY", "command").Val())
+	})
+
+	t.Run("LATENCY HISTOGRAM - cumulative buckets per command", func(t *testing.T) {
+		require.Empty(t, rdb.Do(ctx, "LATENCY", "HISTOGRAM", "lpush", "unknown").Val())
+		for i := 0; i < 10; i++ {
+			require.NoError(t, rdb.Set(ctx, "histogram-key", i, 0).Err())
+		}
+
+		histogram, err := rdb.Do(ctx, "LATENCY", "HISTOGRAM", "SET", "lpush", "unknown").Slice()
+		require.NoError(t, err)
+		require.Len(t, histogram, 2)
+		require.Equal(t, "set", histogram[0])
+		detail := histogram[1].([]interface{})
+		require.Len(t, detail, 4)
+		require.Equal(t, "calls", detail[0])
+		require.EqualValues(t, 10, detail[1])
+		require.Equal(t, "histogram_usec", detail[2])
+		buckets := detail[3].([]interface{})
+		require.NotEmpty(t, buckets)
+		require.Zero(t, len(buckets)%2)
+		var lastBound, lastCount int64
+		for i := 0; i < len(buckets); i += 2 {
+			bound, count := buckets[i].(int64), buckets[i+1].(int64)
+			require.Zero(t, bound&(bound-1), "bound %d should be a power of two", bound)
+			require.Greater(t, bound, lastBound)
+			require.Greater(t, count, lastCount)
+			lastBound, lastCount = bound, count
+		}
+		require.EqualValues(t, 10, lastCount)
+
+		all, err := rdb.Do(ctx, "LATENCY", "HISTOGRAM").Slice()
+		require.NoError(t, err)
+		require.Contains(t, all, "set")
+		require.Contains(t, all, "latency")
+	})
+
+	t.Run("LATENCY - invalid subcommands", func(t *testing.T) {
+		require.ErrorContains(t, rdb.Do(ctx, "LATENCY", "FOO").Err(), "LATENCY subcommand must be one of")
+		require.ErrorContains(t, rdb.Do(ctx, "LATENCY", "HISTORY").Err(), "LATENCY subcommand must be one of")
+		require.ErrorContains(t, rdb.Do(ctx, "LATENCY", "LATEST", "foo").Err(), "LATENCY subcommand must be one of")
+	})
+}
+
+func TestLatencyWithNamespace(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{"requirepass": "foobared"})
+	defer srv.Close()
+	ctx := context.Background()
+	rdb := srv.NewAdminClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+	nsClient := srv.NewNamespaceClient("ns1")
+	defer func() { require.NoError(t, nsClient.Close()) }()
+
+	t.Run("LATENCY RESET requires the admin permission", func(t *testing.T) {
+		util.ErrorRegexp(t, nsClient.Do(ctx, "LATENCY", "RESET").Err(), ".*admin permission.*")
+		require.NoError(t, nsClient.Do(ctx, "LATENCY", "LATEST").Err())
+		require.NoError(t, rdb.Do(ctx, "LATENCY", "RESET").Err())
+	})
+}
@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("audit log is disabled by default and can't be changed at runtime", func(t *testing.T) {
+		srv := util.StartServer(t, map[string]string{})
+		defer srv.Close()
+		rdb := srv.NewClient()
+		defer func() { require.NoError(t, rdb.Close()) }()
+
+		auditLogPath := filepath.Join(srv.Dir(), "audit.log")
+		require.NoError(t, rdb.FlushAll(ctx).Err())
+		require.NoFileExists(t, auditLogPath)
+		util.ErrorRegexp(t, rdb.ConfigSet(ctx, "audit-log", auditLogPath).Err(), ".*Unsupported CONFIG parameter.*")
+	})
+
+	auditLogPath := filepath.Join(t.TempDir(), "audit.log")
+	srv := util.StartServer(t, map[string]string{"requirepass": "admin-token", "audit-log": auditLogPath})
+	defer srv.Close()
+
+	rdb := srv.NewClientWithOption(&redis.Options{Password: "admin-token", PoolSize: 1})
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	readAuditLog := func() []string {
+		content, err := os.ReadFile(auditLogPath)
+		require.NoError(t, err)
+		return strings.Split(strings.TrimSpace(string(content)), "\n")
+	}
+
+	t.Run("administrative commands are recorded", func(t *testing.T) {
+		require.NoError(t, rdb.Do(ctx, "CLIENT", "SETNAME", "auditor").Err())
+		require.NoError(t, rdb.ConfigSet(ctx, "masterauth", "secret").Err())
+		require.NoError(t, rdb.ConfigGet(ctx, "maxclients").Err())
+		require.NoError(t, rdb.Set(ctx, "key", "value", 0).Err())
+		require.NoError(t, rdb.Do(ctx, "NAMESPACE", "ADD", "audit-ns", "audit-token").Err())
+		require.NoError(t, rdb.FlushDB(ctx).Err())
+		require.Error(t, rdb.Do(ctx, "FAILOVER").Err())
+
+		nsClient := srv.NewClientWithOption(&redis.Options{Password: "audit-token"})
+		defer func() { require.NoError(t, nsClient.Close()) }()
+		require.Error(t, nsClient.Do(ctx, "NAMESPACE", "DEL", "audit-ns").Err())
+
+		lines := readAuditLog()
+		require.Len(t, lines, 5)
+		require.Regexp(t, `^\d+\.\d{6} \[__namespace 127\.0\.0\.1:\d+\] id=\d+ name=auditor result=ok `+
+			`"config" "set" "masterauth" "\(redacted\)"$`, lines[0])
+		require.Contains(t, lines[1], `name=auditor result=ok "NAMESPACE" "ADD" "audit-ns" "(redacted)"`)
+		require.Contains(t, lines[2], `name=auditor result=ok "flushdb"`)
+		require.Contains(t, lines[3], `name=auditor result=err "FAILOVER"`)
+		require.Regexp(t, `\[audit-ns .*\] .* result=err "NAMESPACE" "DEL" "audit-ns"$`, lines[4])
+		require.NotContains(t, strings.Join(lines, "\n"), "secret")
+		require.NotContains(t, strings.Join(lines, "\n"), "audit-token")
+	})
+}
@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package log
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLogFormat(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{
+		"log-format": "json",
+	})
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("log-format can't be changed at runtime", func(t *testing.T) {
+		require.Equal(t, map[string]string{"log-format": "json"}, rdb.ConfigGet(ctx, "log-format").Val())
+		require.Error(t, rdb.ConfigSet(ctx, "log-format", "text").Err())
+	})
+
+	t.Run("every log line is a structured record", func(t *testing.T) {
+		// the line is logged on startup, so match the whole log file
+		srv.WaitForLogPatternSince(t, 0, `"component":"server","msg":"Ready to accept connections"`, 5*time.Second)
+
+		f, err := os.Open(srv.LogFilePath())
+		require.NoError(t, err)
+		defer func() { require.NoError(t, f.Close()) }()
+
+		lines := 0
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var record struct {
+				TS        string                 `json:"ts"`
+				Level     string                 `json:"level"`
+				Thread    string                 `json:"thread"`
+				Component string                 `json:"component"`
+				Msg       string                 `json:"msg"`
+				Fields    map[string]interface{} `json:"fields"`
+			}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &record), "invalid log line: %s", scanner.Text())
+			_, err := time.Parse(time.RFC3339Nano, record.TS)
+			require.NoError(t, err)
+			require.Contains(t, []string{"INFO", "WARNING", "ERROR"}, record.Level)
+			require.NotEmpty(t, record.Component)
+			require.Contains(t, record.Fields, "file")
+			require.Contains(t, record.Fields, "line")
+			lines++
+		}
+		require.NoError(t, scanner.Err())
+		require.Greater(t, lines, 0)
+	})
+}
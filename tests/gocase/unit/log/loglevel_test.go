@@ -0,0 +1,112 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ */
+
+package log
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogComponentVerbosity(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("log-level can be changed at runtime", func(t *testing.T) {
+		require.NoError(t, rdb.ConfigSet(ctx, "log-level", "warning").Err())
+		require.Equal(t, map[string]string{"log-level": "warning"}, rdb.ConfigGet(ctx, "log-level").Val())
+		require.NoError(t, rdb.ConfigSet(ctx, "log-level", "info").Err())
+		require.Error(t, rdb.ConfigSet(ctx, "log-level", "verbose").Err())
+	})
+
+	t.Run("log-component-verbosity only accepts known components", func(t *testing.T) {
+		require.Equal(t, map[string]string{"log-component-verbosity": ""}, rdb.ConfigGet(ctx, "log-component-verbosity").Val())
+		require.NoError(t, rdb.ConfigSet(ctx, "log-component-verbosity", "replication:2 migration").Err())
+		require.Equal(t, map[string]string{"log-component-verbosity": "replication:2 migration"},
+			rdb.ConfigGet(ctx, "log-component-verbosity").Val())
+
+		util.ErrorRegexp(t, rdb.ConfigSet(ctx, "log-component-verbosity", "server").Err(), ".*unknown log component.*")
+		util.ErrorRegexp(t, rdb.ConfigSet(ctx, "log-component-verbosity", "rocksdb:x").Err(), ".*invalid verbosity.*")
+		require.NoError(t, rdb.ConfigSet(ctx, "log-component-verbosity", "").Err())
+	})
+
+	t.Run("verbose logs of a component can be turned on at runtime", func(t *testing.T) {
+		offset := srv.LogOffset(t)
+		require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+		require.NoError(t, rdb.Do(ctx, "compact").Err())
+		srv.WaitForLogPatternSince(t, offset, `event_listener/compaction_completed`, 10*time.Second)
+		srv.FailIfLogMatches(t, `event_listener/compaction_begin`)
+
+		offset = srv.LogOffset(t)
+		require.NoError(t, rdb.ConfigSet(ctx, "log-component-verbosity", "rocksdb").Err())
+		require.NoError(t, rdb.Set(ctx, "foo", "baz", 0).Err())
+		// the first compaction may be still running on other column families, which rejects a new one
+		require.Eventually(t, func() bool {
+			return rdb.Do(ctx, "compact").Err() == nil
+		}, 10*time.Second, 100*time.Millisecond)
+		srv.WaitForLogPatternSince(t, offset, `event_listener/compaction_begin`, 10*time.Second)
+	})
+}
+
+// failureRecorder records the failures reported to it instead of failing the test.
+type failureRecorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *failureRecorder) Errorf(format string, args ...interface{}) { r.failed = true }
+
+func (r *failureRecorder) FailNow() { r.failed = true }
+
+func TestLogMatchHelpers(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("WaitForLogPattern ignores the lines logged before the call", func(t *testing.T) {
+		require.NoError(t, rdb.Do(ctx, "compact").Err())
+		srv.WaitForLogPattern(t, `Compact was triggered by manual`, 10*time.Second)
+
+		recorder := &failureRecorder{TB: t}
+		srv.WaitForLogPattern(recorder, `Compact was triggered by manual`, time.Second)
+		require.True(t, recorder.failed)
+
+		srv.WaitForLogPatternSince(t, 0, `Compact was triggered by manual`, time.Second)
+	})
+
+	t.Run("FailIfLogMatches fails only if the log matches", func(t *testing.T) {
+		srv.FailIfLogMatches(t, `no such log line`)
+
+		recorder := &failureRecorder{TB: t}
+		srv.FailIfLogMatches(recorder, `Ready to accept connections`)
+		require.True(t, recorder.failed)
+	})
+}
@@ -0,0 +1,66 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	srv := util.StartMetricsServer(t, map[string]string{})
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("Scrape the metrics of INFO fields", func(t *testing.T) {
+		metrics := srv.ScrapeMetrics(t)
+		require.Contains(t, metrics, "kvrocks_uptime_in_seconds")
+		require.Contains(t, metrics, "kvrocks_connected_clients")
+		require.EqualValues(t, srv.Port(), metrics["kvrocks_tcp_port"])
+		require.Contains(t, metrics, `kvrocks_estimate_keys{cf="default"}`)
+		require.NotContains(t, metrics, "kvrocks_redis_version")
+	})
+
+	t.Run("Metrics are only served locally by default", func(t *testing.T) {
+		require.Equal(t, map[string]string{"metrics-bind": "127.0.0.1"}, rdb.ConfigGet(ctx, "metrics-bind").Val())
+		require.ErrorContains(t, rdb.ConfigSet(ctx, "metrics-bind", "0.0.0.0").Err(), "Unsupported CONFIG parameter")
+	})
+
+	t.Run("Command stats are labeled by the command", func(t *testing.T) {
+		require.NoError(t, rdb.ConfigResetStat(ctx).Err())
+		for i := 0; i < 3; i++ {
+			require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+		}
+		require.NoError(t, rdb.LPush(ctx, "list", "a").Err())
+		require.Error(t, rdb.Get(ctx, "list").Err())
+
+		metrics := srv.ScrapeMetrics(t)
+		require.EqualValues(t, 3, metrics[`kvrocks_commandstats_calls{cmd="set"}`])
+		require.EqualValues(t, 1, metrics[`kvrocks_commandstats_failed_calls{cmd="get"}`])
+		require.EqualValues(t, 1, metrics[`kvrocks_errorstats_count{error="WRONGTYPE"}`])
+		require.Contains(t, metrics, `kvrocks_latency_percentiles_usec_p99{cmd="set"}`)
+	})
+}
@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package migrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	srcSrv := util.StartServer(t, map[string]string{})
+	defer srcSrv.Close()
+	dstSrv := util.StartServer(t, map[string]string{"requirepass": "foobared"})
+	defer dstSrv.Close()
+
+	ctx := context.Background()
+	src := srcSrv.NewClient()
+	defer func() { require.NoError(t, src.Close()) }()
+	dst := dstSrv.NewClientWithOption(&redis.Options{Password: "foobared"})
+	defer func() { require.NoError(t, dst.Close()) }()
+
+	host := dstSrv.Host()
+	port := dstSrv.Port()
+	migrate := func(args ...interface{}) *redis.Cmd {
+		return src.Do(ctx, append([]interface{}{"MIGRATE", host, port}, args...)...)
+	}
+
+	t.Run("MIGRATE a single key", func(t *testing.T) {
+		require.NoError(t, src.Set(ctx, "key", "value", time.Hour).Err())
+		require.Equal(t, "OK", migrate("key", 0, 1000, "AUTH", "foobared").Val())
+		require.EqualValues(t, 0, src.Exists(ctx, "key").Val())
+		require.Equal(t, "value", dst.Get(ctx, "key").Val())
+		util.BetweenValues(t, dst.TTL(ctx, "key").Val(), 50*time.Minute, time.Hour)
+	})
+
+	t.Run("MIGRATE missing keys", func(t *testing.T) {
+		require.Equal(t, "NOKEY", migrate("not-exist", 0, 1000, "AUTH", "foobared").Val())
+	})
+
+	t.Run("MIGRATE multiple keys with COPY and REPLACE", func(t *testing.T) {
+		require.NoError(t, src.RPush(ctx, "list", "a", "b").Err())
+		require.NoError(t, src.HSet(ctx, "hash", "f", "v").Err())
+		require.NoError(t, dst.Set(ctx, "list", "old", 0).Err())
+
+		util.ErrorRegexp(t, migrate("", 0, 1000, "AUTH", "foobared", "KEYS", "hash", "list").Err(),
+			".*Target instance replied with error.*already exists.*")
+		// the keys migrated before the error are removed
+		require.EqualValues(t, 0, src.Exists(ctx, "hash").Val())
+		require.EqualValues(t, 1, src.Exists(ctx, "list").Val())
+
+		require.Equal(t, "OK", migrate("", 0, 1000, "COPY", "REPLACE", "AUTH", "foobared", "KEYS", "list").Val())
+		require.Equal(t, []string{"a", "b"}, dst.LRange(ctx, "list", 0, -1).Val())
+		require.Equal(t, map[string]string{"f": "v"}, dst.HGetAll(ctx, "hash").Val())
+		require.Equal(t, []string{"a", "b"}, src.LRange(ctx, "list", 0, -1).Val())
+	})
+
+	t.Run("MIGRATE with wrong arguments or target", func(t *testing.T) {
+		require.NoError(t, src.Set(ctx, "key", "value", 0).Err())
+		util.ErrorRegexp(t, migrate("key", 0, 1000).Err(), ".*Target instance replied with error.*")
+		require.EqualValues(t, 1, src.Exists(ctx, "key").Val())
+
+		util.ErrorRegexp(t, migrate("key", 0, 1000, "KEYS", "key").Err(), ".*key argument must be set to the empty string.*")
+		util.ErrorRegexp(t, src.Do(ctx, "MIGRATE", host, 0, "key", 0, 1000).Err(), ".*out of numeric range.*")
+	})
+}
@@ -100,15 +100,35 @@ func TestMulti(t *testing.T) {
 
 	t.Run("EXEC fails if there are errors while queueing commands #1", func(t *testing.T) {
 		require.NoError(t, rdb.Del(ctx, "foo1", "foo2").Err())
-		require.NoError(t, rdb.Do(ctx, "MULTI").Err())
-		require.NoError(t, rdb.Do(ctx, "SET", "foo1", "bar1").Err())
-		require.Error(t, rdb.Do(ctx, "non-existing-command").Err())
-		require.NoError(t, rdb.Do(ctx, "SET", "foo2", "bar2").Err())
-		require.EqualError(t, rdb.Do(ctx, "EXEC").Err(), "EXECABORT Transaction discarded")
+		c := srv.NewTCPClient()
+		defer func() { require.NoError(t, c.Close()) }()
+
+		r := util.ExecTxn(t, c, []string{"SET", "foo1", "bar1"}, []string{"non-existing-command"}, []string{"SET", "foo2", "bar2"})
+		r.Queued.RequireValue(0, "QUEUED").RequireError(1, "ERR").RequireValue(2, "QUEUED")
+		r.RequireExecError("EXECABORT")
 		require.Zero(t, rdb.Exists(ctx, "foo1").Val())
 		require.Zero(t, rdb.Exists(ctx, "foo2").Val())
 	})
 
+	t.Run("EXEC doesn't roll back if a command fails at runtime", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "foo1", "foo2").Err())
+		c := srv.NewTCPClient()
+		defer func() { require.NoError(t, c.Close()) }()
+
+		r := util.ExecTxn(t, c, []string{"SET", "foo1", "bar1"}, []string{"LPUSH", "foo1", "a"}, []string{"SET", "foo2", "bar2"})
+		r.Queued.RequireNoErrors()
+		r.Replies.RequireValue(0, "OK").RequireError(1, "WRONGTYPE").RequireValue(2, "OK")
+		require.Equal(t, "bar2", rdb.Get(ctx, "foo2").Val())
+	})
+
+	t.Run("Pipeline replies are collected positionally", func(t *testing.T) {
+		c := srv.NewTCPClient()
+		defer func() { require.NoError(t, c.Close()) }()
+
+		util.ExecPipeline(t, c, []string{"SET", "foo1", "bar1"}, []string{"INCR", "foo1"}, []string{"GET", "foo1"}).
+			RequireValue(0, "OK").RequireError(1, "ERR").RequireValue(2, "bar1")
+	})
+
 	t.Run("If EXEC aborts, the client MULTI state is cleared", func(t *testing.T) {
 		require.NoError(t, rdb.Del(ctx, "foo1", "foo2").Err())
 		require.NoError(t, rdb.Do(ctx, "MULTI").Err())
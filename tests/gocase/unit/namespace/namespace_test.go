@@ -21,8 +21,10 @@ package namespace
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/apache/kvrocks/tests/gocase/util"
 	"github.com/redis/go-redis/v9"
@@ -113,23 +115,18 @@ func TestNamespace(t *testing.T) {
 		threads := 4
 		countPerThread := 10
 
-		var wg sync.WaitGroup
 		var nsTokens sync.Map
-		for i := 0; i < threads; i++ {
-			wg.Add(1)
-			go func(index int) {
-				defer wg.Done()
-				for j := 0; j < countPerThread; j++ {
-					ns := "ns" + util.RandString(16, 16, util.Alpha)
-					token := util.RandString(16, 16, util.Alpha)
-					nsTokens.Store(ns, token)
-					r := rdb.Do(ctx, "NAMESPACE", "ADD", ns, token)
-					require.NoError(t, r.Err())
-					require.Equal(t, "OK", r.Val())
+		util.RunConcurrently(t, threads, func(ctx context.Context, i int) error {
+			for j := 0; j < countPerThread; j++ {
+				ns := "ns" + util.RandString(16, 16, util.Alpha)
+				token := util.RandString(16, 16, util.Alpha)
+				nsTokens.Store(ns, token)
+				if err := rdb.Do(ctx, "NAMESPACE", "ADD", ns, token).Err(); err != nil {
+					return err
 				}
-			}(i)
-		}
-		wg.Wait()
+			}
+			return nil
+		})
 
 		nsTokens.Range(func(key, value interface{}) bool {
 			r := rdb.Do(ctx, "NAMESPACE", "GET", key)
@@ -138,6 +135,90 @@ func TestNamespace(t *testing.T) {
 			return true
 		})
 	})
+
+	t.Run("Keys are isolated between namespaces", func(t *testing.T) {
+		c1 := srv.NewNamespaceClient("isolated1")
+		defer func() { require.NoError(t, c1.Close()) }()
+		c2 := srv.NewNamespaceClient("isolated2")
+		defer func() { require.NoError(t, c2.Close()) }()
+
+		require.NoError(t, c1.Set(ctx, "key", "v1", 0).Err())
+		require.NoError(t, c2.Set(ctx, "key", "v2", 0).Err())
+		require.Equal(t, "v1", c1.Get(ctx, "key").Val())
+		require.Equal(t, "v2", c2.Get(ctx, "key").Val())
+		require.ErrorContains(t, c1.Do(ctx, "NAMESPACE", "GET", "isolated2").Err(), "admin")
+
+		// the existing token is reused
+		c3 := srv.NewNamespaceClient("isolated1")
+		defer func() { require.NoError(t, c3.Close()) }()
+		require.Equal(t, "v1", c3.Get(ctx, "key").Val())
+
+		wrong := srv.NewClientWithAuth("wrong")
+		defer func() { require.NoError(t, wrong.Close()) }()
+		require.ErrorContains(t, wrong.Ping(ctx).Err(), "invalid password")
+	})
+
+	t.Run("FLUSHDB only flushes the current namespace", func(t *testing.T) {
+		fixture := util.SetupNamespaces(t, srv, 3)
+		defer fixture.Close()
+
+		for _, ns := range fixture.Namespaces {
+			require.NoError(t, ns.Client.Set(ctx, "key", ns.Name, 0).Err())
+		}
+		require.NoError(t, fixture.Client(0).FlushDB(ctx).Err())
+
+		require.Zero(t, fixture.Client(0).Exists(ctx, "key").Val())
+		for _, ns := range fixture.Namespaces[1:] {
+			require.Equal(t, ns.Name, ns.Client.Get(ctx, "key").Val())
+		}
+	})
+
+	t.Run("Stats of namespaces", func(t *testing.T) {
+		fixture := util.SetupNamespaces(t, srv, 2)
+		defer fixture.Close()
+
+		for i, ns := range fixture.Namespaces {
+			for j := 0; j <= i; j++ {
+				require.NoError(t, ns.Client.Set(ctx, fmt.Sprintf("key%d", j), "value", time.Duration(j)*time.Hour).Err())
+			}
+			require.NoError(t, ns.Client.Do(ctx, "DBSIZE", "scan").Err())
+		}
+		for _, ns := range fixture.Namespaces {
+			require.Eventually(t, func() bool {
+				stats, err := rdb.Do(ctx, "NAMESPACE", "STATS", ns.Name).Slice()
+				if err != nil || len(stats) < 12 {
+					return false
+				}
+				lastScanTime, ok := stats[11].(int64)
+				return ok && lastScanTime > 0
+			}, 5*time.Second, 100*time.Millisecond)
+		}
+
+		stats, err := rdb.Do(ctx, "NAMESPACE", "STATS", fixture.Namespaces[1].Name).Slice()
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"keys", int64(2), "expires", int64(1)}, stats[:4])
+		require.Equal(t, "used_db_size", stats[8])
+		require.Len(t, stats, 12)
+		require.Equal(t, "last_scan_time", stats[10])
+		lastScanTime, ok := stats[11].(int64)
+		require.True(t, ok, "last_scan_time should be an integer")
+		require.Positive(t, lastScanTime)
+
+		all, err := rdb.Do(ctx, "NAMESPACE", "STATS", "*").Slice()
+		require.NoError(t, err)
+		require.Contains(t, all, fixture.Namespaces[0].Name)
+		require.Contains(t, all, "__namespace")
+		require.Equal(t, redis.Nil, rdb.Do(ctx, "NAMESPACE", "STATS", "not-exist").Err())
+		require.Error(t, fixture.Client(0).Do(ctx, "NAMESPACE", "STATS", "*").Err())
+
+		info := util.ParseInfo(t, rdb, "keyspace")
+		ns := info.Map("keyspace", "namespace_"+fixture.Namespaces[1].Name)
+		require.Equal(t, "2", ns["keys"])
+		require.Equal(t, "1", ns["expires"])
+		require.Contains(t, ns, "used_db_size")
+		_, ok = util.ParseInfo(t, fixture.Client(0), "keyspace").Lookup("keyspace", "namespace_"+fixture.Namespaces[0].Name)
+		require.False(t, ok, "namespaces are visible to the admin only")
+	})
 }
 
 func TestNamespaceReplicate(t *testing.T) {
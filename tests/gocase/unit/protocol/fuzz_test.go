@@ -0,0 +1,45 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package protocol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+)
+
+// FuzzProtocol only runs the seed corpus by default, run it with e.g.
+// `go test ./unit/protocol -run '^$' -fuzz FuzzProtocol -fuzztime 10m` to fuzz.
+// The server requires a password, so that mutated inputs can't run commands
+// like SHUTDOWN or FLUSHALL and only the protocol parsing is exercised.
+func FuzzProtocol(f *testing.F) {
+	util.SkipIfExternal(f)
+	srv := util.StartServer(f, map[string]string{"requirepass": "fuzzer-password"})
+	defer srv.Close()
+
+	for _, seed := range util.RESPSeeds() {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		srv.RequireSurvivesInput(t, data, 5*time.Second)
+	})
+}
@@ -21,9 +21,12 @@ package protocol
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 )
 
@@ -137,3 +140,87 @@ func TestProtocolNetwork(t *testing.T) {
 		c.MustRead(t, "+string")
 	})
 }
+
+func TestProtocolReplies(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+
+	c := srv.NewTCPClient()
+	defer func() { require.NoError(t, c.Close()) }()
+
+	t.Run("command split across partial writes", func(t *testing.T) {
+		require.NoError(t, c.Write("*3\r\n$3\r\nSET\r\n$1"))
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, c.Write("\r\nk\r\n$5\r\nva"))
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, c.Write("lue\r\n"))
+		c.MustReadRESPValue(t, "OK")
+	})
+
+	t.Run("structured replies", func(t *testing.T) {
+		require.NoError(t, c.WriteArgs("GET", "k"))
+		c.MustReadRESPValue(t, "value")
+		require.NoError(t, c.WriteArgs("GET", "no-such-key"))
+		c.MustReadRESPValue(t, nil)
+		require.NoError(t, c.WriteArgs("RPUSH", "list", "a", "", "c"))
+		c.MustReadRESPValue(t, int64(3))
+		require.NoError(t, c.WriteArgs("LRANGE", "list", "0", "-1"))
+		c.MustReadRESPValue(t, []interface{}{"a", "", "c"})
+		require.NoError(t, c.WriteArgs("INCR", "list"))
+		r, err := c.ReadRESPValue()
+		require.NoError(t, err)
+		require.IsType(t, util.RESPError(""), r)
+		require.Contains(t, r.(util.RESPError).Error(), "WRONGTYPE")
+	})
+
+	t.Run("binary-safe bulk strings", func(t *testing.T) {
+		v := "a\r\nb\x00c"
+		require.NoError(t, c.WriteArgs("SET", "bin", v))
+		c.MustReadRESPValue(t, "OK")
+		require.NoError(t, c.WriteArgs("GET", "bin"))
+		c.MustReadRESPValue(t, v)
+	})
+}
+
+func TestProtocolNetworkFaults(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+
+	proxy := util.StartProxy(t, srv.HostPort())
+	defer proxy.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+	require.NoError(t, rdb.Set(ctx, "big", strings.Repeat("A", 1024*1024), 0).Err())
+
+	t.Run("client times out when replies are delayed", func(t *testing.T) {
+		defer proxy.Reset()
+		c := srv.NewClientWithOption(&redis.Options{Addr: proxy.Addr(), ReadTimeout: 100 * time.Millisecond, MaxRetries: -1})
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.Ping(ctx).Err())
+
+		proxy.SetLatency(500 * time.Millisecond)
+		require.ErrorContains(t, c.Ping(ctx).Err(), "i/o timeout")
+	})
+
+	t.Run("connection cut in the middle of a bulk reply", func(t *testing.T) {
+		defer proxy.Reset()
+		c := proxy.NewClient()
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.Ping(ctx).Err())
+
+		proxy.CutAfter(64 * 1024)
+		require.Error(t, c.Get(ctx, "big").Err())
+		require.Equal(t, "PONG", rdb.Ping(ctx).Val())
+	})
+
+	t.Run("large bulk reply is read completely under throttled bandwidth", func(t *testing.T) {
+		defer proxy.Reset()
+		c := proxy.NewClient()
+		defer func() { require.NoError(t, c.Close()) }()
+
+		proxy.SetBandwidth(4 * 1024 * 1024)
+		require.Len(t, c.Get(ctx, "big").Val(), 1024*1024)
+	})
+}
@@ -23,6 +23,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/apache/kvrocks/tests/gocase/util"
 	"github.com/redis/go-redis/v9"
@@ -423,4 +424,38 @@ func TestPubSub(t *testing.T) {
 		require.NoError(t, pubsub.Unsubscribe(ctx))
 		require.EqualValues(t, 0, receiveType(t, pubsub, &redis.Subscription{}).Count)
 	})
+
+	t.Run("Pubsub messages are collected as push messages", func(t *testing.T) {
+		c := srv.NewRESP3Client()
+		defer func() { require.NoError(t, c.Close()) }()
+
+		c.Send("SUBSCRIBE", "pushchan")
+		require.Equal(t, util.RESPPush{"subscribe", "pushchan", int64(1)}, c.WaitForPush("subscribe", 5*time.Second))
+
+		require.EqualValues(t, 1, rdb.Publish(ctx, "pushchan", "hello").Val())
+		require.Equal(t, util.RESPPush{"message", "pushchan", "hello"}, c.WaitForPush("message", 5*time.Second))
+		c.RequireNoPush(100 * time.Millisecond)
+
+		c.Send("UNSUBSCRIBE", "pushchan")
+		require.Equal(t, util.RESPPush{"unsubscribe", "pushchan", int64(0)}, c.WaitForPush("unsubscribe", 5*time.Second))
+		require.EqualValues(t, 0, rdb.Publish(ctx, "pushchan", "hello").Val())
+		c.RequireNoPush(100 * time.Millisecond)
+	})
+
+	t.Run("Keyspace events are parsed from the notification channels", func(t *testing.T) {
+		c := srv.NewClient()
+		defer func() { require.NoError(t, c.Close()) }()
+		events := util.SubscribeKeyspaceEvents(t, c, "__key*__:*")
+		defer func() { require.NoError(t, events.Close()) }()
+
+		require.NoError(t, rdb.Publish(ctx, "__keyspace@0__:foo", "set").Err())
+		require.NoError(t, rdb.Publish(ctx, "__keyevent@0__:del", "foo").Err())
+		require.NoError(t, rdb.Publish(ctx, "__other@0__:foo", "set").Err())
+
+		require.Equal(t, util.KeyspaceEvent{Kind: "keyspace", DB: "0", Key: "foo", Event: "set"},
+			events.Expect("set", "foo", 5*time.Second))
+		require.Equal(t, util.KeyspaceEvent{Kind: "keyevent", DB: "0", Key: "foo", Event: "del"},
+			events.Expect("del", "foo", 5*time.Second))
+		events.ExpectNone(100 * time.Millisecond)
+	})
 }
@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package reset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReset(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("RESET should clear the client name", func(t *testing.T) {
+		c := srv.NewTCPClient()
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.WriteArgs("CLIENT", "SETNAME", "foo"))
+		c.MustRead(t, "+OK")
+		require.NoError(t, c.WriteArgs("RESET"))
+		c.MustRead(t, "+RESET")
+		require.NoError(t, c.WriteArgs("CLIENT", "GETNAME"))
+		c.MustRead(t, "$-1")
+	})
+
+	t.Run("RESET should turn off the no-touch and no-evict modes", func(t *testing.T) {
+		c := srv.NewTCPClient()
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.WriteArgs("CLIENT", "NO-TOUCH", "on"))
+		c.MustRead(t, "+OK")
+		require.NoError(t, c.WriteArgs("CLIENT", "NO-EVICT", "on"))
+		c.MustRead(t, "+OK")
+		require.NoError(t, c.WriteArgs("RESET"))
+		c.MustRead(t, "+RESET")
+		require.NoError(t, c.WriteArgs("CLIENT", "INFO"))
+		c.MustMatch(t, `\$\d+`)
+		c.MustMatch(t, ".* flags=N .*")
+	})
+
+	t.Run("RESET should discard the transaction", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "reset-key").Err())
+		c := srv.NewTCPClient()
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.WriteArgs("MULTI"))
+		c.MustRead(t, "+OK")
+		require.NoError(t, c.WriteArgs("SET", "reset-key", "bar"))
+		c.MustRead(t, "+QUEUED")
+		require.NoError(t, c.WriteArgs("RESET"))
+		c.MustRead(t, "+RESET")
+		require.NoError(t, c.WriteArgs("EXEC"))
+		c.MustMatch(t, ".*EXEC without MULTI.*")
+		require.Zero(t, rdb.Exists(ctx, "reset-key").Val())
+	})
+
+	t.Run("RESET should unwatch the keys", func(t *testing.T) {
+		c := srv.NewTCPClient()
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.WriteArgs("WATCH", "reset-key"))
+		c.MustRead(t, "+OK")
+		require.NoError(t, rdb.Set(ctx, "reset-key", "foo", 0).Err())
+		require.NoError(t, c.WriteArgs("RESET"))
+		c.MustRead(t, "+RESET")
+		require.NoError(t, c.WriteArgs("MULTI"))
+		c.MustRead(t, "+OK")
+		require.NoError(t, c.WriteArgs("SET", "reset-key", "bar"))
+		c.MustRead(t, "+QUEUED")
+		require.NoError(t, c.WriteArgs("EXEC"))
+		c.MustRead(t, "*1")
+		c.MustRead(t, "+OK")
+		require.Equal(t, "bar", rdb.Get(ctx, "reset-key").Val())
+	})
+
+	t.Run("RESET should unsubscribe all channels and patterns", func(t *testing.T) {
+		c := srv.NewTCPClient()
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.WriteArgs("SUBSCRIBE", "reset-channel"))
+		for _, line := range []string{"*3", "$9", "subscribe", "$13", "reset-channel", ":1"} {
+			c.MustRead(t, line)
+		}
+		require.NoError(t, c.WriteArgs("PSUBSCRIBE", "reset-*"))
+		for _, line := range []string{"*3", "$10", "psubscribe", "$7", "reset-*", ":2"} {
+			c.MustRead(t, line)
+		}
+		require.NoError(t, c.WriteArgs("RESET"))
+		c.MustRead(t, "+RESET")
+		require.Zero(t, rdb.Publish(ctx, "reset-channel", "hello").Val())
+	})
+
+	t.Run("RESET should exit the monitor mode", func(t *testing.T) {
+		c := srv.NewTCPClient()
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.WriteArgs("MONITOR"))
+		c.MustRead(t, "+OK")
+		require.Equal(t, "1", util.FindInfoEntry(rdb, "monitor_clients"))
+		c.MustMatch(t, ".*info.*")
+		require.NoError(t, c.WriteArgs("RESET"))
+		c.MustRead(t, "+RESET")
+		require.Equal(t, "0", util.FindInfoEntry(rdb, "monitor_clients"))
+		require.NoError(t, rdb.Set(ctx, "reset-key", "foo", 0).Err())
+		require.NoError(t, c.WriteArgs("PING"))
+		c.MustRead(t, "+PONG")
+	})
+}
+
+func TestResetWithPassword(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{"requirepass": "foobared"})
+	defer srv.Close()
+
+	t.Run("RESET should be allowed before the authentication", func(t *testing.T) {
+		c := srv.NewTCPClient()
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.WriteArgs("RESET"))
+		c.MustRead(t, "+RESET")
+		require.NoError(t, c.WriteArgs("PING"))
+		c.MustMatch(t, ".*NOAUTH.*")
+	})
+
+	t.Run("RESET should de-authenticate the connection", func(t *testing.T) {
+		c := srv.NewTCPClient()
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.WriteArgs("AUTH", "foobared"))
+		c.MustRead(t, "+OK")
+		require.NoError(t, c.WriteArgs("PING"))
+		c.MustRead(t, "+PONG")
+		require.NoError(t, c.WriteArgs("RESET"))
+		c.MustRead(t, "+RESET")
+		require.NoError(t, c.WriteArgs("PING"))
+		c.MustMatch(t, ".*NOAUTH.*")
+	})
+
+	t.Run("RESET should drop the namespace of the connection", func(t *testing.T) {
+		admin := srv.NewAdminClient()
+		defer func() { require.NoError(t, admin.Close()) }()
+		require.NoError(t, admin.Do(context.Background(), "NAMESPACE", "ADD", "ns1", "ns1-token").Err())
+
+		c := srv.NewTCPClient()
+		defer func() { require.NoError(t, c.Close()) }()
+		require.NoError(t, c.WriteArgs("AUTH", "ns1-token"))
+		c.MustRead(t, "+OK")
+		require.NoError(t, c.WriteArgs("RESET"))
+		c.MustRead(t, "+RESET")
+		require.NoError(t, c.WriteArgs("GET", "foo"))
+		c.MustMatch(t, ".*NOAUTH.*")
+	})
+}
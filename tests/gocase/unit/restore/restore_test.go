@@ -21,6 +21,9 @@ package restore
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -29,8 +32,19 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if err := util.CloseSharedServers(); err != nil {
+		fmt.Println(err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
 func TestRestore_String(t *testing.T) {
-	srv := util.StartServer(t, map[string]string{})
+	srv := util.AcquireSharedServer(t, map[string]string{})
 	defer srv.Close()
 
 	ctx := context.Background()
@@ -56,7 +70,7 @@ func TestRestore_String(t *testing.T) {
 }
 
 func TestRestore_Hash(t *testing.T) {
-	srv := util.StartServer(t, map[string]string{})
+	srv := util.AcquireSharedServer(t, map[string]string{})
 	defer srv.Close()
 
 	ctx := context.Background()
@@ -102,7 +116,7 @@ func TestRestore_Hash(t *testing.T) {
 }
 
 func TestRestore_ZSet(t *testing.T) {
-	srv := util.StartServer(t, map[string]string{})
+	srv := util.AcquireSharedServer(t, map[string]string{})
 	defer srv.Close()
 
 	ctx := context.Background()
@@ -155,7 +169,7 @@ func TestRestore_ZSet(t *testing.T) {
 }
 
 func TestRestore_List(t *testing.T) {
-	srv := util.StartServer(t, map[string]string{})
+	srv := util.AcquireSharedServer(t, map[string]string{})
 	defer srv.Close()
 
 	ctx := context.Background()
@@ -196,7 +210,7 @@ func TestRestore_List(t *testing.T) {
 }
 
 func TestRestore_Set(t *testing.T) {
-	srv := util.StartServer(t, map[string]string{})
+	srv := util.AcquireSharedServer(t, map[string]string{})
 	defer srv.Close()
 
 	ctx := context.Background()
@@ -242,7 +256,7 @@ func TestRestore_Set(t *testing.T) {
 }
 
 func TestRestoreWithTTL(t *testing.T) {
-	srv := util.StartServer(t, map[string]string{})
+	srv := util.AcquireSharedServer(t, map[string]string{})
 	defer srv.Close()
 
 	ctx := context.Background()
@@ -279,7 +293,7 @@ func TestRestoreWithTTL(t *testing.T) {
 }
 
 func TestRestoreWithExpiredTTL(t *testing.T) {
-	srv := util.StartServer(t, map[string]string{})
+	srv := util.AcquireSharedServer(t, map[string]string{})
 	defer srv.Close()
 
 	ctx := context.Background()
@@ -297,3 +311,66 @@ func TestRestoreWithExpiredTTL(t *testing.T) {
 	require.NoError(t, rdb.Do(ctx, "RESTORE", key, 1111, value, "REPLACE", "ABSTTL").Err())
 	require.EqualError(t, rdb.Get(ctx, key).Err(), redis.Nil.Error())
 }
+
+func TestDump(t *testing.T) {
+	srv := util.AcquireSharedServer(t, map[string]string{})
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("Dump the payload in the Redis format", func(t *testing.T) {
+		key := util.RandString(32, 64, util.Alpha)
+		require.ErrorIs(t, rdb.Dump(ctx, key).Err(), redis.Nil)
+
+		require.NoError(t, rdb.Set(ctx, key, "bar", 0).Err())
+		require.Equal(t, "\x00\x03bar\x08\x00_\x93\xa5\xdfG\x7fw/", rdb.Dump(ctx, key).Val())
+	})
+
+	t.Run("Dump and restore all types", func(t *testing.T) {
+		src := util.RandString(32, 64, util.Alpha)
+		dst := util.RandString(32, 64, util.Alpha)
+		restore := func() {
+			payload, err := rdb.Dump(ctx, src).Result()
+			require.NoError(t, err)
+			require.NoError(t, rdb.RestoreReplace(ctx, dst, 0, payload).Err())
+		}
+
+		require.NoError(t, rdb.Set(ctx, src, strings.Repeat("x", 20000), 0).Err())
+		restore()
+		require.Equal(t, strings.Repeat("x", 20000), rdb.Get(ctx, dst).Val())
+
+		require.NoError(t, rdb.Del(ctx, src).Err())
+		require.NoError(t, rdb.SetBit(ctx, src, 7, 1).Err())
+		restore()
+		require.Equal(t, "\x01", rdb.Get(ctx, dst).Val())
+
+		require.NoError(t, rdb.Del(ctx, src).Err())
+		require.NoError(t, rdb.RPush(ctx, src, "a", "", "c").Err())
+		restore()
+		require.Equal(t, []string{"a", "", "c"}, rdb.LRange(ctx, dst, 0, -1).Val())
+
+		require.NoError(t, rdb.Del(ctx, src).Err())
+		require.NoError(t, rdb.SAdd(ctx, src, "a", "b", "c").Err())
+		restore()
+		require.Equal(t, []string{"a", "b", "c"}, rdb.SMembers(ctx, dst).Val())
+
+		require.NoError(t, rdb.Del(ctx, src).Err())
+		require.NoError(t, rdb.ZAdd(ctx, src, redis.Z{Score: 1.5, Member: "a"}, redis.Z{Score: -2, Member: "b"}).Err())
+		restore()
+		require.Equal(t, []redis.Z{{Score: -2, Member: "b"}, {Score: 1.5, Member: "a"}},
+			rdb.ZRangeWithScores(ctx, dst, 0, -1).Val())
+
+		require.NoError(t, rdb.Del(ctx, src).Err())
+		require.NoError(t, rdb.HSet(ctx, src, "f1", "v1", "f2", "v2").Err())
+		restore()
+		require.Equal(t, map[string]string{"f1": "v1", "f2": "v2"}, rdb.HGetAll(ctx, dst).Val())
+	})
+
+	t.Run("Dump the unsupported type", func(t *testing.T) {
+		key := util.RandString(32, 64, util.Alpha)
+		require.NoError(t, rdb.XAdd(ctx, &redis.XAddArgs{Stream: key, Values: []string{"k", "v"}}).Err())
+		util.ErrorRegexp(t, rdb.Dump(ctx, key).Err(), ".*stream type can't be dumped.*")
+	})
+}
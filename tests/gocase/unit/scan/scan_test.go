@@ -97,6 +97,34 @@ func ScanTest(t *testing.T, rdb *redis.Client, ctx context.Context) {
 		require.Len(t, keys, 1000)
 	})
 
+	t.Run("SCAN TYPE", func(t *testing.T) {
+		require.NoError(t, rdb.FlushDB(ctx).Err())
+		for i := 0; i < 100; i++ {
+			require.NoError(t, rdb.Set(ctx, fmt.Sprintf("string:%d", i), "v", 0).Err())
+			require.NoError(t, rdb.HSet(ctx, fmt.Sprintf("hash:%d", i), "f", "v").Err())
+			require.NoError(t, rdb.SAdd(ctx, fmt.Sprintf("set:%d", i), "m").Err())
+		}
+
+		for _, typ := range []string{"string", "hash", "set"} {
+			var expected []string
+			for i := 0; i < 100; i++ {
+				expected = append(expected, fmt.Sprintf("%s:%d", typ, i))
+			}
+			require.ElementsMatch(t, expected, slices.Compact(scanAll(t, rdb, "type", typ)))
+			require.ElementsMatch(t, expected, slices.Compact(scanAll(t, rdb, "count", 7, "type", strings.ToUpper(typ))))
+		}
+		require.ElementsMatch(t, []string{"hash:1", "hash:10", "hash:11", "hash:12", "hash:13", "hash:14", "hash:15",
+			"hash:16", "hash:17", "hash:18", "hash:19"}, slices.Compact(scanAll(t, rdb, "match", "hash:1*", "count", 3, "type", "hash")))
+		require.Empty(t, scanAll(t, rdb, "type", "zset"))
+
+		// keys of other types are counted, so a page may contain fewer keys than COUNT
+		_, keys := scan(t, rdb, "0", "count", 10, "type", "list")
+		require.Empty(t, keys)
+
+		util.ErrorRegexp(t, rdb.Do(ctx, "SCAN", "0", "type", "foo").Err(), ".*unknown type name.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "SCAN", "0", "type", "none").Err(), ".*unknown type name.*")
+	})
+
 	t.Run("SCAN guarantees check under write load", func(t *testing.T) {
 		require.NoError(t, rdb.FlushDB(ctx).Err())
 		util.Populate(t, rdb, "", 100, 10)
@@ -162,6 +162,7 @@ func TestFunction(t *testing.T) {
 	})
 
 	t.Run("Restart server and test again", func(t *testing.T) {
+		util.SkipIfExternal(t)
 		srv.Restart()
 
 		require.Equal(t, rdb.Do(ctx, "FCALL", "myget", 1, "x").Val(), "2")
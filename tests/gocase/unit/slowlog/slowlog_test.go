@@ -23,6 +23,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/apache/kvrocks/tests/gocase/util"
 	"github.com/redis/go-redis/v9"
@@ -172,4 +173,104 @@ func TestSlowlog(t *testing.T) {
 		require.NotEmpty(t, val[0].ClientAddr)
 	})
 
+	t.Run("SLOWLOG - entries are logged with the timing and client of the command", func(t *testing.T) {
+		require.NoError(t, rdb.ConfigSet(ctx, "slowlog-max-len", "128").Err())
+		require.NoError(t, rdb.ConfigSet(ctx, "slowlog-log-slower-than", "100000").Err())
+		util.SlowlogReset(t, rdb)
+		require.Empty(t, util.SlowlogGet(t, rdb))
+
+		require.NoError(t, rdb.Do(ctx, "client", "setname", "typed").Err())
+		before := time.Now()
+		require.NoError(t, rdb.Do(ctx, "debug", "sleep", 0.2).Err())
+		require.NoError(t, rdb.Ping(ctx).Err())
+
+		entry := util.WaitForSlowlog(t, rdb, 5*time.Second, "DEBUG", "sleep")
+		require.Equal(t, []string{"debug", "sleep", "0.2"}, entry.Args)
+		require.GreaterOrEqual(t, entry.Duration, 200*time.Millisecond)
+		util.BetweenValues(t, entry.Time.Unix(), before.Unix(), time.Now().Unix())
+		require.Equal(t, "typed", entry.ClientName)
+		require.NotEmpty(t, entry.ClientAddr)
+		util.RequireNoSlowlog(t, rdb, "ping")
+	})
+}
+
+func TestSlowlogNamespace(t *testing.T) {
+	password := "pwd"
+	srv := util.StartServer(t, map[string]string{
+		"requirepass":             password,
+		"slowlog-log-slower-than": "100000",
+	})
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClientWithOption(&redis.Options{Password: password})
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	require.NoError(t, rdb.Do(ctx, "namespace", "add", "ns1", "token1").Err())
+	require.NoError(t, rdb.Do(ctx, "namespace", "add", "ns2", "token2").Err())
+	ns1 := srv.NewClientWithOption(&redis.Options{Password: "token1"})
+	defer func() { require.NoError(t, ns1.Close()) }()
+	ns2 := srv.NewClientWithOption(&redis.Options{Password: "token2"})
+	defer func() { require.NoError(t, ns2.Close()) }()
+
+	require.NoError(t, rdb.Do(ctx, "debug", "sleep", 0.2).Err())
+	require.NoError(t, ns1.Do(ctx, "debug", "sleep", 0.2).Err())
+	require.NoError(t, ns1.Do(ctx, "debug", "sleep", 0.2).Err())
+	require.NoError(t, ns2.Do(ctx, "debug", "sleep", 0.2).Err())
+
+	t.Run("SLOWLOG - GET WITHNAMESPACE appends the namespace of the entry", func(t *testing.T) {
+		entries, err := rdb.Do(ctx, "slowlog", "get", "*", "withnamespace").Slice()
+		require.NoError(t, err)
+		require.Len(t, entries, 4)
+		var namespaces []string
+		for _, entry := range entries {
+			fields := entry.([]interface{})
+			require.Len(t, fields, 7)
+			namespaces = append(namespaces, fields[6].(string))
+		}
+		require.Equal(t, []string{"ns2", "ns1", "ns1", "__namespace"}, namespaces)
+
+		// entries keep the 6 fields by default
+		val, err := rdb.SlowLogGet(ctx, -1).Result()
+		require.NoError(t, err)
+		require.Len(t, val, 4)
+	})
+
+	t.Run("SLOWLOG - admin can filter entries by NAMESPACE", func(t *testing.T) {
+		require.EqualValues(t, 4, rdb.Do(ctx, "slowlog", "len").Val())
+		require.EqualValues(t, 2, rdb.Do(ctx, "slowlog", "len", "namespace", "ns1").Val())
+		entries, err := rdb.Do(ctx, "slowlog", "get", "namespace", "ns2", "withnamespace").Slice()
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, "ns2", entries[0].([]interface{})[6])
+		require.Len(t, rdb.Do(ctx, "slowlog", "get", "1", "namespace", "ns1").Val(), 1)
+	})
+
+	t.Run("SLOWLOG - namespace clients only see their own entries", func(t *testing.T) {
+		require.EqualValues(t, 2, ns1.Do(ctx, "slowlog", "len").Val())
+		val, err := ns1.SlowLogGet(ctx, -1).Result()
+		require.NoError(t, err)
+		require.Len(t, val, 2)
+		for _, entry := range val {
+			require.Equal(t, []string{"debug", "sleep", "0.2"}, entry.Args)
+		}
+		require.EqualValues(t, 2, ns1.Do(ctx, "slowlog", "len", "namespace", "ns1").Val())
+		util.ErrorRegexp(t, ns1.Do(ctx, "slowlog", "get", "namespace", "ns2").Err(), ".*admin permission.*")
+		util.ErrorRegexp(t, ns1.Do(ctx, "slowlog", "reset", "namespace", "ns2").Err(), ".*admin permission.*")
+	})
+
+	t.Run("SLOWLOG - namespace clients only reset their own entries", func(t *testing.T) {
+		require.NoError(t, ns1.Do(ctx, "slowlog", "reset").Err())
+		require.EqualValues(t, 0, ns1.Do(ctx, "slowlog", "len").Val())
+		require.EqualValues(t, 1, ns2.Do(ctx, "slowlog", "len").Val())
+		require.EqualValues(t, 2, rdb.Do(ctx, "slowlog", "len").Val())
+
+		require.NoError(t, rdb.Do(ctx, "slowlog", "reset", "namespace", "ns2").Err())
+		require.EqualValues(t, 1, rdb.Do(ctx, "slowlog", "len").Val())
+	})
+
+	t.Run("SLOWLOG - invalid arguments", func(t *testing.T) {
+		util.ErrorRegexp(t, rdb.Do(ctx, "slowlog", "len", "withnamespace").Err(), ".*syntax.*")
+		require.Error(t, rdb.Do(ctx, "slowlog", "get", "namespace").Err())
+	})
 }
@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package sort
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSort(t *testing.T) {
+	srv := util.StartServer(t, map[string]string{})
+	defer srv.Close()
+	ctx := context.Background()
+	rdb := srv.NewClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("SORT numeric and ALPHA", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "mylist").Err())
+		require.NoError(t, rdb.RPush(ctx, "mylist", 3, 10, 1, 2.5).Err())
+		require.Equal(t, []string{"1", "2.5", "3", "10"}, rdb.Sort(ctx, "mylist", &redis.Sort{}).Val())
+		require.Equal(t, []string{"10", "3", "2.5", "1"}, rdb.Sort(ctx, "mylist", &redis.Sort{Order: "DESC"}).Val())
+		require.Equal(t, []string{"1", "10", "2.5", "3"}, rdb.Sort(ctx, "mylist", &redis.Sort{Alpha: true}).Val())
+
+		require.NoError(t, rdb.RPush(ctx, "mylist", "a").Err())
+		util.ErrorRegexp(t, rdb.Sort(ctx, "mylist", &redis.Sort{}).Err(), ".*can't be converted into double.*")
+	})
+
+	t.Run("SORT sets and sorted sets", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "myset", "myzset").Err())
+		require.NoError(t, rdb.SAdd(ctx, "myset", 5, 2, 8).Err())
+		require.Equal(t, []string{"2", "5", "8"}, rdb.Sort(ctx, "myset", &redis.Sort{}).Val())
+
+		require.NoError(t, rdb.ZAdd(ctx, "myzset",
+			redis.Z{Score: 1, Member: "c"}, redis.Z{Score: 2, Member: "a"}, redis.Z{Score: 3, Member: "b"}).Err())
+		require.Equal(t, []string{"a", "b", "c"}, rdb.Sort(ctx, "myzset", &redis.Sort{Alpha: true}).Val())
+		// BY without '*' skips sorting and keeps the score order
+		require.Equal(t, []string{"c", "a", "b"}, rdb.Sort(ctx, "myzset", &redis.Sort{By: "nosort"}).Val())
+		require.Equal(t, []string{"a", "c"},
+			rdb.Sort(ctx, "myzset", &redis.Sort{By: "nosort", Offset: 1, Count: 2, Order: "DESC"}).Val())
+	})
+
+	t.Run("SORT LIMIT", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "mylist").Err())
+		require.NoError(t, rdb.RPush(ctx, "mylist", 5, 4, 3, 2, 1).Err())
+		require.Equal(t, []string{"2", "3"}, rdb.Sort(ctx, "mylist", &redis.Sort{Offset: 1, Count: 2}).Val())
+		require.Equal(t, []string{"4", "5"}, rdb.Sort(ctx, "mylist", &redis.Sort{Offset: 3, Count: -1}).Val())
+		require.Equal(t, []string{}, rdb.Sort(ctx, "mylist", &redis.Sort{Offset: 10, Count: 2}).Val())
+		require.Equal(t, []string{"4", "3"}, rdb.Sort(ctx, "mylist", &redis.Sort{By: "nosort", Offset: 1, Count: 2}).Val())
+	})
+
+	t.Run("SORT BY and GET patterns", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "users").Err())
+		require.NoError(t, rdb.RPush(ctx, "users", 1, 2, 3).Err())
+		require.NoError(t, rdb.MSet(ctx, "weight_1", 30, "weight_2", 10, "weight_3", 20).Err())
+		require.NoError(t, rdb.HSet(ctx, "user_1", "name", "alice", "age", 40).Err())
+		require.NoError(t, rdb.HSet(ctx, "user_2", "name", "bob", "age", 20).Err())
+		require.NoError(t, rdb.HSet(ctx, "user_3", "age", 30).Err())
+
+		require.Equal(t, []string{"2", "3", "1"}, rdb.Sort(ctx, "users", &redis.Sort{By: "weight_*"}).Val())
+		require.Equal(t, []string{"2", "3", "1"}, rdb.Sort(ctx, "users", &redis.Sort{By: "user_*->age"}).Val())
+
+		require.Equal(t, []interface{}{"2", "bob", "3", nil, "1", "alice"}, rdb.SortInterfaces(ctx, "users",
+			&redis.Sort{By: "weight_*", Get: []string{"#", "user_*->name"}}).Val())
+		// missing weights are sorted first with ALPHA
+		require.Equal(t, []string{"3", "1", "2"}, rdb.Sort(ctx, "users",
+			&redis.Sort{By: "user_*->name", Alpha: true}).Val())
+	})
+
+	t.Run("SORT STORE and SORT_RO", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "mylist", "dst").Err())
+		require.NoError(t, rdb.RPush(ctx, "mylist", 3, 1, 2).Err())
+		require.NoError(t, rdb.Set(ctx, "dst", "value", 0).Err())
+		require.EqualValues(t, 3, rdb.SortStore(ctx, "mylist", "dst", &redis.Sort{}).Val())
+		require.Equal(t, []string{"1", "2", "3"}, rdb.LRange(ctx, "dst", 0, -1).Val())
+
+		require.EqualValues(t, 0, rdb.SortStore(ctx, "not-exist", "dst", &redis.Sort{}).Val())
+		require.EqualValues(t, 0, rdb.Exists(ctx, "dst").Val())
+
+		require.Equal(t, []string{"3", "2", "1"}, rdb.SortRO(ctx, "mylist", &redis.Sort{Order: "DESC"}).Val())
+		util.ErrorRegexp(t, rdb.Do(ctx, "SORT_RO", "mylist", "STORE", "dst").Err(), ".*syntax error.*")
+	})
+
+	t.Run("SORT keys include the STORE destination", func(t *testing.T) {
+		keys, err := rdb.Do(ctx, "COMMAND", "GETKEYS", "SORT", "mylist", "BY", "w_*", "LIMIT", "0", "10",
+			"GET", "store", "STORE", "dst").StringSlice()
+		require.NoError(t, err)
+		require.Equal(t, []string{"mylist", "dst"}, keys)
+
+		keys, err = rdb.Do(ctx, "COMMAND", "GETKEYS", "SORT", "mylist", "ALPHA").StringSlice()
+		require.NoError(t, err)
+		require.Equal(t, []string{"mylist"}, keys)
+	})
+
+	t.Run("SORT against wrong type", func(t *testing.T) {
+		require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+		util.ErrorRegexp(t, rdb.Sort(ctx, "foo", &redis.Sort{}).Err(), ".*WRONGTYPE.*")
+	})
+}
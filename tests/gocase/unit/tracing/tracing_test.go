@@ -0,0 +1,203 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/stretchr/testify/require"
+)
+
+type otlpSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+	Attributes   []struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue *string `json:"stringValue"`
+			IntValue    *string `json:"intValue"`
+		} `json:"value"`
+	} `json:"attributes"`
+	Status *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+func (s *otlpSpan) attribute(key string) string {
+	for _, attr := range s.Attributes {
+		if attr.Key != key {
+			continue
+		}
+		if attr.Value.StringValue != nil {
+			return *attr.Value.StringValue
+		}
+		if attr.Value.IntValue != nil {
+			return *attr.Value.IntValue
+		}
+	}
+	return ""
+}
+
+// collector is a fake OTLP/HTTP collector which keeps all received spans
+type collector struct {
+	mu    sync.Mutex
+	spans []otlpSpan
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []otlpSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if r.URL.Path != "/v1/traces" || json.NewDecoder(r.Body).Decode(&req) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, resourceSpans := range req.ResourceSpans {
+		for _, scopeSpans := range resourceSpans.ScopeSpans {
+			c.spans = append(c.spans, scopeSpans.Spans...)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *collector) find(name string, match func(*otlpSpan) bool) *otlpSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.spans {
+		if c.spans[i].Name == name && match(&c.spans[i]) {
+			span := c.spans[i]
+			return &span
+		}
+	}
+	return nil
+}
+
+func (c *collector) waitFor(t *testing.T, name string, match func(*otlpSpan) bool) *otlpSpan {
+	var span *otlpSpan
+	require.Eventually(t, func() bool {
+		span = c.find(name, match)
+		return span != nil
+	}, 10*time.Second, 100*time.Millisecond, "span %s is not exported", name)
+	return span
+}
+
+func TestTracing(t *testing.T) {
+	// the collector must be reachable from the server
+	util.SkipIfExternal(t)
+	c := &collector{}
+	httpSrv := httptest.NewServer(c)
+	defer httpSrv.Close()
+
+	srv := util.StartServer(t, map[string]string{
+		"tracing-otlp-endpoint": httpSrv.URL + "/v1/traces",
+		"requirepass":           "foobared",
+	})
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClientWithAuth("foobared")
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("Commands are traced with their RocksDB reads and writes", func(t *testing.T) {
+		require.NoError(t, rdb.MSet(ctx, "trace-a", "1", "trace-b", "2").Err())
+		require.Equal(t, []interface{}{"1", "2"}, rdb.MGet(ctx, "trace-a", "trace-b").Val())
+
+		mset := c.waitFor(t, "kvrocks.mset", func(s *otlpSpan) bool { return true })
+		require.Equal(t, 2, mset.Kind)
+		require.Equal(t, "kvrocks", mset.attribute("db.system"))
+		require.Equal(t, "__namespace", mset.attribute("db.namespace"))
+		require.Equal(t, "mset", mset.attribute("db.operation.name"))
+		require.Equal(t, "2", mset.attribute("kvrocks.key_count"))
+		require.Empty(t, mset.ParentSpanID)
+
+		write := c.waitFor(t, "rocksdb.write", func(s *otlpSpan) bool { return s.ParentSpanID == mset.SpanID })
+		require.Equal(t, mset.TraceID, write.TraceID)
+		require.Equal(t, "2", write.attribute("kvrocks.batch.count"))
+
+		mget := c.waitFor(t, "kvrocks.mget", func(s *otlpSpan) bool { return true })
+		read := c.waitFor(t, "rocksdb.read", func(s *otlpSpan) bool { return s.ParentSpanID == mget.SpanID })
+		require.Equal(t, mget.TraceID, read.TraceID)
+		require.NotEmpty(t, read.attribute("kvrocks.read.count"))
+	})
+
+	t.Run("Failed commands are traced with the error status", func(t *testing.T) {
+		require.NoError(t, rdb.LPush(ctx, "trace-list", "a").Err())
+		require.Error(t, rdb.Get(ctx, "trace-list").Err())
+
+		get := c.waitFor(t, "kvrocks.get", func(s *otlpSpan) bool { return s.Status != nil })
+		require.Equal(t, 2, get.Status.Code)
+		require.Contains(t, get.Status.Message, "WRONGTYPE")
+	})
+
+	t.Run("Commands of the namespace are traced with the namespace", func(t *testing.T) {
+		require.NoError(t, rdb.Do(ctx, "NAMESPACE", "ADD", "trace-ns", "trace-token").Err())
+		nsClient := srv.NewClientWithAuth("trace-token")
+		defer func() { require.NoError(t, nsClient.Close()) }()
+		require.NoError(t, nsClient.Set(ctx, "trace-ns-key", "v", 0).Err())
+
+		c.waitFor(t, "kvrocks.set", func(s *otlpSpan) bool { return s.attribute("db.namespace") == "trace-ns" })
+	})
+}
+
+func TestTracingReplication(t *testing.T) {
+	util.SkipIfExternal(t)
+	c := &collector{}
+	httpSrv := httptest.NewServer(c)
+	defer httpSrv.Close()
+
+	master := util.StartServer(t, map[string]string{"tracing-otlp-endpoint": httpSrv.URL + "/v1/traces"})
+	defer master.Close()
+	masterClient := master.NewClient()
+	defer func() { require.NoError(t, masterClient.Close()) }()
+
+	replica := util.StartServer(t, map[string]string{})
+	defer replica.Close()
+	replicaClient := replica.NewClient()
+	defer func() { require.NoError(t, replicaClient.Close()) }()
+
+	ctx := context.Background()
+	util.SlaveOf(t, replicaClient, master)
+	util.WaitForSync(t, replicaClient)
+
+	require.NoError(t, masterClient.Set(ctx, "trace-repl", "v", 0).Err())
+	util.WaitForOffsetSync(t, masterClient, replicaClient)
+
+	span := c.waitFor(t, "kvrocks.replication.propagate", func(s *otlpSpan) bool { return true })
+	require.Equal(t, 4, span.Kind)
+	require.NotEmpty(t, span.attribute("kvrocks.replica.addr"))
+}
@@ -312,4 +312,36 @@ func TestBitmap(t *testing.T) {
 		require.EqualValues(t, str[4], res.Val()[1])
 		require.EqualValues(t, 'r', res.Val()[2])
 	})
+
+	t.Run("BITFIELD with # offsets and overflow behaviors", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "bf").Err())
+		require.Equal(t, []int64{0, 100}, rdb.BitField(ctx, "bf", "SET", "i8", "#1", 100, "GET", "i8", 8).Val())
+		require.Equal(t, []int64{100}, rdb.BitField(ctx, "bf", "GET", "u8", "#1").Val())
+
+		for _, expected := range [][]int64{{1, 1}, {2, 2}, {3, 3}, {0, 3}} {
+			require.Equal(t, expected, rdb.BitField(ctx, "bf", "INCRBY", "u2", 100, 1, "OVERFLOW", "SAT", "INCRBY", "u2", 102, 1).Val())
+		}
+		require.Equal(t, []interface{}{nil}, rdb.Do(ctx, "BITFIELD", "bf", "OVERFLOW", "FAIL", "INCRBY", "u2", 102, 1).Val())
+		require.Equal(t, []int64{-128}, rdb.BitField(ctx, "bf", "OVERFLOW", "WRAP", "INCRBY", "i8", "#1", 28).Val())
+
+		util.ErrorRegexp(t, rdb.BitField(ctx, "bf", "GET", "u8", "#-1").Err(), ".*bit offset is not an integer or out of range.*")
+		util.ErrorRegexp(t, rdb.BitField(ctx, "bf", "GET", "u8", "#536870912").Err(), ".*bit offset is not an integer or out of range.*")
+	})
+
+	t.Run("BITFIELD_RO only supports GET", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "bf").Err())
+		require.NoError(t, rdb.BitField(ctx, "bf", "SET", "u8", 0, 255).Err())
+		require.Equal(t, []interface{}{int64(255), int64(15)}, rdb.Do(ctx, "BITFIELD_RO", "bf", "GET", "u8", 0, "GET", "u4", 4).Val())
+		require.Equal(t, []interface{}{int64(255)}, rdb.Do(ctx, "BITFIELD_RO", "bf", "GET", "u8", "#0").Val())
+		require.Equal(t, []interface{}{int64(0)}, rdb.Do(ctx, "BITFIELD_RO", "bf-not-exist", "GET", "u8", 0).Val())
+		util.ErrorRegexp(t, rdb.Do(ctx, "BITFIELD_RO", "bf", "SET", "u8", 0, 1).Err(), ".*only supports the GET subcommand.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "BITFIELD_RO", "bf", "INCRBY", "u8", 0, 1).Err(), ".*only supports the GET subcommand.*")
+		require.Equal(t, []interface{}{int64(255)}, rdb.Do(ctx, "EVAL_RO", "return redis.call('BITFIELD_RO', KEYS[1], 'GET', 'u8', 0)", 1, "bf").Val())
+	})
+
+	t.Run("BITFIELD against wrong type", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "bf").Err())
+		require.NoError(t, rdb.LPush(ctx, "bf", "a").Err())
+		util.ErrorRegexp(t, rdb.BitField(ctx, "bf", "GET", "u8", 0).Err(), ".*(WRONGTYPE|not a bitmap).*")
+	})
 }
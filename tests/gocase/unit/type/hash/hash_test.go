@@ -832,6 +832,39 @@ func TestHash(t *testing.T) {
 			// TODO: Add test to verify randomness of the selected random fields
 		})
 
+		t.Run("HRandField picks distinct fields with their values from a large hash", func(t *testing.T) {
+			testKey := "test-hash-1"
+			require.NoError(t, rdb.Del(ctx, testKey).Err())
+			for i := 0; i < 100; i++ {
+				require.NoError(t, rdb.HSet(ctx, testKey, fmt.Sprintf("field%d", i), fmt.Sprintf("value%d", i)).Err())
+			}
+
+			seen := make(map[string]bool)
+			for i := 0; i < 100; i++ {
+				resultWithValues, err := rdb.HRandFieldWithValues(ctx, testKey, 10).Result()
+				require.NoError(t, err)
+				require.Len(t, resultWithValues, 10)
+				fields := make(map[string]bool)
+				for _, kv := range resultWithValues {
+					require.Equal(t, strings.Replace(kv.Key, "field", "value", 1), kv.Value)
+					require.False(t, fields[kv.Key])
+					fields[kv.Key] = true
+					seen[kv.Key] = true
+				}
+			}
+			// every field is picked with a probability of 1/10 in each round
+			require.Greater(t, len(seen), 90)
+
+			resultWithValues, err := rdb.HRandFieldWithValues(ctx, testKey, -200).Result()
+			require.NoError(t, err)
+			require.Len(t, resultWithValues, 200)
+			for _, kv := range resultWithValues {
+				require.Equal(t, strings.Replace(kv.Key, "field", "value", 1), kv.Value)
+			}
+
+			util.ErrorRegexp(t, rdb.Do(ctx, "HRANDFIELD", testKey, "-9223372036854775808").Err(), ".*out of range.*")
+		})
+
 	}
 }
 
@@ -21,10 +21,12 @@ package list
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -986,6 +988,18 @@ func TestList(t *testing.T) {
 			require.Empty(t, rdb.LPos(ctx, "mylist", "x", redis.LPosArgs{Rank: -1}).Val())
 		})
 
+		t.Run("LPOS COUNT replies integers and accepts repeated options", func(t *testing.T) {
+			rd := srv.NewTCPClient()
+			defer func() { require.NoError(t, rd.Close()) }()
+			require.NoError(t, rd.WriteArgs("LPOS", "mylist", "c", "COUNT", "2"))
+			rd.MustRead(t, "*2")
+			rd.MustRead(t, ":2")
+			rd.MustRead(t, ":6")
+
+			require.Equal(t, []interface{}{int64(7)},
+				rdb.Do(ctx, "LPOS", "mylist", "c", "RANK", 1, "RANK", -1, "COUNT", 0, "COUNT", 1, "MAXLEN", 0).Val())
+		})
+
 		t.Run("LPOS MAXLEN", func(t *testing.T) {
 			require.Equal(t, []int64{0}, rdb.LPosCount(ctx, "mylist", "a", 0, redis.LPosArgs{MaxLen: 1}).Val())
 			require.Empty(t, rdb.LPosCount(ctx, "mylist", "c", 0, redis.LPosArgs{MaxLen: 1}).Val())
@@ -1482,4 +1496,44 @@ func TestList(t *testing.T) {
 			require.EqualValues(t, 0, rdb.Exists(ctx, key2).Val())
 		})
 	}
+
+	t.Run("LMPOP and BLMPOP with invalid arguments", func(t *testing.T) {
+		require.NoError(t, rdb.RPush(ctx, "lmpop-invalid", "a").Err())
+		util.ErrorRegexp(t, rdb.Do(ctx, "lmpop", "0", "lmpop-invalid", "LEFT").Err(), ".*numkeys should be greater than 0.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "lmpop", "1", "lmpop-invalid", "LEFT", "count", "0").Err(),
+			".*count should be greater than 0.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "lmpop", "1", "lmpop-invalid", "UP").Err(), ".*syntax.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "blmpop", "-1", "1", "lmpop-invalid", "LEFT").Err(),
+			".*timeout should not be negative.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "blmpop", "0", "0", "lmpop-invalid", "LEFT").Err(),
+			".*numkeys should be greater than 0.*")
+		require.EqualValues(t, 1, rdb.LLen(ctx, "lmpop-invalid").Val())
+	})
+
+	t.Run("BLPOP with concurrent consumers gets every element once", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "swarm").Err())
+
+		var popped sync.Map
+		errs := util.RunConcurrentlyWithOptions(t, 20, util.ConcurrentOptions{
+			// consumers time out if there are no more elements
+			Expected:    func(err error) bool { return errors.Is(err, redis.Nil) },
+			StopOnError: true,
+		}, func(ctx context.Context, i int) error {
+			if i%2 == 0 {
+				return rdb.RPush(ctx, "swarm", i).Err()
+			}
+			r, err := rdb.BLPop(ctx, 2*time.Second, "swarm").Result()
+			if err != nil {
+				return err
+			}
+			if _, loaded := popped.LoadOrStore(r[1], i); loaded {
+				return fmt.Errorf("element %s is popped twice", r[1])
+			}
+			return nil
+		})
+
+		remaining := rdb.LLen(ctx, "swarm").Val()
+		timeouts := int64(errs.Expected()[redis.Nil.Error()])
+		require.Equal(t, remaining, timeouts)
+	})
 }
@@ -82,6 +82,15 @@ func TestSet(t *testing.T) {
 		require.EqualValues(t, []string{"bar", "foo"}, cmd.Val())
 	})
 
+	t.Run("SMISMEMBER with duplicated members, missing key and wrong type", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "myset", "mylist").Err())
+		require.NoError(t, rdb.SAdd(ctx, "myset", "a", "b").Err())
+		require.EqualValues(t, []bool{true, false, true, true}, rdb.SMIsMember(ctx, "myset", "a", "c", "b", "a").Val())
+		require.EqualValues(t, []bool{false, false}, rdb.SMIsMember(ctx, "myset-not-exist", "a", "b").Val())
+		require.NoError(t, rdb.LPush(ctx, "mylist", "a").Err())
+		util.ErrorRegexp(t, rdb.SMIsMember(ctx, "mylist", "a").Err(), ".*WRONGTYPE.*")
+	})
+
 	t.Run("SADD, SCARD, SISMEMBER, SMISMEMBER, SMEMBERS basics - intset", func(t *testing.T) {
 		CreateSet(t, rdb, ctx, "myset", []interface{}{17})
 		require.EqualValues(t, 1, rdb.SAdd(ctx, "myset", 16).Val())
@@ -441,6 +450,23 @@ func TestSet(t *testing.T) {
 		require.EqualValues(t, []string{}, rdb.SInter(ctx, "set1", "set2", "key3").Val())
 	})
 
+	t.Run("SINTERCARD against non-existing keys and a large set", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "set1", "set2", "set3").Err())
+		large := make([]interface{}, 0, 1000)
+		for i := 0; i < 1000; i++ {
+			large = append(large, i)
+		}
+		CreateSet(t, rdb, ctx, "set1", large)
+		CreateSet(t, rdb, ctx, "set2", []interface{}{1, 500, 999, 1000})
+		require.EqualValues(t, 3, rdb.SInterCard(ctx, 0, "set1", "set2").Val())
+		require.EqualValues(t, 2, rdb.SInterCard(ctx, 2, "set2", "set1").Val())
+		require.EqualValues(t, 0, rdb.SInterCard(ctx, 0, "set1", "set2", "set3").Val())
+
+		require.NoError(t, rdb.Set(ctx, "set3", "string", 0).Err())
+		require.ErrorContains(t, rdb.SInterCard(ctx, 0, "set1", "set3").Err(), "WRONGTYPE")
+		require.NoError(t, rdb.Del(ctx, "set3").Err())
+	})
+
 	t.Run("SINTERCARD with wrong args", func(t *testing.T) {
 		CreateSet(t, rdb, ctx, "set1", []interface{}{"foo", "2"})
 		CreateSet(t, rdb, ctx, "set2", []interface{}{"a", "b", "2"})
@@ -656,6 +682,61 @@ func TestSet(t *testing.T) {
 		require.ErrorContains(t, rdb.Do(ctx, "srandmember", "myset", 1, 1).Err(), "wrong number of arguments")
 	})
 
+	t.Run("SRANDMEMBER without <count> replies a single member", func(t *testing.T) {
+		CreateSet(t, rdb, ctx, "myset", []interface{}{"a", "b", "c"})
+		require.Contains(t, []string{"a", "b", "c"}, rdb.SRandMember(ctx, "myset").Val())
+		require.Equal(t, redis.Nil, rdb.SRandMember(ctx, "nonexisting_key").Err())
+	})
+
+	t.Run("SRANDMEMBER with <count>", func(t *testing.T) {
+		CreateSet(t, rdb, ctx, "myset", []interface{}{"a", "b", "c"})
+		require.Empty(t, rdb.SRandMemberN(ctx, "myset", 0).Val())
+		require.Empty(t, rdb.SRandMemberN(ctx, "nonexisting_key", 5).Val())
+		require.Empty(t, rdb.SRandMemberN(ctx, "nonexisting_key", -5).Val())
+
+		// a count larger than the cardinality replies the whole set
+		require.ElementsMatch(t, []string{"a", "b", "c"}, rdb.SRandMemberN(ctx, "myset", 10).Val())
+
+		members := rdb.SRandMemberN(ctx, "myset", 2).Val()
+		require.Len(t, members, 2)
+		require.NotEqual(t, members[0], members[1])
+		require.Subset(t, []string{"a", "b", "c"}, members)
+
+		// a negative count may reply the same member multiple times
+		members = rdb.SRandMemberN(ctx, "myset", -20).Val()
+		require.Len(t, members, 20)
+		require.Subset(t, []string{"a", "b", "c"}, members)
+
+		// the count less than -LONG_MAX/2 is rejected like Redis
+		util.ErrorRegexp(t, rdb.Do(ctx, "SRANDMEMBER", "myset", "-9223372036854775808").Err(), ".*out of range.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "SRANDMEMBER", "myset", "-4611686018427387904").Err(), ".*out of range.*")
+	})
+
+	t.Run("SRANDMEMBER distribution is not biased towards the first members", func(t *testing.T) {
+		var elements []interface{}
+		for i := 0; i < 100; i++ {
+			elements = append(elements, i)
+		}
+		CreateSet(t, rdb, ctx, "myset", elements)
+
+		seen := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			for _, member := range rdb.SRandMemberN(ctx, "myset", 10).Val() {
+				seen[member] = true
+			}
+		}
+		require.Greater(t, len(seen), 90)
+
+		counts := make(map[string]int)
+		for _, member := range rdb.SRandMemberN(ctx, "myset", -10000).Val() {
+			counts[member]++
+		}
+		require.Len(t, counts, 100)
+		for _, count := range counts {
+			require.Greater(t, count, 40)
+		}
+	})
+
 	SetupMove := func() {
 		require.NoError(t, rdb.Del(ctx, "myset3", "myset4").Err())
 		CreateSet(t, rdb, ctx, "myset1", []interface{}{1, "a", "b"})
@@ -193,6 +193,22 @@ func TestString(t *testing.T) {
 		util.BetweenValues(t, rdb.TTL(ctx, "foo").Val(), 5*time.Second, 10*time.Second)
 	})
 
+	t.Run("GETEX EXAT and PXAT in the past delete the key", func(t *testing.T) {
+		require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+		require.Equal(t, "bar", rdb.Do(ctx, "getex", "foo", "exat", time.Now().Add(-10*time.Second).Unix()).Val())
+		require.EqualValues(t, 0, rdb.Exists(ctx, "foo").Val())
+
+		require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+		require.Equal(t, "bar", rdb.Do(ctx, "getex", "foo", "pxat", 1).Val())
+		require.EqualValues(t, 0, rdb.Exists(ctx, "foo").Val())
+
+		require.NoError(t, rdb.SetBit(ctx, "foo", 0, 1).Err())
+		require.NoError(t, rdb.Do(ctx, "getex", "foo", "pxat", 1).Err())
+		require.EqualValues(t, 0, rdb.Exists(ctx, "foo").Val())
+
+		require.ErrorIs(t, rdb.Do(ctx, "getex", "foo", "pxat", 1).Err(), redis.Nil)
+	})
+
 	t.Run("GETEX PERSIST option", func(t *testing.T) {
 		require.NoError(t, rdb.Del(ctx, "foo").Err())
 		require.NoError(t, rdb.Set(ctx, "foo", "bar", 10*time.Second).Err())
@@ -261,6 +277,17 @@ func TestString(t *testing.T) {
 		require.Equal(t, "", rdb.GetDel(ctx, "foo").Val())
 	})
 
+	t.Run("GETDEL against bitmap and wrong type", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "foo").Err())
+		require.NoError(t, rdb.SetBit(ctx, "foo", 1, 1).Err())
+		require.Equal(t, "\x40", rdb.GetDel(ctx, "foo").Val())
+		require.EqualValues(t, 0, rdb.Exists(ctx, "foo").Val())
+
+		require.NoError(t, rdb.LPush(ctx, "foo", "bar").Err())
+		util.ErrorRegexp(t, rdb.GetDel(ctx, "foo").Err(), ".*WRONGTYPE.*")
+		require.EqualValues(t, 1, rdb.Exists(ctx, "foo").Val())
+	})
+
 	t.Run("MGET command", func(t *testing.T) {
 		require.NoError(t, rdb.FlushDB(ctx).Err())
 		require.NoError(t, rdb.Set(ctx, "foo", "BAR", 0).Err())
@@ -801,4 +828,57 @@ func TestString(t *testing.T) {
 		require.ErrorContains(t, rdb.Do(ctx, "CAD", "cad_key").Err(), "ERR wrong number of arguments")
 		require.ErrorContains(t, rdb.Do(ctx, "CAD", "cad_key", "123", "234").Err(), "ERR wrong number of arguments")
 	})
+
+	rna1 := "CACCTTCCCAGGTAACAAACCAACCAACTTTCGATCTCTTGTAGATCTGTTCTCTAAACGAACTTTAAAATCTGTGTGGCTGTCACTCGGCTGCATGCTTAGTGCACTCACGCAGTATAATTAATAACTAATTACTGTCGTTGACAGGACACGAGTAACTCGTCTATCTTCTGCAGGCTGCTTACGGTTTCGTCCGTGTTGCAGCCGATCATCAGCACATCTAGGTTTCGTCCGGGTGTG"
+	rna2 := "ATTAAAGGTTTATACCTTCCCAGGTAACAAACCAACCAACTTTCGATCTCTTGTAGATCTGTTCTCTAAACGAACTTTAAAATCTGTGTGGCTGTCACTCGGCTGCATGCTTAGTGCACTCACGCAGTATAATTAATAACTAATTACTGTCGTTGACAGGACACGAGTAACTCGTCTATCTTCTGCAGGCTGCTTACGGTTTCGTCCGTGTTGCAGCCGATCATCAGCACATCTAGGTTT"
+	rnalcs := "ACCTTCCCAGGTAACAAACCAACCAACTTTCGATCTCTTGTAGATCTGTTCTCTAAACGAACTTTAAAATCTGTGTGGCTGTCACTCGGCTGCATGCTTAGTGCACTCACGCAGTATAATTAATAACTAATTACTGTCGTTGACAGGACACGAGTAACTCGTCTATCTTCTGCAGGCTGCTTACGGTTTCGTCCGTGTTGCAGCCGATCATCAGCACATCTAGGTTT"
+
+	t.Run("LCS basic", func(t *testing.T) {
+		require.NoError(t, rdb.Set(ctx, "virus1", rna1, 0).Err())
+		require.NoError(t, rdb.Set(ctx, "virus2", rna2, 0).Err())
+		require.Equal(t, rnalcs, rdb.LCS(ctx, &redis.LCSQuery{Key1: "virus1", Key2: "virus2"}).Val().MatchString)
+	})
+
+	t.Run("LCS len", func(t *testing.T) {
+		require.NoError(t, rdb.Set(ctx, "virus1", rna1, 0).Err())
+		require.NoError(t, rdb.Set(ctx, "virus2", rna2, 0).Err())
+		require.EqualValues(t, len(rnalcs), rdb.LCS(ctx, &redis.LCSQuery{Key1: "virus1", Key2: "virus2", Len: true}).Val().Len)
+	})
+
+	t.Run("LCS indexes", func(t *testing.T) {
+		require.NoError(t, rdb.Set(ctx, "virus1", rna1, 0).Err())
+		require.NoError(t, rdb.Set(ctx, "virus2", rna2, 0).Err())
+		require.Equal(t, &redis.LCSMatch{
+			Matches: []redis.LCSMatchedPosition{
+				{Key1: redis.LCSPosition{Start: 238, End: 238}, Key2: redis.LCSPosition{Start: 239, End: 239}},
+				{Key1: redis.LCSPosition{Start: 236, End: 236}, Key2: redis.LCSPosition{Start: 238, End: 238}},
+				{Key1: redis.LCSPosition{Start: 229, End: 230}, Key2: redis.LCSPosition{Start: 236, End: 237}},
+				{Key1: redis.LCSPosition{Start: 224, End: 224}, Key2: redis.LCSPosition{Start: 235, End: 235}},
+				{Key1: redis.LCSPosition{Start: 1, End: 222}, Key2: redis.LCSPosition{Start: 13, End: 234}},
+			},
+			Len: 227,
+		}, rdb.LCS(ctx, &redis.LCSQuery{Key1: "virus1", Key2: "virus2", Idx: true}).Val())
+	})
+
+	t.Run("LCS indexes with match len and minimum match len", func(t *testing.T) {
+		require.NoError(t, rdb.Set(ctx, "virus1", rna1, 0).Err())
+		require.NoError(t, rdb.Set(ctx, "virus2", rna2, 0).Err())
+		require.Equal(t, &redis.LCSMatch{
+			Matches: []redis.LCSMatchedPosition{
+				{Key1: redis.LCSPosition{Start: 1, End: 222}, Key2: redis.LCSPosition{Start: 13, End: 234}, MatchLen: 222},
+			},
+			Len: 227,
+		}, rdb.LCS(ctx, &redis.LCSQuery{Key1: "virus1", Key2: "virus2", Idx: true, MinMatchLen: 5, WithMatchLen: true}).Val())
+	})
+
+	t.Run("LCS with missing keys and wrong options", func(t *testing.T) {
+		require.NoError(t, rdb.Del(ctx, "virus1", "virus2").Err())
+		require.NoError(t, rdb.Set(ctx, "virus1", rna1, 0).Err())
+		require.Equal(t, "", rdb.LCS(ctx, &redis.LCSQuery{Key1: "virus1", Key2: "virus2"}).Val().MatchString)
+		util.ErrorRegexp(t, rdb.Do(ctx, "LCS", "virus1", "virus2", "LEN", "IDX").Err(), ".*please just use IDX.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "LCS", "virus1", "virus2", "FOO").Err(), ".*syntax error.*")
+
+		require.NoError(t, rdb.LPush(ctx, "virus2", "a").Err())
+		util.ErrorRegexp(t, rdb.Do(ctx, "LCS", "virus1", "virus2").Err(), ".*WRONGTYPE.*")
+	})
 }
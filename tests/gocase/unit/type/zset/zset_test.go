@@ -147,6 +147,25 @@ func basicTests(t *testing.T, rdb *redis.Client, ctx context.Context, encoding s
 		require.EqualValues(t, 2, rdb.ZAddArgs(ctx, "ztmp", redis.ZAddArgs{Ch: true, Members: []redis.Z{{Member: "abc", Score: 0.5}, {Member: "newAbc1", Score: 10}, {Member: "newAbc2"}}}).Val())
 	})
 
+	t.Run(fmt.Sprintf("ZSET ZADD INCR with LT/GT/XX reports the new score - %s", encoding), func(t *testing.T) {
+		rdb.Del(ctx, "ztmp")
+		incr := func(args redis.ZAddArgs, member string, score float64) *redis.FloatCmd {
+			args.Members = []redis.Z{{Member: member, Score: score}}
+			return rdb.ZAddArgsIncr(ctx, "ztmp", args)
+		}
+		require.EqualValues(t, 1, rdb.ZAdd(ctx, "ztmp", redis.Z{Member: "abc", Score: 0}).Val())
+		require.Equal(t, 5.0, incr(redis.ZAddArgs{GT: true}, "abc", 5).Val())
+		require.Equal(t, 0.0, incr(redis.ZAddArgs{LT: true}, "abc", -5).Val())
+		require.Equal(t, -5.0, incr(redis.ZAddArgs{LT: true}, "abc", -5).Val())
+		require.Equal(t, redis.Nil, incr(redis.ZAddArgs{LT: true}, "abc", 0).Err())
+		require.Equal(t, redis.Nil, incr(redis.ZAddArgs{GT: true}, "abc", 0).Err())
+		require.Equal(t, -5.0, incr(redis.ZAddArgs{}, "abc", 0).Val())
+		require.Equal(t, -4.0, incr(redis.ZAddArgs{XX: true, GT: true}, "abc", 1).Val())
+		require.Equal(t, redis.Nil, incr(redis.ZAddArgs{XX: true}, "new", 1).Err())
+		require.Equal(t, 3.0, incr(redis.ZAddArgs{GT: true}, "new", 3).Val())
+		require.Equal(t, []redis.Z{{Score: -4, Member: "abc"}, {Score: 3, Member: "new"}}, rdb.ZRangeWithScores(ctx, "ztmp", 0, -1).Val())
+	})
+
 	t.Run(fmt.Sprintf("ZSET ZADD NX/XX option supports a single pair - %s", encoding), func(t *testing.T) {
 		rdb.Del(ctx, "ztmp")
 		require.EqualValues(t, 2, rdb.ZAddArgs(ctx, "ztmp", redis.ZAddArgs{NX: true, Members: []redis.Z{{Member: "a", Score: 1}, {Member: "b", Score: 2}}}).Val())
@@ -257,6 +276,58 @@ func basicTests(t *testing.T, rdb *redis.Client, ctx context.Context, encoding s
 		require.Equal(t, int64(0), rdb.ZCard(ctx, "zdoesntexist").Val())
 	})
 
+	t.Run(fmt.Sprintf("ZRANDMEMBER basics - %s", encoding), func(t *testing.T) {
+		rdb.Del(ctx, "ztmp")
+		rdb.ZAdd(ctx, "ztmp", redis.Z{Score: 10, Member: "a"}, redis.Z{Score: 20, Member: "b"}, redis.Z{Score: 30, Member: "c"})
+
+		require.Contains(t, []string{"a", "b", "c"}, rdb.Do(ctx, "zrandmember", "ztmp").Val())
+		require.Equal(t, redis.Nil, rdb.Do(ctx, "zrandmember", "zdoesntexist").Err())
+		require.Empty(t, rdb.ZRandMember(ctx, "zdoesntexist", 3).Val())
+		require.Empty(t, rdb.ZRandMember(ctx, "ztmp", 0).Val())
+
+		require.Equal(t, []string{"a", "b", "c"}, rdb.ZRandMember(ctx, "ztmp", 5).Val())
+		require.Equal(t, []redis.Z{{Score: 10, Member: "a"}, {Score: 20, Member: "b"}, {Score: 30, Member: "c"}},
+			rdb.ZRandMemberWithScores(ctx, "ztmp", 3).Val())
+
+		members := rdb.ZRandMember(ctx, "ztmp", 2).Val()
+		require.Len(t, members, 2)
+		require.NotEqual(t, members[0], members[1])
+		require.Subset(t, []string{"a", "b", "c"}, members)
+
+		scores := map[string]float64{"a": 10, "b": 20, "c": 30}
+		withScores := rdb.ZRandMemberWithScores(ctx, "ztmp", -10).Val()
+		require.Len(t, withScores, 10)
+		for _, z := range withScores {
+			require.Equal(t, scores[z.Member.(string)], z.Score)
+		}
+
+		util.ErrorRegexp(t, rdb.Do(ctx, "zrandmember", "ztmp", "a").Err(), ".*not an integer.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "zrandmember", "ztmp", 1, "withvalues").Err(), ".*syntax error.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "zrandmember", "ztmp", 1, "withscores", "a").Err(), ".*syntax error.*")
+		util.ErrorRegexp(t, rdb.Do(ctx, "zrandmember", "ztmp", "-9223372036854775808").Err(), ".*out of range.*")
+	})
+
+	t.Run(fmt.Sprintf("ZRANDMEMBER picks every member of a large zset - %s", encoding), func(t *testing.T) {
+		rdb.Del(ctx, "ztmp")
+		for i := 0; i < 100; i++ {
+			rdb.ZAdd(ctx, "ztmp", redis.Z{Score: float64(i), Member: fmt.Sprintf("m%d", i)})
+		}
+
+		seen := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			withScores := rdb.ZRandMemberWithScores(ctx, "ztmp", 10).Val()
+			require.Len(t, withScores, 10)
+			picked := make(map[string]bool)
+			for _, z := range withScores {
+				require.Equal(t, fmt.Sprintf("m%d", int(z.Score)), z.Member)
+				require.False(t, picked[z.Member.(string)])
+				picked[z.Member.(string)] = true
+				seen[z.Member.(string)] = true
+			}
+		}
+		require.Greater(t, len(seen), 90)
+	})
+
 	t.Run("ZREM removes key after last element is removed", func(t *testing.T) {
 		rdb.Del(ctx, "ztmp")
 		rdb.ZAdd(ctx, "ztmp", redis.Z{Score: 10, Member: "x"}, redis.Z{Score: 20, Member: "y"})
@@ -440,6 +511,40 @@ func basicTests(t *testing.T, rdb *redis.Client, ctx context.Context, encoding s
 		require.Equal(t, []redis.Z{{Score: 1, Member: "a"}, {Score: 2, Member: "b"}}, zset)
 	})
 
+	t.Run(fmt.Sprintf("BZMPOP is woken up by ZINCRBY and ZUNIONSTORE - %s", encoding), func(t *testing.T) {
+		rdb.Del(ctx, "zseta", "zsetb", "zsetc")
+
+		rd := srv.NewClient()
+		defer func() { require.NoError(t, rd.Close()) }()
+		waitBlocked := func() {
+			require.Eventually(t, func() bool {
+				cnt, _ := strconv.Atoi(util.FindInfoEntry(rdb, "blocked_clients"))
+				return cnt == 1
+			}, 5*time.Second, 100*time.Millisecond)
+		}
+
+		ch := make(chan *redis.ZSliceWithKeyCmd)
+		go func() {
+			ch <- rd.BZMPop(ctx, 0, "min", 10, "zseta", "zsetb")
+		}()
+		waitBlocked()
+		rdb.ZIncrBy(ctx, "zsetb", 5, "x")
+		key, zset := (<-ch).Val()
+		require.Equal(t, "zsetb", key)
+		require.Equal(t, []redis.Z{{Score: 5, Member: "x"}}, zset)
+
+		rdb.ZAdd(ctx, "zsetc", redis.Z{Score: 1, Member: "a"}, redis.Z{Score: 2, Member: "b"})
+		go func() {
+			ch <- rd.BZMPop(ctx, 0, "max", 1, "zseta")
+		}()
+		waitBlocked()
+		require.EqualValues(t, 2, rdb.ZUnionStore(ctx, "zseta", &redis.ZStore{Keys: []string{"zsetc"}}).Val())
+		key, zset = (<-ch).Val()
+		require.Equal(t, "zseta", key)
+		require.Equal(t, []redis.Z{{Score: 2, Member: "b"}}, zset)
+		require.EqualValues(t, 1, rdb.ZCard(ctx, "zseta").Val())
+	})
+
 	t.Run(fmt.Sprintf("BZMPOP error - %s", encoding), func(t *testing.T) {
 		rdb.Del(ctx, "zseta")
 		rdb.Del(ctx, "zsetb")
@@ -539,6 +644,25 @@ func basicTests(t *testing.T, rdb *redis.Client, ctx context.Context, encoding s
 		}, rdb.ZRangeWithScores(ctx, "zdst", 0, -1).Val())
 	})
 
+	t.Run(fmt.Sprintf("ZRANGESTORE REV with BYSCORE, BYLEX and LIMIT - %s", encoding), func(t *testing.T) {
+		rdb.Del(ctx, "zsrc", "zdst")
+		for i, member := range []string{"a", "b", "c", "d", "e"} {
+			rdb.ZAdd(ctx, "zsrc", redis.Z{Score: float64(i + 1), Member: member})
+		}
+
+		require.EqualValues(t, 2, rdb.Do(ctx, "zrangestore", "zdst", "zsrc", 5, 1, "byscore", "rev", "limit", 1, 2).Val())
+		require.Equal(t, []redis.Z{{3, "c"}, {4, "d"}}, rdb.ZRangeWithScores(ctx, "zdst", 0, -1).Val())
+
+		require.EqualValues(t, 3, rdb.Do(ctx, "zrangestore", "zdst", "zsrc", "(e", "-", "bylex", "rev", "limit", 0, 3).Val())
+		require.Equal(t, []string{"b", "c", "d"}, rdb.ZRange(ctx, "zdst", 0, -1).Val())
+	})
+
+	t.Run(fmt.Sprintf("ZRANGESTORE reports both keys - %s", encoding), func(t *testing.T) {
+		r, err := rdb.Do(ctx, "COMMAND", "GETKEYS", "zrangestore", "zdst", "zsrc", 0, -1).Slice()
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"zdst", "zsrc"}, r)
+	})
+
 	t.Run(fmt.Sprintf("ZRANGESTORE error - %s", encoding), func(t *testing.T) {
 		rdb.Del(ctx, "zsrc")
 		rdb.Del(ctx, "zdst")
@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package unixsocket
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/apache/kvrocks/tests/gocase/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnixSocket(t *testing.T) {
+	srv := util.StartUnixSocketServer(t, map[string]string{"unixsocketperm": "700"})
+	defer srv.Close()
+
+	ctx := context.Background()
+	rdb := srv.NewClientWithUnixSocket()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	t.Run("Unix socket has the configured permissions", func(t *testing.T) {
+		fi, err := os.Stat(srv.UnixSocket())
+		require.NoError(t, err)
+		require.NotZero(t, fi.Mode()&os.ModeSocket)
+		require.EqualValues(t, 0700, fi.Mode().Perm())
+	})
+
+	t.Run("Commands work over the unix socket", func(t *testing.T) {
+		require.Equal(t, "PONG", rdb.Ping(ctx).Val())
+		require.NoError(t, rdb.Set(ctx, "foo", "bar", 0).Err())
+		require.Equal(t, "bar", rdb.Get(ctx, "foo").Val())
+	})
+
+	t.Run("TCP and unix socket clients share the same data", func(t *testing.T) {
+		tcp := srv.NewClient()
+		defer func() { require.NoError(t, tcp.Close()) }()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					require.NoError(t, rdb.Incr(ctx, "counter").Err())
+					require.NoError(t, tcp.Incr(ctx, "counter").Err())
+				}
+				require.NoError(t, tcp.Set(ctx, fmt.Sprintf("key-%d", i), i, 0).Err())
+			}(i)
+		}
+		wg.Wait()
+
+		require.Equal(t, "2000", rdb.Get(ctx, "counter").Val())
+		for i := 0; i < 10; i++ {
+			require.Equal(t, fmt.Sprint(i), rdb.Get(ctx, fmt.Sprintf("key-%d", i)).Val())
+		}
+	})
+}
@@ -0,0 +1,117 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const logTailLines = 200
+
+// ArtifactsDir creates and returns a new directory for the diagnostics of the test,
+// which is never deleted by the harness.
+func ArtifactsDir(t testing.TB) (string, error) {
+	base := *artifactsDir
+	if base == "" {
+		if *workspace == "" {
+			return "", fmt.Errorf("neither `-artifactsDir` nor `-workspace` is set")
+		}
+		base = filepath.Join(*workspace, "artifacts")
+	}
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return "", err
+	}
+	name := strings.ReplaceAll(t.Name(), "/", "_")
+	return os.MkdirTemp(base, fmt.Sprintf("%s-%d-*", name, time.Now().UnixMilli()))
+}
+
+// DumpTopology writes CLUSTER NODES, CLUSTERX VERSION, INFO and the tail of the log
+// of every node into a file per node under a new artifacts directory, and returns
+// the directory. It's meant for the post-mortem of failures, so errors are logged or
+// written into the dump instead of failing the test again.
+func (c *KvrocksCluster) DumpTopology() string {
+	dir, err := ArtifactsDir(c.t)
+	if err != nil {
+		c.t.Logf("cannot create the artifacts directory: %v", err)
+		return ""
+	}
+
+	var expected strings.Builder
+	fmt.Fprintf(&expected, "version: %d\n%s\n", c.version, c.NodesString())
+	if err := os.WriteFile(filepath.Join(dir, "topology.txt"), []byte(expected.String()), 0644); err != nil {
+		c.t.Logf("cannot dump the topology: %v", err)
+	}
+
+	for i, node := range c.Nodes {
+		path := filepath.Join(dir, fmt.Sprintf("node-%d-%d.txt", i, node.Server.Port()))
+		if err := os.WriteFile(path, []byte(dumpNode(node)), 0644); err != nil {
+			c.t.Logf("cannot dump the node %s: %v", node.ID, err)
+		}
+	}
+	c.t.Logf("the cluster state is dumped to %s", dir)
+	return dir
+}
+
+func dumpNode(node *ClusterNode) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# node %s at %s, dir %s\n", node.ID, node.Server.HostPort(), node.Server.Dir())
+	for _, args := range [][]interface{}{
+		{"CLUSTER", "NODES"},
+		{"CLUSTERX", "VERSION"},
+		{"CLUSTER", "INFO"},
+		{"INFO"},
+	} {
+		fmt.Fprintf(&b, "\n# %s\n", strings.TrimSpace(fmt.Sprintln(args...)))
+		if v, err := node.Client.Do(ctx, args...).Result(); err != nil {
+			fmt.Fprintf(&b, "error: %v\n", err)
+		} else {
+			fmt.Fprintf(&b, "%v\n", v)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n# last %d lines of %s\n", logTailLines, node.Server.LogFilePath())
+	if tail, err := tailFile(node.Server.LogFilePath(), logTailLines); err != nil {
+		fmt.Fprintf(&b, "error: %v\n", err)
+	} else {
+		b.WriteString(tail)
+	}
+	return b.String()
+}
+
+func tailFile(path string, n int) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, ""), nil
+}
@@ -20,8 +20,10 @@
 package util
 
 import (
+	"context"
 	"testing"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/exp/constraints"
 )
@@ -52,3 +54,24 @@ func RetryEventually(t testing.TB, condition func() bool, maxAttempts int, msgAn
 	}
 	require.Fail(t, "Condition never satisfied", msgAndArgs...)
 }
+
+// RequireRoundTrip sets the key to the value and asserts that both of them are
+// read back byte by byte, which covers the binary-safety of keys and values.
+func RequireRoundTrip(t testing.TB, rdb *redis.Client, key, value string) {
+	ctx := context.Background()
+	require.NoError(t, rdb.Set(ctx, key, value, 0).Err())
+	require.EqualValues(t, len(value), rdb.StrLen(ctx, key).Val())
+	got, err := rdb.Get(ctx, key).Result()
+	require.NoError(t, err)
+	require.True(t, got == value, "value of key %q mismatches, length: %d, expected length: %d", key, len(got), len(value))
+
+	var found bool
+	iter := rdb.Scan(ctx, 0, "", 0).Iterator()
+	for iter.Next(ctx) {
+		if iter.Val() == key {
+			found = true
+		}
+	}
+	require.NoError(t, iter.Err())
+	require.True(t, found, "key %q is not returned by SCAN", key)
+}
@@ -0,0 +1,148 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// BackupDir returns the directory where BGSAVE of the server puts the backup.
+func (s *KvrocksServer) BackupDir() string {
+	s.requireLocal()
+	if dir := s.configs["backup-dir"]; dir != "" {
+		return dir
+	}
+	return filepath.Join(s.configs["dir"], "backup")
+}
+
+// BGSave triggers a backup of the server and waits until it is finished successfully.
+func (s *KvrocksServer) BGSave(t testing.TB) {
+	c := s.NewClient()
+	defer func() { require.NoError(t, c.Close()) }()
+
+	require.NoError(t, c.Do(context.Background(), "bgsave").Err())
+	require.Eventually(t, func() bool {
+		return FindInfoEntry(c, "bgsave_in_progress", "persistence") == "0"
+	}, time.Minute, 100*time.Millisecond)
+	require.Equal(t, "ok", FindInfoEntry(c, "last_bgsave_status", "persistence"))
+}
+
+// Backup describes the backup on disk.
+type Backup struct {
+	Dir string
+	// Files are the paths of all regular files relative to Dir, in lexical order.
+	Files []string
+	// Size is the total size of all files in bytes.
+	Size int64
+}
+
+// HasFile returns whether the backup has a file whose name starts with the prefix, e.g. "MANIFEST-".
+func (b *Backup) HasFile(prefix string) bool {
+	for _, f := range b.Files {
+		if strings.HasPrefix(filepath.Base(f), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TriggerBackup triggers a backup of the server by BGSAVE, waits until it's finished
+// successfully and returns the files of the backup.
+func (s *KvrocksServer) TriggerBackup(t testing.TB) *Backup {
+	s.BGSave(t)
+	return ReadBackup(t, s.BackupDir())
+}
+
+// ReadBackup lists the files of the backup in the directory.
+func ReadBackup(t testing.TB, dir string) *Backup {
+	b := &Backup{Dir: dir}
+	require.NoError(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		b.Files = append(b.Files, rel)
+		b.Size += info.Size()
+		return nil
+	}))
+	return b
+}
+
+// CloneFromBackup takes a backup of the source server and starts a new server
+// with the given configs whose database is a copy of the backup.
+func CloneFromBackup(t testing.TB, src *KvrocksServer, configs map[string]string) *KvrocksServer {
+	src.BGSave(t)
+
+	s := StartServer(t, configs)
+	s.close(true)
+
+	dbDir := filepath.Join(s.configs["dir"], "db")
+	require.NoError(t, os.RemoveAll(dbDir))
+	require.NoError(t, copyDir(src.BackupDir(), dbDir))
+
+	s.start()
+	return s
+}
+
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+		return copyFile(path, target, info.Mode())
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,112 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// BatchResult holds the replies of a batch of commands positionally,
+// error replies are kept as RESPError.
+type BatchResult struct {
+	t       testing.TB
+	Replies []interface{}
+}
+
+// ExecPipeline sends all commands at once and collects their replies.
+func ExecPipeline(t testing.TB, c *TCPClient, cmds ...[]string) *BatchResult {
+	for _, cmd := range cmds {
+		require.NoError(t, c.WriteArgs(cmd...))
+	}
+	return readBatch(t, c, len(cmds))
+}
+
+func readBatch(t testing.TB, c *TCPClient, n int) *BatchResult {
+	r := &BatchResult{t: t}
+	for i := 0; i < n; i++ {
+		v, err := c.ReadRESPValue()
+		require.NoError(t, err)
+		r.Replies = append(r.Replies, v)
+	}
+	return r
+}
+
+// RequireValue asserts the i-th reply equals to v.
+func (r *BatchResult) RequireValue(i int, v interface{}) *BatchResult {
+	require.Less(r.t, i, len(r.Replies))
+	require.Equal(r.t, v, r.Replies[i], "reply %d", i)
+	return r
+}
+
+// RequireError asserts the i-th reply is an error starting with the prefix.
+func (r *BatchResult) RequireError(i int, prefix string) *BatchResult {
+	require.Less(r.t, i, len(r.Replies))
+	err, ok := r.Replies[i].(RESPError)
+	require.True(r.t, ok, "reply %d is not an error: %v", i, r.Replies[i])
+	require.True(r.t, strings.HasPrefix(string(err), prefix), "reply %d: %s doesn't start with %s", i, err, prefix)
+	return r
+}
+
+// RequireNoErrors asserts none of the replies is an error.
+func (r *BatchResult) RequireNoErrors() *BatchResult {
+	for i, reply := range r.Replies {
+		_, ok := reply.(RESPError)
+		require.False(r.t, ok, "reply %d is an error: %v", i, reply)
+	}
+	return r
+}
+
+// TxnResult holds the replies of a transaction.
+type TxnResult struct {
+	// Queued are the replies of the commands while queueing, i.e. QUEUED or errors.
+	Queued *BatchResult
+	// Exec is the raw reply of EXEC, which is nil if the transaction is aborted
+	// by WATCH, or a RESPError if it's discarded because of queueing errors.
+	Exec interface{}
+	// Replies are the replies of the commands in EXEC, nil if EXEC doesn't reply an array.
+	Replies *BatchResult
+}
+
+// ExecTxn sends MULTI, the commands and EXEC at once, and collects all replies.
+func ExecTxn(t testing.TB, c *TCPClient, cmds ...[]string) *TxnResult {
+	all := append([][]string{{"MULTI"}}, cmds...)
+	all = append(all, []string{"EXEC"})
+	batch := ExecPipeline(t, c, all...)
+	batch.RequireValue(0, "OK")
+
+	r := &TxnResult{
+		Queued: &BatchResult{t: t, Replies: batch.Replies[1 : len(batch.Replies)-1]},
+		Exec:   batch.Replies[len(batch.Replies)-1],
+	}
+	if replies, ok := r.Exec.([]interface{}); ok {
+		r.Replies = &BatchResult{t: t, Replies: replies}
+	}
+	return r
+}
+
+// RequireExecError asserts EXEC replied an error starting with the prefix, e.g. EXECABORT.
+func (r *TxnResult) RequireExecError(prefix string) {
+	err, ok := r.Exec.(RESPError)
+	require.True(r.Queued.t, ok, "EXEC doesn't reply an error: %v", r.Exec)
+	require.True(r.Queued.t, strings.HasPrefix(string(err), prefix), "EXEC: %s doesn't start with %s", err, prefix)
+}
@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+type ChaosAction string
+
+const (
+	// ChaosKill kills the node with SIGKILL and recovers it after the downtime.
+	ChaosKill ChaosAction = "kill"
+	// ChaosRestart shuts the node down gracefully and starts it again.
+	ChaosRestart ChaosAction = "restart"
+	// ChaosPartition makes the node unreachable through its proxy for the downtime.
+	ChaosPartition ChaosAction = "partition"
+)
+
+type ChaosOptions struct {
+	Seed int64
+	// Interval is the pause between two faults.
+	Interval time.Duration
+	// Downtime is how long a killed or partitioned node stays unavailable.
+	Downtime time.Duration
+	// Actions are the faults to pick from randomly, all of them if empty.
+	Actions []ChaosAction
+}
+
+func DefaultChaosOptions() ChaosOptions {
+	return ChaosOptions{
+		Seed:     time.Now().UnixNano(),
+		Interval: time.Second,
+		Downtime: 500 * time.Millisecond,
+		Actions:  []ChaosAction{ChaosKill, ChaosRestart, ChaosPartition},
+	}
+}
+
+// Chaos injects random faults into the nodes of a cluster. Every node is fronted by
+// a proxy, so clients created by Client see partitions while the nodes still serve
+// the clients of the cluster itself, which are used to recover and inspect them.
+type Chaos struct {
+	t       testing.TB
+	cluster *KvrocksCluster
+	opts    ChaosOptions
+	rnd     *rand.Rand
+	proxies map[*ClusterNode]*Proxy
+
+	// Events records the faults in the order they were injected, e.g. "kill node 1".
+	Events []string
+}
+
+func NewChaos(t testing.TB, cluster *KvrocksCluster, opts ChaosOptions) *Chaos {
+	if len(opts.Actions) == 0 {
+		opts.Actions = DefaultChaosOptions().Actions
+	}
+	t.Logf("chaos seed: %d", opts.Seed)
+
+	ch := &Chaos{
+		t:       t,
+		cluster: cluster,
+		opts:    opts,
+		rnd:     rand.New(rand.NewSource(opts.Seed)),
+		proxies: make(map[*ClusterNode]*Proxy),
+	}
+	for _, node := range cluster.Nodes {
+		ch.proxies[node] = StartProxy(t, node.Server.HostPort())
+	}
+	return ch
+}
+
+// Client returns a new client which connects to the node through its proxy.
+func (ch *Chaos) Client(node *ClusterNode) *redis.Client {
+	return ch.proxies[node].NewClient()
+}
+
+// Run injects faults into random nodes until the duration elapses. It runs on the
+// calling goroutine, so the verification workload should be started in another one
+// before calling it. Every node is available again when Run returns.
+func (ch *Chaos) Run(d time.Duration) {
+	deadline := time.Now().Add(d)
+	for time.Now().Before(deadline) {
+		time.Sleep(ch.opts.Interval)
+
+		i := ch.rnd.Intn(len(ch.cluster.Nodes))
+		node := ch.cluster.Nodes[i]
+		action := ch.opts.Actions[ch.rnd.Intn(len(ch.opts.Actions))]
+		ch.Events = append(ch.Events, fmt.Sprintf("%s node %d", action, i))
+
+		switch action {
+		case ChaosKill:
+			node.Server.Kill()
+			time.Sleep(ch.opts.Downtime)
+			node.Server.Recover()
+			ch.cluster.ResyncNode(node)
+		case ChaosRestart:
+			node.Server.Restart()
+			ch.cluster.ResyncNode(node)
+		case ChaosPartition:
+			proxy := ch.proxies[node]
+			proxy.SetPartitioned(true)
+			proxy.CutConnections()
+			time.Sleep(ch.opts.Downtime)
+			proxy.SetPartitioned(false)
+		default:
+			require.Fail(ch.t, "unknown chaos action", "%s", action)
+		}
+	}
+}
+
+// WaitForConverge waits until all nodes are reachable and agree on the topology.
+func (ch *Chaos) WaitForConverge() {
+	ch.cluster.WaitForClusterOK()
+}
+
+func (ch *Chaos) Close() {
+	for _, proxy := range ch.proxies {
+		proxy.Close()
+	}
+}
@@ -49,11 +49,26 @@ func WaitForSync(t testing.TB, slave *redis.Client) {
 }
 
 func WaitForOffsetSync(t testing.TB, master, slave *redis.Client) {
-	require.Eventually(t, func() bool {
-		o1 := FindInfoEntry(master, "master_repl_offset")
-		o2 := FindInfoEntry(slave, "master_repl_offset")
-		return o1 == o2
-	}, 5*time.Second, 100*time.Millisecond)
+	WaitForOffsetSyncWithTimeout(t, master, slave, 5*time.Second)
+}
+
+// WaitForOffsetSyncWithTimeout waits until the sequence number applied by the slave
+// catches up with the latest sequence number of the master.
+func WaitForOffsetSyncWithTimeout(t testing.TB, master, slave *redis.Client, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for {
+		masterOffset := FindInfoEntry(master, "master_repl_offset", "replication")
+		slaveOffset := FindInfoEntry(slave, "slave_repl_offset", "replication")
+		if masterOffset != "" && masterOffset == slaveOffset {
+			return
+		}
+		// report the offsets of the last poll rather than the ones before polling
+		if time.Now().After(deadline) {
+			require.FailNow(t, "slave offset doesn't catch up with the master",
+				"master offset: %s, slave offset: %s", masterOffset, slaveOffset)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
 }
 
 func SlaveOf(t testing.TB, slave *redis.Client, master *KvrocksServer) {
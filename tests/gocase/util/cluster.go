@@ -0,0 +1,200 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+const ClusterSlots = 16384
+
+type ClusterNode struct {
+	Server *KvrocksServer
+	Client *redis.Client
+
+	ID string
+	// Slots are the slot ranges served by the node.
+	Slots []SlotRange
+}
+
+// SlotRange is the inclusive slot range [Start, End].
+type SlotRange struct {
+	Start, End int
+}
+
+// HasSlot returns whether the node serves the slot according to the current topology.
+func (node *ClusterNode) HasSlot(slot int) bool {
+	for _, r := range node.Slots {
+		if r.Start <= slot && slot <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+func (node *ClusterNode) addSlot(slot int) {
+	node.Slots = append(node.Slots, SlotRange{slot, slot})
+}
+
+func (node *ClusterNode) removeSlot(slot int) {
+	var slots []SlotRange
+	for _, r := range node.Slots {
+		if r.Start <= slot && slot <= r.End {
+			if r.Start < slot {
+				slots = append(slots, SlotRange{r.Start, slot - 1})
+			}
+			if slot < r.End {
+				slots = append(slots, SlotRange{slot + 1, r.End})
+			}
+		} else {
+			slots = append(slots, r)
+		}
+	}
+	node.Slots = slots
+}
+
+type KvrocksCluster struct {
+	t       testing.TB
+	Nodes   []*ClusterNode
+	version int64
+}
+
+// StartCluster starts n cluster-enabled servers and assigns slotsPerNode consecutive
+// slots to each of them, starting from slot 0. If slotsPerNode is not positive,
+// all slots are split evenly across the nodes. The topology is set on every node.
+func StartCluster(t testing.TB, n, slotsPerNode int, configs map[string]string) *KvrocksCluster {
+	require.Greater(t, n, 0)
+	if slotsPerNode <= 0 {
+		slotsPerNode = (ClusterSlots + n - 1) / n
+	}
+	require.LessOrEqual(t, (n-1)*slotsPerNode, ClusterSlots-1, "too many slots per node")
+
+	c := &KvrocksCluster{t: t}
+	for i := 0; i < n; i++ {
+		nodeConfigs := map[string]string{"cluster-enabled": "yes"}
+		for k, v := range configs {
+			nodeConfigs[k] = v
+		}
+		srv := StartServer(t, nodeConfigs)
+
+		start := i * slotsPerNode
+		end := start + slotsPerNode - 1
+		if end >= ClusterSlots {
+			end = ClusterSlots - 1
+		}
+
+		idx := strconv.Itoa(i)
+		node := &ClusterNode{
+			Server: srv,
+			Client: srv.NewClient(),
+			ID:     strings.Repeat("x", 40-len(idx)) + idx,
+			Slots:  []SlotRange{{start, end}},
+		}
+		require.NoError(t, node.Client.Do(context.Background(), "clusterx", "SETNODEID", node.ID).Err())
+		c.Nodes = append(c.Nodes, node)
+	}
+
+	c.SetTopology()
+	return c
+}
+
+// NodesString returns the topology in the format accepted by CLUSTERX SETNODES.
+func (c *KvrocksCluster) NodesString() string {
+	var lines []string
+	for _, node := range c.Nodes {
+		line := fmt.Sprintf("%s %s %d master -", node.ID, node.Server.Host(), node.Server.Port())
+		for _, r := range node.Slots {
+			line += fmt.Sprintf(" %d-%d", r.Start, r.End)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Version returns the topology version last applied by SetTopology.
+func (c *KvrocksCluster) Version() int64 {
+	return c.version
+}
+
+// SetTopology bumps the cluster version and pushes the current topology to all nodes.
+func (c *KvrocksCluster) SetTopology() {
+	c.version++
+	nodes := c.NodesString()
+	for _, node := range c.Nodes {
+		require.NoError(c.t, node.Client.Do(context.Background(), "clusterx", "SETNODES", nodes, c.version).Err())
+	}
+}
+
+// ResyncNode pushes the node ID and the current topology to the node again,
+// e.g. after it was restarted with `persist-cluster-nodes-enabled no`.
+func (c *KvrocksCluster) ResyncNode(node *ClusterNode) {
+	ctx := context.Background()
+	require.NoError(c.t, node.Client.Do(ctx, "clusterx", "SETNODEID", node.ID).Err())
+	require.NoError(c.t, node.Client.Do(ctx, "clusterx", "SETNODES", c.NodesString(), c.version).Err())
+}
+
+// NodeBySlot returns the node which serves the slot according to the current topology.
+func (c *KvrocksCluster) NodeBySlot(slot int) *ClusterNode {
+	for _, node := range c.Nodes {
+		if node.HasSlot(slot) {
+			return node
+		}
+	}
+	return nil
+}
+
+// WaitForClusterOK waits until every node reports the cluster state as ok
+// and has applied the latest topology version.
+func (c *KvrocksCluster) WaitForClusterOK() {
+	ctx := context.Background()
+	require.Eventually(c.t, func() bool {
+		for _, node := range c.Nodes {
+			info, err := node.Client.ClusterInfo(ctx).Result()
+			if err != nil || !strings.Contains(info, "cluster_state:ok") {
+				return false
+			}
+			version, err := node.Client.Do(ctx, "clusterx", "version").Int64()
+			if err != nil || version != c.version {
+				return false
+			}
+		}
+		return true
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+// Close stops all nodes. If the test has failed, the state of every node is dumped
+// by DumpTopology before stopping it.
+func (c *KvrocksCluster) Close() {
+	if c.t.Failed() {
+		c.DumpTopology()
+	}
+	for _, node := range c.Nodes {
+		require.NoError(c.t, node.Client.Close())
+		node.Server.Close()
+	}
+}
@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type ConcurrentOptions struct {
+	// Expected decides whether an error is expected, e.g. the timeout of a blocking command.
+	// Expected errors are counted but don't fail the test. All errors are unexpected if it's nil.
+	Expected func(err error) bool
+	// StopOnError cancels the context passed to all goroutines on the first unexpected error.
+	StopOnError bool
+}
+
+// ConcurrentErrors aggregates the errors of RunConcurrently, errors with the same message
+// are deduplicated and counted.
+type ConcurrentErrors struct {
+	mu         sync.Mutex
+	expected   map[string]int
+	unexpected map[string]int
+}
+
+func (e *ConcurrentErrors) add(err error, expected bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if expected {
+		e.expected[err.Error()]++
+	} else {
+		e.unexpected[err.Error()]++
+	}
+}
+
+// Expected returns the expected errors and their counts.
+func (e *ConcurrentErrors) Expected() map[string]int {
+	return e.expected
+}
+
+// Unexpected returns the unexpected errors and their counts.
+func (e *ConcurrentErrors) Unexpected() map[string]int {
+	return e.unexpected
+}
+
+func (e *ConcurrentErrors) String() string {
+	var lines []string
+	for msg, count := range e.unexpected {
+		lines = append(lines, fmt.Sprintf("%dx %s", count, msg))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// RunConcurrently runs fn in n goroutines with the index of each goroutine, and fails the
+// test with all deduplicated errors returned by them after they are done. Unlike calling
+// require in the goroutines, which can't stop the test properly, fn should return the error.
+func RunConcurrently(t testing.TB, n int, fn func(ctx context.Context, i int) error) *ConcurrentErrors {
+	return RunConcurrentlyWithOptions(t, n, ConcurrentOptions{}, fn)
+}
+
+func RunConcurrentlyWithOptions(
+	t testing.TB,
+	n int,
+	opts ConcurrentOptions,
+	fn func(ctx context.Context, i int) error,
+) *ConcurrentErrors {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := &ConcurrentErrors{expected: make(map[string]int), unexpected: make(map[string]int)}
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := func() (err error) {
+				defer func() {
+					if r := recover(); r != nil {
+						err = fmt.Errorf("panic: %v", r)
+					}
+				}()
+				return fn(ctx, i)
+			}()
+			if err == nil {
+				return
+			}
+
+			expected := opts.Expected != nil && opts.Expected(err)
+			errs.add(err, expected)
+			if !expected && opts.StopOnError {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.Empty(t, errs.unexpected, "unexpected errors in concurrent goroutines:\n%s", errs)
+	return errs
+}
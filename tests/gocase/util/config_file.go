@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// ConfigFile is a snapshot of a config file.
+type ConfigFile struct {
+	// Directives maps lowercase directive names to their values,
+	// the values of repeated directives are joined by newlines.
+	Directives map[string]string
+	// Others are the lines which are not directives, e.g. comments and blank lines.
+	Others []string
+}
+
+func (s *KvrocksServer) ConfigFilePath() string {
+	s.requireLocal()
+	return filepath.Join(s.configs["dir"], "kvrocks.conf")
+}
+
+// SnapshotConfigFile reads and parses the config file of the server.
+func (s *KvrocksServer) SnapshotConfigFile(t testing.TB) *ConfigFile {
+	content, err := os.ReadFile(s.ConfigFilePath())
+	require.NoError(t, err)
+	return ParseConfigFile(string(content))
+}
+
+func ParseConfigFile(content string) *ConfigFile {
+	f := &ConfigFile{Directives: make(map[string]string)}
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			f.Others = append(f.Others, line)
+			continue
+		}
+
+		key, value, _ := strings.Cut(trimmed, " ")
+		key = strings.ToLower(key)
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		if v, ok := f.Directives[key]; ok {
+			value = v + "\n" + value
+		}
+		f.Directives[key] = value
+	}
+	return f
+}
+
+// ConfigDiff is the difference between two snapshots of a config file.
+type ConfigDiff struct {
+	Added   map[string]string
+	Removed map[string]string
+	// Changed maps the directive to its old and new values.
+	Changed map[string][2]string
+	// LostLines are the non-directive lines which don't survive.
+	LostLines []string
+}
+
+func DiffConfigFile(before, after *ConfigFile) *ConfigDiff {
+	diff := &ConfigDiff{
+		Added:   make(map[string]string),
+		Removed: make(map[string]string),
+		Changed: make(map[string][2]string),
+	}
+	for k, v := range before.Directives {
+		if newValue, ok := after.Directives[k]; !ok {
+			diff.Removed[k] = v
+		} else if newValue != v {
+			diff.Changed[k] = [2]string{v, newValue}
+		}
+	}
+	for k, v := range after.Directives {
+		if _, ok := before.Directives[k]; !ok {
+			diff.Added[k] = v
+		}
+	}
+
+	remaining := make(map[string]int)
+	for _, line := range after.Others {
+		remaining[line]++
+	}
+	for _, line := range before.Others {
+		if remaining[line] > 0 {
+			remaining[line]--
+		} else {
+			diff.LostLines = append(diff.LostLines, line)
+		}
+	}
+	return diff
+}
+
+// RewriteConfig applies the changes by CONFIG SET, persists them by CONFIG REWRITE,
+// and returns how the config file is changed by the rewrite.
+func (s *KvrocksServer) RewriteConfig(t testing.TB, rdb *redis.Client, changes map[string]string) *ConfigDiff {
+	ctx := context.Background()
+	before := s.SnapshotConfigFile(t)
+	for k, v := range changes {
+		require.NoError(t, rdb.ConfigSet(ctx, k, v).Err())
+	}
+	require.NoError(t, rdb.ConfigRewrite(ctx).Err())
+	return DiffConfigFile(before, s.SnapshotConfigFile(t))
+}
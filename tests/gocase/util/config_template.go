@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The maximum depth of nested includes, which stops include cycles.
+const maxConfigIncludeDepth = 8
+
+// ConfigTemplate is a config file in the text/template format, which can express what
+// a flat map of configs can't, e.g. comments, directives repeated on multiple lines
+// like `rename-command` and the order of directives. `{{.Dir}}` and `{{.Port}}` are
+// replaced by the directory and the port of the server.
+//
+// Lines like `include <name>` are replaced by the rendered content of Includes[name].
+// Kvrocks reads a single config file, so includes are expanded before writing it.
+type ConfigTemplate struct {
+	Content  string
+	Includes map[string]string
+}
+
+type configTemplateData struct {
+	Dir  string
+	Port int
+}
+
+func (tmpl *ConfigTemplate) render(t testing.TB, dir string, port int) string {
+	content, err := tmpl.expand(tmpl.Content, configTemplateData{Dir: dir, Port: port}, 0)
+	require.NoError(t, err)
+	// the configs appended after the template must start on a new line
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content
+}
+
+func (tmpl *ConfigTemplate) expand(content string, data configTemplateData, depth int) (string, error) {
+	if depth > maxConfigIncludeDepth {
+		return "", errors.New("config includes are nested too deep, there may be a cycle")
+	}
+
+	parsed, err := template.New("config").Parse(content)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := parsed.Execute(&b, data); err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.ToLower(fields[0]) != "include" {
+			continue
+		}
+		included, ok := tmpl.Includes[fields[1]]
+		if !ok {
+			return "", errors.New("config include not found: " + fields[1])
+		}
+		expanded, err := tmpl.expand(included, data, depth+1)
+		if err != nil {
+			return "", err
+		}
+		lines[i] = strings.TrimSuffix(expanded, "\n")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// StartServerWithConfigTemplate starts a server with the config file rendered from the template,
+// followed by the configs which take precedence over the directives in the template.
+func StartServerWithConfigTemplate(t testing.TB, tmpl *ConfigTemplate, configs map[string]string) *KvrocksServer {
+	SkipIfExternal(t)
+	require.NotEmpty(t, *binPath, "please set the binary path by `-binPath`")
+	return startServerWithBinary(t, *binPath, true, tmpl, configs, []string{})
+}
+
+// StartServerWithInvalidConfig requires the server to exit with an error when it's started
+// with the config file rendered from the template, and returns the output of the server
+// which contains the reason.
+func StartServerWithInvalidConfig(t testing.TB, tmpl *ConfigTemplate, configs map[string]string) string {
+	SkipIfExternal(t)
+	require.NotEmpty(t, *binPath, "please set the binary path by `-binPath`")
+
+	cmd, addr, stdout, stderr := prepareServer(t, *binPath, true, tmpl, configs, []string{})
+	defer releasePort(addr)
+	require.NoError(t, cmd.Start())
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		var exitErr *exec.ExitError
+		require.ErrorAs(t, err, &exitErr, "the server is expected to exit with an error")
+	case <-time.After(time.Minute):
+		require.NoError(t, cmd.Process.Kill())
+		<-done
+		require.FailNow(t, "the server started with an invalid config")
+	}
+
+	require.NoError(t, stdout.Close())
+	require.NoError(t, stderr.Close())
+	out, err := os.ReadFile(stdout.Name())
+	require.NoError(t, err)
+	errOut, err := os.ReadFile(stderr.Name())
+	require.NoError(t, err)
+
+	if *deleteOnExit {
+		require.NoError(t, os.RemoveAll(configs["dir"]))
+	}
+	return string(out) + string(errOut)
+}
@@ -0,0 +1,210 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// RedisServer is a real Redis server which kvrocks is compared against.
+type RedisServer struct {
+	t    testing.TB
+	cmd  *exec.Cmd
+	addr *net.TCPAddr
+	dir  string
+}
+
+// StartRedisServer starts redis-server specified by `-redisServerPath` or found in PATH,
+// without persistence. The test is skipped if it isn't available.
+func StartRedisServer(t testing.TB) *RedisServer {
+	bin := *redisServerPath
+	if bin == "" {
+		var err error
+		if bin, err = exec.LookPath("redis-server"); err != nil {
+			t.Skip("redis-server is not found, please set it by `-redisServerPath`")
+		}
+	}
+
+	addr, err := findFreePort()
+	require.NoError(t, err)
+	dir, err := os.MkdirTemp("", "redis-server-*")
+	require.NoError(t, err)
+
+	cmd := exec.Command(bin, "--bind", addr.IP.String(), "--port", fmt.Sprint(addr.Port),
+		"--dir", dir, "--save", "", "--appendonly", "no")
+	// don't leak the process and its directory if it fails to start
+	started := false
+	defer func() {
+		if started {
+			return
+		}
+		if cmd.Process != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		}
+		releasePort(addr)
+		_ = os.RemoveAll(dir)
+	}()
+	require.NoError(t, cmd.Start())
+
+	c := redis.NewClient(&redis.Options{Addr: addr.String()})
+	defer func() { require.NoError(t, c.Close()) }()
+	require.Eventually(t, func() bool {
+		return c.Ping(context.Background()).Err() == nil
+	}, time.Minute, 100*time.Millisecond)
+
+	started = true
+	return &RedisServer{t: t, cmd: cmd, addr: addr, dir: dir}
+}
+
+func (s *RedisServer) NewTCPClient() *TCPClient {
+	c, err := net.Dial(s.addr.Network(), s.addr.String())
+	require.NoError(s.t, err)
+	return newTCPClient(c)
+}
+
+func (s *RedisServer) Close() {
+	require.NoError(s.t, s.cmd.Process.Kill())
+	_ = s.cmd.Wait()
+	releasePort(s.addr)
+	require.NoError(s.t, os.RemoveAll(s.dir))
+}
+
+// Divergence is a documented difference between kvrocks and Redis. The replies of
+// commands with the given name are not compared, but the differences are still logged.
+type Divergence struct {
+	Command string
+	Reason  string
+}
+
+// unorderedReplies are the commands whose array replies have no defined order.
+var unorderedReplies = map[string]bool{
+	"keys": true, "smembers": true, "sinter": true, "sunion": true, "sdiff": true,
+	"hkeys": true, "hvals": true,
+}
+
+// unorderedPairReplies are the commands whose array replies consist of field-value pairs
+// in no defined order, which are sorted by pairs to keep the fields and values together.
+var unorderedPairReplies = map[string]bool{
+	"hgetall": true,
+}
+
+// Conformance sends the same commands to both kvrocks and Redis and compares the replies.
+// Errors are only compared by whether they're errors, since the messages differ a lot.
+type Conformance struct {
+	t           testing.TB
+	kvrocks     *TCPClient
+	redis       *TCPClient
+	divergences map[string]string
+	mismatches  []string
+}
+
+// NewConformance connects to both servers, commands of the divergences are tolerated.
+func NewConformance(t testing.TB, srv *KvrocksServer, redisSrv *RedisServer, divergences ...Divergence) *Conformance {
+	c := &Conformance{
+		t:           t,
+		kvrocks:     srv.NewTCPClient(),
+		redis:       redisSrv.NewTCPClient(),
+		divergences: make(map[string]string),
+	}
+	for _, d := range divergences {
+		c.divergences[strings.ToLower(d.Command)] = d.Reason
+	}
+	return c
+}
+
+// Run sends the commands in order and records the replies which mismatch.
+func (c *Conformance) Run(cmds ...[]string) {
+	for _, cmd := range cmds {
+		expected := c.do(c.redis, cmd)
+		actual := c.do(c.kvrocks, cmd)
+		if reflect.DeepEqual(expected, actual) {
+			continue
+		}
+
+		name := strings.ToLower(cmd[0])
+		if reason, ok := c.divergences[name]; ok {
+			c.t.Logf("tolerated divergence of %s (%s): redis: %v, kvrocks: %v", name, reason, expected, actual)
+			continue
+		}
+		c.mismatches = append(c.mismatches,
+			fmt.Sprintf("%s: redis: %v, kvrocks: %v", strings.Join(cmd, " "), expected, actual))
+	}
+}
+
+func (c *Conformance) do(client *TCPClient, cmd []string) interface{} {
+	require.NoError(c.t, client.WriteArgs(cmd...))
+	v, err := client.ReadRESPValue()
+	require.NoError(c.t, err)
+	return normalizeReply(strings.ToLower(cmd[0]), v)
+}
+
+func normalizeReply(name string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case RESPError:
+		return RESPError("ERR")
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i := range v {
+			values[i] = normalizeReply("", v[i])
+		}
+		if unorderedReplies[name] {
+			sort.Slice(values, func(i, j int) bool {
+				return fmt.Sprint(values[i]) < fmt.Sprint(values[j])
+			})
+		} else if unorderedPairReplies[name] && len(values)%2 == 0 {
+			pairs := make([][2]interface{}, 0, len(values)/2)
+			for i := 0; i < len(values); i += 2 {
+				pairs = append(pairs, [2]interface{}{values[i], values[i+1]})
+			}
+			sort.Slice(pairs, func(i, j int) bool {
+				return fmt.Sprint(pairs[i][0]) < fmt.Sprint(pairs[j][0])
+			})
+			for i, pair := range pairs {
+				values[2*i], values[2*i+1] = pair[0], pair[1]
+			}
+		}
+		return values
+	default:
+		return v
+	}
+}
+
+// RequireConformant fails the test with all mismatched replies.
+func (c *Conformance) RequireConformant() {
+	require.Empty(c.t, c.mismatches, "replies mismatch between Redis and kvrocks")
+}
+
+func (c *Conformance) Close() {
+	require.NoError(c.t, c.kvrocks.Close())
+	require.NoError(c.t, c.redis.Close())
+}
@@ -0,0 +1,142 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// Every test package runs in its own process, so each process appends the names of
+// the commands it exercised to its own file in the coverage directory, and the
+// report merges all files.
+var coveredCommands = struct {
+	sync.Mutex
+	names map[string]struct{}
+	file  *os.File
+}{names: make(map[string]struct{})}
+
+func recordCommand(name string) {
+	if *commandCoverage == "" {
+		return
+	}
+	name = strings.ToLower(name)
+
+	coveredCommands.Lock()
+	defer coveredCommands.Unlock()
+	if _, ok := coveredCommands.names[name]; ok {
+		return
+	}
+	coveredCommands.names[name] = struct{}{}
+
+	if coveredCommands.file == nil {
+		if err := os.MkdirAll(*commandCoverage, 0755); err != nil {
+			return
+		}
+		path := filepath.Join(*commandCoverage, fmt.Sprintf("commands-%d.txt", os.Getpid()))
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return
+		}
+		coveredCommands.file = f
+	}
+	_, _ = coveredCommands.file.WriteString(name + "\n")
+}
+
+// coverageHook records the commands sent by go-redis clients.
+type coverageHook struct{}
+
+func (coverageHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (coverageHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		recordCommand(cmd.Name())
+		return next(ctx, cmd)
+	}
+}
+
+func (coverageHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			// MULTI and EXEC wrapping transactions are recorded as well
+			recordCommand(cmd.Name())
+		}
+		return next(ctx, cmds)
+	}
+}
+
+// CoveredCommands returns the names of all commands recorded in the coverage directory.
+func CoveredCommands(t testing.TB) map[string]struct{} {
+	require.NotEmpty(t, *commandCoverage, "command coverage is not enabled")
+
+	files, err := filepath.Glob(filepath.Join(*commandCoverage, "commands-*.txt"))
+	require.NoError(t, err)
+
+	names := make(map[string]struct{})
+	for _, path := range files {
+		func() {
+			f, err := os.Open(path)
+			require.NoError(t, err)
+			defer func() { require.NoError(t, f.Close()) }()
+
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				if name := strings.TrimSpace(scanner.Text()); name != "" {
+					names[name] = struct{}{}
+				}
+			}
+			require.NoError(t, scanner.Err())
+		}()
+	}
+	return names
+}
+
+// UncoveredCommands returns the sorted names of commands supported by the server,
+// according to COMMAND, that haven't been exercised by any recorded test case.
+func UncoveredCommands(t testing.TB, rdb *redis.Client) []string {
+	ctx := context.Background()
+	commands, err := rdb.Command(ctx).Result()
+	require.NoError(t, err)
+	count, err := rdb.Do(ctx, "COMMAND", "COUNT").Int()
+	require.NoError(t, err)
+	require.Len(t, commands, count, "COMMAND and COMMAND COUNT disagree")
+
+	covered := CoveredCommands(t)
+	var uncovered []string
+	for name := range commands {
+		if _, ok := covered[strings.ToLower(name)]; !ok {
+			uncovered = append(uncovered, name)
+		}
+	}
+	sort.Strings(uncovered)
+	return uncovered
+}
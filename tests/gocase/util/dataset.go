@@ -0,0 +1,269 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// DataSpec describes a reproducible key space: the same spec always produces
+// the same keys and values, so the data can be verified after replication,
+// migration or restore without keeping a copy of it.
+type DataSpec struct {
+	Seed   int64
+	Prefix string
+
+	// the number of keys of each type
+	Strings int
+	Hashes  int
+	Lists   int
+	Sets    int
+	ZSets   int
+
+	// Elements is the number of fields, elements or members of each complex key.
+	Elements int
+	// ValueSize is the size of string values, hash values and list elements.
+	ValueSize int
+}
+
+type dataKey struct {
+	name string
+	typ  string
+}
+
+func (spec *DataSpec) keys() []dataKey {
+	var keys []dataKey
+	for _, item := range []struct {
+		typ string
+		n   int
+	}{
+		{"string", spec.Strings}, {"hash", spec.Hashes}, {"list", spec.Lists}, {"set", spec.Sets}, {"zset", spec.ZSets},
+	} {
+		for i := 0; i < item.n; i++ {
+			keys = append(keys, dataKey{name: fmt.Sprintf("%s%s:%d", spec.Prefix, item.typ, i), typ: item.typ})
+		}
+	}
+	return keys
+}
+
+func (spec *DataSpec) rand(key string) *rand.Rand {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return rand.New(rand.NewSource(spec.Seed ^ int64(h.Sum64())))
+}
+
+func randBytes(r *rand.Rand, n int) string {
+	const chars = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = chars[r.Intn(len(chars))]
+	}
+	return string(b)
+}
+
+// expected returns the canonical content of the key, which is in the same form as keyContent.
+func (spec *DataSpec) expected(key dataKey) []string {
+	r := spec.rand(key.name)
+	switch key.typ {
+	case "string":
+		return []string{randBytes(r, spec.ValueSize)}
+	case "list":
+		var content []string
+		for i := 0; i < spec.Elements; i++ {
+			content = append(content, randBytes(r, spec.ValueSize))
+		}
+		return content
+	default:
+		content := make(map[string]string)
+		for i := 0; i < spec.Elements; i++ {
+			member := fmt.Sprintf("m%d-%s", i, randBytes(r, 8))
+			switch key.typ {
+			case "hash":
+				content[member] = randBytes(r, spec.ValueSize)
+			case "set":
+				content[member] = ""
+			case "zset":
+				content[member] = strconv.Itoa(r.Intn(1000000))
+			}
+		}
+		return canonicalMap(content)
+	}
+}
+
+func canonicalMap(m map[string]string) []string {
+	var content []string
+	for k, v := range m {
+		content = append(content, k+"\x00"+v)
+	}
+	sort.Strings(content)
+	return content
+}
+
+func checksum(content []string) uint64 {
+	h := fnv.New64a()
+	for _, item := range content {
+		_, _ = h.Write([]byte(strconv.Itoa(len(item))))
+		_, _ = h.Write([]byte(item))
+	}
+	return h.Sum64()
+}
+
+// Populate writes the key space described by the spec.
+func (spec *DataSpec) Populate(t testing.TB, rdb *redis.Client) {
+	ctx := context.Background()
+	p := rdb.Pipeline()
+
+	for _, key := range spec.keys() {
+		content := spec.expected(key)
+		p.Del(ctx, key.name)
+		switch key.typ {
+		case "string":
+			p.Set(ctx, key.name, content[0], 0)
+		case "list":
+			for _, v := range content {
+				p.RPush(ctx, key.name, v)
+			}
+		default:
+			for _, item := range content {
+				member, value, _ := strings.Cut(item, "\x00")
+				switch key.typ {
+				case "hash":
+					p.HSet(ctx, key.name, member, value)
+				case "set":
+					p.SAdd(ctx, key.name, member)
+				case "zset":
+					score, err := strconv.ParseFloat(value, 64)
+					require.NoError(t, err)
+					p.ZAdd(ctx, key.name, redis.Z{Score: score, Member: member})
+				}
+			}
+		}
+
+		if p.Len() >= 1000 {
+			_, err := p.Exec(ctx)
+			require.NoError(t, err)
+		}
+	}
+
+	_, err := p.Exec(ctx)
+	require.NoError(t, err)
+}
+
+// Verify re-derives the expected content of every key in the spec and fails
+// the test with the list of keys whose checksums don't match.
+func (spec *DataSpec) Verify(t testing.TB, rdb *redis.Client) {
+	var mismatched []string
+	for _, key := range spec.keys() {
+		if KeyChecksum(t, rdb, key.name) != checksum(spec.expected(key)) {
+			mismatched = append(mismatched, key.name)
+		}
+	}
+	require.Empty(t, mismatched, "keys mismatch with the data spec")
+}
+
+// KeyChecksum returns a checksum of the key content which doesn't depend on the
+// order of hash fields, set members or sorted set members with the same score.
+// It returns 0 if the key doesn't exist.
+func KeyChecksum(t testing.TB, rdb *redis.Client, key string) uint64 {
+	content := keyContent(t, rdb, key)
+	if content == nil {
+		return 0
+	}
+	return checksum(content)
+}
+
+func keyContent(t testing.TB, rdb *redis.Client, key string) []string {
+	ctx := context.Background()
+	typ, err := rdb.Type(ctx, key).Result()
+	require.NoError(t, err)
+
+	switch typ {
+	case "none":
+		return nil
+	case "string", "bitmap":
+		v, err := rdb.Get(ctx, key).Result()
+		require.NoError(t, err)
+		return []string{v}
+	case "sortedint":
+		n, err := rdb.Do(ctx, "SICARD", key).Int64()
+		require.NoError(t, err)
+		v, err := rdb.Do(ctx, "SIRANGE", key, 0, n).StringSlice()
+		require.NoError(t, err)
+		return v
+	case "stream":
+		entries, err := rdb.XRange(ctx, key, "-", "+").Result()
+		require.NoError(t, err)
+		var content []string
+		for _, entry := range entries {
+			content = append(content, entry.ID)
+			content = append(content, canonicalMap(toStringMap(entry.Values))...)
+		}
+		return content
+	case "ReJSON-RL":
+		v, err := rdb.Do(ctx, "JSON.GET", key).Text()
+		require.NoError(t, err)
+		return []string{v}
+	case "list":
+		v, err := rdb.LRange(ctx, key, 0, -1).Result()
+		require.NoError(t, err)
+		return v
+	case "hash":
+		v, err := rdb.HGetAll(ctx, key).Result()
+		require.NoError(t, err)
+		return canonicalMap(v)
+	case "set":
+		members, err := rdb.SMembers(ctx, key).Result()
+		require.NoError(t, err)
+		m := make(map[string]string, len(members))
+		for _, member := range members {
+			m[member] = ""
+		}
+		return canonicalMap(m)
+	case "zset":
+		members, err := rdb.ZRangeWithScores(ctx, key, 0, -1).Result()
+		require.NoError(t, err)
+		m := make(map[string]string, len(members))
+		for _, z := range members {
+			m[z.Member.(string)] = strconv.FormatFloat(z.Score, 'f', -1, 64)
+		}
+		return canonicalMap(m)
+	default:
+		require.Fail(t, "unsupported key type", "key %s has the type %s", key, typ)
+		return nil
+	}
+}
+
+func toStringMap(m map[string]interface{}) map[string]string {
+	r := make(map[string]string, len(m))
+	for k, v := range m {
+		r[k] = fmt.Sprint(v)
+	}
+	return r
+}
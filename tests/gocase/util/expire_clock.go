@@ -0,0 +1,48 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// AdvanceExpireClock moves the clock which decides the expiration of keys on the server
+// forward by d, so that keys expire without sleeping. Only expiration is affected,
+// other timestamps like the ones of stream IDs still follow the wall clock.
+func AdvanceExpireClock(t testing.TB, srv *KvrocksServer, d time.Duration) {
+	setExpireClockOffset(t, srv, srv.expireClockOffset+d)
+}
+
+// ResetExpireClock moves the expiration clock of the server back to the wall clock.
+func ResetExpireClock(t testing.TB, srv *KvrocksServer) {
+	setExpireClockOffset(t, srv, 0)
+}
+
+func setExpireClockOffset(t testing.TB, srv *KvrocksServer, offset time.Duration) {
+	rdb := srv.NewAdminClient()
+	defer func() { require.NoError(t, rdb.Close()) }()
+
+	require.NoError(t, rdb.Do(context.Background(), "DEBUG", "SET-CLOCK-OFFSET", offset.Milliseconds()).Err())
+	srv.expireClockOffset = offset
+}
@@ -0,0 +1,120 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The suite can run against an already running server instead of starting binaries,
+// e.g. as a smoke test of a deployment. Since the test cases write and flush data,
+// the server should be dedicated to the tests.
+const (
+	// ExternalAddrEnv is the address of the external server, e.g. 127.0.0.1:6666.
+	ExternalAddrEnv = "KVROCKS_EXTERNAL_ADDR"
+	// ExternalPasswordEnv is the password used by clients of the external server.
+	ExternalPasswordEnv = "KVROCKS_EXTERNAL_PASSWORD"
+	// ExternalTLSEnv enables TLS connections to the external server if it's "yes".
+	ExternalTLSEnv = "KVROCKS_EXTERNAL_TLS"
+	// ExternalTLSCACertEnv is the CA certificate to verify the external server,
+	// the system roots are used if it's not set.
+	ExternalTLSCACertEnv = "KVROCKS_EXTERNAL_TLS_CA_CERT"
+)
+
+func IsExternalServer() bool {
+	return os.Getenv(ExternalAddrEnv) != ""
+}
+
+// SkipIfExternal skips the test if it runs against an external server,
+// e.g. because it restarts the server or reads its files.
+func SkipIfExternal(t testing.TB) {
+	if IsExternalServer() {
+		t.Skip("the test manages the server process, which is not possible against an external server")
+	}
+}
+
+// externalTests records the top level tests using the external server, since
+// tests which need more than one server can't run against it.
+var externalTests = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+func startExternalServer(t testing.TB, configs map[string]string, options []string) *KvrocksServer {
+	if len(configs) > 0 || len(options) > 0 {
+		t.Skipf("the test needs the configs %v and options %v, which can't be applied to an external server", configs, options)
+	}
+
+	name := strings.SplitN(t.Name(), "/", 2)[0]
+	externalTests.Lock()
+	if externalTests.names[name] {
+		externalTests.Unlock()
+		t.Skip("the test needs more than one server, but only one external server is available")
+	}
+	externalTests.names[name] = true
+	externalTests.Unlock()
+	t.Cleanup(func() {
+		externalTests.Lock()
+		defer externalTests.Unlock()
+		delete(externalTests.names, name)
+	})
+
+	addr, err := net.ResolveTCPAddr("tcp", os.Getenv(ExternalAddrEnv))
+	require.NoError(t, err)
+
+	s := &KvrocksServer{t: t, addr: addr, configs: configs, external: true}
+	c := s.NewClient()
+	defer func() { require.NoError(t, c.Close()) }()
+	require.NoError(t, c.Ping(context.Background()).Err(), "the external server is not available")
+	return s
+}
+
+func externalTLSConfig() (*tls.Config, error) {
+	if os.Getenv(ExternalTLSEnv) != "yes" {
+		return nil, nil
+	}
+
+	conf := &tls.Config{}
+	if path := os.Getenv(ExternalTLSCACertEnv); path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("failed to load the CA certificate of the external server")
+		}
+	}
+	return conf, nil
+}
+
+func (s *KvrocksServer) requireLocal() {
+	require.False(s.t, s.external, "the test manages the server process, please skip it by SkipIfExternal")
+}
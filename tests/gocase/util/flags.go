@@ -26,6 +26,15 @@ var workspace = flag.String("workspace", "", "directory of cases workspace")
 var deleteOnExit = flag.Bool("deleteOnExit", false, "whether to delete workspace on exit")
 var cliPath = flag.String("cliPath", "redis-cli", "path to redis-cli")
 var tlsEnable = flag.Bool("tlsEnable", false, "enable TLS-related test cases")
+var workloadBaseline = flag.String("workloadBaseline", "", "path to the workload baseline file, it will be created if not exists")
+var workloadTolerance = flag.Float64("workloadTolerance", 0.3, "allowed regression ratio against the workload baseline")
+var oldBinPath = flag.String("oldBinPath", "", "path to the kvrocks binary of an older release for compatibility cases")
+var shareServers = flag.Bool("shareServers", true, "reuse servers acquired by AcquireSharedServer across test cases")
+var sanitizer = flag.String("sanitizer", "", "the sanitizer (asan or tsan) the binary is built with, whose reports fail the cases")
+var redisServerPath = flag.String("redisServerPath", "", "path to redis-server for conformance cases, looked up in PATH if empty")
+var commandCoverage = flag.String("commandCoverage", "", "directory to record the commands exercised by test cases, disabled if empty")
+var keepArtifacts = flag.Bool("keepArtifacts", false, "keep the directories of servers used by failed cases even with `-deleteOnExit`, and log their paths")
+var artifactsDir = flag.String("artifactsDir", "", "directory to dump diagnostics of failed cases into, `artifacts` under the workspace if empty")
 
 func CLIPath() string {
 	return *cliPath
@@ -34,3 +43,15 @@ func CLIPath() string {
 func TLSEnable() bool {
 	return *tlsEnable
 }
+
+func WorkloadBaseline() string {
+	return *workloadBaseline
+}
+
+func WorkloadTolerance() float64 {
+	return *workloadTolerance
+}
+
+func CommandCoverageDir() string {
+	return *commandCoverage
+}
@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// EncodeRESP encodes the arguments as a RESP array of bulk strings, i.e. a command.
+func EncodeRESP(args ...string) []byte {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("*%d\r\n", len(args)))
+	for _, arg := range args {
+		b.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg))
+	}
+	return []byte(b.String())
+}
+
+// RESPSeeds returns the seed corpus of the protocol fuzzer, which is made of real
+// commands in both the multi-bulk and the inline format, plus some malformed requests.
+func RESPSeeds() [][]byte {
+	commands := [][]string{
+		{"PING"},
+		{"SET", "key", "value"},
+		{"GET", "key"},
+		{"HSET", "hash", "field", "value"},
+		{"LPUSH", "list", "a", "b", "c"},
+		{"ZADD", "zset", "1.5", "member"},
+		{"SETBIT", "bitmap", "100", "1"},
+		{"XADD", "stream", "*", "field", "value"},
+		{"EVAL", "return redis.call('GET', KEYS[1])", "1", "key"},
+		{"MULTI"},
+		{"EXEC"},
+		{"HELLO", "3"},
+		{"SUBSCRIBE", "channel"},
+		{"CLIENT", "SETNAME", "fuzzer"},
+		{"SCAN", "0", "MATCH", "*", "COUNT", "10"},
+	}
+
+	var seeds [][]byte
+	var pipeline []byte
+	for _, cmd := range commands {
+		seeds = append(seeds, EncodeRESP(cmd...))
+		seeds = append(seeds, []byte(strings.Join(cmd, " ")+"\r\n"))
+		pipeline = append(pipeline, EncodeRESP(cmd...)...)
+	}
+	return append(seeds,
+		pipeline,
+		[]byte("*1\r\n$4\r\nPI"),
+		[]byte("*-1\r\n"),
+		[]byte("*3\r\n$-1\r\n$0\r\n\r\n:1\r\n"),
+		[]byte("*1\r\n$2147483648\r\n"),
+		[]byte("*100000000\r\n"),
+		[]byte("\"unbalanced quotes\r\n"),
+		[]byte("\r\n\r\n\n"),
+	)
+}
+
+// RequireSurvivesInput writes the data through a raw connection, and then requires
+// the server neither exits nor stops serving other clients. On failure, the input is
+// saved into the `crashers` directory of the server, which is kept after Close.
+func (s *KvrocksServer) RequireSurvivesInput(t testing.TB, data []byte, timeout time.Duration) {
+	if err := s.feedRawInput(data, timeout); err != nil {
+		s.saveCrasher(t, data)
+		require.NoError(t, err)
+	}
+}
+
+func (s *KvrocksServer) feedRawInput(data []byte, timeout time.Duration) error {
+	c, err := net.DialTimeout("tcp", s.addr.String(), timeout)
+	if err != nil {
+		return fmt.Errorf("cannot connect to the server: %w", err)
+	}
+	// the server may wait for the rest of an incomplete request, which is fine,
+	// so the connection is drained for a short while and then abandoned
+	_ = c.SetDeadline(time.Now().Add(timeout / 10))
+	_, _ = c.Write(data)
+	_, _ = io.Copy(io.Discard, c)
+	_ = c.Close()
+
+	// the server must still serve a new client in time, which fails as well if it exited
+	health, err := net.DialTimeout("tcp", s.addr.String(), timeout)
+	if err != nil {
+		return fmt.Errorf("cannot connect to the server: %w", err)
+	}
+	defer func() { _ = health.Close() }()
+	_ = health.SetDeadline(time.Now().Add(timeout))
+	if _, err := health.Write(EncodeRESP("PING")); err != nil {
+		return fmt.Errorf("cannot send PING: %w", err)
+	}
+	line, err := bufio.NewReader(health).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("no reply of PING, the server may hang: %w", err)
+	}
+	// NOAUTH is fine since the fuzzer may run against a server with requirepass
+	if line != "+PONG\r\n" && !strings.HasPrefix(line, "-NOAUTH") {
+		return errors.New("unexpected reply of PING: " + strings.TrimSpace(line))
+	}
+	return nil
+}
+
+func (s *KvrocksServer) saveCrasher(t testing.TB, data []byte) {
+	if s.external {
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	dir := filepath.Join(s.Dir(), "crashers")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	path := filepath.Join(dir, hex.EncodeToString(sum[:8])+".resp")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	s.RetainDir()
+	t.Logf("the crashing input is saved to %s, the server log is at %s", path, s.Dir())
+}
@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// Info is a snapshot of the INFO reply, which maps lowercase section names
+// to the fields of the section.
+type Info struct {
+	t        testing.TB
+	Sections map[string]map[string]string
+}
+
+// ParseInfo issues INFO with the given sections and parses the reply into an Info snapshot.
+func ParseInfo(t testing.TB, rdb *redis.Client, section ...string) *Info {
+	r, err := rdb.Info(context.Background(), section...).Result()
+	require.NoError(t, err)
+	return ParseInfoString(t, r)
+}
+
+func ParseInfoString(t testing.TB, s string) *Info {
+	info := &Info{t: t, Sections: make(map[string]map[string]string)}
+
+	var fields map[string]string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			// comment lines like "# Last scan db time: ..." are not section headers
+			if !strings.Contains(line, ":") {
+				fields = make(map[string]string)
+				info.Sections[strings.ToLower(strings.TrimSpace(line[1:]))] = fields
+			}
+			continue
+		}
+
+		kv := strings.SplitN(line, ":", 2)
+		require.Len(t, kv, 2, "malformed INFO line: %s", line)
+		if fields == nil {
+			fields = make(map[string]string)
+			info.Sections[""] = fields
+		}
+		fields[kv[0]] = strings.TrimSpace(kv[1])
+	}
+
+	return info
+}
+
+// Lookup returns the value of the field in the section. If the section is empty,
+// all sections are searched.
+func (info *Info) Lookup(section, field string) (string, bool) {
+	if section != "" {
+		v, ok := info.Sections[strings.ToLower(section)][field]
+		return v, ok
+	}
+	for _, fields := range info.Sections {
+		if v, ok := fields[field]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func (info *Info) String(section, field string) string {
+	v, ok := info.Lookup(section, field)
+	require.True(info.t, ok, "field %s not found in INFO section %s", field, section)
+	return v
+}
+
+func (info *Info) Int(section, field string) int64 {
+	v, err := strconv.ParseInt(info.String(section, field), 10, 64)
+	require.NoError(info.t, err)
+	return v
+}
+
+func (info *Info) Float(section, field string) float64 {
+	v, err := strconv.ParseFloat(info.String(section, field), 64)
+	require.NoError(info.t, err)
+	return v
+}
+
+// Duration interprets the integer value of the field in the given unit,
+// e.g. Duration("server", "uptime_in_seconds", time.Second).
+func (info *Info) Duration(section, field string, unit time.Duration) time.Duration {
+	return time.Duration(info.Int(section, field)) * unit
+}
+
+// Map parses fields with a value like "keys=1,expires=0,avg_ttl=0" into a map.
+func (info *Info) Map(section, field string) map[string]string {
+	m := make(map[string]string)
+	for _, item := range strings.Split(info.String(section, field), ",") {
+		kv := strings.SplitN(item, "=", 2)
+		require.Len(info.t, kv, 2, "malformed INFO value: %s", item)
+		m[kv[0]] = kv[1]
+	}
+	return m
+}
@@ -0,0 +1,187 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// TTLs of the same key may differ slightly between two servers, e.g. a replica
+// applies the expiration a bit later, so they are compared with a tolerance.
+const diffTTLTolerance = 2 * time.Second
+
+// KeyMismatch is a key which differs between two key spaces.
+type KeyMismatch struct {
+	Namespace string
+	Key       string
+	Reason    string
+}
+
+func (m KeyMismatch) String() string {
+	if m.Namespace != "" {
+		return fmt.Sprintf("%s/%s: %s", m.Namespace, m.Key, m.Reason)
+	}
+	return fmt.Sprintf("%s: %s", m.Key, m.Reason)
+}
+
+// DiffKeyspace scans all keys of both clients and compares their types, values and TTLs.
+// The mismatches are sorted by the key.
+func DiffKeyspace(t testing.TB, a, b *redis.Client) []KeyMismatch {
+	keysA, keysB := scanAllKeys(t, a), scanAllKeys(t, b)
+
+	var mismatches []KeyMismatch
+	for key := range keysA {
+		if _, ok := keysB[key]; !ok {
+			mismatches = append(mismatches, KeyMismatch{Key: key, Reason: "missing on b"})
+			continue
+		}
+		if reason := diffKey(t, a, b, key); reason != "" {
+			mismatches = append(mismatches, KeyMismatch{Key: key, Reason: reason})
+		}
+	}
+	for key := range keysB {
+		if _, ok := keysA[key]; !ok {
+			mismatches = append(mismatches, KeyMismatch{Key: key, Reason: "missing on a"})
+		}
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool { return mismatches[i].Key < mismatches[j].Key })
+	return mismatches
+}
+
+// DiffServers compares the key spaces of all namespaces on both servers. The clients
+// are authenticated with `requirepass` of each server to list the namespaces.
+func DiffServers(t testing.TB, a, b *KvrocksServer) []KeyMismatch {
+	tokensA, tokensB := namespaceTokens(t, a), namespaceTokens(t, b)
+
+	var namespaces []string
+	for ns := range tokensA {
+		namespaces = append(namespaces, ns)
+	}
+	for ns := range tokensB {
+		if _, ok := tokensA[ns]; !ok {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Strings(namespaces)
+
+	var mismatches []KeyMismatch
+	for _, ns := range namespaces {
+		tokenA, okA := tokensA[ns]
+		tokenB, okB := tokensB[ns]
+		if !okA || !okB {
+			side := "a"
+			if okA {
+				side = "b"
+			}
+			mismatches = append(mismatches, KeyMismatch{Namespace: ns, Reason: "namespace missing on " + side})
+			continue
+		}
+
+		func() {
+			rdbA, rdbB := a.NewClientWithAuth(tokenA), b.NewClientWithAuth(tokenB)
+			defer func() {
+				require.NoError(t, rdbA.Close())
+				require.NoError(t, rdbB.Close())
+			}()
+			for _, m := range DiffKeyspace(t, rdbA, rdbB) {
+				m.Namespace = ns
+				mismatches = append(mismatches, m)
+			}
+		}()
+	}
+	return mismatches
+}
+
+// RequireSameKeyspace fails the test with all mismatches if the key spaces
+// of the two servers differ in any namespace.
+func RequireSameKeyspace(t testing.TB, a, b *KvrocksServer) {
+	mismatches := DiffServers(t, a, b)
+	require.Empty(t, mismatches, "key spaces mismatch between %s and %s", a.HostPort(), b.HostPort())
+}
+
+func scanAllKeys(t testing.TB, rdb *redis.Client) map[string]struct{} {
+	ctx := context.Background()
+	keys := make(map[string]struct{})
+	var cursor uint64
+	for {
+		batch, next, err := rdb.Scan(ctx, cursor, "*", 1000).Result()
+		require.NoError(t, err)
+		for _, key := range batch {
+			keys[key] = struct{}{}
+		}
+		if next == 0 {
+			return keys
+		}
+		cursor = next
+	}
+}
+
+// diffKey returns the reason why the key differs, or an empty string if it's the same.
+func diffKey(t testing.TB, a, b *redis.Client, key string) string {
+	ctx := context.Background()
+
+	typA, err := a.Type(ctx, key).Result()
+	require.NoError(t, err)
+	typB, err := b.Type(ctx, key).Result()
+	require.NoError(t, err)
+	if typA != typB {
+		return fmt.Sprintf("type %s != %s", typA, typB)
+	}
+
+	// keyContent returns nil if the key expired after SCAN, which is reported as a value mismatch
+	contentA, contentB := keyContent(t, a, key), keyContent(t, b, key)
+	if checksum(contentA) != checksum(contentB) {
+		return "value mismatch"
+	}
+
+	ttlA, err := a.PTTL(ctx, key).Result()
+	require.NoError(t, err)
+	ttlB, err := b.PTTL(ctx, key).Result()
+	require.NoError(t, err)
+	if (ttlA < 0) != (ttlB < 0) || (ttlA-ttlB).Abs() > diffTTLTolerance {
+		return fmt.Sprintf("ttl %v != %v", ttlA, ttlB)
+	}
+	return ""
+}
+
+// namespaceTokens maps all namespaces of the server to their tokens, including
+// the default namespace whose token is `requirepass`.
+func namespaceTokens(t testing.TB, s *KvrocksServer) map[string]string {
+	admin := s.NewAdminClient()
+	defer func() { require.NoError(t, admin.Close()) }()
+
+	r, err := admin.Do(context.Background(), "NAMESPACE", "GET", "*").StringSlice()
+	require.NoError(t, err)
+	require.Zero(t, len(r)%2, "malformed NAMESPACE GET reply: %v", r)
+
+	tokens := make(map[string]string, len(r)/2)
+	for i := 0; i < len(r); i += 2 {
+		tokens[r[i]] = r[i+1]
+	}
+	return tokens
+}
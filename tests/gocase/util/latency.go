@@ -0,0 +1,235 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// Latencies are bucketed like HDR histograms: values below 2*histogramSubBuckets
+// nanoseconds are exact, and larger values are bucketed with a relative error
+// of at most 1/histogramSubBuckets, i.e. about 3%.
+const (
+	histogramSubBuckets = 32
+	histogramBuckets    = (64 - 5) * histogramSubBuckets
+)
+
+// LatencyHistogram records durations in fixed memory and answers percentile queries.
+type LatencyHistogram struct {
+	counts [histogramBuckets]int64
+	total  int64
+	max    time.Duration
+}
+
+func histogramIndex(v uint64) int {
+	if v < 2*histogramSubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - 6
+	return (shift+1)*histogramSubBuckets + int(v>>shift) - histogramSubBuckets
+}
+
+// histogramUpperBound returns the largest value which falls into the bucket.
+func histogramUpperBound(idx int) uint64 {
+	if idx < 2*histogramSubBuckets {
+		return uint64(idx)
+	}
+	shift := idx/histogramSubBuckets - 1
+	mantissa := uint64(idx%histogramSubBuckets + histogramSubBuckets)
+	return (mantissa+1)<<shift - 1
+}
+
+func (h *LatencyHistogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	h.counts[histogramIndex(uint64(d))]++
+	h.total++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+func (h *LatencyHistogram) Count() int64 {
+	return h.total
+}
+
+func (h *LatencyHistogram) Max() time.Duration {
+	return h.max
+}
+
+// Percentile returns the latency below which the given fraction of samples fall,
+// e.g. Percentile(0.99) for P99. It returns 0 if nothing is recorded.
+func (h *LatencyHistogram) Percentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := int64(float64(h.total)*p + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for idx, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			if d := time.Duration(histogramUpperBound(idx)); d < h.max {
+				return d
+			}
+			return h.max
+		}
+	}
+	return h.max
+}
+
+func (h *LatencyHistogram) String() string {
+	return fmt.Sprintf("count: %d, p50: %v, p99: %v, p999: %v, max: %v",
+		h.total, h.Percentile(0.5), h.Percentile(0.99), h.Percentile(0.999), h.max)
+}
+
+// LatencyRecorder records the latency of every command sent by a client into
+// per-command histograms. Pipelines and transactions are recorded per round trip
+// under the name "pipeline".
+type LatencyRecorder struct {
+	t       testing.TB
+	stopped atomic.Bool
+
+	mu         sync.Mutex
+	histograms map[string]*LatencyHistogram
+}
+
+// NewLatencyRecorder starts recording the commands of the client until Stop is called.
+func NewLatencyRecorder(t testing.TB, rdb *redis.Client) *LatencyRecorder {
+	r := &LatencyRecorder{t: t, histograms: make(map[string]*LatencyHistogram)}
+	rdb.AddHook(latencyHook{r})
+	return r
+}
+
+func (r *LatencyRecorder) record(name string, d time.Duration) {
+	if r.stopped.Load() {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.histograms[name]
+	if !ok {
+		h = &LatencyHistogram{}
+		r.histograms[name] = h
+	}
+	h.Record(d)
+}
+
+// Histogram returns a copy of the histogram of the command, which is empty
+// if the command hasn't been recorded.
+func (r *LatencyRecorder) Histogram(cmd string) *LatencyHistogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h := &LatencyHistogram{}
+	if recorded, ok := r.histograms[strings.ToLower(cmd)]; ok {
+		*h = *recorded
+	}
+	return h
+}
+
+func (r *LatencyRecorder) Percentile(cmd string, p float64) time.Duration {
+	return r.Histogram(cmd).Percentile(p)
+}
+
+// RequirePercentileBelow fails if the given percentile of the command latency exceeds the limit.
+func (r *LatencyRecorder) RequirePercentileBelow(cmd string, p float64, limit time.Duration) {
+	h := r.Histogram(cmd)
+	require.NotZero(r.t, h.Count(), "no latency is recorded for %s", cmd)
+	require.LessOrEqual(r.t, h.Percentile(p), limit, "p%g latency of %s is too high, %s", p*100, cmd, h)
+}
+
+func (r *LatencyRecorder) RequireP50Below(cmd string, limit time.Duration) {
+	r.RequirePercentileBelow(cmd, 0.5, limit)
+}
+
+func (r *LatencyRecorder) RequireP99Below(cmd string, limit time.Duration) {
+	r.RequirePercentileBelow(cmd, 0.99, limit)
+}
+
+func (r *LatencyRecorder) RequireP999Below(cmd string, limit time.Duration) {
+	r.RequirePercentileBelow(cmd, 0.999, limit)
+}
+
+// Reset drops all recorded latencies, e.g. after warming up.
+func (r *LatencyRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.histograms = make(map[string]*LatencyHistogram)
+}
+
+// Stop stops recording, since hooks can't be removed from a client.
+func (r *LatencyRecorder) Stop() {
+	r.stopped.Store(true)
+}
+
+func (r *LatencyRecorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var names []string
+	for name := range r.histograms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s: %s", name, r.histograms[name]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type latencyHook struct {
+	r *LatencyRecorder
+}
+
+func (h latencyHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h latencyHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.r.record(cmd.Name(), time.Since(start))
+		return err
+	}
+}
+
+func (h latencyHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.r.record("pipeline", time.Since(start))
+		return err
+	}
+}
@@ -0,0 +1,63 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ScrapeMetrics fetches the Prometheus metrics of a server started by StartMetricsServer,
+// and returns the samples keyed by the metric name with labels, e.g. `kvrocks_commandstats_calls{cmd="get"}`.
+func (s *KvrocksServer) ScrapeMetrics(t testing.TB) map[string]float64 {
+	require.NotNil(t, s.metricsAddr, "the server is not started by StartMetricsServer")
+	resp, err := http.Get(fmt.Sprintf("http://%s/metrics", s.metricsAddr))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, resp.Body.Close()) }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+
+	return parseMetrics(t, resp.Body)
+}
+
+func parseMetrics(t testing.TB, r io.Reader) map[string]float64 {
+	metrics := make(map[string]float64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		require.Greater(t, i, 0, "malformed metric line: %s", line)
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		require.NoError(t, err, "malformed metric line: %s", line)
+		metrics[line[:i]] = v
+	}
+	require.NoError(t, scanner.Err())
+	return metrics
+}
@@ -0,0 +1,100 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// KeysInSlot scans the keys of the node and returns the ones which belong to the slot.
+func KeysInSlot(t testing.TB, rdb *redis.Client, slot int) []string {
+	ctx := context.Background()
+	var keys []string
+	iter := rdb.Scan(ctx, 0, "*", 1000).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		s, err := rdb.ClusterKeySlot(ctx, key).Result()
+		require.NoError(t, err)
+		if int(s) == slot {
+			keys = append(keys, key)
+		}
+	}
+	require.NoError(t, iter.Err())
+	return keys
+}
+
+// WaitForMigrateSuccess waits until the node reports the migration of the slot succeeded,
+// and fails immediately if the migration failed.
+func WaitForMigrateSuccess(t testing.TB, rdb *redis.Client, slot int, timeout time.Duration) {
+	require.Eventually(t, func() bool {
+		info := rdb.ClusterInfo(context.Background()).Val()
+		if !strings.Contains(info, fmt.Sprintf("migrating_slot: %d\r\n", slot)) {
+			return false
+		}
+		require.NotContains(t, info, "migrating_state: fail", "failed to migrate slot %d", slot)
+		return strings.Contains(info, "migrating_state: success")
+	}, timeout, 100*time.Millisecond)
+}
+
+// MigrateSlotAndVerify migrates the slot from one node to another and hands the slot
+// over to the destination in the topology of all nodes, just like the cluster controller.
+// It verifies that all keys of the slot are moved with the same content, and the
+// requests for the slot are redirected to the destination by the other nodes.
+func MigrateSlotAndVerify(t testing.TB, c *KvrocksCluster, slot int, from, to *ClusterNode) {
+	ctx := context.Background()
+	require.True(t, from.HasSlot(slot), "slot %d is not served by the source node", slot)
+
+	checksums := make(map[string]uint64)
+	for _, key := range KeysInSlot(t, from.Client, slot) {
+		checksums[key] = KeyChecksum(t, from.Client, key)
+	}
+
+	require.NoError(t, from.Client.Do(ctx, "clusterx", "migrate", slot, to.ID).Err())
+	WaitForMigrateSuccess(t, from.Client, slot, time.Minute)
+
+	c.version++
+	for _, node := range c.Nodes {
+		require.NoError(t, node.Client.Do(ctx, "clusterx", "setslot", slot, "node", to.ID, c.version).Err())
+	}
+	from.removeSlot(slot)
+	to.addSlot(slot)
+
+	for key, sum := range checksums {
+		require.Equal(t, sum, KeyChecksum(t, to.Client, key), "key %s mismatches after migration", key)
+	}
+
+	moved := fmt.Sprintf("MOVED %d %s", slot, to.Server.HostPort())
+	for _, node := range c.Nodes {
+		if node == to {
+			continue
+		}
+		for key := range checksums {
+			require.EqualError(t, node.Client.Exists(ctx, key).Err(), moved)
+		}
+		require.EqualError(t, node.Client.Get(ctx, SlotTable[slot]).Err(), moved)
+	}
+}
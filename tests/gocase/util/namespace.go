@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// Namespace is a namespace created by SetupNamespaces, along with a client authenticated with its token.
+type Namespace struct {
+	Name   string
+	Token  string
+	Client *redis.Client
+}
+
+type NamespaceFixture struct {
+	t          testing.TB
+	srv        *KvrocksServer
+	Namespaces []*Namespace
+}
+
+// SetupNamespaces creates n namespaces named fixture0, fixture1, ... with the tokens
+// fixture0-token, fixture1-token, ... and a client for each of them. The server must be
+// started with `requirepass`. Close the fixture to flush and delete the namespaces.
+func SetupNamespaces(t testing.TB, srv *KvrocksServer, n int) *NamespaceFixture {
+	f := &NamespaceFixture{t: t, srv: srv}
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("fixture%d", i)
+		f.Namespaces = append(f.Namespaces, &Namespace{
+			Name:   name,
+			Token:  name + "-token",
+			Client: srv.NewNamespaceClient(name),
+		})
+	}
+	return f
+}
+
+// Client returns the client of the i-th namespace.
+func (f *NamespaceFixture) Client(i int) *redis.Client {
+	return f.Namespaces[i].Client
+}
+
+// Close flushes the keys of all namespaces, closes their clients and deletes them.
+func (f *NamespaceFixture) Close() {
+	ctx := context.Background()
+	admin := f.srv.NewAdminClient()
+	defer func() { require.NoError(f.t, admin.Close()) }()
+
+	for _, ns := range f.Namespaces {
+		require.NoError(f.t, ns.Client.FlushDB(ctx).Err())
+		require.NoError(f.t, ns.Client.Close())
+		require.NoError(f.t, admin.Do(ctx, "NAMESPACE", "DEL", ns.Name).Err())
+	}
+}
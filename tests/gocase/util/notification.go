@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// KeyspaceEvent is a notification published on the __keyspace@<db>__:<key>
+// or __keyevent@<db>__:<event> channels.
+type KeyspaceEvent struct {
+	// Kind is either "keyspace" or "keyevent".
+	Kind  string
+	DB    string
+	Key   string
+	Event string
+}
+
+type KeyspaceEvents struct {
+	t      testing.TB
+	pubsub *redis.PubSub
+	C      <-chan KeyspaceEvent
+}
+
+// SubscribeKeyspaceEvents subscribes the channels matching the pattern, e.g. "__key*__:*",
+// and returns after the subscription is confirmed, so no event published afterward is missed.
+func SubscribeKeyspaceEvents(t testing.TB, rdb *redis.Client, pattern string) *KeyspaceEvents {
+	ctx := context.Background()
+	pubsub := rdb.PSubscribe(ctx, pattern)
+	msg, err := pubsub.Receive(ctx)
+	require.NoError(t, err)
+	require.IsType(t, &redis.Subscription{}, msg)
+
+	c := make(chan KeyspaceEvent, 1024)
+	go func() {
+		defer close(c)
+		for msg := range pubsub.Channel() {
+			if event, ok := parseKeyspaceEvent(msg.Channel, msg.Payload); ok {
+				c <- event
+			}
+		}
+	}()
+	return &KeyspaceEvents{t: t, pubsub: pubsub, C: c}
+}
+
+func parseKeyspaceEvent(channel, payload string) (KeyspaceEvent, bool) {
+	prefix, suffix, ok := strings.Cut(channel, "__:")
+	if !ok {
+		return KeyspaceEvent{}, false
+	}
+	kind, db, ok := strings.Cut(strings.TrimPrefix(prefix, "__"), "@")
+	if !ok {
+		return KeyspaceEvent{}, false
+	}
+
+	switch kind {
+	case "keyspace":
+		return KeyspaceEvent{Kind: kind, DB: db, Key: suffix, Event: payload}, true
+	case "keyevent":
+		return KeyspaceEvent{Kind: kind, DB: db, Key: payload, Event: suffix}, true
+	default:
+		return KeyspaceEvent{}, false
+	}
+}
+
+// Expect waits for an event of the key, events of other keys or types are skipped.
+func (e *KeyspaceEvents) Expect(event, key string, timeout time.Duration) KeyspaceEvent {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case ev, ok := <-e.C:
+			require.True(e.t, ok, "subscription is closed while waiting for event %s of key %s", event, key)
+			if ev.Event == event && ev.Key == key {
+				return ev
+			}
+		case <-deadline:
+			require.Fail(e.t, "timeout waiting for keyspace event", "event: %s, key: %s", event, key)
+			return KeyspaceEvent{}
+		}
+	}
+}
+
+// ExpectNone asserts no event arrives in the given duration.
+func (e *KeyspaceEvents) ExpectNone(d time.Duration) {
+	select {
+	case ev, ok := <-e.C:
+		if ok {
+			require.Fail(e.t, "unexpected keyspace event", "%+v", ev)
+		}
+	case <-time.After(d):
+	}
+}
+
+func (e *KeyspaceEvents) Close() error {
+	return e.pubsub.Close()
+}
@@ -0,0 +1,201 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// Shared servers are pooled by their configs. A server is put back to the pool
+// when it's closed, and is handed out again after its state is reset.
+var sharedServers = struct {
+	sync.Mutex
+	idle map[string][]*KvrocksServer
+	all  []*KvrocksServer
+	// configs are the runtime configs of each server right after it started
+	configs map[*KvrocksServer]map[string]string
+
+	// statistics of the pool, reported by CloseSharedServers
+	acquired    int
+	startupTime time.Duration
+}{idle: make(map[string][]*KvrocksServer), configs: make(map[*KvrocksServer]map[string]string)}
+
+func sharedServerKey(configs map[string]string) string {
+	var items []string
+	for k, v := range configs {
+		items = append(items, fmt.Sprintf("%s %s", k, v))
+	}
+	sort.Strings(items)
+	return strings.Join(items, "\n")
+}
+
+// AcquireSharedServer returns an idle server started with the same configs, or starts
+// a new one. Closing the returned server puts it back to the pool instead of stopping it,
+// so it's only suitable for tests which don't restart the server. Before being handed out
+// again, the server is reset: all data and scripts are flushed, the namespaces are deleted
+// and the configs changed by CONFIG SET are restored.
+// Packages using it should call CloseSharedServers in TestMain after running the tests.
+// Servers are not shared if `-shareServers=false`, which is useful to measure the win.
+func AcquireSharedServer(t testing.TB, configs map[string]string) *KvrocksServer {
+	if !*shareServers {
+		return StartServer(t, configs)
+	}
+
+	key := sharedServerKey(configs)
+
+	sharedServers.Lock()
+	sharedServers.acquired++
+	idle := sharedServers.idle[key]
+	if len(idle) == 0 {
+		sharedServers.Unlock()
+
+		start := time.Now()
+		s := StartServer(t, configs)
+		s.sharedKey = key
+		runtimeConfigs := s.runtimeConfigs()
+		sharedServers.Lock()
+		sharedServers.startupTime += time.Since(start)
+		sharedServers.all = append(sharedServers.all, s)
+		sharedServers.configs[s] = runtimeConfigs
+		sharedServers.Unlock()
+		return s
+	}
+	s := idle[len(idle)-1]
+	sharedServers.idle[key] = idle[:len(idle)-1]
+	runtimeConfigs := sharedServers.configs[s]
+	sharedServers.Unlock()
+
+	s.t = t
+	s.resetSharedState(runtimeConfigs)
+	return s
+}
+
+func (s *KvrocksServer) newSharedAdminClient() *redis.Client {
+	return s.NewClientWithOption(&redis.Options{Password: s.configs["requirepass"]})
+}
+
+func (s *KvrocksServer) runtimeConfigs() map[string]string {
+	c := s.newSharedAdminClient()
+	defer func() { require.NoError(s.t, c.Close()) }()
+	configs, err := c.ConfigGet(context.Background(), "*").Result()
+	require.NoError(s.t, err)
+	return configs
+}
+
+// resetSharedState drops everything the previous test may have left in the server.
+func (s *KvrocksServer) resetSharedState(runtimeConfigs map[string]string) {
+	ctx := context.Background()
+	c := s.newSharedAdminClient()
+	defer func() { require.NoError(s.t, c.Close()) }()
+
+	for k, v := range s.runtimeConfigs() {
+		if initial, ok := runtimeConfigs[k]; ok && initial != v {
+			require.NoError(s.t, c.ConfigSet(ctx, k, initial).Err(), "failed to restore config %s", k)
+		}
+	}
+
+	// namespaces are only available if the server is started with requirepass
+	if namespaces, err := c.Do(ctx, "NAMESPACE", "GET", "*").StringSlice(); err == nil {
+		for i := 0; i+1 < len(namespaces); i += 2 {
+			if namespaces[i] != "__namespace" {
+				require.NoError(s.t, c.Do(ctx, "NAMESPACE", "DEL", namespaces[i]).Err())
+			}
+		}
+	}
+
+	require.NoError(s.t, c.FlushAll(ctx).Err())
+	require.NoError(s.t, c.ScriptFlush(ctx).Err())
+}
+
+func releaseSharedServer(s *KvrocksServer) {
+	sharedServers.Lock()
+	defer sharedServers.Unlock()
+	sharedServers.idle[s.sharedKey] = append(sharedServers.idle[s.sharedKey], s)
+}
+
+var errSharedServer = errors.New("failed to stop the shared server")
+
+// sharedServerTB reports the failures of stopping shared servers in TestMain,
+// where the tests which acquired them have already completed.
+type sharedServerTB struct {
+	testing.TB
+	errs []string
+}
+
+func (tb *sharedServerTB) Helper() {}
+
+func (tb *sharedServerTB) Name() string { return "CloseSharedServers" }
+
+func (tb *sharedServerTB) Failed() bool { return len(tb.errs) > 0 }
+
+func (tb *sharedServerTB) Logf(format string, args ...interface{}) { fmt.Printf(format+"\n", args...) }
+
+func (tb *sharedServerTB) Errorf(format string, args ...interface{}) {
+	tb.errs = append(tb.errs, fmt.Sprintf(format, args...))
+}
+
+func (tb *sharedServerTB) FailNow() { panic(errSharedServer) }
+
+// CloseSharedServers stops all servers started by AcquireSharedServer, and prints
+// how much startup time sharing saved, estimated by the average startup time.
+func CloseSharedServers() error {
+	sharedServers.Lock()
+	defer sharedServers.Unlock()
+
+	if started := len(sharedServers.all); started > 0 {
+		saved := sharedServers.startupTime / time.Duration(started) * time.Duration(sharedServers.acquired-started)
+		fmt.Printf("shared servers: %d acquisitions served by %d servers, %v spent on startup, about %v saved\n",
+			sharedServers.acquired, started, sharedServers.startupTime, saved)
+	}
+
+	tb := &sharedServerTB{}
+	for _, s := range sharedServers.all {
+		s.t = tb
+		s.sharedKey = ""
+		func() {
+			defer func() {
+				if r := recover(); r != nil && r != errSharedServer {
+					panic(r)
+				}
+			}()
+			s.Close()
+		}()
+	}
+	sharedServers.all = nil
+	sharedServers.idle = make(map[string][]*KvrocksServer)
+	sharedServers.configs = make(map[*KvrocksServer]map[string]string)
+	sharedServers.acquired = 0
+	sharedServers.startupTime = 0
+
+	if len(tb.errs) > 0 {
+		return fmt.Errorf("%w: %s", errSharedServer, strings.Join(tb.errs, "; "))
+	}
+	return nil
+}
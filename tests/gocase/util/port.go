@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+const maxPortAttempts = 100
+
+// Ports are picked by the kernel and then reserved by taking an exclusive flock
+// on a file named after the port, so that test packages running concurrently
+// in different processes never hand out the same port before the server binds it.
+// The lock is held until the port is released or the process exits.
+var reservedPorts = struct {
+	sync.Mutex
+	files map[int]*os.File
+}{files: make(map[int]*os.File)}
+
+func portLockDir() string {
+	if *workspace != "" {
+		return filepath.Join(*workspace, ".ports")
+	}
+	return filepath.Join(os.TempDir(), "kvrocks-gocase-ports")
+}
+
+func lockPort(port int) (bool, error) {
+	dir := portLockDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf("%d.lock", port)), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return false, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		return false, nil
+	}
+
+	reservedPorts.Lock()
+	defer reservedPorts.Unlock()
+	reservedPorts.files[port] = f
+	return true, nil
+}
+
+func releasePort(addr *net.TCPAddr) {
+	if addr == nil {
+		return
+	}
+
+	reservedPorts.Lock()
+	defer reservedPorts.Unlock()
+	if f, ok := reservedPorts.files[addr.Port]; ok {
+		// closing the file releases the flock
+		_ = f.Close()
+		delete(reservedPorts.files, addr.Port)
+	}
+}
+
+func findFreePort() (*net.TCPAddr, error) {
+	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < maxPortAttempts; i++ {
+		lis, err := net.ListenTCP("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		freeAddr := lis.Addr().(*net.TCPAddr)
+		if err := lis.Close(); err != nil {
+			return nil, err
+		}
+
+		if ok, err := lockPort(freeAddr.Port); err != nil {
+			return nil, err
+		} else if ok {
+			return freeAddr, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot reserve a free port after %d attempts", maxPortAttempts)
+}
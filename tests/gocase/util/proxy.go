@@ -0,0 +1,277 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// Proxy is a TCP proxy which forwards traffic between clients and a target address,
+// and can inject faults into the forwarded streams. All faults apply to both directions
+// and can be changed at any time; changes take effect from the next forwarded chunk.
+type Proxy struct {
+	t      testing.TB
+	lis    net.Listener
+	target string
+
+	mu          sync.Mutex
+	rnd         *rand.Rand
+	latency     time.Duration
+	dropRate    float64
+	dupRate     float64
+	bandwidth   int
+	cutAfter    int64
+	partitioned bool
+	conns       map[net.Conn]struct{}
+
+	wg sync.WaitGroup
+}
+
+// StartProxy starts a proxy listening on a free local port and forwarding to target.
+func StartProxy(t testing.TB, target string) *Proxy {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	p := &Proxy{
+		t:        t,
+		lis:      lis,
+		target:   target,
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		cutAfter: -1,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	p.wg.Add(1)
+	go p.serve()
+	return p
+}
+
+func (p *Proxy) Addr() string {
+	return p.lis.Addr().String()
+}
+
+func (p *Proxy) Host() string {
+	return p.lis.Addr().(*net.TCPAddr).IP.String()
+}
+
+func (p *Proxy) Port() uint64 {
+	return uint64(p.lis.Addr().(*net.TCPAddr).Port)
+}
+
+func (p *Proxy) NewClient() *redis.Client {
+	return redis.NewClient(&redis.Options{Addr: p.Addr()})
+}
+
+// SetSeed makes the random drop and duplicate decisions reproducible.
+func (p *Proxy) SetSeed(seed int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rnd = rand.New(rand.NewSource(seed))
+}
+
+// SetLatency delays every forwarded chunk by d.
+func (p *Proxy) SetLatency(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latency = d
+}
+
+// SetDropRate discards forwarded chunks with the given probability in [0, 1].
+func (p *Proxy) SetDropRate(rate float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dropRate = rate
+}
+
+// SetDuplicateRate forwards chunks twice with the given probability in [0, 1].
+func (p *Proxy) SetDuplicateRate(rate float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dupRate = rate
+}
+
+// SetBandwidth limits every direction of every connection to the given bytes per second,
+// zero means unlimited.
+func (p *Proxy) SetBandwidth(bytesPerSecond int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bandwidth = bytesPerSecond
+}
+
+// CutAfter closes the connection after n more bytes have been forwarded in either direction,
+// which can be used to interrupt a command or a reply in the middle.
+func (p *Proxy) CutAfter(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cutAfter = n
+}
+
+// SetPartitioned makes the proxy silently discard all traffic and refuse new connections,
+// just like the target became unreachable, until it is set back to false.
+func (p *Proxy) SetPartitioned(partitioned bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.partitioned = partitioned
+}
+
+// Reset removes all injected faults.
+func (p *Proxy) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latency, p.dropRate, p.dupRate, p.bandwidth, p.cutAfter, p.partitioned = 0, 0, 0, 0, -1, false
+}
+
+// CutConnections closes all connections established through the proxy.
+func (p *Proxy) CutConnections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for c := range p.conns {
+		_ = c.Close()
+	}
+}
+
+func (p *Proxy) Close() {
+	require.NoError(p.t, p.lis.Close())
+	p.CutConnections()
+	p.wg.Wait()
+}
+
+func (p *Proxy) serve() {
+	defer p.wg.Done()
+	for {
+		client, err := p.lis.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		} else if err != nil {
+			continue
+		}
+
+		p.mu.Lock()
+		partitioned := p.partitioned
+		p.mu.Unlock()
+		if partitioned {
+			_ = client.Close()
+			continue
+		}
+
+		server, err := net.Dial("tcp", p.target)
+		if err != nil {
+			_ = client.Close()
+			continue
+		}
+
+		p.mu.Lock()
+		p.conns[client] = struct{}{}
+		p.conns[server] = struct{}{}
+		p.mu.Unlock()
+
+		p.wg.Add(2)
+		go p.pipe(client, server)
+		go p.pipe(server, client)
+	}
+}
+
+func (p *Proxy) pipe(src, dst net.Conn) {
+	defer p.wg.Done()
+	defer func() {
+		_ = src.Close()
+		_ = dst.Close()
+		p.mu.Lock()
+		delete(p.conns, src)
+		delete(p.conns, dst)
+		p.mu.Unlock()
+	}()
+
+	buf := make([]byte, 16*1024)
+	for {
+		n, err := src.Read(p.limitBuffer(buf))
+		if err != nil {
+			return
+		}
+
+		chunk, times, delay, cut := p.applyFaults(buf[:n])
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+		for i := 0; i < times; i++ {
+			if _, err := dst.Write(chunk); err != nil {
+				return
+			}
+		}
+		if cut {
+			return
+		}
+	}
+}
+
+// limitBuffer shrinks the read buffer so that a single chunk fits the bandwidth of 100ms.
+func (p *Proxy) limitBuffer(buf []byte) []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if limit := p.bandwidth / 10; p.bandwidth > 0 && limit < len(buf) {
+		if limit < 1 {
+			limit = 1
+		}
+		return buf[:limit]
+	}
+	return buf
+}
+
+// applyFaults decides how a chunk should be forwarded: the (possibly truncated) data,
+// how many times it is written, how long to wait before writing and whether to cut
+// the connection afterward.
+func (p *Proxy) applyFaults(chunk []byte) ([]byte, int, time.Duration, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.partitioned || (p.dropRate > 0 && p.rnd.Float64() < p.dropRate) {
+		return nil, 0, p.latency, false
+	}
+
+	times := 1
+	if p.dupRate > 0 && p.rnd.Float64() < p.dupRate {
+		times = 2
+	}
+
+	delay := p.latency
+	if p.bandwidth > 0 {
+		delay += time.Duration(len(chunk)*times) * time.Second / time.Duration(p.bandwidth)
+	}
+
+	cut := false
+	if p.cutAfter >= 0 {
+		if int64(len(chunk)) >= p.cutAfter {
+			chunk, times, cut = chunk[:p.cutAfter], 1, true
+			p.cutAfter = -1
+		} else {
+			p.cutAfter -= int64(len(chunk))
+		}
+	}
+
+	return chunk, times, delay, cut
+}
@@ -114,3 +114,79 @@ func RandomValue() string {
 		},
 	)
 }
+
+// EdgeCaseKeys returns keys which break clients and servers assuming keys are
+// printable and NUL-terminated: empty, whitespace, CRLF, NUL bytes, RESP markers,
+// invalid and multi-byte UTF-8.
+func EdgeCaseKeys() []string {
+	return []string{
+		"",
+		" ",
+		"\x00",
+		"a\x00b",
+		"\x00\x00\x00",
+		"\r\n",
+		"key\r\n$3\r\nfoo\r\n",
+		"\n",
+		"\t",
+		"*1\r\n",
+		"\xff\xfe\xfd",
+		"\xc3\x28",
+		"键",
+		"ключ",
+		"🔑🗝️",
+		"é",
+		strings.Repeat("k", 1024),
+	}
+}
+
+// RandomBinaryKey returns a key of random length in [1, 64] mixing raw bytes,
+// NUL bytes, CRLF and multi-byte UTF-8 characters.
+func RandomBinaryKey() string {
+	var sb strings.Builder
+	for n := 1 + rand.Intn(64); sb.Len() < n; {
+		RandPathNoResult(
+			func() { sb.WriteByte(byte(rand.Intn(256))) },
+			func() { sb.WriteByte(0) },
+			func() { sb.WriteString("\r\n") },
+			func() { sb.WriteString(RandomUnicodeString(1, 2)) },
+		)
+	}
+	return sb.String()
+}
+
+// RandomUnicodeString returns a valid UTF-8 string of [min, max] characters
+// from the 2, 3 and 4 bytes ranges.
+func RandomUnicodeString(min, max int) string {
+	var sb strings.Builder
+	for n := min + rand.Intn(max-min+1); n > 0; n-- {
+		sb.WriteRune(RandPath(
+			func() rune { return rune(0x80 + rand.Intn(0x800-0x80)) },
+			func() rune { return rune(0x4e00 + rand.Intn(0x9fff-0x4e00)) },
+			func() rune { return rune(0x1f300 + rand.Intn(0x1f64f-0x1f300)) },
+		))
+	}
+	return sb.String()
+}
+
+// RandomBinaryValue returns a value of the given size consisting of random bytes.
+func RandomBinaryValue(size int) string {
+	b := make([]byte, size)
+	_, _ = rand.Read(b)
+	return string(b)
+}
+
+// HugeValue returns a value of the given size, e.g. 512MB, which is cheap to
+// generate since a random 4KB block is repeated.
+func HugeValue(size int) string {
+	block := RandomBinaryValue(4096)
+	var sb strings.Builder
+	sb.Grow(size)
+	for sb.Len() < size {
+		if size-sb.Len() < len(block) {
+			block = block[:size-sb.Len()]
+		}
+		sb.WriteString(block)
+	}
+	return sb.String()
+}
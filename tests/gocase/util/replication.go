@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+type ReplicationPair struct {
+	t testing.TB
+
+	Master        *KvrocksServer
+	MasterClient  *redis.Client
+	Replica       *KvrocksServer
+	ReplicaClient *redis.Client
+}
+
+// StartReplicaOf starts a new server as a replica of the master and waits until
+// the full sync is done. The master is owned by the caller, while the replica
+// is stopped by Close.
+func StartReplicaOf(t testing.TB, master *KvrocksServer, configs map[string]string) *ReplicationPair {
+	replica := StartServer(t, configs)
+	p := &ReplicationPair{
+		t:             t,
+		Master:        master,
+		MasterClient:  master.NewClient(),
+		Replica:       replica,
+		ReplicaClient: replica.NewClient(),
+	}
+	p.RestoreLink()
+	return p
+}
+
+// WaitForFullSync waits until the replication link is up and no full sync is in progress.
+func WaitForFullSync(t testing.TB, replica *redis.Client) {
+	require.Eventually(t, func() bool {
+		return FindInfoEntry(replica, "master_link_status") == "up" &&
+			FindInfoEntry(replica, "master_sync_in_progress") == "0"
+	}, time.Minute, 100*time.Millisecond)
+}
+
+// BreakLink turns the replica into a master by SLAVEOF NO ONE.
+func (p *ReplicationPair) BreakLink() {
+	require.NoError(p.t, p.ReplicaClient.SlaveOf(context.Background(), "NO", "ONE").Err())
+	require.Equal(p.t, "master", FindInfoEntry(p.ReplicaClient, "role"))
+}
+
+// RestoreLink points the replica to the master again and waits until it catches up.
+func (p *ReplicationPair) RestoreLink() {
+	SlaveOf(p.t, p.ReplicaClient, p.Master)
+	WaitForFullSync(p.t, p.ReplicaClient)
+	WaitForOffsetSyncWithTimeout(p.t, p.MasterClient, p.ReplicaClient, time.Minute)
+}
+
+func (p *ReplicationPair) Close() {
+	require.NoError(p.t, p.MasterClient.Close())
+	require.NoError(p.t, p.ReplicaClient.Close())
+	p.Replica.Close()
+}
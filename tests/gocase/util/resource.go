@@ -0,0 +1,164 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+	"github.com/stretchr/testify/require"
+)
+
+type ResourceSample struct {
+	Time    time.Time
+	RSS     uint64
+	FDs     int32
+	Threads int32
+}
+
+// ResourceSampler periodically records the resource usage of a server process.
+// It's bound to the process, so it should be restarted after the server restarts.
+type ResourceSampler struct {
+	t    testing.TB
+	proc *process.Process
+
+	mu      sync.Mutex
+	samples []ResourceSample
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// StartResourceSampler takes a sample immediately and then every interval until Stop is called.
+func (s *KvrocksServer) StartResourceSampler(interval time.Duration) *ResourceSampler {
+	s.requireLocal()
+	proc, err := process.NewProcess(int32(s.cmd.Process.Pid))
+	require.NoError(s.t, err)
+
+	r := &ResourceSampler{
+		t:    s.t,
+		proc: proc,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	first, err := r.sample()
+	require.NoError(s.t, err)
+	r.samples = append(r.samples, first)
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				// the process may exit in the middle of a test, e.g. when it's killed
+				if sample, err := r.sample(); err == nil {
+					r.mu.Lock()
+					r.samples = append(r.samples, sample)
+					r.mu.Unlock()
+				}
+			}
+		}
+	}()
+	return r
+}
+
+func (r *ResourceSampler) sample() (ResourceSample, error) {
+	mem, err := r.proc.MemoryInfo()
+	if err != nil {
+		return ResourceSample{}, err
+	}
+	fds, err := r.proc.NumFDs()
+	if err != nil {
+		return ResourceSample{}, err
+	}
+	threads, err := r.proc.NumThreads()
+	if err != nil {
+		return ResourceSample{}, err
+	}
+	return ResourceSample{Time: time.Now(), RSS: mem.RSS, FDs: fds, Threads: threads}, nil
+}
+
+// Samples returns a copy of the samples recorded so far.
+func (r *ResourceSampler) Samples() []ResourceSample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ResourceSample(nil), r.samples...)
+}
+
+// MaxRSS returns the peak RSS of all samples in bytes.
+func (r *ResourceSampler) MaxRSS() uint64 {
+	var rss uint64
+	for _, sample := range r.Samples() {
+		if sample.RSS > rss {
+			rss = sample.RSS
+		}
+	}
+	return rss
+}
+
+// RequireRSSBelow asserts the RSS never exceeded the limit in bytes.
+func (r *ResourceSampler) RequireRSSBelow(limit uint64) {
+	require.Less(r.t, r.MaxRSS(), limit, "peak RSS exceeds the limit")
+}
+
+// RequireNoFdLeak waits until the number of open file descriptors falls back to
+// at most the first sample plus the tolerance, since connections and files may
+// be closed asynchronously.
+func (r *ResourceSampler) RequireNoFdLeak(tolerance int32, timeout time.Duration) {
+	base := r.Samples()[0].FDs
+	r.waitForSample(timeout, func(sample ResourceSample) bool {
+		return sample.FDs <= base+tolerance
+	}, "open file descriptors don't fall back to %d", base+tolerance)
+}
+
+// RequireNoThreadLeak is like RequireNoFdLeak but checks the number of threads.
+func (r *ResourceSampler) RequireNoThreadLeak(tolerance int32, timeout time.Duration) {
+	base := r.Samples()[0].Threads
+	r.waitForSample(timeout, func(sample ResourceSample) bool {
+		return sample.Threads <= base+tolerance
+	}, "threads don't fall back to %d", base+tolerance)
+}
+
+func (r *ResourceSampler) waitForSample(timeout time.Duration, f func(ResourceSample) bool, msgAndArgs ...interface{}) {
+	var last ResourceSample
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		sample, err := r.sample()
+		require.NoError(r.t, err)
+		if f(sample) {
+			return
+		}
+		last = sample
+		time.Sleep(100 * time.Millisecond)
+	}
+	r.t.Logf("last sample: %+v", last)
+	require.Fail(r.t, "resource usage doesn't recover in time", msgAndArgs...)
+}
+
+func (r *ResourceSampler) Stop() {
+	close(r.stop)
+	<-r.done
+}
@@ -0,0 +1,223 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// respQueue is an unbounded queue of RESP values, so that the reader of
+// the connection never blocks on values which nobody consumes.
+type respQueue struct {
+	mu     sync.Mutex
+	values []interface{}
+	err    error
+	// notify is signaled whenever a value is pushed or the queue is closed
+	notify chan struct{}
+}
+
+func newRESPQueue() *respQueue {
+	return &respQueue{notify: make(chan struct{}, 1)}
+}
+
+func (q *respQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *respQueue) push(v interface{}) {
+	q.mu.Lock()
+	q.values = append(q.values, v)
+	q.mu.Unlock()
+	q.signal()
+}
+
+// close makes pop return the error once all queued values are consumed.
+func (q *respQueue) close(err error) {
+	q.mu.Lock()
+	q.err = err
+	q.mu.Unlock()
+	q.signal()
+}
+
+// pop returns the first value of the queue, it returns false if no value
+// arrives before the deadline, or an error if the queue is closed.
+func (q *respQueue) pop(deadline <-chan time.Time) (interface{}, bool, error) {
+	for {
+		q.mu.Lock()
+		if len(q.values) > 0 {
+			v := q.values[0]
+			q.values = q.values[1:]
+			q.mu.Unlock()
+			return v, true, nil
+		}
+		err := q.err
+		q.mu.Unlock()
+		if err != nil {
+			// keep the queue signaled for the following pops
+			q.signal()
+			return nil, false, err
+		}
+
+		select {
+		case <-q.notify:
+		case <-deadline:
+			return nil, false, nil
+		}
+	}
+}
+
+// RESP3Client is a raw connection which negotiates the protocol by HELLO 3,
+// and separates out-of-band push messages from the replies of commands.
+// Both are queued without bounds until they're consumed.
+type RESP3Client struct {
+	t testing.TB
+	c *TCPClient
+
+	// Proto is the protocol version replied by HELLO.
+	Proto int64
+
+	pushes  *respQueue
+	replies *respQueue
+	// subscribed is set once a subscribe command is sent, from then on pubsub
+	// messages of RESP2 are treated as push messages as well.
+	subscribed atomic.Bool
+}
+
+func (s *KvrocksServer) NewRESP3Client() *RESP3Client {
+	c := &RESP3Client{
+		t:       s.t,
+		c:       s.NewTCPClient(),
+		pushes:  newRESPQueue(),
+		replies: newRESPQueue(),
+	}
+	go c.readLoop()
+
+	r := c.Do("HELLO", "3")
+	switch v := r.(type) {
+	case RESPMap:
+		proto, _ := v.Get("proto")
+		c.Proto, _ = proto.(int64)
+	case []interface{}:
+		for i := 0; i+1 < len(v); i += 2 {
+			if v[i] == "proto" {
+				c.Proto, _ = v[i+1].(int64)
+			}
+		}
+	default:
+		require.Fail(s.t, "unexpected HELLO reply", "%v", r)
+	}
+	return c
+}
+
+func (c *RESP3Client) readLoop() {
+	for {
+		v, err := c.c.ReadRESPValue()
+		if err != nil {
+			c.pushes.close(err)
+			c.replies.close(err)
+			return
+		}
+		if push, ok := c.asPush(v); ok {
+			c.pushes.push(push)
+		} else {
+			c.replies.push(v)
+		}
+	}
+}
+
+func (c *RESP3Client) asPush(v interface{}) (RESPPush, bool) {
+	if push, ok := v.(RESPPush); ok {
+		return push, true
+	}
+	if values, ok := v.([]interface{}); ok && len(values) > 0 && c.subscribed.Load() {
+		if kind, ok := values[0].(string); ok && isPubSubKind(kind) {
+			return values, true
+		}
+	}
+	return nil, false
+}
+
+func isPubSubKind(kind string) bool {
+	switch strings.ToLower(kind) {
+	case "message", "pmessage", "subscribe", "psubscribe", "unsubscribe", "punsubscribe":
+		return true
+	}
+	return false
+}
+
+// Send writes the command without waiting for the reply, e.g. for SUBSCRIBE
+// whose confirmations are delivered as push messages.
+func (c *RESP3Client) Send(args ...string) {
+	if len(args) > 0 && strings.HasSuffix(strings.ToLower(args[0]), "subscribe") {
+		c.subscribed.Store(true)
+	}
+	require.NoError(c.t, c.c.WriteArgs(args...))
+}
+
+// Do sends the command and returns its reply, push messages received meanwhile
+// are queued for WaitForPush. Error replies are returned as RESPError.
+func (c *RESP3Client) Do(args ...string) interface{} {
+	c.Send(args...)
+	r, ok, err := c.replies.pop(time.After(10 * time.Second))
+	if err != nil {
+		require.Fail(c.t, "failed to read the reply", "command: %v, error: %v", args, err)
+	} else if !ok {
+		require.Fail(c.t, "timeout waiting for the reply", "command: %v", args)
+	}
+	return r
+}
+
+// WaitForPush returns the next push message whose kind, i.e. the first element,
+// equals to the given kind case-insensitively. Other push messages are discarded.
+func (c *RESP3Client) WaitForPush(kind string, timeout time.Duration) RESPPush {
+	deadline := time.After(timeout)
+	for {
+		v, ok, err := c.pushes.pop(deadline)
+		require.NoError(c.t, err, "connection is closed while waiting for push message %s", kind)
+		if !ok {
+			require.Fail(c.t, "timeout waiting for push message", "kind: %s", kind)
+			return nil
+		}
+		if push := v.(RESPPush); len(push) > 0 && strings.EqualFold(fmt.Sprint(push[0]), kind) {
+			return push
+		}
+	}
+}
+
+// RequireNoPush asserts that no push message arrives in the given duration.
+func (c *RESP3Client) RequireNoPush(d time.Duration) {
+	if v, ok, _ := c.pushes.pop(time.After(d)); ok {
+		require.Fail(c.t, "unexpected push message", "%v", v)
+	}
+}
+
+func (c *RESP3Client) Close() error {
+	return c.c.Close()
+}
@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// RocksDBOptions are the RocksDB tunables of the server. Zero values are left as default,
+// and sizes are in the same units as in kvrocks.conf, e.g. MB for the write buffer.
+type RocksDBOptions struct {
+	WriteBufferSize        int
+	MaxWriteBufferNumber   int
+	TargetFileSizeBase     int
+	BlockSize              int
+	BlockCacheSize         int
+	MaxOpenFiles           int
+	MaxBackgroundJobs      int
+	DelayedWriteRate       int64
+	Compression            string
+	DisableAutoCompactions bool
+	// MaxIOMB is `max-io-mb`, which limits the IO rate of flushes and compactions.
+	MaxIOMB int
+	// Extra holds any other option by its name without the `rocksdb.` prefix.
+	Extra map[string]string
+}
+
+// Configs returns the options as the configs of the server.
+func (o *RocksDBOptions) Configs() map[string]string {
+	configs := make(map[string]string)
+	setInt := func(name string, v int64) {
+		if v != 0 {
+			configs[name] = strconv.FormatInt(v, 10)
+		}
+	}
+	setInt("rocksdb.write_buffer_size", int64(o.WriteBufferSize))
+	setInt("rocksdb.max_write_buffer_number", int64(o.MaxWriteBufferNumber))
+	setInt("rocksdb.target_file_size_base", int64(o.TargetFileSizeBase))
+	setInt("rocksdb.block_size", int64(o.BlockSize))
+	setInt("rocksdb.block_cache_size", int64(o.BlockCacheSize))
+	setInt("rocksdb.max_open_files", int64(o.MaxOpenFiles))
+	setInt("rocksdb.max_background_jobs", int64(o.MaxBackgroundJobs))
+	setInt("rocksdb.delayed_write_rate", o.DelayedWriteRate)
+	setInt("max-io-mb", int64(o.MaxIOMB))
+	if o.Compression != "" {
+		configs["rocksdb.compression"] = o.Compression
+	}
+	if o.DisableAutoCompactions {
+		configs["rocksdb.disable_auto_compactions"] = "yes"
+	}
+	for k, v := range o.Extra {
+		configs["rocksdb."+k] = v
+	}
+	return configs
+}
+
+// Apply adds the options into the configs and returns them, e.g.
+// StartServer(t, opts.Apply(map[string]string{"workers": "4"})).
+func (o *RocksDBOptions) Apply(configs map[string]string) map[string]string {
+	for k, v := range o.Configs() {
+		configs[k] = v
+	}
+	return configs
+}
+
+// RequireRocksDBOptions requires the effective values of the options reported by CONFIG GET
+// to be the same as the given ones.
+func RequireRocksDBOptions(t testing.TB, rdb *redis.Client, opts *RocksDBOptions) {
+	ctx := context.Background()
+	for k, v := range opts.Configs() {
+		r, err := rdb.ConfigGet(ctx, k).Result()
+		require.NoError(t, err)
+		require.Equal(t, v, r[k], "the effective value of %s", k)
+	}
+}
+
+// RocksDBStats returns the per column family values of the field in the rocksdb section
+// of INFO, e.g. RocksDBStats(t, rdb, "estimate_keys")["metadata"].
+func RocksDBStats(t testing.TB, rdb *redis.Client, field string) map[string]int64 {
+	info := ParseInfo(t, rdb, "rocksdb")
+	stats := make(map[string]int64)
+	for k, v := range info.Sections["rocksdb"] {
+		if !strings.HasPrefix(k, field+"[") || !strings.HasSuffix(k, "]") {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		require.NoError(t, err, "malformed value of %s", k)
+		stats[k[len(field)+1:len(k)-1]] = n
+	}
+	require.NotEmpty(t, stats, "field %s not found in INFO rocksdb", field)
+	return stats
+}
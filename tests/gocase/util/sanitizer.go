@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sanitizerLogPrefix is the prefix of sanitizer reports in the server directory,
+// the sanitizer runtime appends the pid of the process to it.
+const sanitizerLogPrefix = "sanitizer"
+
+// sanitizerEnv returns the environment of a server process built with the sanitizer
+// specified by `-sanitizer`, which writes the reports into the server directory
+// instead of stderr, so that they can be harvested after the server exits.
+func sanitizerEnv(t testing.TB, dir string) []string {
+	var name string
+	switch *sanitizer {
+	case "":
+		return nil
+	case "asan":
+		name = "ASAN_OPTIONS"
+	case "tsan":
+		name = "TSAN_OPTIONS"
+	default:
+		require.Failf(t, "unknown sanitizer", "`-sanitizer` should be asan or tsan, got %s", *sanitizer)
+	}
+
+	options := fmt.Sprintf("log_path=%s", filepath.Join(dir, sanitizerLogPrefix))
+	if existing := os.Getenv(name); existing != "" {
+		options = existing + ":" + options
+	}
+	return append(os.Environ(), name+"="+options)
+}
+
+// sanitizerReports returns the content of all non-empty sanitizer reports in the directory.
+func sanitizerReports(t testing.TB, dir string) []string {
+	if *sanitizer == "" {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, sanitizerLogPrefix+".*"))
+	require.NoError(t, err)
+
+	var reports []string
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		if len(strings.TrimSpace(string(content))) > 0 {
+			reports = append(reports, fmt.Sprintf("%s:\n%s", path, content))
+		}
+	}
+	return reports
+}
+
+// requireNoSanitizerReports fails the test with the reports attached if the sanitizer
+// found any issue, and keeps the server directory for further investigation.
+func (s *KvrocksServer) requireNoSanitizerReports() {
+	if reports := sanitizerReports(s.t, s.configs["dir"]); len(reports) > 0 {
+		s.RetainDir()
+		require.Fail(s.t, "the sanitizer reported issues", strings.Join(reports, "\n"))
+	}
+}
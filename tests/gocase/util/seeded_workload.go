@@ -0,0 +1,341 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	seededWorkloadPrefix = "seeded:"
+	seededWorkloadKeys   = 50
+	// TTL replies may be smaller than the model predicts since time passes while running
+	seededWorkloadTTLSlack = 10
+	// the number of executed commands printed on a mismatch
+	seededWorkloadHistory = 20
+)
+
+// WorkloadMix maps the command names to their relative weights in a seeded workload.
+type WorkloadMix map[string]int
+
+// DefaultWorkloadMix contains all commands supported by the seeded workload.
+func DefaultWorkloadMix() WorkloadMix {
+	return WorkloadMix{
+		"SET": 4, "GET": 4, "DEL": 1, "INCR": 2, "APPEND": 1,
+		"LPUSH": 2, "LPOP": 1, "HSET": 2, "HGET": 2, "SADD": 2, "SISMEMBER": 2,
+		"EXPIRE": 1, "PERSIST": 1, "TTL": 1,
+	}
+}
+
+// WorkloadCommand is a generated command along with the reply predicted by the shadow model.
+// A nil Expected means a nil reply.
+type WorkloadCommand struct {
+	Args     []interface{}
+	Expected interface{}
+}
+
+func (c WorkloadCommand) String() string {
+	var args []string
+	for _, arg := range c.Args {
+		args = append(args, fmt.Sprint(arg))
+	}
+	return strings.Join(args, " ")
+}
+
+type modelValue struct {
+	typ  string
+	str  string
+	list []string
+	hash map[string]string
+	set  map[string]struct{}
+	// ttl is the TTL in seconds set on the key, 0 means no TTL
+	ttl int64
+}
+
+// Workload generates a reproducible stream of commands from the seed, and tracks
+// their effects in a shadow model to predict the replies. TTLs are long enough
+// that keys never expire during a run.
+type Workload struct {
+	Seed     int64
+	rnd      *rand.Rand
+	commands []string
+	model    map[string]*modelValue
+	executed int
+	history  []WorkloadCommand
+}
+
+func NewWorkload(seed int64, mix WorkloadMix) *Workload {
+	var commands []string
+	for cmd, weight := range mix {
+		for i := 0; i < weight; i++ {
+			commands = append(commands, strings.ToUpper(cmd))
+		}
+	}
+	// the order of map iteration is random, so sort them to make the stream reproducible
+	sort.Strings(commands)
+
+	return &Workload{
+		Seed:     seed,
+		rnd:      rand.New(rand.NewSource(seed)),
+		commands: commands,
+		model:    make(map[string]*modelValue),
+	}
+}
+
+func (w *Workload) key(typ string) string {
+	return fmt.Sprintf("%s%s:%d", seededWorkloadPrefix, typ, w.rnd.Intn(seededWorkloadKeys))
+}
+
+func (w *Workload) anyKey() string {
+	return w.key([]string{"string", "counter", "list", "hash", "set"}[w.rnd.Intn(5)])
+}
+
+func (w *Workload) member() string {
+	return fmt.Sprintf("m%d", w.rnd.Intn(20))
+}
+
+// Next generates the next command and applies it to the shadow model.
+func (w *Workload) Next() WorkloadCommand {
+	if len(w.commands) == 0 {
+		panic("the workload mix is empty")
+	}
+
+	switch cmd := w.commands[w.rnd.Intn(len(w.commands))]; cmd {
+	case "SET":
+		key, value := w.key("string"), randBytes(w.rnd, 1+w.rnd.Intn(32))
+		v := &modelValue{typ: "string", str: value}
+		w.model[key] = v
+		if w.rnd.Intn(4) == 0 {
+			v.ttl = 1000 + w.rnd.Int63n(1000)
+			return WorkloadCommand{Args: []interface{}{"SET", key, value, "EX", v.ttl}, Expected: "OK"}
+		}
+		return WorkloadCommand{Args: []interface{}{"SET", key, value}, Expected: "OK"}
+	case "GET":
+		key := w.key("string")
+		if v, ok := w.model[key]; ok {
+			return WorkloadCommand{Args: []interface{}{"GET", key}, Expected: v.str}
+		}
+		return WorkloadCommand{Args: []interface{}{"GET", key}}
+	case "DEL":
+		key := w.anyKey()
+		_, ok := w.model[key]
+		delete(w.model, key)
+		return WorkloadCommand{Args: []interface{}{"DEL", key}, Expected: boolToInt(ok)}
+	case "INCR":
+		key := w.key("counter")
+		v, ok := w.model[key]
+		if !ok {
+			v = &modelValue{typ: "string", str: "0"}
+			w.model[key] = v
+		}
+		n, _ := strconv.ParseInt(v.str, 10, 64)
+		v.str = strconv.FormatInt(n+1, 10)
+		return WorkloadCommand{Args: []interface{}{"INCR", key}, Expected: n + 1}
+	case "APPEND":
+		key, value := w.key("string"), randBytes(w.rnd, 1+w.rnd.Intn(8))
+		v, ok := w.model[key]
+		if !ok {
+			v = &modelValue{typ: "string"}
+			w.model[key] = v
+		}
+		v.str += value
+		return WorkloadCommand{Args: []interface{}{"APPEND", key, value}, Expected: int64(len(v.str))}
+	case "LPUSH":
+		key, value := w.key("list"), w.member()
+		v, ok := w.model[key]
+		if !ok {
+			v = &modelValue{typ: "list"}
+			w.model[key] = v
+		}
+		v.list = append([]string{value}, v.list...)
+		return WorkloadCommand{Args: []interface{}{"LPUSH", key, value}, Expected: int64(len(v.list))}
+	case "LPOP":
+		key := w.key("list")
+		v, ok := w.model[key]
+		if !ok {
+			return WorkloadCommand{Args: []interface{}{"LPOP", key}}
+		}
+		head := v.list[0]
+		if v.list = v.list[1:]; len(v.list) == 0 {
+			delete(w.model, key)
+		}
+		return WorkloadCommand{Args: []interface{}{"LPOP", key}, Expected: head}
+	case "HSET":
+		key, field, value := w.key("hash"), w.member(), randBytes(w.rnd, 8)
+		v, ok := w.model[key]
+		if !ok {
+			v = &modelValue{typ: "hash", hash: make(map[string]string)}
+			w.model[key] = v
+		}
+		_, exists := v.hash[field]
+		v.hash[field] = value
+		return WorkloadCommand{Args: []interface{}{"HSET", key, field, value}, Expected: boolToInt(!exists)}
+	case "HGET":
+		key, field := w.key("hash"), w.member()
+		if v, ok := w.model[key]; ok {
+			if value, ok := v.hash[field]; ok {
+				return WorkloadCommand{Args: []interface{}{"HGET", key, field}, Expected: value}
+			}
+		}
+		return WorkloadCommand{Args: []interface{}{"HGET", key, field}}
+	case "SADD":
+		key, member := w.key("set"), w.member()
+		v, ok := w.model[key]
+		if !ok {
+			v = &modelValue{typ: "set", set: make(map[string]struct{})}
+			w.model[key] = v
+		}
+		_, exists := v.set[member]
+		v.set[member] = struct{}{}
+		return WorkloadCommand{Args: []interface{}{"SADD", key, member}, Expected: boolToInt(!exists)}
+	case "SISMEMBER":
+		key, member := w.key("set"), w.member()
+		exists := false
+		if v, ok := w.model[key]; ok {
+			_, exists = v.set[member]
+		}
+		return WorkloadCommand{Args: []interface{}{"SISMEMBER", key, member}, Expected: boolToInt(exists)}
+	case "EXPIRE":
+		key, ttl := w.anyKey(), 1000+w.rnd.Int63n(1000)
+		v, ok := w.model[key]
+		if ok {
+			v.ttl = ttl
+		}
+		return WorkloadCommand{Args: []interface{}{"EXPIRE", key, ttl}, Expected: boolToInt(ok)}
+	case "PERSIST":
+		key := w.anyKey()
+		v, ok := w.model[key]
+		hadTTL := ok && v.ttl > 0
+		if ok {
+			v.ttl = 0
+		}
+		return WorkloadCommand{Args: []interface{}{"PERSIST", key}, Expected: boolToInt(hadTTL)}
+	case "TTL":
+		key := w.anyKey()
+		v, ok := w.model[key]
+		switch {
+		case !ok:
+			return WorkloadCommand{Args: []interface{}{"TTL", key}, Expected: int64(-2)}
+		case v.ttl == 0:
+			return WorkloadCommand{Args: []interface{}{"TTL", key}, Expected: int64(-1)}
+		default:
+			return WorkloadCommand{Args: []interface{}{"TTL", key}, Expected: v.ttl}
+		}
+	default:
+		panic("unsupported command in the workload mix: " + cmd)
+	}
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Run executes the next n commands one by one and requires every reply to match the
+// shadow model. On a mismatch, the seed and the recently executed commands are reported,
+// so that the failure can be replayed with the same seed and mix.
+func (w *Workload) Run(t testing.TB, rdb *redis.Client, n int) {
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		cmd := w.Next()
+		w.executed++
+		w.history = append(w.history, cmd)
+		if len(w.history) > seededWorkloadHistory {
+			w.history = w.history[1:]
+		}
+
+		actual, err := rdb.Do(ctx, cmd.Args...).Result()
+		if errors.Is(err, redis.Nil) {
+			actual, err = nil, nil
+		}
+		require.NoError(t, err, "command #%d of the workload with seed %d: %s", w.executed, w.Seed, cmd)
+		if !workloadReplyMatches(cmd, actual) {
+			require.Fail(t, "reply mismatches the shadow model",
+				"seed: %d, command #%d: %s, expected: %v, actual: %v, recent commands:\n%s",
+				w.Seed, w.executed, cmd, cmd.Expected, actual, w.recentCommands())
+		}
+	}
+}
+
+func workloadReplyMatches(cmd WorkloadCommand, actual interface{}) bool {
+	if cmd.Args[0] == "TTL" {
+		expected, ok := cmd.Expected.(int64)
+		ttl, isInt := actual.(int64)
+		if ok && isInt && expected > 0 {
+			return ttl <= expected && ttl > expected-seededWorkloadTTLSlack
+		}
+	}
+	return cmd.Expected == actual
+}
+
+func (w *Workload) recentCommands() string {
+	var lines []string
+	for i, cmd := range w.history {
+		lines = append(lines, fmt.Sprintf("#%d %s", w.executed-len(w.history)+i+1, cmd))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Verify requires the key space written by the workload to be the same as the shadow model.
+func (w *Workload) Verify(t testing.TB, rdb *redis.Client) {
+	ctx := context.Background()
+	keys, err := rdb.Keys(ctx, seededWorkloadPrefix+"*").Result()
+	require.NoError(t, err)
+	require.Len(t, keys, len(w.model), "the number of keys mismatches the shadow model, seed: %d", w.Seed)
+
+	var mismatched []string
+	for key, v := range w.model {
+		if KeyChecksum(t, rdb, key) != checksum(v.content()) {
+			mismatched = append(mismatched, key)
+		}
+	}
+	sort.Strings(mismatched)
+	require.Empty(t, mismatched, "keys mismatch with the shadow model, seed: %d", w.Seed)
+}
+
+// content returns the canonical content of the value in the same form as keyContent.
+func (v *modelValue) content() []string {
+	switch v.typ {
+	case "string":
+		return []string{v.str}
+	case "list":
+		return v.list
+	case "hash":
+		return canonicalMap(v.hash)
+	default:
+		m := make(map[string]string, len(v.set))
+		for member := range v.set {
+			m[member] = ""
+		}
+		return canonicalMap(m)
+	}
+}
@@ -22,12 +22,14 @@ package util
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"sync"
 	"syscall"
 	"testing"
@@ -42,13 +44,31 @@ import (
 type KvrocksServer struct {
 	t   testing.TB
 	cmd *exec.Cmd
+	// bin is the path of the binary, which is used to start the server again on restart
+	bin string
 
-	addr    *net.TCPAddr
-	tlsAddr *net.TCPAddr
+	addr        *net.TCPAddr
+	tlsAddr     *net.TCPAddr
+	metricsAddr *net.TCPAddr
+	// unixSocket is the path of the unix socket if the server listens on one
+	unixSocket string
 
 	configs map[string]string
 
 	clean func(bool)
+
+	// external is set if the server is not started by the tests, see IsExternalServer
+	external bool
+
+	// sharedKey is the key of the pool which the server is put back to on Close,
+	// it's empty if the server is not shared.
+	sharedKey string
+
+	// retainDir keeps the directory of the server on Close even with `-deleteOnExit`
+	retainDir bool
+
+	// expireClockOffset is the offset set by AdvanceExpireClock, the server forgets it on restart
+	expireClockOffset time.Duration
 }
 
 func (s *KvrocksServer) HostPort() string {
@@ -71,14 +91,89 @@ func (s *KvrocksServer) TLSAddr() string {
 	return s.tlsAddr.String()
 }
 
+func (s *KvrocksServer) UnixSocket() string {
+	return s.unixSocket
+}
+
+// Dir returns the directory holding the data, logs and config file of the server.
+func (s *KvrocksServer) Dir() string {
+	s.requireLocal()
+	return s.configs["dir"]
+}
+
+// RetainDir keeps the directory of the server after Close even if the workspace
+// is deleted on exit, so that the artifacts of a failure can be inspected.
+func (s *KvrocksServer) RetainDir() {
+	s.retainDir = true
+}
+
+func (s *KvrocksServer) LogFilePath() string {
+	s.requireLocal()
+	dir := s.configs["log-dir"]
+	if dir == "" {
+		dir = s.configs["dir"]
+	}
+	if s.configs["log-format"] == "json" {
+		return filepath.Join(dir, "kvrocks.json.log")
+	}
+	return filepath.Join(dir, "kvrocks.INFO")
+}
+
 func (s *KvrocksServer) LogFileMatches(t testing.TB, pattern string) bool {
-	dir := s.configs["dir"]
-	content, err := os.ReadFile(dir + "/kvrocks.INFO")
+	content, err := os.ReadFile(s.LogFilePath())
 	require.NoError(t, err)
 	p := regexp.MustCompile(pattern)
 	return p.Match(content)
 }
 
+// LogOffset returns the current size of the log file, which marks the position
+// that WaitForLogPatternSince starts matching from.
+func (s *KvrocksServer) LogOffset(t testing.TB) int64 {
+	info, err := os.Stat(s.LogFilePath())
+	if os.IsNotExist(err) {
+		return 0
+	}
+	require.NoError(t, err)
+	return info.Size()
+}
+
+func (s *KvrocksServer) logSince(t testing.TB, offset int64) []byte {
+	content, err := os.ReadFile(s.LogFilePath())
+	require.NoError(t, err)
+	// the log file has been rotated since the offset was taken
+	if int64(len(content)) < offset {
+		return content
+	}
+	return content[offset:]
+}
+
+// WaitForLogPattern polls the log file until a line written after the call matches
+// the pattern, and fails the test if no match shows up within the timeout.
+func (s *KvrocksServer) WaitForLogPattern(t testing.TB, pattern string, timeout time.Duration) {
+	s.WaitForLogPatternSince(t, s.LogOffset(t), pattern, timeout)
+}
+
+// WaitForLogPatternSince is like WaitForLogPattern but matches the lines written
+// after the offset, which is taken by LogOffset before triggering the expected log.
+func (s *KvrocksServer) WaitForLogPatternSince(t testing.TB, offset int64, pattern string, timeout time.Duration) {
+	p := regexp.MustCompile(pattern)
+	require.Eventually(t, func() bool {
+		return p.Match(s.logSince(t, offset))
+	}, timeout, 100*time.Millisecond, "pattern %q is not found in the log file %s", pattern, s.LogFilePath())
+}
+
+// FailIfLogMatches fails the test with the first matching line if the log file matches the pattern.
+func (s *KvrocksServer) FailIfLogMatches(t testing.TB, pattern string) {
+	content, err := os.ReadFile(s.LogFilePath())
+	require.NoError(t, err)
+	p := regexp.MustCompile(pattern)
+	for _, line := range strings.Split(string(content), "\n") {
+		if p.MatchString(line) {
+			require.Fail(t, "unexpected log line", "pattern %q matches: %s", pattern, line)
+		}
+	}
+}
+
 func (s *KvrocksServer) NewClient() *redis.Client {
 	return s.NewClientWithOption(&redis.Options{})
 }
@@ -87,10 +182,76 @@ func (s *KvrocksServer) NewClientWithOption(options *redis.Options) *redis.Clien
 	if options.Addr == "" {
 		options.Addr = s.addr.String()
 	}
-	return redis.NewClient(options)
+	if s.external {
+		if options.Password == "" {
+			options.Password = os.Getenv(ExternalPasswordEnv)
+		}
+		if options.TLSConfig == nil {
+			tlsConfig, err := externalTLSConfig()
+			require.NoError(s.t, err)
+			options.TLSConfig = tlsConfig
+		}
+	}
+	rdb := redis.NewClient(options)
+	if *commandCoverage != "" {
+		rdb.AddHook(coverageHook{})
+	}
+	return rdb
+}
+
+// NewClientWithAuth returns a client authenticated with the token, which is either
+// the `requirepass` of the server or the token of a namespace.
+func (s *KvrocksServer) NewClientWithAuth(token string) *redis.Client {
+	return s.NewClientWithOption(&redis.Options{Password: token})
+}
+
+// NewAdminClient returns a client authenticated with the `requirepass` of the server.
+func (s *KvrocksServer) NewAdminClient() *redis.Client {
+	return s.NewClientWithAuth(s.configs["requirepass"])
+}
+
+// NewNamespaceClient adds the namespace if it doesn't exist, and returns a client
+// authenticated with its token. The server must be started with `requirepass`.
+func (s *KvrocksServer) NewNamespaceClient(namespace string) *redis.Client {
+	if !s.external {
+		require.NotEmpty(s.t, s.configs["requirepass"], "namespaces require the server to be started with requirepass")
+	}
+
+	ctx := context.Background()
+	admin := s.NewAdminClient()
+	defer func() { require.NoError(s.t, admin.Close()) }()
+
+	token, err := admin.Do(ctx, "NAMESPACE", "GET", namespace).Text()
+	if errors.Is(err, redis.Nil) {
+		token = namespace + "-token"
+		require.NoError(s.t, admin.Do(ctx, "NAMESPACE", "ADD", namespace, token).Err())
+	} else {
+		require.NoError(s.t, err)
+	}
+	return s.NewClientWithAuth(token)
+}
+
+func (s *KvrocksServer) NewTLSClient() *redis.Client {
+	tlsConfig, err := DefaultTLSConfig()
+	require.NoError(s.t, err)
+	return s.NewClientWithOption(&redis.Options{TLSConfig: tlsConfig, Addr: s.TLSAddr()})
+}
+
+func (s *KvrocksServer) NewClientWithUnixSocket() *redis.Client {
+	require.NotEmpty(s.t, s.unixSocket, "the server doesn't listen on a unix socket")
+	return s.NewClientWithOption(&redis.Options{Network: "unix", Addr: s.unixSocket})
 }
 
 func (s *KvrocksServer) NewTCPClient() *TCPClient {
+	if s.external {
+		tlsConfig, err := externalTLSConfig()
+		require.NoError(s.t, err)
+		if tlsConfig != nil {
+			c, err := tls.Dial(s.addr.Network(), s.addr.String(), tlsConfig)
+			require.NoError(s.t, err)
+			return newTCPClient(c)
+		}
+	}
 	c, err := net.Dial(s.addr.Network(), s.addr.String())
 	require.NoError(s.t, err)
 	return newTCPClient(c)
@@ -103,10 +264,41 @@ func (s *KvrocksServer) NewTCPTLSClient(conf *tls.Config) *TCPClient {
 }
 
 func (s *KvrocksServer) Close() {
-	s.close(false)
+	if s.sharedKey != "" {
+		releaseSharedServer(s)
+		return
+	}
+	if s.external {
+		return
+	}
+	s.close(s.keepArtifacts())
+	releasePort(s.addr)
+	releasePort(s.tlsAddr)
+	releasePort(s.metricsAddr)
+	if s.unixSocket != "" {
+		require.NoError(s.t, os.RemoveAll(s.unixSocket))
+	}
+}
+
+// keepArtifacts reports whether the directory of the server is kept for the post-mortem
+// of the failed test with `-keepArtifacts`, and logs where the data, config and logs are.
+func (s *KvrocksServer) keepArtifacts() bool {
+	if !*keepArtifacts || !s.t.Failed() {
+		return false
+	}
+	s.t.Logf("the server %s of the failed test is kept, data and config: %s, log: %s",
+		s.HostPort(), s.Dir(), s.LogFilePath())
+	return true
 }
 
 func (s *KvrocksServer) close(keepDir bool) {
+	// the process has already exited if it was killed
+	if s.cmd.ProcessState != nil {
+		s.requireNoSanitizerReports()
+		s.clean(keepDir || s.retainDir)
+		return
+	}
+
 	require.NoError(s.t, s.cmd.Process.Signal(syscall.SIGTERM))
 	f := func(err error) { require.NoError(s.t, err) }
 
@@ -131,15 +323,105 @@ func (s *KvrocksServer) close(keepDir bool) {
 		wg.Wait()
 	}()
 	f(s.cmd.Wait())
-	s.clean(keepDir)
+	s.requireNoSanitizerReports()
+	s.clean(keepDir || s.retainDir)
 }
 
 func (s *KvrocksServer) Restart() {
+	s.RestartWithConfig(map[string]string{})
+}
+
+// RestartWithConfig stops the server and starts it again with the same data directory,
+// port and config file, in which the given directives are overridden before starting.
+func (s *KvrocksServer) RestartWithConfig(changes map[string]string) {
+	s.requireLocal()
 	s.close(true)
 
-	b := *binPath
-	require.NotEmpty(s.t, b, "please set the binary path by `-binPath`")
-	cmd := exec.Command(b)
+	if len(changes) > 0 {
+		require.NoError(s.t, updateConfigFile(s.ConfigFilePath(), changes))
+		for k, v := range changes {
+			s.configs[k] = v
+		}
+	}
+
+	s.start()
+}
+
+// Upgrade restarts a server started by StartOldServer with the binary under test,
+// keeping its data directory, port and config file.
+func (s *KvrocksServer) Upgrade() {
+	s.requireLocal()
+	s.close(true)
+	s.bin = *binPath
+	s.start()
+}
+
+// Kill sends SIGKILL to the server to simulate a crash, so that it has no chance
+// to flush memtables or to shut down gracefully. The data directory is kept,
+// and the server can be started on it again by Recover.
+func (s *KvrocksServer) Kill() {
+	s.requireLocal()
+	require.NoError(s.t, s.cmd.Process.Kill())
+	require.EqualError(s.t, s.cmd.Wait(), "signal: killed")
+}
+
+// Pause sends SIGSTOP to the server to freeze it, e.g. to make a replica fall behind
+// its master, and Resume lets it continue.
+func (s *KvrocksServer) Pause() {
+	s.requireLocal()
+	require.NoError(s.t, s.cmd.Process.Signal(syscall.SIGSTOP))
+}
+
+func (s *KvrocksServer) Resume() {
+	s.requireLocal()
+	require.NoError(s.t, s.cmd.Process.Signal(syscall.SIGCONT))
+}
+
+// GracefulStop sends SIGTERM to the server and requires it to exit with the code 0
+// within the timeout, and returns how long the shutdown took. The data directory is
+// kept, and the server can be started on it again by Recover.
+func (s *KvrocksServer) GracefulStop(timeout time.Duration) time.Duration {
+	s.requireLocal()
+	require.Nil(s.t, s.cmd.ProcessState, "the server has already exited")
+
+	start := time.Now()
+	require.NoError(s.t, s.cmd.Process.Signal(syscall.SIGTERM))
+
+	done := make(chan error, 1)
+	go func() { done <- s.cmd.Wait() }()
+	select {
+	case err := <-done:
+		elapsed := time.Since(start)
+		require.NoError(s.t, err, "the server didn't exit cleanly, exit code: %d", s.ExitCode())
+		return elapsed
+	case <-time.After(timeout):
+		require.NoError(s.t, s.cmd.Process.Kill())
+		<-done
+		require.FailNow(s.t, "the server didn't exit in time", "timeout: %v", timeout)
+		return 0
+	}
+}
+
+// ExitCode returns the exit code of the server after it exited, or -1 if it's
+// still running or was terminated by a signal.
+func (s *KvrocksServer) ExitCode() int {
+	s.requireLocal()
+	if s.cmd.ProcessState == nil {
+		return -1
+	}
+	return s.cmd.ProcessState.ExitCode()
+}
+
+// Recover starts the killed or stopped server again on its data directory and port,
+// and waits until it accepts commands, i.e. it recovered from the WAL successfully.
+func (s *KvrocksServer) Recover() {
+	require.NotNil(s.t, s.cmd.ProcessState, "the server must be killed or stopped before recovering")
+	s.Restart()
+}
+
+func (s *KvrocksServer) start() {
+	cmd := exec.Command(s.bin)
+	s.expireClockOffset = 0
 
 	dir := s.configs["dir"]
 	f, err := os.Open(filepath.Join(dir, "kvrocks.conf"))
@@ -147,6 +429,7 @@ func (s *KvrocksServer) Restart() {
 	defer func() { require.NoError(s.t, f.Close()) }()
 
 	cmd.Args = append(cmd.Args, "-c", f.Name())
+	cmd.Env = sanitizerEnv(s.t, dir)
 
 	stdout, err := os.Create(filepath.Join(dir, "stdout"))
 	require.NoError(s.t, err)
@@ -175,9 +458,10 @@ func (s *KvrocksServer) Restart() {
 }
 
 func StartTLSServer(t testing.TB, configs map[string]string) *KvrocksServer {
-	dir := *workspace
-	require.NotEmpty(t, dir, "please set the workspace by `-workspace`")
-	dir = filepath.Join(dir, "..", "tls", "cert")
+	SkipIfExternal(t)
+	require.NotEmpty(t, *workspace, "please set the workspace by `-workspace`")
+	dir := tlsCertDir()
+	require.NoError(t, GenerateTLSCerts(dir))
 
 	configs["tls-cert-file"] = filepath.Join(dir, "server.crt")
 	configs["tls-key-file"] = filepath.Join(dir, "server.key")
@@ -193,6 +477,38 @@ func StartTLSServer(t testing.TB, configs map[string]string) *KvrocksServer {
 	return s
 }
 
+// StartMetricsServer starts a server which serves the Prometheus metrics on a free port,
+// see ScrapeMetrics.
+func StartMetricsServer(t testing.TB, configs map[string]string) *KvrocksServer {
+	SkipIfExternal(t)
+	addr, err := findFreePort()
+	require.NoError(t, err)
+	configs["metrics-port"] = fmt.Sprintf("%d", addr.Port)
+
+	s := StartServer(t, configs)
+	s.metricsAddr = addr
+
+	return s
+}
+
+// StartUnixSocketServer starts a server which listens on a unix socket besides the TCP port.
+// If `unixsocket` is not configured, a socket in the temp directory is used, since
+// the path of a unix socket is limited to about 100 bytes.
+func StartUnixSocketServer(t testing.TB, configs map[string]string) *KvrocksServer {
+	if configs["unixsocket"] == "" {
+		f, err := os.CreateTemp("", "kvrocks-*.sock")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		require.NoError(t, os.Remove(f.Name()))
+		configs["unixsocket"] = f.Name()
+	}
+
+	s := StartServer(t, configs)
+	s.unixSocket = configs["unixsocket"]
+
+	return s
+}
+
 func StartServer(t testing.TB, configs map[string]string) *KvrocksServer {
 	return StartServerWithCLIOptions(t, true, configs, []string{})
 }
@@ -203,10 +519,37 @@ func StartServerWithCLIOptions(
 	configs map[string]string,
 	options []string,
 ) *KvrocksServer {
+	if IsExternalServer() {
+		return startExternalServer(t, configs, options)
+	}
+
+	require.NotEmpty(t, *binPath, "please set the binary path by `-binPath`")
+	return startServerWithBinary(t, *binPath, withConfigFile, nil, configs, options)
+}
 
-	b := *binPath
-	require.NotEmpty(t, b, "please set the binary path by `-binPath`")
-	cmd := exec.Command(b)
+// StartOldServer starts a server with the binary of an older release specified by `-oldBinPath`,
+// which is used to test the compatibility between releases. The test is skipped if it's not set.
+// Note that the configs must be supported by the older release.
+func StartOldServer(t testing.TB, configs map[string]string) *KvrocksServer {
+	if *oldBinPath == "" {
+		t.Skip("please set the binary path of an older release by `-oldBinPath`")
+	}
+	SkipIfExternal(t)
+	return startServerWithBinary(t, *oldBinPath, true, nil, configs, []string{})
+}
+
+// prepareServer creates the directory and the config file of a new server on a free port,
+// and returns the command to start it along with its output files. The config file is
+// rendered from the template if any, followed by the configs.
+func prepareServer(
+	t testing.TB,
+	bin string,
+	withConfigFile bool,
+	template *ConfigTemplate,
+	configs map[string]string,
+	options []string,
+) (*exec.Cmd, *net.TCPAddr, *os.File, *os.File) {
+	cmd := exec.Command(bin)
 
 	addr, err := findFreePort()
 	require.NoError(t, err)
@@ -226,6 +569,10 @@ func StartServerWithCLIOptions(
 		require.NoError(t, err)
 		defer func() { require.NoError(t, f.Close()) }()
 
+		if template != nil {
+			_, err := f.WriteString(template.render(t, dir, addr.Port))
+			require.NoError(t, err)
+		}
 		for k := range configs {
 			_, err := f.WriteString(fmt.Sprintf("%s %s\n", k, configs[k]))
 			require.NoError(t, err)
@@ -237,6 +584,7 @@ func StartServerWithCLIOptions(
 		}
 	}
 	cmd.Args = append(cmd.Args, options...)
+	cmd.Env = sanitizerEnv(t, dir)
 
 	stdout, err := os.Create(filepath.Join(dir, "stdout"))
 	require.NoError(t, err)
@@ -245,6 +593,20 @@ func StartServerWithCLIOptions(
 	require.NoError(t, err)
 	cmd.Stderr = stderr
 
+	return cmd, addr, stdout, stderr
+}
+
+func startServerWithBinary(
+	t testing.TB,
+	bin string,
+	withConfigFile bool,
+	template *ConfigTemplate,
+	configs map[string]string,
+	options []string,
+) *KvrocksServer {
+	cmd, addr, stdout, stderr := prepareServer(t, bin, withConfigFile, template, configs, options)
+	dir := configs["dir"]
+
 	require.NoError(t, cmd.Start())
 
 	c := redis.NewClient(&redis.Options{Addr: addr.String()})
@@ -261,30 +623,49 @@ func StartServerWithCLIOptions(
 	}, time.Minute, time.Second)
 	require.NotContains(t, status, process.Zombie, "Kvrocks has been unexpectedly exited while starting server")
 
-	return &KvrocksServer{
+	s := &KvrocksServer{
 		t:       t,
 		cmd:     cmd,
+		bin:     bin,
 		addr:    addr,
 		configs: configs,
-		clean: func(keepDir bool) {
-			require.NoError(t, stdout.Close())
-			require.NoError(t, stderr.Close())
-			if *deleteOnExit && !keepDir {
-				require.NoError(t, os.RemoveAll(dir))
-			}
-		},
 	}
+	// report to s.t rather than t, which changes when the server is shared by tests
+	s.clean = func(keepDir bool) {
+		require.NoError(s.t, stdout.Close())
+		require.NoError(s.t, stderr.Close())
+		if *deleteOnExit && !keepDir {
+			require.NoError(s.t, os.RemoveAll(dir))
+		}
+	}
+	return s
 }
 
-func findFreePort() (*net.TCPAddr, error) {
-	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
+// updateConfigFile replaces the values of existing directives in the config file
+// and appends the directives which are not present yet.
+func updateConfigFile(path string, changes map[string]string) error {
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	lis, err := net.ListenTCP("tcp", addr)
-	if err != nil {
-		return nil, err
+
+	applied := make(map[string]bool)
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	for i, line := range lines {
+		// keys may contain spaces, e.g. "rename-command KEYS"
+		line = strings.TrimSpace(line) + " "
+		for k, v := range changes {
+			if strings.HasPrefix(line, k+" ") {
+				lines[i] = fmt.Sprintf("%s %s", k, v)
+				applied[k] = true
+			}
+		}
+	}
+	for k, v := range changes {
+		if !applied[k] {
+			lines = append(lines, fmt.Sprintf("%s %s", k, v))
+		}
 	}
-	defer func() { _ = lis.Close() }()
-	return lis.Addr().(*net.TCPAddr), nil
+
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
 }
@@ -0,0 +1,163 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+var slowDiskSeq atomic.Int64
+
+// SlowDisk throttles the disk IO of a server process by a cgroup, on cgroup v2 by io.max
+// and on cgroup v1 by the blkio controller. Note that cgroup v1 can't throttle buffered
+// writes, which are written back by the kernel on behalf of the process, so only the
+// synced writes like WAL syncs and SST file flushes are throttled there.
+type SlowDisk struct {
+	t      testing.TB
+	s      *KvrocksServer
+	v2     bool
+	dir    string
+	device string
+}
+
+// ThrottleDisk limits the read and write bandwidth of the server to the disk holding its
+// directory. It requires the permission to manage cgroups, and the test is skipped
+// if that's not available. The limit applies to the current process of the server,
+// so it should be applied again after the server is restarted.
+func (s *KvrocksServer) ThrottleDisk(t testing.TB, bytesPerSecond int64) *SlowDisk {
+	SkipIfExternal(t)
+
+	device, err := blockDevice(s.Dir())
+	if err != nil {
+		t.Skipf("cannot throttle the disk: %v", err)
+	}
+
+	d := &SlowDisk{t: t, s: s, device: device}
+	name := fmt.Sprintf("kvrocks-gocase-%d-%d", os.Getpid(), slowDiskSeq.Add(1))
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err == nil {
+		d.v2 = true
+		d.dir = filepath.Join(cgroupRoot, name)
+		// the io controller must be enabled for children of the root cgroup
+		if err := os.WriteFile(filepath.Join(cgroupRoot, "cgroup.subtree_control"), []byte("+io"), 0644); err != nil {
+			t.Skipf("cannot enable the io controller of cgroup v2: %v", err)
+		}
+	} else {
+		d.dir = filepath.Join(cgroupRoot, "blkio", name)
+	}
+
+	if err := os.Mkdir(d.dir, 0755); err != nil {
+		t.Skipf("cannot create the cgroup: %v", err)
+	}
+	if err := d.setLimit(bytesPerSecond); err != nil {
+		_ = os.Remove(d.dir)
+		t.Skipf("cannot limit the disk bandwidth: %v", err)
+	}
+	require.NoError(t, d.moveServer(d.dir))
+	return d
+}
+
+func (d *SlowDisk) setLimit(bytesPerSecond int64) error {
+	if d.v2 {
+		limit := "max"
+		if bytesPerSecond > 0 {
+			limit = strconv.FormatInt(bytesPerSecond, 10)
+		}
+		return os.WriteFile(filepath.Join(d.dir, "io.max"),
+			[]byte(fmt.Sprintf("%s rbps=%s wbps=%s", d.device, limit, limit)), 0644)
+	}
+
+	// zero removes the limit on cgroup v1
+	for _, file := range []string{"blkio.throttle.read_bps_device", "blkio.throttle.write_bps_device"} {
+		content := fmt.Sprintf("%s %d", d.device, bytesPerSecond)
+		if err := os.WriteFile(filepath.Join(d.dir, file), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetLimit changes the bandwidth limit, zero means unlimited.
+func (d *SlowDisk) SetLimit(bytesPerSecond int64) {
+	require.NoError(d.t, d.setLimit(bytesPerSecond))
+}
+
+func (d *SlowDisk) moveServer(dir string) error {
+	pid := strconv.Itoa(d.s.cmd.Process.Pid)
+	return os.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(pid), 0644)
+}
+
+// Close moves the server back to the root cgroup and removes the throttling cgroup.
+func (d *SlowDisk) Close() {
+	if d.s.cmd.ProcessState == nil {
+		root := cgroupRoot
+		if !d.v2 {
+			root = filepath.Join(cgroupRoot, "blkio")
+		}
+		require.NoError(d.t, d.moveServer(root))
+	}
+	require.NoError(d.t, os.Remove(d.dir))
+}
+
+// blockDevice returns the "major:minor" of the whole disk holding the path,
+// since cgroups can't throttle partitions.
+func blockDevice(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", errors.New("unsupported file system")
+	}
+
+	dev := uint64(stat.Dev)
+	major := (dev>>8)&0xfff | (dev>>32)&^0xfff
+	minor := dev&0xff | (dev>>12)&^0xff
+	if major == 0 {
+		return "", fmt.Errorf("%s is not on a block device", path)
+	}
+	device := fmt.Sprintf("%d:%d", major, minor)
+
+	sysfs, err := filepath.EvalSymlinks(filepath.Join("/sys/dev/block", device))
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(sysfs, "partition")); err == nil {
+		// the directory of a partition is in the directory of its disk
+		content, err := os.ReadFile(filepath.Join(filepath.Dir(sysfs), "dev"))
+		if err != nil {
+			return "", err
+		}
+		device = strings.TrimSpace(string(content))
+	}
+	return device, nil
+}
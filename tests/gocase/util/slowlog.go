@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// SlowlogGet returns all entries of the slowlog, the newest first.
+func SlowlogGet(t testing.TB, rdb *redis.Client) []redis.SlowLog {
+	entries, err := rdb.SlowLogGet(context.Background(), -1).Result()
+	require.NoError(t, err)
+	return entries
+}
+
+func SlowlogReset(t testing.TB, rdb *redis.Client) {
+	require.NoError(t, rdb.Do(context.Background(), "slowlog", "reset").Err())
+}
+
+// FindSlowlog returns the newest entry whose arguments start with the given ones,
+// the command name is compared case-insensitively.
+func FindSlowlog(t testing.TB, rdb *redis.Client, args ...string) (redis.SlowLog, bool) {
+	for _, entry := range SlowlogGet(t, rdb) {
+		if slowlogMatches(entry, args) {
+			return entry, true
+		}
+	}
+	return redis.SlowLog{}, false
+}
+
+func slowlogMatches(entry redis.SlowLog, args []string) bool {
+	if len(entry.Args) < len(args) {
+		return false
+	}
+	for i, arg := range args {
+		if i == 0 && !strings.EqualFold(entry.Args[0], arg) || i > 0 && entry.Args[i] != arg {
+			return false
+		}
+	}
+	return true
+}
+
+// WaitForSlowlog waits until an entry of the command appears in the slowlog and returns it.
+func WaitForSlowlog(t testing.TB, rdb *redis.Client, timeout time.Duration, args ...string) redis.SlowLog {
+	var entry redis.SlowLog
+	require.Eventually(t, func() bool {
+		var ok bool
+		entry, ok = FindSlowlog(t, rdb, args...)
+		return ok
+	}, timeout, 50*time.Millisecond, "command %v is not logged in the slowlog", args)
+	return entry
+}
+
+// RequireNoSlowlog asserts the command is not logged in the slowlog.
+func RequireNoSlowlog(t testing.TB, rdb *redis.Client, args ...string) {
+	entry, ok := FindSlowlog(t, rdb, args...)
+	require.False(t, ok, "command %v is unexpectedly logged in the slowlog: %+v", args, entry)
+}
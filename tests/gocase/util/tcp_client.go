@@ -24,10 +24,13 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -130,6 +133,7 @@ func (c *TCPClient) WriteArgs(args ...string) error {
 	if len(args) == 0 {
 		return errors.New("args cannot be empty")
 	}
+	recordCommand(args[0])
 
 	cmd := fmt.Sprintf("*%d\r\n", len(args))
 	for _, arg := range args {
@@ -138,3 +142,135 @@ func (c *TCPClient) WriteArgs(args ...string) error {
 
 	return c.Write(cmd)
 }
+
+func (c *TCPClient) SetReadTimeout(d time.Duration) error {
+	return c.c.SetReadDeadline(time.Now().Add(d))
+}
+
+// RESPPush is an out-of-band push message read by ReadRESPValue.
+type RESPPush []interface{}
+
+// RESPMapEntry is a field-value pair of a map read by ReadRESPValue.
+type RESPMapEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// RESPMap is a map read by ReadRESPValue. It keeps the pairs in the order sent by the server,
+// since keys may be aggregates which can't be the keys of a Go map.
+type RESPMap []RESPMapEntry
+
+// Get returns the value of the first pair whose key equals to the given key.
+func (m RESPMap) Get(key interface{}) (interface{}, bool) {
+	for _, entry := range m {
+		if reflect.DeepEqual(entry.Key, key) {
+			return entry.Value, true
+		}
+	}
+	return nil, false
+}
+
+// RESPError is an error reply read by ReadRESPValue.
+type RESPError string
+
+func (e RESPError) Error() string {
+	return string(e)
+}
+
+// ReadRESPValue reads a complete RESP2 or RESP3 value from the connection. Values are
+// converted to Go types: simple and bulk strings to string, integers to int64, doubles
+// to float64, booleans to bool, errors to RESPError, arrays and sets to []interface{}, pushes
+// to RESPPush, maps to RESPMap, and null values to nil. Attributes are skipped.
+func (c *TCPClient) ReadRESPValue() (interface{}, error) {
+	line, err := c.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("empty RESP line")
+	}
+
+	typ, payload := line[0], line[1:]
+	switch typ {
+	case '+':
+		return payload, nil
+	case '-':
+		return RESPError(payload), nil
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case ',':
+		return strconv.ParseFloat(payload, 64)
+	case '#':
+		return payload == "t", nil
+	case '_':
+		return nil, nil
+	case '(':
+		return payload, nil
+	case '$', '!', '=':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		s := string(buf[:n])
+		if typ == '!' {
+			return RESPError(s), nil
+		} else if typ == '=' && len(s) >= 4 {
+			// skip the format of verbatim strings, e.g. "txt:"
+			return s[4:], nil
+		}
+		return s, nil
+	case '*', '~', '>':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			v, err := c.ReadRESPValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		if typ == '>' {
+			return RESPPush(values), nil
+		}
+		return values, nil
+	case '%', '|':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		m := make(RESPMap, 0, n)
+		for i := 0; i < n; i++ {
+			k, err := c.ReadRESPValue()
+			if err != nil {
+				return nil, err
+			}
+			v, err := c.ReadRESPValue()
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, RESPMapEntry{Key: k, Value: v})
+		}
+		if typ == '|' {
+			return c.ReadRESPValue()
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unknown RESP type: %q", typ)
+	}
+}
+
+func (c *TCPClient) MustReadRESPValue(t testing.TB, v interface{}) {
+	r, err := c.ReadRESPValue()
+	require.NoError(t, err)
+	require.Equal(t, v, r)
+}
@@ -20,14 +20,141 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
 	"os"
 	"path/filepath"
+	"time"
 )
 
+func tlsCertDir() string {
+	return filepath.Join(*workspace, "..", "tls", "cert")
+}
+
+var tlsCertFiles = []string{"ca.crt", "server.crt", "server.key"}
+
+func tlsCertsExist(dir string) bool {
+	for _, name := range tlsCertFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateTLSCerts creates a self-signed CA and a server certificate signed by it
+// in dir as ca.crt, server.crt and server.key. Existing files are left untouched,
+// so certificates provisioned in advance (e.g. by minica) take precedence.
+//
+// Test packages run in parallel and may call it at the same time, so the whole set
+// is generated in a temporary directory which is then renamed to dir. Only one of
+// the racing packages succeeds in renaming, and the others use its certificates,
+// which guarantees that the CA and the certificate on disk always match.
+func GenerateTLSCerts(dir string) error {
+	if tlsCertsExist(dir) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	if err := generateTLSCerts(tmpDir); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpDir, 0755); err != nil {
+		return err
+	}
+	// renaming fails if another package has populated dir in the meantime
+	if err := os.Rename(tmpDir, dir); err != nil && !tlsCertsExist(dir) {
+		return err
+	}
+	return nil
+}
+
+func generateTLSCerts(dir string) error {
+	caPath := filepath.Join(dir, "ca.crt")
+	certPath := filepath.Join(dir, "server.crt")
+	keyPath := filepath.Join(dir, "server.key")
+
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := notBefore.Add(365 * 24 * time.Hour)
+
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kvrocks test CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		// the server certificate is also used as the client certificate in tests
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caTemplate, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+
+	if err := writePEM(keyPath, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)); err != nil {
+		return err
+	}
+	if err := writePEM(certPath, "CERTIFICATE", certDER); err != nil {
+		return err
+	}
+	return writePEM(caPath, "CERTIFICATE", caDER)
+}
+
+func writePEM(path, typ string, der []byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pem.Encode(f, &pem.Block{Type: typ, Bytes: der}); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 func DefaultTLSConfig() (*tls.Config, error) {
-	dir := filepath.Join(*workspace, "..", "tls", "cert")
+	dir := tlsCertDir()
+	if err := GenerateTLSCerts(dir); err != nil {
+		return nil, err
+	}
 
 	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
 	if err != nil {
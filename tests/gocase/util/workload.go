@@ -0,0 +1,192 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+type WorkloadOptions struct {
+	// Clients is the number of goroutines issuing commands concurrently.
+	Clients int
+	// Pipeline is the number of commands sent in one round trip.
+	Pipeline int
+	Duration time.Duration
+	// Keys is the size of the key space, keys are picked uniformly from it.
+	Keys      int
+	ValueSize int
+	// Mix maps the command names SET, GET, LPUSH and ZADD to their relative weights.
+	Mix map[string]int
+}
+
+func DefaultWorkloadOptions() WorkloadOptions {
+	return WorkloadOptions{
+		Clients:   8,
+		Pipeline:  1,
+		Duration:  5 * time.Second,
+		Keys:      10000,
+		ValueSize: 64,
+		Mix:       map[string]int{"SET": 1, "GET": 1, "LPUSH": 1, "ZADD": 1},
+	}
+}
+
+type WorkloadResult struct {
+	Ops       int64         `json:"ops"`
+	Errors    int64         `json:"errors"`
+	Elapsed   time.Duration `json:"elapsed"`
+	OpsPerSec float64       `json:"ops_per_sec"`
+	// Latencies are measured per round trip, i.e. per pipeline when pipelining.
+	P50  time.Duration `json:"p50"`
+	P99  time.Duration `json:"p99"`
+	P999 time.Duration `json:"p999"`
+}
+
+func (r *WorkloadResult) String() string {
+	return fmt.Sprintf("ops: %d, errors: %d, ops/sec: %.0f, p50: %v, p99: %v, p999: %v",
+		r.Ops, r.Errors, r.OpsPerSec, r.P50, r.P99, r.P999)
+}
+
+// RunWorkload drives a mixed workload against the server for the configured duration
+// and reports the throughput and latency percentiles.
+func RunWorkload(t testing.TB, rdb *redis.Client, opts WorkloadOptions) *WorkloadResult {
+	require.Greater(t, opts.Clients, 0)
+	require.Greater(t, opts.Pipeline, 0)
+	require.Greater(t, opts.Keys, 0)
+
+	var commands []string
+	for cmd, weight := range opts.Mix {
+		cmd = strings.ToUpper(cmd)
+		require.Contains(t, []string{"SET", "GET", "LPUSH", "ZADD"}, cmd, "unsupported command in the workload mix")
+		for i := 0; i < weight; i++ {
+			commands = append(commands, cmd)
+		}
+	}
+	require.NotEmpty(t, commands, "the workload mix is empty")
+	sort.Strings(commands)
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Duration)
+	defer cancel()
+
+	var ops, errs atomic.Int64
+	latencies := make([][]time.Duration, opts.Clients)
+	value := strings.Repeat("x", opts.ValueSize)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < opts.Clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(i)))
+			for ctx.Err() == nil {
+				p := rdb.Pipeline()
+				for j := 0; j < opts.Pipeline; j++ {
+					key := fmt.Sprintf("workload:%d", r.Intn(opts.Keys))
+					switch commands[r.Intn(len(commands))] {
+					case "SET":
+						p.Set(ctx, key, value, 0)
+					case "GET":
+						p.Get(ctx, key)
+					case "LPUSH":
+						p.LPush(ctx, key+":list", value)
+					case "ZADD":
+						p.ZAdd(ctx, key+":zset", redis.Z{Score: r.Float64(), Member: value})
+					}
+				}
+
+				begin := time.Now()
+				cmds, _ := p.Exec(ctx)
+				if ctx.Err() != nil {
+					return
+				}
+				latencies[i] = append(latencies[i], time.Since(begin))
+
+				for _, cmd := range cmds {
+					// GET replies nil for keys which haven't been set yet
+					if err := cmd.Err(); err != nil && !errors.Is(err, redis.Nil) {
+						errs.Add(1)
+					}
+				}
+				ops.Add(int64(len(cmds)))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var all []time.Duration
+	for _, l := range latencies {
+		all = append(all, l...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	percentile := func(p float64) time.Duration {
+		if len(all) == 0 {
+			return 0
+		}
+		return all[int(float64(len(all)-1)*p)]
+	}
+
+	elapsed := time.Since(start)
+	return &WorkloadResult{
+		Ops:       ops.Load(),
+		Errors:    errs.Load(),
+		Elapsed:   elapsed,
+		OpsPerSec: float64(ops.Load()) / elapsed.Seconds(),
+		P50:       percentile(0.5),
+		P99:       percentile(0.99),
+		P999:      percentile(0.999),
+	}
+}
+
+// RequireNoRegression compares the result against the baseline stored in the file,
+// and fails if the throughput dropped or the p99 latency grew by more than the tolerance,
+// e.g. 0.3 for 30%. If the file doesn't exist, the result is stored as the new baseline.
+func (r *WorkloadResult) RequireNoRegression(t testing.TB, path string, tolerance float64) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		content, err = json.MarshalIndent(r, "", "  ")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, content, 0644))
+		t.Logf("workload baseline is written to %s: %s", path, r)
+		return
+	}
+	require.NoError(t, err)
+
+	var baseline WorkloadResult
+	require.NoError(t, json.Unmarshal(content, &baseline))
+
+	require.GreaterOrEqual(t, r.OpsPerSec, baseline.OpsPerSec*(1-tolerance),
+		"throughput regression, baseline: %s, current: %s", &baseline, r)
+	require.LessOrEqual(t, float64(r.P99), float64(baseline.P99)*(1+tolerance),
+		"p99 latency regression, baseline: %s, current: %s", &baseline, r)
+}